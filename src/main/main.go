@@ -3,31 +3,46 @@ package main
 // a command line tool that generates the issuer's keys 
 
 import (
+	"crypto/ecdsa"
+	"crypto/rand"
 	// "crypto/x509"
 	// "encoding/pem"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	math "github.com/IBM/mathlib"
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
-	"log"
+	"log/slog"
 	psidentity "psidentity"
 	rpsidentity "psidentity/psidentity"
-	"psidentity/translator/amcl"
 	user "psidentity/user"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	FP256BN_AMCL        = "FP256BN_AMCL"
-	BN254               = "BN254"
-	FP256BN_AMCL_MIRACL = "FP256BN_AMCL_MIRACL"
-	BLS12_377_GURVY     = "BLS12_377_GURVY"
-	BLS12_381_GURVY     = "BLS12_381_GURVY"
-	BLS12_381           = "BLS12_381"
+	FP256BN_AMCL        = rpsidentity.CurveFP256BN_AMCL
+	BN254               = rpsidentity.CurveBN254
+	FP256BN_AMCL_MIRACL = rpsidentity.CurveFP256BN_AMCL_MIRACL
+	BLS12_377_GURVY     = rpsidentity.CurveBLS12_377_GURVY
+	BLS12_381_GURVY     = rpsidentity.CurveBLS12_381_GURVY
+	BLS12_381           = rpsidentity.CurveBLS12_381
+)
+
+// the roles owning a DID
+const (
+	didRoleIssuer = "issuer"
+	didRoleUser   = "user"
 )
 
 // command line flags
@@ -37,11 +52,115 @@ var (
 	outputDir = app.Flag("output", "The output directory in which to place artifacts").Default("config").String()
 	curveID   = app.Flag("curve", "The curve to use to generate the crypto material").Short('c').Default(FP256BN_AMCL).Enum(FP256BN_AMCL, BN254, FP256BN_AMCL_MIRACL, BLS12_377_GURVY, BLS12_381_GURVY, BLS12_381)
 
+	compressed = app.Flag("compressed", "Encode the points of new keys and credentials in the compressed form").Bool()
+
+	didRegistryDir = app.Flag("did-registry", "The directory of the local DID registry, did-registry in the output directory if empty").String()
+
+	passphraseFile = app.Flag("passphrase-file", "A file holding the passphrase sealing the secret keys").ExistingFile()
+	passphraseEnv  = app.Flag("passphrase-env", "The environment variable holding the passphrase sealing the secret keys").Default("PSIDENTITY_PASSPHRASE").String()
+	plaintextKeys  = app.Flag("plaintext-keys", "Store the secret keys in plaintext instead of sealing them under a passphrase").Bool()
+
+	logLevel  = app.Flag("log-level", "The lowest level of the log records to write").Default("info").Enum("debug", "info", "warn", "error")
+	logFormat = app.Flag("log-format", "The format of the log records").Default("text").Enum("text", "json")
+
+	metricsOut = app.Flag("metrics-out", "A file receiving the metrics of the command in the Prometheus text exposition format, for a textfile collector").String()
+	metrics    *rpsidentity.MetricsRegistry
+
+	parallelism = app.Flag("parallelism", "The number of workers verifying the derive creds of an aggregate cred, the number of CPUs if 0").Default("0").Int()
+
+	revocationPk          = app.Flag("revocation-pk", "Require the derive creds to prove their non-revocation, for --revocation-cri or else in --revocation-epoch, signed by the long term revocation public key in this PEM or DER file").ExistingFile()
+	revocationEpoch       = app.Flag("revocation-epoch", "The current revocation epoch the derive creds prove their non-revocation in").Int()
+	revocationCRI         = app.Flag("revocation-cri", "The CRI written by publish-accumulator or publish-epoch, the derive creds prove the non-revocation of their revocation handle in its epoch").ExistingFile()
+	revocationHandleIndex = app.Flag("revocation-handle-index", "The index of the hidden revocation handle attribute").Default(strconv.Itoa(psidentity.AttributeIndexRevocationHandle)).Int()
+
+	acceptRetiringKeys = app.Flag("accept-retiring-keys", "Accept creds of the retiring issuer keys until they retire").Default("true").Bool()
+
 	genIssuerKey    = app.Command("issuer-keygen", "Generate issuer key material")
+	genIssuerKeySchema     = genIssuerKey.Flag("schema", "The schema of the creds of the issuer key, recorded in the key, the default schema if empty").String()
+	genIssuerKeyAttributes = genIssuerKey.Flag("attribute", "The attribute names of the schema, in order, ending with NotBefore and NotAfter for creds with a validity window").Strings()
+	rotateIssuerKey        = app.Command("rotate-issuer-key", "Replace the issuer key by a new version, keeping the old public key as a retiring key")
+	rotateIssuerKeyOverlap = rotateIssuerKey.Flag("overlap", "How long the creds of the old issuer key are still accepted").Default("720h").Duration()
 	genPrimaryCred    = app.Command("primary-cred", "Generate primary cred")
+	genCredValidity   = genPrimaryCred.Flag("validity", "How long the primary cred is valid, it expires at the end of a UTC day").Default("720h").Duration()
 	genDeriveCred    = app.Command("derive-cred", "Generate derive cred")
+	genDeriveCredWallet = genDeriveCred.Flag("wallet-cred", "Derive from the cred of the wallet ID instead of the primary cred in user-cred").String()
+	genDeriveCredWitness = genDeriveCred.Flag("witness", "The accumulator witness of the primary cred proving its non-revocation for --revocation-cri, the Witness in user-cred if empty").String()
 	genAggregateCred    = app.Command("aggregate-cred", "Generate aggregate cred")
 
+	genRevocationKey       = app.Command("revocation-keygen", "Generate the revocation authority keys and the empty accumulator the revocation handles of the primary creds are added to")
+	revokeCred             = app.Command("revoke", "Revoke a primary cred, deleting its revocation handle from the accumulator, and start a new epoch")
+	revokeCredPath         = revokeCred.Arg("credential", "The primary cred file to revoke").Required().ExistingFile()
+	revocationStatus       = app.Command("revocation-status", "Show the accumulator epoch and the revocation status of a primary cred")
+	revocationStatusPath   = revocationStatus.Arg("credential", "The primary cred file to check").ExistingFile()
+	issueWitness           = app.Command("issue-witness", "Issue the accumulator witness the holder of a primary cred proves its non-revocation with in the current epoch")
+	issueWitnessPath       = issueWitness.Arg("credential", "The primary cred file to issue the witness for").Required().ExistingFile()
+	publishAccumulator     = app.Command("publish-accumulator", "Write the CRI of the current epoch, the accumulator value signed with the long term revocation key")
+	publishEpoch           = app.Command("publish-epoch", "Write the CRI of the current epoch, an epoch key signed with the long term revocation key and its signature of every unrevoked handle")
+	rekey                  = app.Command("rekey", "Seal the secret keys under a new passphrase, or store them in plaintext with --plaintext-keys")
+	rekeyPassphraseFile    = rekey.Flag("new-passphrase-file", "A file holding the new passphrase").ExistingFile()
+	rekeyPassphraseEnv     = rekey.Flag("new-passphrase-env", "The environment variable holding the new passphrase").Default("PSIDENTITY_NEW_PASSPHRASE").String()
+	migrateArtifacts       = app.Command("migrate", "Convert the key and credential files in the legacy format to the envelope format")
+	inspectArtifact        = app.Command("inspect", "Print a key or credential file as JSON and verify it with the keys in the output directory")
+	inspectArtifactPath    = inspectArtifact.Arg("file", "The key or credential file to inspect").Required().ExistingFile()
+	exportVC               = app.Command("export-vc", "Export the user creds as W3C verifiable credentials and presentation")
+	verifyVC               = app.Command("verify-vc", "Verify a W3C verifiable credential or presentation with the issuer and user public keys")
+	verifyVCPath           = verifyVC.Arg("file", "The verifiable credential or presentation to verify").Required().ExistingFile()
+	verifyVCIssuerDID      = verifyVC.Flag("issuer-did", "Resolve the issuer public key by DID instead of reading the issuer key directory").String()
+	verifyVCUserDID        = verifyVC.Flag("user-did", "Resolve the user public key by DID instead of reading the user key directory").String()
+
+	serve                  = app.Command("serve", "Verify the verifiable credentials and presentations POSTed to /verify and serve the metrics at /metrics")
+	serveListen            = serve.Flag("listen", "The address the HTTP server listens on").Default("127.0.0.1:9464").String()
+
+	bench                  = app.Command("bench", "Measure the latency and output size of every operation of the protocol")
+	benchAttributes        = bench.Flag("attributes", "The number of attributes of the primary cred").Default("4").Int()
+	benchMessages          = bench.Flag("messages", "The number of derive creds in the aggregate cred").Default("1").Int()
+	benchIterations        = bench.Flag("iterations", "How many times each operation runs").Default("10").Int()
+	benchFormat            = bench.Flag("format", "The format of the report").Default("csv").Enum("csv", "json")
+	benchOut               = bench.Flag("out", "The file to write the report to, standard output if empty").String()
+
+	did                    = app.Command("did", "Manage the did:flex DIDs publishing the issuer and user public keys")
+	didCreate              = did.Command("create", "Create the DID publishing the public key of the role")
+	didCreateRole          = didCreate.Flag("role", "Whose public key the DID publishes").Default(didRoleIssuer).Enum(didRoleIssuer, didRoleUser)
+	didUpdate              = did.Command("update", "Publish the current public key of the role in its DID")
+	didUpdateRole          = didUpdate.Flag("role", "Whose public key the DID publishes").Default(didRoleIssuer).Enum(didRoleIssuer, didRoleUser)
+	didDeactivate          = did.Command("deactivate", "Deactivate the DID of the role")
+	didDeactivateRole      = didDeactivate.Flag("role", "Whose public key the DID publishes").Default(didRoleIssuer).Enum(didRoleIssuer, didRoleUser)
+	didResolve             = did.Command("resolve", "Print the DID document of a DID")
+	didResolveID           = didResolve.Arg("did", "The DID to resolve").Required().String()
+
+	walletCmd              = app.Command("wallet", "Manage the primary creds of many issuers stored on the device")
+	walletList             = walletCmd.Command("list", "List the creds in the wallet, the creds expiring first first")
+	walletListIssuer       = walletList.Flag("issuer", "Only list the creds of the issuer key ID").String()
+	walletListSchema       = walletList.Flag("schema", "Only list the creds of the schema").String()
+	walletListValid        = walletList.Flag("valid", "Only list the creds inside their validity window").Bool()
+	walletImport           = walletCmd.Command("import", "Import a primary cred signed by a key of the issuer key directory, or a cred exported from a wallet")
+	walletImportPath       = walletImport.Arg("file", "The primary cred file or exported cred").Required().ExistingFile()
+	walletImportSchema     = walletImport.Flag("schema", "The expected schema of the primary cred, which must be the schema of its issuer key").Default(rpsidentity.DefaultSchema).String()
+	walletImportAttributes = walletImport.Flag("attribute", "The expected names of the attributes of the schema, in order, the schema of the issuer key is not checked if empty").Strings()
+	walletExport           = walletCmd.Command("export", "Export a cred with the issuer public key that signed it")
+	walletExportID         = walletExport.Arg("id", "The wallet ID of the cred").Required().String()
+	walletExportOut        = walletExport.Flag("out", "The file to write the cred to, standard output if empty").String()
+	walletDelete           = walletCmd.Command("delete", "Delete a cred from the wallet")
+	walletDeleteID         = walletDelete.Arg("id", "The wallet ID of the cred").Required().String()
+	walletSelect           = walletCmd.Command("select", "Pick the valid creds disclosing the attributes, and their disclosure masks")
+	walletSelectAttributes = walletSelect.Arg("attributes", "The names of the attributes to disclose").Required().Strings()
+
+	presentationRequest           = app.Command("presentation-request", "Write a presentation request asking a holder to disclose attributes and prove predicates")
+	presentationRequestDisclose   = presentationRequest.Flag("disclose", "The name of an attribute to disclose").Required().Strings()
+	presentationRequestPredicates = presentationRequest.Flag("predicate", "A predicate on a hidden attribute, <attribute>>=<value> or <attribute><=<value>").Strings()
+	presentationRequestIssuers    = presentationRequest.Flag("issuer", "The key ID of an accepted issuer, any trusted issuer if empty").Strings()
+	presentationRequestSchemas    = presentationRequest.Flag("schema", "An accepted schema, <name>:<attribute>,<attribute>,..., the default schema if empty").Strings()
+	presentationRequestTTL        = presentationRequest.Flag("ttl", "How long the holder has to answer the request").Default("5m").Duration()
+	presentationRequestFormat     = presentationRequest.Flag("format", "The format of the request").Default("json").Enum("json", "yaml")
+	presentationRequestOut        = presentationRequest.Flag("out", "The file to write the request to, standard output if empty").String()
+	present                       = app.Command("present", "Answer a presentation request with the creds of the wallet")
+	presentRequest                = present.Arg("request", "The presentation request").Required().ExistingFile()
+	presentOut                    = present.Flag("out", "The file to write the presentation to, standard output if empty").String()
+	verifyPresentation            = app.Command("verify-presentation", "Verify that a presentation satisfies a presentation request")
+	verifyPresentationRequest     = verifyPresentation.Arg("request", "The presentation request").Required().ExistingFile()
+	verifyPresentationPath        = verifyPresentation.Arg("presentation", "The presentation to verify").Required().ExistingFile()
+	verifyPresentationUserDID     = verifyPresentation.Flag("user-did", "Resolve the user public key by DID instead of reading the user key directory").String()
+
 	// genUserConfig   = app.Command("userconfig", "Generate a default user certificate")
 	// deriveAggregate = app.Command("derive-aggregate", "User certification derive and aggregate")
 	// genCAInput              = genSignerConfig.Flag("ca-input", "The folder where CA's secrets are stored").String()
@@ -53,60 +172,32 @@ var (
 	// version = app.Command("version", "Show version information")
 )
 
-type Translator interface {
-	G1ToProto(*math.G1) *amcl.ECP
-	G1FromProto(*amcl.ECP) (*math.G1, error)
-	G1FromRawBytes([]byte) (*math.G1, error)
-	G2ToProto(*math.G2) *amcl.ECP2
-	G2FromProto(*amcl.ECP2) (*math.G2, error)
-}
-
 func main() {
 	app.HelpFlag.Short('h')
 
 	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+	setupLogger()
+	metrics = setupMetrics(command == serve.FullCommand())
+	defer writeMetrics()
 
-	var curve *math.Curve
-	var tr Translator
-	switch *curveID {
-	case FP256BN_AMCL:
-		curve = math.Curves[math.FP256BN_AMCL]
-		tr = &amcl.Fp256bn{C: curve}
-	// case BN254:
-	// 	curve = math.Curves[math.BN254]
-	// 	tr = &amcl.Gurvy{C: curve}
-	// case FP256BN_AMCL_MIRACL:
-	// 	curve = math.Curves[math.FP256BN_AMCL_MIRACL]
-	// 	tr = &amcl.Fp256bnMiracl{C: curve}
-	// case BLS12_377_GURVY:
-	// 	curve = math.Curves[math.BLS12_377_GURVY]
-	// 	tr = &amcl.Gurvy{C: curve}
-	// case BLS12_381_GURVY:
-	// 	curve = math.Curves[math.BLS12_381_GURVY]
-	// 	tr = &amcl.Gurvy{C: curve}
-	// case BLS12_381:
-	// 	curve = math.Curves[math.BLS12_381]
-	// 	tr = &amcl.Gurvy{C: curve}
-	default:
-		handleError(fmt.Errorf("invalid curve [%s]", *curveID))
-	}
-
-	psid := rpsidentity.Psidentity{
-		Curve: curve,
-	}
+	psid := *newPsidentity()
+	tr := psid.Translator
 
 	switch command {
 
 	case genIssuerKey.FullCommand():
 		//isk, ipk, err := rpsidentity.GenerateIssuerKey(psid, tr)		//commit for idemix issuer
-		isk, ipk, err := rpsidentity.GenerateIssuerKeyPS(psid, tr)
+		var isk, ipk []byte
+		var err error
+		if *genIssuerKeySchema != "" {
+			isk, ipk, err = rpsidentity.GenerateIssuerKeyWithSchemaPS(&rpsidentity.CredentialSchema{Name: *genIssuerKeySchema, Attributes: *genIssuerKeyAttributes}, psid, tr)
+		} else {
+			isk, ipk, err = rpsidentity.GenerateIssuerKeyPS(psid, tr)
+		}
 		handleError(err)
 		usk, upk, err := rpsidentity.GenerateUserKeyPS(psid, tr)
 		handleError(err)
 
-		revocationKey, err := rpsidentity.GenerateRevocationKeyPS(psid)
-		// fmt.Printf("the type of revocationKey:%T",revocationKey)
-		handleError(err)
 		// encodedRevocationSK, err := x509.MarshalECPrivateKey(revocationKey)
 		// handleError(err)
 		// pemEncodedRevocationSK := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encodedRevocationSK})
@@ -121,30 +212,63 @@ func main() {
 		// path2 := filepath.Join(*outputDir, psidentity.PsIdentityConfigDirUser)
 		// checkDirectoryNotExists(path2, fmt.Sprintf("Directory %s already exists", path2))
 
-		// write private and public keys to the file
-		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey), 0770))
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerSecretKey), isk)
+		// write private and public keys to the key store, the issuer secret key bound to its public key
+		store := keyStore()
+		if *plaintextKeys {
+			store = rpsidentity.NewFileKeyStore(*outputDir, newPsidentity())
+		} else {
+			passphrase(true)
+		}
 		// writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuer, psidentity.PsIdentityConfigRevocationKey), pemEncodedRevocationSK)
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey), ipk)
+		handleError(store.PutIssuerKey(psidentity.PsIdentityDirIssuerKey, ipk, isk))
 		// writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuer, psidentity.PsIdentityConfigRevocationKey), revocationKey)
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigRevocationKey), revocationKey)
 
 
 
-		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirUserKey), 0770))
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey), usk)
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey), upk)
+		handleError(store.Put(rpsidentity.KeyID{Name: psidentity.PsIdentityDirUserKey, Type: psidentity.PsIdentityConfigUserPublicKey}, upk))
+		handleError(store.Put(rpsidentity.KeyID{Name: psidentity.PsIdentityDirUserKey, Type: psidentity.PsIdentityConfigUserSecretKey}, usk))
 
 
+	case rotateIssuerKey.FullCommand():
+		oldIpk := readIssuerPublicKey()
+		store := keyStore()
+		if *plaintextKeys {
+			store = rpsidentity.NewFileKeyStore(*outputDir, newPsidentity())
+		}
+		rotation, err := rpsidentity.RotateIssuerKeyWithStore(store, psidentity.PsIdentityDirIssuerKey, *rotateIssuerKeyOverlap, psid, tr)
+		handleError(err)
+
+		// the rotation statement is kept with the archived public key it retires
+		archived := rpsidentity.ArchivedIssuerKeyName(psidentity.PsIdentityDirIssuerKey, oldIpk.GetVersion())
+		writeArtifact(filepath.Join(*outputDir, archived, psidentity.PsIdentityConfigIssuerKeyRotation), psidentity.PsIdentityConfigIssuerKeyRotation, nil, rotation)
+		ipk := readIssuerPublicKey()
+		fmt.Printf("Issuer key %s (version %d) replaces %s (version %d), which retires at %s\n",
+			rpsidentity.IssuerKeyID(ipk), ipk.GetVersion(), rpsidentity.IssuerKeyID(oldIpk), oldIpk.GetVersion(),
+			time.Now().Add(*rotateIssuerKeyOverlap).UTC().Format(time.RFC3339))
+
 	case genPrimaryCred.FullCommand():
-		log.Printf("PrimaryCred\n")
+		slog.Info("PrimaryCred")
 		UserAttributeNames := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
-		log.Printf("UserAttributeNames is %v\n", UserAttributeNames)
+		slog.Debug("UserAttributeNames", "attrs", UserAttributeNames)
+
+		// with a revocation authority, the cred gets a unique revocation handle which is added to the accumulator
+		accKey := optionalAccumulatorKey()
+		if accKey != nil {
+			UserAttributeNames[*revocationHandleIndex] = newRevocationHandle()
+		}
 
-		key, _ := readIssuerKey()
+		ipk := readIssuerPublicKey()
 		// revKey := readRevocationKey()
 
-		primaryconfig, err := rpsidentity.GenerateUserPrimaryCred(UserAttributeNames, key, psid, tr)
+		// the issuer assigns the validity window and signs it in clear
+		var window *rpsidentity.ValidityWindow
+		if ipk.GetValidityWindow() {
+			now := time.Now()
+			window = &rpsidentity.ValidityWindow{NotBefore: now, NotAfter: now.Add(*genCredValidity)}
+		}
+
+		// the issuer secret key is only used inside the key store
+		primaryconfig, err := rpsidentity.GenerateUserPrimaryCredWithStore(UserAttributeNames, window, keyStore(), psidentity.PsIdentityDirIssuerKey, psid, tr)
 		handleError(err)
 
 		// path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigPrimaryCred)
@@ -152,20 +276,45 @@ func main() {
 
 		// Write config to file
 		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred), 0770))
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigPrimaryCred), primaryconfig)
+		writeArtifact(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigPrimaryCred), psidentity.PsIdentityConfigPrimaryCred, ipk.GetHash(), primaryconfig)
 		// log.Printf("write primary cred successful")
+		primaryCred := readUserPrimaryCred(ipk.GetHash())
+		printValidity(primaryCred.GetAttrs())
+
+		// the new cred is a member of the accumulator from the next epoch on
+		if accKey != nil {
+			state := readRevocationState()
+			handleError(state.Add(accKey, psid.RevocationHandle(UserAttributeNames[*revocationHandleIndex]), psid.Curve, tr))
+			writeRevocationState(state)
+			slog.Info("Primary cred added to the accumulator", "epoch", state.GetEpoch())
+		} else {
+			slog.Info("No revocation authority, the primary cred has no revocation handle")
+		}
 
 	case genDeriveCred.FullCommand():
-		log.Printf("DeriveCred\n")
-		UserAttributeNames := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
-		log.Printf("UserAttributeNames is %v\n", UserAttributeNames)
-
-		key, _ := readIssuerKey()
-		ukey := readUserKey()
-		primaryCred := readUserPrimaryCred()
-		log.Printf("The value of primaryCred:%v", primaryCred)
-
-		deriveconfig, aggregateconfig, err := rpsidentity.GenerateUserDeriveCred(UserAttributeNames, primaryCred, key, ukey, psid, tr)
+		slog.Info("DeriveCred")
+		var primaryCred *rpsidentity.PrimaryCredential
+		var ipk *rpsidentity.IssuerPublicKeyPS
+		var err error
+		if *genDeriveCredWallet != "" {
+			// the wallet keeps the issuer public key with the cred
+			primaryCred, ipk, err = wallet().Credential(*genDeriveCredWallet)
+			handleError(err)
+		} else {
+			// the primary cred may have been signed by a retiring issuer key
+			cred := readUserPrimaryCred(nil)
+			primaryCred = &cred
+			ipk, err = issuerKeySet().Key(primaryCred.GetIssuerKeyId(), time.Now())
+			handleError(errors.WithMessage(err, "user primary cred"))
+		}
+		slog.Debug("primaryCred", "primary_cred", primaryCred)
+
+		// the attributes, including the validity window, are the ones in the primary cred
+		UserAttributeNames := primaryCred.GetAttrs()
+		slog.Debug("UserAttributeNames", "attrs", UserAttributeNames)
+		printValidity(UserAttributeNames)
+
+		deriveconfig, aggregateconfig, err := rpsidentity.GenerateUserDeriveCredWithStore(UserAttributeNames, primaryCred, ipk, keyStore(), psidentity.PsIdentityDirUserKey, nonRevocationWitness(), psid, tr)
 		handleError(err)
 
 		// path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigDeriveCred)
@@ -173,16 +322,344 @@ func main() {
 
 		// // Write config to file
 		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred), 0770))
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigDeriveCred), deriveconfig)
-		log.Printf("write derive cred successful")
-		writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigAggregateCred), aggregateconfig)
-		log.Printf("write aggregate cred successful")
+		writeArtifact(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigDeriveCred), psidentity.PsIdentityConfigDeriveCred, ipk.GetHash(), deriveconfig)
+		slog.Info("write derive cred successful")
+		writeArtifact(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigAggregateCred), psidentity.PsIdentityConfigAggregateCred, ipk.GetHash(), aggregateconfig)
+		slog.Info("write aggregate cred successful")
 
 	
+	case genRevocationKey.FullCommand():
+		statePath := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationState)
+		checkDirectoryNotExists(statePath, fmt.Sprintf("The revocation authority already exists in %s", statePath))
+		ltKey, ltPk, accKey, state, err := rpsidentity.GenerateRevocationAuthorityPS(psid, tr)
+		handleError(err)
+
+		// the secret keys are sealed like the other secret keys, the public key is published as PEM for verifiers
+		dir := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation)
+		handleError(os.MkdirAll(dir, 0770))
+		writeArtifact(filepath.Join(dir, psidentity.PsIdentityConfigLongTermRevocationKey), psidentity.PsIdentityConfigLongTermRevocationKey, nil, ltKey)
+		writeArtifact(filepath.Join(dir, psidentity.PsIdentityConfigPairingAccumulatorKey), psidentity.PsIdentityConfigPairingAccumulatorKey, nil, accKey)
+		writeFile(filepath.Join(dir, psidentity.PsIdentityConfigRevocationPublicKey), ltPk)
+		writeArtifact(statePath, psidentity.PsIdentityConfigRevocationState, nil, state)
+		slog.Info("write revocation authority successful")
+
+	case revokeCred.FullCommand():
+		rh := readCredHandle(*revokeCredPath)
+		state := readRevocationState()
+		handleError(state.Revoke(readAccumulatorKey(), rh, psid.Curve, tr))
+		writeRevocationState(state)
+		fmt.Printf("Credential revoked, accumulator epoch %d, publish its CRI with publish-accumulator or publish-epoch\n", state.GetEpoch())
+
+	case revocationStatus.FullCommand():
+		state := readRevocationState()
+		if *revocationStatusPath != "" {
+			fmt.Printf("Credential is %s\n", state.Status(readCredHandle(*revocationStatusPath)))
+		}
+		fmt.Printf("Accumulator epoch %d, %d members, %d revoked\n", state.GetEpoch(), len(state.GetMembers()), len(state.GetRevoked()))
+
+	case issueWitness.FullCommand():
+		rh := readCredHandle(*issueWitnessPath)
+		state := readRevocationState()
+		witness, err := state.Witness(readAccumulatorKey(), rh, psid.Curve, tr)
+		handleError(err)
+		witnessBytes, err := proto.Marshal(witness)
+		handleError(err)
+
+		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred), 0770))
+		writeArtifact(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigWitness), psidentity.PsIdentityConfigWitness, nil, witnessBytes)
+		slog.Info("write witness successful", "epoch", state.GetEpoch())
+
+	case publishAccumulator.FullCommand():
+		state := readRevocationState()
+		cri, err := state.CRI(readLongTermRevocationKey(), readAccumulatorKey())
+		handleError(err)
+		criBytes, err := proto.Marshal(cri)
+		handleError(err)
+		path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigCRI)
+		writeArtifact(path, psidentity.PsIdentityConfigCRI, nil, criBytes)
+		fmt.Printf("Accumulator epoch %d, CRI written to %s\n", cri.GetEpoch(), path)
+		fmt.Printf("Verifiers check it with --revocation-pk %s --revocation-cri %s\n", filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationPublicKey), path)
+
+	case publishEpoch.FullCommand():
+		state := readRevocationState()
+		cri, err := state.EpochCRI(readLongTermRevocationKey(), rand.Reader, psid.Curve, tr)
+		handleError(err)
+		criBytes, err := proto.Marshal(cri)
+		handleError(err)
+		path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigCRI)
+		writeArtifact(path, psidentity.PsIdentityConfigCRI, nil, criBytes)
+		fmt.Printf("Revocation epoch %d, CRI with %d unrevoked handles written to %s\n", cri.GetEpoch(), len(state.GetMembers()), path)
+		fmt.Printf("Verifiers check it with --revocation-pk %s --revocation-epoch %d\n", filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationPublicKey), cri.GetEpoch())
+
+	case rekey.FullCommand():
+		rekeySecretKeys()
+
+	case migrateArtifacts.FullCommand():
+		migrate()
+
+	case inspectArtifact.FullCommand():
+		raw, err := ioutil.ReadFile(*inspectArtifactPath)
+		handleError(errors.Wrapf(err, "failed to open %s", *inspectArtifactPath))
+		report, err := rpsidentity.InspectArtifact(raw, filepath.Base(*inspectArtifactPath), inspectKeys())
+		handleError(errors.WithMessagef(err, "cannot inspect %s", *inspectArtifactPath))
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		handleError(err)
+		fmt.Println(string(reportJSON))
+
+	case exportVC.FullCommand():
+		// the creds are exported with the issuer key that signed them
+		keys := issuerKeySet()
+		now := time.Now()
+		credDir := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred)
+		exported := 0
+		if _, err := os.Stat(filepath.Join(credDir, psidentity.PsIdentityConfigPrimaryCred)); err == nil {
+			primaryCred := readUserPrimaryCred(nil)
+			ipk, err := keys.Key(primaryCred.GetIssuerKeyId(), now)
+			handleError(errors.WithMessage(err, "user primary cred"))
+			vc, err := psid.PrimaryCredentialToVC(&primaryCred, ipk)
+			handleError(err)
+			writeJSON(filepath.Join(credDir, psidentity.PsIdentityConfigPrimaryCredVC), vc)
+			exported++
+		}
+		if _, err := os.Stat(filepath.Join(credDir, psidentity.PsIdentityConfigDeriveCred)); err == nil {
+			deriveCred := readUserDeriveCred()
+			ipk, err := keys.Key(deriveCred.GetIssuerKeyId(), now)
+			handleError(errors.WithMessage(err, "user derive cred"))
+			vc, err := psid.DeriveCredentialToVC(&deriveCred, ipk)
+			handleError(err)
+			writeJSON(filepath.Join(credDir, psidentity.PsIdentityConfigDeriveCredVC), vc)
+			exported++
+		}
+		if _, err := os.Stat(filepath.Join(credDir, psidentity.PsIdentityConfigAggregateCred)); err == nil {
+			aggregateCred := readUserAggregateCred(nil)
+			var keyID string
+			if len(aggregateCred.GetMessages()) > 0 {
+				keyID = aggregateCred.GetMessages()[0].GetIssuerKeyId()
+			}
+			ipk, err := keys.Key(keyID, now)
+			handleError(errors.WithMessage(err, "user aggregate cred"))
+			vp, err := psid.AggregateCredentialToVP(aggregateCred, ipk)
+			handleError(err)
+			writeJSON(filepath.Join(credDir, psidentity.PsIdentityConfigAggregateCredVP), vp)
+			exported++
+		}
+		if exported == 0 {
+			handleError(errors.Errorf("no user creds in %s", credDir))
+		}
+		slog.Info("export of verifiable documents successful", "documents", exported)
+
+	case serve.FullCommand():
+		serveVerifier(psid)
+
+	case verifyVC.FullCommand():
+		raw, err := ioutil.ReadFile(*verifyVCPath)
+		handleError(errors.Wrapf(err, "failed to open %s", *verifyVCPath))
+		var ipk *rpsidentity.IssuerPublicKeyPS
+		if *verifyVCIssuerDID != "" {
+			ipk, err = rpsidentity.ResolveIssuerKey(didRegistry(), *verifyVCIssuerDID)
+			handleError(errors.WithMessagef(err, "cannot resolve %s", *verifyVCIssuerDID))
+		}
+		if rpsidentity.IsVP(raw) {
+			vp, err := rpsidentity.ParseVP(raw)
+			handleError(err)
+			if ipk == nil && len(vp.VerifiableCredential) > 0 {
+				ipk = vcIssuerKey(vp.VerifiableCredential[0].Issuer)
+			}
+			var upk *rpsidentity.UserPublicKey
+			if *verifyVCUserDID != "" {
+				upk, err = rpsidentity.ResolveUserKey(didRegistry(), *verifyVCUserDID)
+				handleError(errors.WithMessagef(err, "cannot resolve %s", *verifyVCUserDID))
+			} else {
+				upk = readUserPublicKey()
+			}
+			handleError(errors.WithMessage(psid.VerifyVP(vp, ipk, upk, time.Now()), "verifiable presentation is not valid"))
+			fmt.Printf("Verifiable presentation of %d credentials is valid\n", len(vp.VerifiableCredential))
+		} else {
+			vc, err := rpsidentity.ParseVC(raw)
+			handleError(err)
+			if ipk == nil {
+				ipk = vcIssuerKey(vc.Issuer)
+			}
+			handleError(errors.WithMessage(psid.VerifyVC(vc, ipk, time.Now()), "verifiable credential is not valid"))
+			fmt.Printf("Verifiable credential of %s is valid\n", vc.Issuer)
+		}
+
+	case didCreate.FullCommand():
+		didPath, keyPath, keyType := didFiles(*didCreateRole)
+		checkDirectoryNotExists(keyPath, fmt.Sprintf("The %s already has a DID controller key \"%s\"", *didCreateRole, keyPath))
+		key, err := rpsidentity.GenerateDIDControllerKey()
+		handleError(err)
+		keyBytes, err := rpsidentity.DIDControllerKeyToBytes(key)
+		handleError(err)
+
+		doc := didDocument(*didCreateRole, key)
+		handleError(rpsidentity.CreateDID(didRegistry(), doc, key))
+		handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirDID), 0770))
+		writeArtifact(keyPath, keyType, nil, keyBytes)
+		writeFile(didPath, []byte(doc.ID))
+		fmt.Println(doc.ID)
+
+	case didUpdate.FullCommand():
+		key, _ := readDIDControllerKey(*didUpdateRole)
+		doc := didDocument(*didUpdateRole, key)
+		handleError(rpsidentity.UpdateDID(didRegistry(), doc, key))
+		slog.Info("DID updated", "did", doc.ID, "version", doc.Version)
+
+	case didDeactivate.FullCommand():
+		key, id := readDIDControllerKey(*didDeactivateRole)
+		handleError(rpsidentity.DeactivateDID(didRegistry(), id, key))
+		slog.Info("DID deactivated", "did", id)
+
+	case bench.FullCommand():
+		rng, err := psid.Curve.Rand()
+		handleError(err)
+		results, err := psid.Bench(rpsidentity.BenchConfig{Attributes: *benchAttributes, Messages: *benchMessages, Iterations: *benchIterations}, rng)
+		handleError(err)
+		out := os.Stdout
+		if *benchOut != "" {
+			out, err = os.Create(*benchOut)
+			handleError(err)
+			defer out.Close()
+		}
+		if *benchFormat == "json" {
+			handleError(rpsidentity.WriteBenchJSON(out, results))
+		} else {
+			handleError(rpsidentity.WriteBenchCSV(out, results))
+		}
+
+	case didResolve.FullCommand():
+		doc, err := rpsidentity.ResolveDID(didRegistry(), *didResolveID)
+		handleError(errors.WithMessagef(err, "cannot resolve %s", *didResolveID))
+		docJSON, err := json.MarshalIndent(doc, "", "  ")
+		handleError(err)
+		fmt.Println(string(docJSON))
+
+	case walletList.FullCommand():
+		query := rpsidentity.WalletQuery{IssuerKeyID: *walletListIssuer, Schema: *walletListSchema}
+		if *walletListValid {
+			query.ValidAt = time.Now()
+		}
+		entries, err := wallet().List(query)
+		handleError(err)
+		for _, entry := range entries {
+			printWalletEntry(entry)
+		}
+
+	case walletImport.FullCommand():
+		raw, err := ioutil.ReadFile(*walletImportPath)
+		handleError(errors.Wrapf(err, "failed to open %s", *walletImportPath))
+		var entry *rpsidentity.WalletEntry
+		if len(raw) != 0 && raw[0] == '{' {
+			entry, err = wallet().ImportBundle(raw)
+		} else {
+			// the cred may have been signed by a retiring issuer key
+			conf := &user.UserPrimaryCred{}
+			handleError(proto.Unmarshal(unwrapArtifact(*walletImportPath, raw, psidentity.PsIdentityConfigPrimaryCred, nil), conf))
+			cred := &rpsidentity.PrimaryCredential{}
+			handleError(proto.Unmarshal(conf.GetPrimaryCred(), cred))
+			ipk, keyErr := issuerKeySet().Key(cred.GetIssuerKeyId(), time.Now())
+			handleError(errors.WithMessage(keyErr, "user primary cred"))
+			var schema *rpsidentity.CredentialSchema
+			if len(*walletImportAttributes) != 0 {
+				schema = &rpsidentity.CredentialSchema{Name: *walletImportSchema, Attributes: *walletImportAttributes}
+			}
+			entry, err = wallet().Import(raw, ipk, schema)
+		}
+		handleError(err)
+		printWalletEntry(entry)
+
+	case walletExport.FullCommand():
+		bundle, err := wallet().Export(*walletExportID)
+		handleError(err)
+		if *walletExportOut == "" {
+			fmt.Println(string(bundle))
+		} else {
+			writeFile(*walletExportOut, bundle)
+		}
+
+	case walletDelete.FullCommand():
+		handleError(wallet().Delete(*walletDeleteID))
+		slog.Info("Credential deleted from the wallet", "id", *walletDeleteID)
+
+	case walletSelect.FullCommand():
+		selections, err := wallet().Select(rpsidentity.WalletQuery{ValidAt: time.Now()}, *walletSelectAttributes)
+		handleError(err)
+		for _, selection := range selections {
+			mask, err := selection.Mask()
+			handleError(err)
+			fmt.Printf("%s discloses %v with mask %v\n", selection.Entry.ID, selection.Attributes, mask)
+		}
+
+	case presentationRequest.FullCommand():
+		var predicates []*rpsidentity.AttributePredicate
+		for _, p := range *presentationRequestPredicates {
+			predicate, err := rpsidentity.ParseAttributePredicate(p)
+			handleError(err)
+			predicates = append(predicates, predicate)
+		}
+		var schemas []*rpsidentity.CredentialSchema
+		for _, s := range *presentationRequestSchemas {
+			name, attributes, ok := strings.Cut(s, ":")
+			if !ok {
+				handleError(errors.Errorf("schema %q is not of the form <name>:<attribute>,<attribute>,...", s))
+			}
+			schemas = append(schemas, &rpsidentity.CredentialSchema{Name: name, Attributes: strings.Split(attributes, ",")})
+		}
+		req, err := rpsidentity.NewPresentationRequest(schemas, *presentationRequestDisclose, predicates, *presentationRequestTTL, rand.Reader)
+		handleError(err)
+		req.Issuers = *presentationRequestIssuers
+		var out []byte
+		if *presentationRequestFormat == "yaml" {
+			out, err = yaml.Marshal(req)
+		} else {
+			out, err = json.MarshalIndent(req, "", "  ")
+		}
+		handleError(err)
+		if *presentationRequestOut == "" {
+			fmt.Print(string(out))
+		} else {
+			writeFile(*presentationRequestOut, out)
+		}
+
+	case present.FullCommand():
+		req := readPresentationRequest(*presentRequest)
+		p, err := wallet().ResolvePresentation(req, keyStore(), psidentity.PsIdentityDirUserKey, time.Now(), rand.Reader)
+		handleError(err)
+		if *presentOut == "" {
+			out, err := json.MarshalIndent(p, "", "  ")
+			handleError(err)
+			fmt.Println(string(out))
+		} else {
+			writeJSON(*presentOut, p)
+		}
+
+	case verifyPresentation.FullCommand():
+		req := readPresentationRequest(*verifyPresentationRequest)
+		raw, err := ioutil.ReadFile(*verifyPresentationPath)
+		handleError(errors.Wrapf(err, "failed to open %s", *verifyPresentationPath))
+		p := &rpsidentity.Presentation{}
+		handleError(errors.Wrapf(json.Unmarshal(raw, p), "failed to parse %s", *verifyPresentationPath))
+		var upk *rpsidentity.UserPublicKey
+		if *verifyPresentationUserDID != "" {
+			upk, err = rpsidentity.ResolveUserKey(didRegistry(), *verifyPresentationUserDID)
+			handleError(errors.WithMessagef(err, "cannot resolve %s", *verifyPresentationUserDID))
+		} else {
+			upk = readUserPublicKey()
+		}
+		disclosed, err := psid.VerifyPresentation(req, p, issuerKeySet().Keys(time.Now()), upk, time.Now())
+		handleError(errors.WithMessage(err, "presentation is not valid"))
+		fmt.Println("Presentation satisfies the request")
+		for _, name := range req.Disclose {
+			fmt.Printf("%s: %s\n", name, disclosed[name])
+		}
+		for _, p := range req.Predicates {
+			fmt.Printf("%s: proven\n", p)
+		}
+
 	case genAggregateCred.FullCommand():
-		log.Printf("AggregateCred\n")
+		slog.Info("AggregateCred")
 		// UserAttributeNames := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
-		// log.Printf("UserAttributeNames is %v\n", UserAttributeNames)
+		// slog.Debug("UserAttributeNames", "attrs", UserAttributeNames)
 
 		// key, _ := readIssuerKey()
 		// ukey := readUserKey()
@@ -205,69 +682,403 @@ func main() {
 		// // Write config to file
 		// handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred), 0770))
 		// writeFile(filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigAggregateCred), aggregateconfig)
-		// log.Printf("write aggregate cred successful")
+		// slog.Info("write aggregate cred successful")
 
 	}
 }
 
 
 
+// printValidity prints the validity window of a credential with the given attributes, if it has one
+func printValidity(attrs []string) {
+	notBefore, notAfter, err := rpsidentity.Validity(attrs)
+	if err != nil {
+		fmt.Println("Credential has no validity window")
+		return
+	}
+	fmt.Printf("Credential valid from %s until %s\n", notBefore.UTC().Format(time.RFC3339), notAfter.UTC().Format(time.RFC3339))
+	if time.Now().After(notAfter) {
+		fmt.Println("Credential has expired")
+	}
+}
+
+// writeJSON writes a document as indented JSON
+func writeJSON(path string, doc interface{}) {
+	docJSON, err := json.MarshalIndent(doc, "", "  ")
+	handleError(err)
+	writeFile(path, docJSON)
+}
+
 // writeFile writes bytes to a file and panics in case of an error
 func writeFile(path string, contents []byte) {
 	handleError(ioutil.WriteFile(path, contents, 0640))
 }
 
-// readIssuerKey reads the issuer key from the current directory
-func readIssuerKey() (rpsidentity.IssuerKeyPS, []byte) {
-	path := filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerSecretKey)
-	//isk, err := ioutil.ReadFile(path)
-	iskBytes, err := ioutil.ReadFile(path)
+// writeFileAtomic replaces the file by the bytes, written to a temporary file in the same directory that is
+// renamed to path, so that an interrupted write leaves the file as it was
+func writeFileAtomic(path string, contents []byte) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	handleError(err)
+	_, err = tmp.Write(contents)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0640)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
 	if err != nil {
-		handleError(errors.Wrapf(err, "failed to open issuer secret key file: %s", path))
+		os.Remove(tmp.Name())
+		handleError(errors.Wrapf(err, "failed to write %s", path))
+	}
+}
+
+// setupLogger logs the records of the --log-level and above to standard error in the --log-format,
+// for the CLI and the library
+func setupLogger() {
+	var level slog.Level
+	handleError(level.UnmarshalText([]byte(*logLevel)))
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if *logFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	logger := slog.New(rpsidentity.NewRedactingHandler(handler))
+	slog.SetDefault(logger)
+	rpsidentity.SetLogger(logger)
+}
+
+// setupMetrics records the metrics of the library in a registry if --metrics-out is set or the command serves
+// them, nil otherwise
+func setupMetrics(serving bool) *rpsidentity.MetricsRegistry {
+	if *metricsOut == "" && !serving {
+		return nil
+	}
+	registry := rpsidentity.NewMetricsRegistry()
+	rpsidentity.SetMetrics(registry)
+	return registry
+}
+
+// writeMetrics writes the metrics of the registry to the --metrics-out file, once: at the end of main or by
+// handleError before exiting, so that the failed commands are counted too
+func writeMetrics() {
+	registry := metrics
+	if registry == nil || *metricsOut == "" {
+		return
+	}
+	metrics = nil
+	f, err := os.Create(*metricsOut)
+	if err == nil {
+		err = registry.WritePrometheus(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
 	}
-	path = filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey)
-	ipkBytes, err := ioutil.ReadFile(path)
 	if err != nil {
-		handleError(errors.Wrapf(err, "failed to open issuer public key file: %s", path))
+		fmt.Fprintln(os.Stderr, errors.Wrapf(err, "failed to write %s", *metricsOut))
+		os.Exit(1)
 	}
+}
 
-	//ipk := &rpsidentity.IssuerPublicKey{}
-	ipk := &rpsidentity.IssuerPublicKeyPS{}
-	handleError(proto.Unmarshal(ipkBytes, ipk))
-	//log.Printf("User key Ipk is %v",ipk)
-	isk := &rpsidentity.IssuerPrivateKeyPS{}
-	handleError(proto.Unmarshal(iskBytes, isk))
-	//log.Printf("User key Isk is %v",isk)
-	log.Printf("Restore issuer Isk and Ipk successful.")
+// newPsidentity returns the Psidentity for the --curve, --compressed, --parallelism and --revocation-* flags.
+// With --revocation-cri, the derive creds prove their non-revocation in the epoch of the CRI and, for an accumulator
+// CRI, the membership of their revocation handle in its accumulator.
+func newPsidentity() *rpsidentity.Psidentity {
+	psid, err := rpsidentity.NewPsidentityForCurve(*curveID)
+	handleError(err)
+	psid.Parallelism = *parallelism
+	if *revocationPk != "" {
+		raw, err := ioutil.ReadFile(*revocationPk)
+		handleError(errors.Wrapf(err, "failed to open %s", *revocationPk))
+		pk, err := rpsidentity.LongTermRevocationPublicKeyFromBytes(raw)
+		handleError(err)
+		psid.NonRevocation = &rpsidentity.NonRevocationPolicy{RevocationPk: pk, Epoch: *revocationEpoch, HandleIndex: *revocationHandleIndex}
+		if *revocationCRI != "" {
+			cri := readCRI()
+			if cri.GetRevocationAlg() == int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+				psid.NonRevocation.AccumulatorCRI = cri
+			}
+			psid.NonRevocation.Epoch = int(cri.GetEpoch())
+		}
+	}
+	if *compressed {
+		psid.Translator = rpsidentity.CompressedTranslator(psid.Translator)
+	}
+	return psid
+}
 
-	//key := rpsidentity.IssuerKey{Isk: isk, Ipk: ipk}
-	key := rpsidentity.IssuerKeyPS{Isk: isk, Ipk: ipk}
+// writeArtifact writes the payload of an artifact in the envelope format, secret keys are sealed under the passphrase
+// unless --plaintext-keys is set
+func writeArtifact(path, artifactType string, issuerKeyHash, payload []byte) {
+	var raw []byte
+	var err error
+	if rpsidentity.IsSecretArtifact(artifactType) && !*plaintextKeys {
+		raw, err = newPsidentity().WrapSealedArtifact(artifactType, issuerKeyHash, payload, passphrase(true), rand.Reader)
+	} else {
+		raw, err = newPsidentity().WrapArtifact(artifactType, issuerKeyHash, payload)
+	}
+	handleError(errors.WithMessagef(err, "failed to wrap %s", path))
+	writeFile(path, raw)
+}
 
-	return key, ipkBytes
+// unwrapArtifact returns the payload of an artifact read from path after checking its type, curve and, if
+// issuerKeyHash is not nil, issuer key. Artifacts in the legacy format are accepted with a warning.
+// Sealed secret keys are opened with the passphrase.
+func unwrapArtifact(path string, raw []byte, artifactType string, issuerKeyHash []byte) []byte {
+	env, err := newPsidentity().UnwrapArtifact(raw, artifactType, issuerKeyHash)
+	handleError(errors.WithMessagef(err, "invalid artifact %s", path))
+	if env.GetSchemaVersion() < rpsidentity.EnvelopeSchemaVersion {
+		slog.Warn("file is in the legacy format, convert it with the migrate command", "path", path)
+	}
+	if !env.GetSealed() {
+		return env.GetPayload()
+	}
+	payload, err := env.OpenPayload(passphrase(false))
+	handleError(errors.WithMessagef(err, "failed to open %s", path))
+	return payload
 }
 
-func readUserKey() rpsidentity.UserKey {
-	path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey)
-	uskBytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		handleError(errors.Wrapf(err, "failed to open user secret key file: %s", path))
+// keyStore returns the key store of the key directories in the output directory. Secret keys are sealed under
+// the passphrase, which is only read when a sealed key is opened.
+func keyStore() rpsidentity.KeyStore {
+	return rpsidentity.NewEncryptedFileKeyStore(*outputDir, newPsidentity(), func() ([]byte, error) {
+		return passphrase(false), nil
+	})
+}
+
+// rekeySecretKeys seals the secret keys under the new passphrase, or stores them in plaintext with --plaintext-keys.
+// Keys in the legacy format are converted to the envelope format.
+func rekeySecretKeys() {
+	var ipkHash []byte
+	ipkPath := filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey)
+	if ipkBytes, err := ioutil.ReadFile(ipkPath); err == nil {
+		ipkHash, err = rpsidentity.IssuerKeyHash(unwrapArtifact(ipkPath, ipkBytes, psidentity.PsIdentityConfigIssuerPublicKey, nil))
+		handleError(err)
 	}
-	path = filepath.Join(*outputDir, psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey)
-	upkBytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		handleError(errors.Wrapf(err, "failed to open user public key file: %s", path))
+
+	var newPassphrase []byte
+	if !*plaintextKeys {
+		newPassphrase = readPassphrase(*rekeyPassphraseFile, *rekeyPassphraseEnv, "New passphrase of the secret keys", true)
 	}
 
-	upk := &rpsidentity.UserPublicKey{}
-	handleError(proto.Unmarshal(upkBytes, upk))
-	usk := &rpsidentity.UserPrivateKey{}
-	handleError(proto.Unmarshal(uskBytes, usk))
-	log.Printf("Restore user Isk and Ipk successful.")
+	artifacts := [][2]string{
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerSecretKey},
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigRevocationKey},
+		{psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigLongTermRevocationKey},
+		{psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigPairingAccumulatorKey},
+		{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey},
+		{psidentity.PsIdentityDirDID, psidentity.PsIdentityConfigIssuerDIDKey},
+		{psidentity.PsIdentityDirDID, psidentity.PsIdentityConfigUserDIDKey},
+	}
+	for _, artifact := range artifacts {
+		path := filepath.Join(*outputDir, artifact[0], artifact[1])
+		raw, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		handleError(err)
 
-	key := rpsidentity.UserKey{Usk: usk, Upk: upk}
+		env, err := newPsidentity().UnwrapArtifact(raw, artifact[1], ipkHash)
+		handleError(errors.WithMessagef(err, "invalid artifact %s", path))
+		var oldPassphrase []byte
+		if env.GetSealed() {
+			oldPassphrase = passphrase(false)
+		}
+		resealed, err := rpsidentity.ResealArtifact(raw, artifact[1], ipkHash, oldPassphrase, newPassphrase, rand.Reader)
+		handleError(errors.WithMessagef(err, "failed to rekey %s", path))
+		writeFileAtomic(path, resealed)
+		if newPassphrase == nil {
+			fmt.Printf("Stored %s in plaintext\n", path)
+		} else {
+			fmt.Printf("Sealed %s under the new passphrase\n", path)
+		}
+	}
+}
 
-	return key
+// migrate converts the artifacts in the legacy format to the envelope format, the legacy files are kept with the suffix .legacy
+func migrate() {
+	var ipkHash []byte
+	ipkPath := filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey)
+	if ipkBytes, err := ioutil.ReadFile(ipkPath); err == nil {
+		ipkHash, err = rpsidentity.IssuerKeyHash(unwrapArtifact(ipkPath, ipkBytes, psidentity.PsIdentityConfigIssuerPublicKey, nil))
+		handleError(err)
+	}
+
+	artifacts := [][2]string{
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey},
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerSecretKey},
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigRevocationKey},
+		{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigRevocationTrapdoor},
+		{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey},
+		{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey},
+		{psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigPrimaryCred},
+		{psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigDeriveCred},
+		{psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigAggregateCred},
+		{psidentity.PsIdentityDirDID, psidentity.PsIdentityConfigIssuerDIDKey},
+		{psidentity.PsIdentityDirDID, psidentity.PsIdentityConfigUserDIDKey},
+	}
+	for _, artifact := range artifacts {
+		path := filepath.Join(*outputDir, artifact[0], artifact[1])
+		raw, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		handleError(err)
+		if rpsidentity.IsEnvelope(raw) {
+			continue
+		}
+
+		migrated, err := rpsidentity.MigrateArtifact(raw, artifact[1], ipkHash)
+		handleError(errors.WithMessagef(err, "failed to migrate %s", path))
+		writeFile(path+".legacy", raw)
+		writeFile(path, migrated)
+		fmt.Printf("Migrated %s\n", path)
+	}
+}
+
+// readIssuerPublicKey reads the issuer public key, without the issuer secret key
+func readIssuerPublicKey() *rpsidentity.IssuerPublicKeyPS {
+	ipk, err := rpsidentity.StoredIssuerPublicKey(keyStore(), psidentity.PsIdentityDirIssuerKey)
+	handleError(errors.WithMessage(err, "failed to read issuer public key"))
+	handleError(errors.WithMessage(rpsidentity.CheckCurveID(*curveID, ipk.GetCurveId()), "issuer public key"))
+	return ipk
+}
+
+// issuerKeySet returns the issuer keys creds are accepted of: the issuer public key and, with --accept-retiring-keys,
+// the archived issuer public keys that have not retired yet
+func issuerKeySet() *rpsidentity.IssuerKeySet {
+	psid := newPsidentity()
+	keys, err := psid.NewIssuerKeySet(readIssuerPublicKey())
+	handleError(err)
+	if !*acceptRetiringKeys {
+		return keys
+	}
+
+	// the archived keys are added from the newest version, each is linked to the next by its rotation statement
+	for version := keys.Active().GetVersion(); version > 0; version-- {
+		name := rpsidentity.ArchivedIssuerKeyName(psidentity.PsIdentityDirIssuerKey, version-1)
+		path := filepath.Join(*outputDir, name, psidentity.PsIdentityConfigIssuerKeyRotation)
+		raw, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		handleError(errors.Wrapf(err, "failed to open issuer key rotation file: %s", path))
+		rotation := &rpsidentity.IssuerKeyRotation{}
+		handleError(proto.Unmarshal(unwrapArtifact(path, raw, psidentity.PsIdentityConfigIssuerKeyRotation, nil), rotation))
+		ipk, err := rpsidentity.StoredIssuerPublicKey(keyStore(), name)
+		handleError(errors.WithMessagef(err, "failed to read issuer public key %s", name))
+		if err := keys.AddRetiring(ipk, rotation); err != nil {
+			slog.Warn("Not accepting issuer key", "version", ipk.GetVersion(), "err", err)
+			break
+		}
+	}
+	return keys
+}
+
+// vcIssuerKey returns the accepted issuer key a verifiable credential names as its issuer
+func vcIssuerKey(issuer string) *rpsidentity.IssuerPublicKeyPS {
+	ipk, err := acceptedIssuerKey(issuerKeySet(), issuer)
+	handleError(err)
+	return ipk
+}
+
+// acceptedIssuerKey returns the key of the issuer key set a verifiable credential names as its issuer
+func acceptedIssuerKey(keys *rpsidentity.IssuerKeySet, issuer string) (*rpsidentity.IssuerPublicKeyPS, error) {
+	for _, ipk := range keys.Keys(time.Now()) {
+		if rpsidentity.VCIssuer(ipk) == issuer {
+			return ipk, nil
+		}
+	}
+	return nil, errors.Errorf("credential issuer %s is not an accepted issuer key", issuer)
+}
+
+// maxVerifyRequest is the largest document the /verify endpoint of serve reads
+const maxVerifyRequest = 1 << 20
+
+// serveVerifier verifies the verifiable credentials and presentations POSTed to /verify with the issuer keys and
+// the user public key read at startup, and serves the metrics of the verifications at /metrics. The server is
+// restarted to accept rotated keys.
+func serveVerifier(psid rpsidentity.Psidentity) {
+	keys := issuerKeySet()
+	upk, err := rpsidentity.StoredUserPublicKey(keyStore(), psidentity.PsIdentityDirUserKey)
+	if err != nil {
+		slog.Info("No user public key, verifiable presentations are refused", "err", err)
+		upk = nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.HandleFunc("/verify", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST a verifiable credential or presentation", http.StatusMethodNotAllowed)
+			return
+		}
+		raw, err := ioutil.ReadAll(io.LimitReader(r.Body, maxVerifyRequest))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result := struct {
+			Valid bool   `json:"valid"`
+			Error string `json:"error,omitempty"`
+		}{Valid: true}
+		status := http.StatusOK
+		if err := verifyDocument(&psid, keys, upk, raw); err != nil {
+			result.Valid, result.Error, status = false, err.Error(), http.StatusUnprocessableEntity
+			slog.Info("verification failed", "err", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			slog.Warn("cannot write response", "err", err)
+		}
+	})
+
+	slog.Info("Serving /verify and /metrics", "listen", *serveListen)
+	handleError(http.ListenAndServe(*serveListen, mux))
+}
+
+// verifyDocument verifies a verifiable credential or presentation with the issuer key it names
+func verifyDocument(psid *rpsidentity.Psidentity, keys *rpsidentity.IssuerKeySet, upk *rpsidentity.UserPublicKey, raw []byte) error {
+	if rpsidentity.IsVP(raw) {
+		vp, err := rpsidentity.ParseVP(raw)
+		if err != nil {
+			return err
+		}
+		if len(vp.VerifiableCredential) == 0 {
+			return errors.New("verifiable presentation has no credential")
+		}
+		if upk == nil {
+			return errors.New("no user public key to verify the presentation with")
+		}
+		ipk, err := acceptedIssuerKey(keys, vp.VerifiableCredential[0].Issuer)
+		if err != nil {
+			return err
+		}
+		return errors.WithMessage(psid.VerifyVP(vp, ipk, upk, time.Now()), "verifiable presentation is not valid")
+	}
+	vc, err := rpsidentity.ParseVC(raw)
+	if err != nil {
+		return err
+	}
+	ipk, err := acceptedIssuerKey(keys, vc.Issuer)
+	if err != nil {
+		return err
+	}
+	return errors.WithMessage(psid.VerifyVC(vc, ipk, time.Now()), "verifiable credential is not valid")
+}
 
+// readUserPublicKey reads the user public key, without the user secret key
+func readUserPublicKey() *rpsidentity.UserPublicKey {
+	upk, err := rpsidentity.StoredUserPublicKey(keyStore(), psidentity.PsIdentityDirUserKey)
+	handleError(errors.WithMessage(err, "failed to read user public key"))
+	handleError(errors.WithMessage(rpsidentity.CheckCurveID(*curveID, upk.GetCurveId()), "user public key"))
+	return upk
 }
 
 // func readUserCred() rpsidentity.PrimaryCredential {
@@ -287,15 +1098,18 @@ func readUserKey() rpsidentity.UserKey {
 
 
 
-func readUserPrimaryCred() rpsidentity.PrimaryCredential {
+// readUserPrimaryCred reads the user primary cred issued with the issuer key of the given hash
+func readUserPrimaryCred(ipkHash []byte) rpsidentity.PrimaryCredential {
 	path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigPrimaryCred)
 	confBytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		handleError(errors.Wrapf(err, "failed to open user cred file: %s", path))
 	}
+	confBytes = unwrapArtifact(path, confBytes, psidentity.PsIdentityConfigPrimaryCred, ipkHash)
 
 	conf := &user.UserPrimaryCred{}
 	handleError(proto.Unmarshal(confBytes, conf))
+	handleError(errors.WithMessage(rpsidentity.CheckCurveID(*curveID, conf.GetCurveId()), "user primary cred"))
 	cred := &rpsidentity.PrimaryCredential{}
 	handleError(proto.Unmarshal(conf.PrimaryCred, cred))
 
@@ -314,15 +1128,35 @@ func readUserPrimaryCred() rpsidentity.PrimaryCredential {
 
 
 
+// readUserAggregateCred reads the user aggregate cred of derive creds issued with the issuer key of the given hash
+func readUserAggregateCred(ipkHash []byte) *rpsidentity.AggregateCredential {
+	path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigAggregateCred)
+	confBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open user cred file: %s", path))
+	}
+	confBytes = unwrapArtifact(path, confBytes, psidentity.PsIdentityConfigAggregateCred, ipkHash)
+
+	conf := &user.UserAggregateCred{}
+	handleError(proto.Unmarshal(confBytes, conf))
+	handleError(errors.WithMessage(rpsidentity.CheckCurveID(*curveID, conf.GetCurveId()), "user aggregate cred"))
+	cred := &rpsidentity.AggregateCredential{}
+	handleError(proto.Unmarshal(conf.AggregateCred, cred))
+
+	return cred
+}
+
 func readUserDeriveCred() rpsidentity.DeriveCredential {
 	path := filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigDeriveCred)
 	confBytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		handleError(errors.Wrapf(err, "failed to open user cred file: %s", path))
 	}
+	confBytes = unwrapArtifact(path, confBytes, psidentity.PsIdentityConfigDeriveCred, nil)
 
 	conf := &user.UserDeriveCred{}
 	handleError(proto.Unmarshal(confBytes, conf))
+	handleError(errors.WithMessage(rpsidentity.CheckCurveID(*curveID, conf.GetCurveId()), "user derive cred"))
 	cred := &rpsidentity.DeriveCredential{}
 	handleError(proto.Unmarshal(conf.DeriveCred, cred))
 
@@ -343,46 +1177,87 @@ func readUserDeriveCred() rpsidentity.DeriveCredential {
 
 
 
-func readRevocationKey() rpsidentity.RsaKey {
-	path := filepath.Join(*outputDir, psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigRevocationKey)
+// readLongTermRevocationKey reads the long term revocation key signing the CRIs, opening it with the passphrase if it is sealed
+func readLongTermRevocationKey() *ecdsa.PrivateKey {
+	path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigLongTermRevocationKey)
 	keyBytes, err := ioutil.ReadFile(path)
 	if err != nil {
-		handleError(errors.Wrapf(err, "failed to open revocation secret key file: %s", path))
+		handleError(errors.Wrapf(err, "failed to open long term revocation key file: %s", path))
 	}
+	key, err := newPsidentity().LongTermRevocationKeyFromBytes(unwrapArtifact(path, keyBytes, psidentity.PsIdentityConfigLongTermRevocationKey, nil))
+	handleError(err)
 
-	rk := &rpsidentity.RsaKey{}
-	handleError(proto.Unmarshal(keyBytes, rk))
+	slog.Debug("Restore long term revocation key successful.")
 
-	log.Printf("Restore revocation key successful.")
-
-
-	return *rk
+	return key
 }
 
+// optionalAccumulatorKey reads the secret key of the accumulator, nil if there is no revocation authority
+func optionalAccumulatorKey() *rpsidentity.PairingAccumulatorKey {
+	path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigPairingAccumulatorKey)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	return readAccumulatorKey()
+}
 
+// readAccumulatorKey reads the secret key of the accumulator, opening it with the passphrase if it is sealed
+func readAccumulatorKey() *rpsidentity.PairingAccumulatorKey {
+	path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigPairingAccumulatorKey)
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open accumulator key file, create it with revocation-keygen: %s", path))
+	}
+	key := &rpsidentity.PairingAccumulatorKey{}
+	handleError(proto.Unmarshal(unwrapArtifact(path, keyBytes, psidentity.PsIdentityConfigPairingAccumulatorKey, nil), key))
 
+	slog.Debug("Restore accumulator key successful.")
 
+	return key
+}
 
+// readCRI reads the CRI of --revocation-cri. It is public and not read with unwrapArtifact, which needs the
+// Psidentity the CRI is part of.
+func readCRI() *rpsidentity.CredentialRevocationInformation {
+	raw, err := ioutil.ReadFile(*revocationCRI)
+	handleError(errors.Wrapf(err, "failed to open %s", *revocationCRI))
+	payload, err := rpsidentity.ArtifactPayload(raw, psidentity.PsIdentityConfigCRI)
+	handleError(errors.WithMessagef(err, "invalid artifact %s", *revocationCRI))
+	cri := &rpsidentity.CredentialRevocationInformation{}
+	handleError(proto.Unmarshal(payload, cri))
+	return cri
+}
 
+// nonRevocationWitness returns the CRI of --revocation-cri and, for an accumulator CRI, the witness of the primary
+// cred the derive cred proves its non-revocation with, nil without --revocation-cri
+func nonRevocationWitness() *rpsidentity.NonRevocationWitness {
+	if *revocationCRI == "" {
+		return nil
+	}
+	cri := readCRI()
+	if cri.GetRevocationAlg() != int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		return &rpsidentity.NonRevocationWitness{CRI: cri, HandleIndex: *revocationHandleIndex}
+	}
+	path := *genDeriveCredWitness
+	if path == "" {
+		path = filepath.Join(*outputDir, psidentity.PsIdentityDirUserCred, psidentity.PsIdentityConfigWitness)
+	}
+	witnessBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open witness file, issue it with issue-witness: %s", path))
+	}
+	witness := &rpsidentity.PairingAccumulatorWitness{}
+	handleError(proto.Unmarshal(unwrapArtifact(path, witnessBytes, psidentity.PsIdentityConfigWitness, nil), witness))
+	return &rpsidentity.NonRevocationWitness{CRI: cri, Witness: witness, HandleIndex: *revocationHandleIndex}
+}
 
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
+// newRevocationHandle returns a random revocation handle attribute
+func newRevocationHandle() string {
+	handle := make([]byte, 32)
+	_, err := rand.Read(handle)
+	handleError(err)
+	return hex.EncodeToString(handle)
+}
 
 //func readRevocationKey() *ecdsa.PrivateKey {
 //	path := filepath.Join(*outputDir, psidentity.PsIdentityDirIssuer, psidentity.PsIdentityConfigRevocationKey)
@@ -411,6 +1286,158 @@ func readRevocationKey() rpsidentity.RsaKey {
 // 	return keyBytes
 // }
 
+// readRevocationState reads the accumulator state created by revocation-keygen
+func readRevocationState() *rpsidentity.RevocationState {
+	path := filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationState)
+	stateBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open accumulator state file, create it with revocation-keygen: %s", path))
+	}
+
+	stateBytes = unwrapArtifact(path, stateBytes, psidentity.PsIdentityConfigRevocationState, nil)
+
+	state := &rpsidentity.RevocationState{}
+	handleError(proto.Unmarshal(stateBytes, state))
+
+	return state
+}
+
+// writeRevocationState writes the accumulator state next to the revocation authority keys
+func writeRevocationState(state *rpsidentity.RevocationState) {
+	stateBytes, err := proto.Marshal(state)
+	handleError(err)
+	handleError(os.MkdirAll(filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation), 0770))
+	writeArtifact(filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationState), psidentity.PsIdentityConfigRevocationState, nil, stateBytes)
+}
+
+// readCredHandle reads a primary cred file and returns its revocation handle
+func readCredHandle(path string) *math.Zr {
+	confBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open user cred file: %s", path))
+	}
+	confBytes = unwrapArtifact(path, confBytes, psidentity.PsIdentityConfigPrimaryCred, nil)
+	rh, err := rpsidentity.PrimaryCredHandle(confBytes, *revocationHandleIndex, newPsidentity().Curve)
+	handleError(err)
+	return rh
+}
+
+// inspectKeys reads the public keys, the accumulator state and the CRI in the output directory that exist,
+// the artifacts are verified with them
+func inspectKeys() *rpsidentity.InspectKeys {
+	keys := &rpsidentity.InspectKeys{}
+	read := func(dir, artifactType string, msg proto.Message) bool {
+		path := filepath.Join(*outputDir, dir, artifactType)
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		payload, err := rpsidentity.ArtifactPayload(raw, artifactType)
+		if err == nil {
+			err = proto.Unmarshal(payload, msg)
+		}
+		if err != nil {
+			slog.Warn("cannot read file", "path", path, "err", err)
+			return false
+		}
+		return true
+	}
+
+	if ipk := (&rpsidentity.IssuerPublicKeyPS{}); read(psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey, ipk) {
+		keys.Ipk = ipk
+	}
+	if upk := (&rpsidentity.UserPublicKey{}); read(psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey, upk) {
+		keys.Upk = upk
+	}
+	if state := (&rpsidentity.RevocationState{}); read(psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationState, state) {
+		keys.Revocation = state
+	}
+	if cri := (&rpsidentity.CredentialRevocationInformation{}); read(psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigCRI, cri) {
+		keys.CRI = cri
+	}
+	if raw, err := ioutil.ReadFile(filepath.Join(*outputDir, psidentity.PsIdentityDirRevocation, psidentity.PsIdentityConfigRevocationPublicKey)); err == nil {
+		pk, err := rpsidentity.LongTermRevocationPublicKeyFromBytes(raw)
+		if err != nil {
+			slog.Warn("cannot read long term revocation public key", "err", err)
+		} else {
+			keys.RevocationPk = pk
+		}
+	}
+	return keys
+}
+
+// didRegistry returns the local DID registry
+func didRegistry() rpsidentity.DIDRegistry {
+	dir := *didRegistryDir
+	if dir == "" {
+		dir = filepath.Join(*outputDir, psidentity.PsIdentityDirDIDRegistry)
+	}
+	return rpsidentity.NewFileDIDRegistry(dir)
+}
+
+// wallet returns the wallet in the output directory
+func wallet() *rpsidentity.Wallet {
+	return rpsidentity.NewWallet(filepath.Join(*outputDir, psidentity.PsIdentityDirWallet), newPsidentity())
+}
+
+// readPresentationRequest reads a presentation request in JSON or YAML
+func readPresentationRequest(path string) *rpsidentity.PresentationRequest {
+	raw, err := ioutil.ReadFile(path)
+	handleError(errors.Wrapf(err, "failed to open %s", path))
+	req, err := rpsidentity.ParsePresentationRequest(raw)
+	handleError(errors.WithMessagef(err, "presentation request %s", path))
+	return req
+}
+
+// printWalletEntry prints the ID, issuer key, schema and validity window of a cred in the wallet
+func printWalletEntry(entry *rpsidentity.WalletEntry) {
+	validity := "no validity window"
+	if !entry.NotAfter.IsZero() {
+		validity = fmt.Sprintf("valid from %s until %s", entry.NotBefore.Format(time.RFC3339), entry.NotAfter.Format(time.RFC3339))
+		if !entry.ValidAt(time.Now()) {
+			validity += " (not valid now)"
+		}
+	}
+	fmt.Printf("%s issuer %s schema %s %v, %s\n", entry.ID, entry.IssuerKeyID, entry.Schema, entry.Attributes, validity)
+}
+
+// didFiles returns the paths of the DID and of the DID controller key of the role, and the artifact type of the key
+func didFiles(role string) (string, string, string) {
+	dir := filepath.Join(*outputDir, psidentity.PsIdentityDirDID)
+	if role == didRoleUser {
+		return filepath.Join(dir, psidentity.PsIdentityConfigUserDID), filepath.Join(dir, psidentity.PsIdentityConfigUserDIDKey), psidentity.PsIdentityConfigUserDIDKey
+	}
+	return filepath.Join(dir, psidentity.PsIdentityConfigIssuerDID), filepath.Join(dir, psidentity.PsIdentityConfigIssuerDIDKey), psidentity.PsIdentityConfigIssuerDIDKey
+}
+
+// didDocument returns the DID document of the controller key publishing the public key of the role
+func didDocument(role string, key *ecdsa.PrivateKey) *rpsidentity.DIDDocument {
+	doc, err := rpsidentity.NewDIDDocument(&key.PublicKey)
+	handleError(err)
+	if role == didRoleUser {
+		handleError(doc.AddUserKey(readUserPublicKey()))
+	} else {
+		handleError(doc.AddIssuerKey(readIssuerPublicKey()))
+	}
+	return doc
+}
+
+// readDIDControllerKey reads the DID controller key of the role, opening it with the passphrase if it is sealed, and its DID
+func readDIDControllerKey(role string) (*ecdsa.PrivateKey, string) {
+	didPath, keyPath, keyType := didFiles(role)
+	keyBytes, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open DID controller key file: %s", keyPath))
+	}
+	key, err := rpsidentity.DIDControllerKeyFromBytes(unwrapArtifact(keyPath, keyBytes, keyType, nil))
+	handleError(err)
+	id, err := ioutil.ReadFile(didPath)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open DID file: %s", didPath))
+	}
+	return key, string(id)
+}
+
 // checkDirectoryNotExists checks whether a directory with the given path already exists and exits if this is the case
 func checkDirectoryNotExists(path string, errorMessage string) {
 	_, err := os.Stat(path)
@@ -422,6 +1449,7 @@ func checkDirectoryNotExists(path string, errorMessage string) {
 func handleError(err error) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		writeMetrics()
 		os.Exit(1)
 	}
 }
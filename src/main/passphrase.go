@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// the passphrase sealing the secret keys, read once per run
+var keyPassphrase []byte
+
+// passphrase returns the passphrase sealing the secret keys from --passphrase-file, the --passphrase-env
+// environment variable or a prompt, confirm asks twice when the passphrase is prompted for new keys
+func passphrase(confirm bool) []byte {
+	if keyPassphrase == nil {
+		keyPassphrase = readPassphrase(*passphraseFile, *passphraseEnv, "Passphrase of the secret keys", confirm)
+	}
+	return keyPassphrase
+}
+
+// readPassphrase reads a passphrase from a file, an environment variable or, if neither is set, the terminal
+func readPassphrase(file, env, prompt string, confirm bool) []byte {
+	if file != "" {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			handleError(errors.Wrapf(err, "failed to read passphrase file: %s", file))
+		}
+		pass := bytes.TrimRight(raw, "\r\n")
+		if len(pass) == 0 {
+			handleError(errors.Errorf("passphrase file %s is empty", file))
+		}
+		return pass
+	}
+	if env != "" && os.Getenv(env) != "" {
+		return []byte(os.Getenv(env))
+	}
+
+	if !isTerminal(int(os.Stdin.Fd())) {
+		handleError(errors.Errorf("%s required, set $%s or use a passphrase file", prompt, env))
+	}
+	pass := promptPassphrase(prompt)
+	if len(pass) == 0 {
+		handleError(errors.New("empty passphrase"))
+	}
+	if confirm && !bytes.Equal(pass, promptPassphrase("Repeat "+prompt)) {
+		handleError(errors.New("passphrases do not match"))
+	}
+	return pass
+}
+
+// promptPassphrase reads a line from the terminal without echo
+func promptPassphrase(prompt string) []byte {
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	pass, err := readNoEcho(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	handleError(errors.WithMessage(err, "failed to read passphrase"))
+	return pass
+}
@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// isTerminal reports whether fd is a terminal
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	return err == nil
+}
+
+// readNoEcho reads a line from the terminal fd with the echo turned off
+func readNoEcho(fd int) ([]byte, error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	saved := *termios
+	termios.Lflag &^= unix.ECHO
+	termios.Lflag |= unix.ICANON | unix.ISIG
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return nil, err
+	}
+	defer unix.IoctlSetTermios(fd, unix.TCSETS, &saved)
+
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := unix.Read(fd, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || buf[0] == '\n' {
+			break
+		}
+		if buf[0] != '\r' {
+			line = append(line, buf[0])
+		}
+	}
+	return line, nil
+}
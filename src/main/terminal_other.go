@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+// isTerminal reports whether fd is a terminal, passphrases are only prompted on linux
+func isTerminal(fd int) bool {
+	return false
+}
+
+// readNoEcho is not supported outside linux
+func readNoEcho(fd int) ([]byte, error) {
+	return nil, errors.New("passphrase prompt not supported")
+}
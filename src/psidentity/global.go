@@ -44,6 +44,17 @@ const (
 	UserAttributeLevel = "LevelOne"
 )
 
+const (
+	// IssuerAttributeNotBefore is the attribute name of the start of the validity window
+	IssuerAttributeNotBefore = "NotBefore"
+
+	// IssuerAttributeNotAfter is the attribute name of the end of the validity window
+	IssuerAttributeNotAfter = "NotAfter"
+)
+
+// IssuerAttributeNames are the names of the attributes of the issuer key, by attribute index
+var IssuerAttributeNames = []string{IssuerAttributeOne, IssuerAttributeTwo, IssuerAttributeThree, IssuerAttributeFour, IssuerAttributeNotBefore, IssuerAttributeNotAfter}
+
 
 const (
 	// AttributeNameOU is the attribute name of the Organization Unit attribute
@@ -66,6 +77,8 @@ const (
 	PsIdentityConfigIssuerPublicKey         = "IssuerPublicKey"
 	PsIdentityConfigIssuerSecretKey			= "IssuerSecretKey"
 	PsIdentityConfigRevocationKey   		= "RevocationKey"
+	PsIdentityConfigRevocationTrapdoor		= "RevocationTrapdoor"
+	PsIdentityConfigIssuerKeyRotation       = "IssuerKeyRotation"
 
 	PsIdentityDirUserKey                    = "user-key"
 	PsIdentityConfigUserSecretKey			= "UserSecretKey"
@@ -75,6 +88,28 @@ const (
 	PsIdentityConfigPrimaryCred             = "PrimaryCred"
 	PsIdentityConfigDeriveCred			    = "DeriveCred"
 	PsIdentityConfigAggregateCred			= "AggregateCred"
+	PsIdentityConfigWitness				    = "Witness"
+	PsIdentityConfigPrimaryCredVC             = "PrimaryCred.vc.json"
+	PsIdentityConfigDeriveCredVC              = "DeriveCred.vc.json"
+	PsIdentityConfigAggregateCredVP           = "AggregateCred.vp.json"
+
+	PsIdentityDirRevocation                 = "revocation"
+	PsIdentityConfigRevocationState         = "RevocationState"
+	PsIdentityConfigCRI                     = "CRI"
+	PsIdentityConfigRevocationPublicKey     = "RevocationPublicKey"
+	PsIdentityConfigLongTermRevocationKey   = "LongTermRevocationKey"
+	PsIdentityConfigPairingAccumulatorKey   = "PairingAccumulatorKey"
+	PsIdentityConfigPairingAccumulator      = "PairingAccumulator"
+
+	PsIdentityDirDID                        = "did"
+	PsIdentityConfigIssuerDID               = "IssuerDID"
+	PsIdentityConfigIssuerDIDKey            = "IssuerDIDControllerKey"
+	PsIdentityConfigUserDID                 = "UserDID"
+	PsIdentityConfigUserDIDKey              = "UserDIDControllerKey"
+	PsIdentityDirDIDRegistry                = "did-registry"
+
+	PsIdentityDirWallet                     = "wallet"
+	PsIdentityConfigWalletEntry             = "WalletEntry.json"
 
 
 	// PsIdentityConfigDirUser                 = "user-config"
@@ -94,4 +129,6 @@ type RevocationAlgorithm int32
 
 const (
 	ALG_NO_REVOCATION RevocationAlgorithm = iota
+	ALG_PAIRING_ACCUMULATOR
+	ALG_EPOCH_SIGNATURE
 )
@@ -1,10 +1,94 @@
 package psidentity
 
 import (
+	"encoding/binary"
 	"math"
 	"math/big"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// HashToPrimeVersion selects the hash-to-prime function used by the accumulator and CreateCRI
+type HashToPrimeVersion uint32
+
+const (
+	// HashToPrimeV0 is the original Hprime, kept to verify accumulators and CRIs created with it
+	HashToPrimeV0 HashToPrimeVersion = iota
+	// HashToPrimeV1 is the SHAKE256 based hash-to-prime, see hashToPrimeV1
+	HashToPrimeV1
+)
+
+// DefaultHashToPrimeVersion is the version used for new accumulators and CRIs
+const DefaultHashToPrimeVersion = HashToPrimeV1
+
+const (
+	// hashToPrimeDomain separates hash-to-prime inputs from any other use of SHAKE256
+	hashToPrimeDomain = "psidentity/hash-to-prime/v1"
+	// hashToPrimeBits is the bit size of the primes of HashToPrimeV1
+	hashToPrimeBits = 256
 )
 
+// HashToPrime maps the member u to a prime with the given version of the hash-to-prime function
+func HashToPrime(version HashToPrimeVersion, u big.Int) (big.Int, error) {
+	switch version {
+	case HashToPrimeV0:
+		return Hprime(u), nil
+	case HashToPrimeV1:
+		return hashToPrimeV1(u.Bytes()), nil
+	default:
+		return big.Int{}, errors.Errorf("unknown hash-to-prime version %d", version)
+	}
+}
+
+// HashBytesToPrime maps raw to a prime with the given version of the hash-to-prime function.
+// HashToPrimeV1 hashes raw as is, so inputs that only differ in leading zero bytes map to different primes.
+// HashToPrimeV0 reads raw as a big-endian integer, as CreateCRI always did.
+func HashBytesToPrime(version HashToPrimeVersion, raw []byte) (big.Int, error) {
+	switch version {
+	case HashToPrimeV0:
+		return Hprime(*new(big.Int).SetBytes(raw)), nil
+	case HashToPrimeV1:
+		return hashToPrimeV1(raw), nil
+	default:
+		return big.Int{}, errors.Errorf("unknown hash-to-prime version %d", version)
+	}
+}
+
+/*
+hashToPrimeV1 hashes the input with a counter j = 0, 1, ... until it finds a prime:
+candidate_j = SHAKE256(domain || len(u) || u || j), truncated to hashToPrimeBits bits,
+with the top bit set (so all primes have the same size) and the low bit set (odd).
+The first candidate that passes the Baillie-PSW test is returned, about 1 in 89 candidates is prime.
+The output only depends on u, and it is collision-resistant as long as SHAKE256 is.
+*/
+func hashToPrimeV1(u []byte) big.Int {
+	var length, counter [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(u)))
+
+	digest := make([]byte, hashToPrimeBits/8)
+	var candidate big.Int
+	for j := uint64(0); ; j++ {
+		binary.BigEndian.PutUint64(counter[:], j)
+
+		h := sha3.NewShake256()
+		h.Write([]byte(hashToPrimeDomain))
+		h.Write(length[:])
+		h.Write(u)
+		h.Write(counter[:])
+		h.Read(digest)
+
+		digest[0] |= 0x80
+		digest[len(digest)-1] |= 0x01
+		candidate.SetBytes(digest)
+
+		// ProbablyPrime(0) runs only the Baillie-PSW test, which has no known pseudoprimes
+		if candidate.ProbablyPrime(0) {
+			return candidate
+		}
+	}
+}
+
 /*
 	RSA Accumulator has a limitation that it deals with only set of primes
 	To overcome this limitation we map arbitrary values to primes in collision-resistant manner
@@ -43,6 +127,8 @@ func Fu(x big.Int) big.Int {
 }
 
 //Hprime returns the prime which is mapped to set element u in collision resistant manner
+//Hprime is HashToPrimeV0: its output depends on float64 rounding and it is slow for large u,
+//new accumulators and CRIs use HashToPrime with DefaultHashToPrimeVersion
 
 func Hprime(u big.Int) big.Int {
 
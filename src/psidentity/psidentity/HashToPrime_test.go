@@ -0,0 +1,74 @@
+package psidentity
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashToPrimeV1Vectors(t *testing.T) {
+	vectors := []struct {
+		u     int64
+		prime string
+	}{
+		{0, "abf08b543c115eddf772464135e10c1b01e1332f21b7bb1768b052d616829beb"},
+		{1, "899feab1cc5f6739c44f552f4a6fce2fabb947b53e7805ee2eb6736d7824ae85"},
+		{11, "dccf46dedd8335c0ed1010a0fd7bede879c0151551ef5a4a6983a36e477372ab"},
+		{1 << 40, "c911c8e02434de33d64f200b906f59fe99b2992136f1f4b01b69fe7cfe698a05"},
+	}
+	for _, v := range vectors {
+		prime, err := HashToPrime(HashToPrimeV1, *big.NewInt(v.u))
+		require.NoError(t, err)
+		require.Equal(t, v.prime, prime.Text(16))
+		require.Equal(t, hashToPrimeBits, prime.BitLen())
+		require.True(t, prime.ProbablyPrime(20))
+	}
+
+	prime := hashToPrimeV1([]byte("psidentity"))
+	require.Equal(t, "c807f380caca87a6e137bb41ef9ab4b5be395f3badb1fb6144d200f2159f0131", prime.Text(16))
+}
+
+func TestHashToPrimeVersions(t *testing.T) {
+	u := *big.NewInt(22)
+
+	v0, err := HashToPrime(HashToPrimeV0, u)
+	require.NoError(t, err)
+	legacy := Hprime(u)
+	require.Equal(t, 0, legacy.Cmp(&v0))
+
+	_, err = HashToPrime(HashToPrimeVersion(7), u)
+	require.Error(t, err)
+
+	// the CRI of a large serialized credential is computed quickly with the new version
+	raw := make([]byte, 4096)
+	for i := range raw {
+		raw[i] = byte(i)
+	}
+	cri, err := CreateCRIVersion(raw, HashToPrimeV1)
+	require.NoError(t, err)
+	require.Len(t, cri, hashToPrimeBits/8)
+}
+
+func TestHashBytesToPrimeLeadingZeros(t *testing.T) {
+	raw := []byte{0x01, 0x02, 0x03}
+	padded := append([]byte{0x00, 0x00}, raw...)
+
+	prime, err := HashBytesToPrime(HashToPrimeV1, raw)
+	require.NoError(t, err)
+	paddedPrime, err := HashBytesToPrime(HashToPrimeV1, padded)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, prime.Cmp(&paddedPrime))
+	require.Equal(t, "e7e950ecec3bfa05f8fdca1cc67e630e430cdc0831b52891ea17ef66e0ccb87b", paddedPrime.Text(16))
+
+	// CRIs are computed from the raw serialized credential
+	cri, err := CreateCRIVersion(padded, HashToPrimeV1)
+	require.NoError(t, err)
+	require.Equal(t, paddedPrime.Bytes(), cri)
+
+	// the legacy version reads the input as an integer
+	legacy, err := HashBytesToPrime(HashToPrimeV0, padded)
+	require.NoError(t, err)
+	expected := Hprime(*new(big.Int).SetBytes(raw))
+	require.Equal(t, 0, expected.Cmp(&legacy))
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"math/big"
+
+	"github.com/pkg/errors"
 	// "github.com/golang/protobuf/proto"
 )
 
@@ -40,14 +42,27 @@ func (i *Psidentity) NewRevocationKey() (*RsaKey, error) {
 	return RsaKeygen(1024)
 }
 
+// NewRevocationKeyWithTrapdoor generates a revocation key together with the factorization
+// of its modulus, which allows the revocation authority to delete members and issue witnesses in O(1)
+func (i *Psidentity) NewRevocationKeyWithTrapdoor() (*RsaKey, *RsaTrapdoor, error) {
+	return RsaKeygenWithTrapdoor(1024)
+}
+
 // Generate N and G
 // lamda is the bit size of N(preferably 2048 bit)
 // Note that the primes factors of N are not exposed for security reason
 func RsaKeygen(lambda int) (*RsaKey, error) {
+	key, _, err := RsaKeygenWithTrapdoor(lambda)
+	return key, err
+}
+
+// RsaKeygenWithTrapdoor generates N and G like RsaKeygen, but also returns the prime factors of N.
+// The trapdoor must stay with the revocation authority, anyone holding it can forge witnesses.
+func RsaKeygenWithTrapdoor(lambda int) (*RsaKey, *RsaTrapdoor, error) {
 
 	privatekey, err := rsa.GenerateKey(rand.Reader, lambda)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 	N := privatekey.PublicKey.N
 	NBytes := N.Bytes()
@@ -67,16 +82,54 @@ func RsaKeygen(lambda int) (*RsaKey, error) {
 	G := new(big.Int).Exp(F, big.NewInt(2), privatekey.PublicKey.N)
 	GBytes := G.Bytes()
 
-	// rsaKey := &RsaKey{
-	// 	N: NBytes,
-	// 	G: GBytes,
-	// }
-
 	return &RsaKey{
-		N: NBytes,
-		G: GBytes,
-	},nil
+			N: NBytes,
+			G: GBytes,
+		}, &RsaTrapdoor{
+			P: privatekey.Primes[0].Bytes(),
+			Q: privatekey.Primes[1].Bytes(),
+		}, nil
+
+}
+
+// phi returns (P-1)(Q-1), the order of the multiplicative group modulo N
+func (td *RsaTrapdoor) phi() *big.Int {
+	one := big.NewInt(1)
+	p := new(big.Int).SetBytes(td.GetP())
+	q := new(big.Int).SetBytes(td.GetQ())
+	return new(big.Int).Mul(p.Sub(p, one), q.Sub(q, one))
+}
 
+// Check verifies that the trapdoor is the factorization of the modulus of the given key
+func (td *RsaTrapdoor) Check(key *RsaKey) error {
+	p := new(big.Int).SetBytes(td.GetP())
+	q := new(big.Int).SetBytes(td.GetQ())
+	if p.Cmp(big.NewInt(1)) <= 0 || q.Cmp(big.NewInt(1)) <= 0 {
+		return errors.Errorf("revocation trapdoor is undefined")
+	}
+	if new(big.Int).Mul(p, q).Cmp(new(big.Int).SetBytes(key.GetN())) != 0 {
+		return errors.Errorf("revocation trapdoor does not match the revocation key")
+	}
+	return nil
+}
+
+// rootWithTrapdoor computes Acc^{1/e} mod N, using that 1/e can be computed modulo phi(N)
+func (c *Accumulator) rootWithTrapdoor(e *big.Int, td *RsaTrapdoor) (*big.Int, error) {
+	eInv := new(big.Int).ModInverse(e, td.phi())
+	if eInv == nil {
+		return nil, errors.Errorf("prime representative is not invertible modulo phi(N)")
+	}
+	Acc := new(big.Int).SetBytes(c.Acc)
+	return Acc.Exp(Acc, eInv, new(big.Int).SetBytes(c.N)), nil
+}
+
+func (c *Accumulator) memberIndex(u *big.Int) int {
+	for i := range c.U {
+		if new(big.Int).SetBytes(c.U[i]).Cmp(u) == 0 {
+			return i
+		}
+	}
+	return -1
 }
 
 
@@ -138,6 +191,8 @@ func RsaKeygen(lambda int) (*RsaKey, error) {
 
 // }
 
+// CreateCRI maps the serialized credential to a prime with HashToPrimeV0.
+// New credentials use CreateCRIVersion with DefaultHashToPrimeVersion and store the version with the CRI.
 func CreateCRI(raw []byte) []byte {
 	// var res *big.Int
 	res := new(big.Int).SetBytes(raw)
@@ -145,29 +200,49 @@ func CreateCRI(raw []byte) []byte {
 	return resp.Bytes()
 }
 
-// Generate the accumulator
-func Generate_Acc(key RsaKey, U []big.Int) *Accumulator {
+// CreateCRIVersion maps the serialized credential to a prime with the given hash-to-prime version
+func CreateCRIVersion(raw []byte, version HashToPrimeVersion) ([]byte, error) {
+	resp, err := HashBytesToPrime(version, raw)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Bytes(), nil
+}
+
+// hprime maps a member to its prime with the hash-to-prime version of the accumulator
+func (c *Accumulator) hprime(u big.Int) (big.Int, error) {
+	return HashToPrime(HashToPrimeVersion(c.GetHashToPrimeVersion()), u)
+}
+
+// Generate the accumulator, members are mapped to primes with DefaultHashToPrimeVersion
+func Generate_Acc(key RsaKey, U []big.Int) (*Accumulator, error) {
+
+	acc := &Accumulator{
+		N:                  key.N,
+		G:                  key.G,
+		HashToPrimeVersion: uint32(DefaultHashToPrimeVersion),
+	}
 
 	Primes := make([]big.Int, len(U))
 	GBytes := key.G
-	var G *big.Int
-	G = G.SetBytes(GBytes)
+	G := new(big.Int).SetBytes(GBytes)
 
 
 	UBytes := make([][]byte, len(U))
 	for i, u := range U {
-		Primes[i] = Hprime(u)
+		prime, err := acc.hprime(u)
+		if err != nil {
+			return nil, err
+		}
+		Primes[i] = prime
 		UBytes[i] = u.Bytes()
 		G.Exp(G, &Primes[i], new(big.Int).SetBytes(key.N))
 
 	}
 
-	return &Accumulator{
-		Acc: G.Bytes(),
-		U:   UBytes,
-		N:   key.N,
-		G:   key.G,
-	}
+	acc.Acc = G.Bytes()
+	acc.U = UBytes
+	return acc, nil
 
 }
 
@@ -177,6 +252,11 @@ func Generate_Acc(key RsaKey, U []big.Int) *Accumulator {
 // 	List map[string]big.Int
 // }
 
+// witnessKey is the key of the witness of u in a WitnessList
+func witnessKey(u *big.Int) string {
+	return u.String()
+}
+
 // Initializes a witness mapping
 func (c *Accumulator) Witness_int() *WitnessList {
 
@@ -189,35 +269,39 @@ func (c *Accumulator) Witness_int() *WitnessList {
 // Generation of witness is multiplication of all primes mapped from members except the one we
 // are proving,prod(say) then,
 // Witness = G^prod(mod N)
-func generate_witness(u big.Int, key RsaKey, U []big.Int) big.Int {
+// The members are mapped to primes with the hash-to-prime version of the accumulator, as in Generate_Acc.
+func (c *Accumulator) generate_witness(u big.Int, U []big.Int) (big.Int, error) {
 
-	N := key.N
+	N := c.N
 
 	Primes := make([]big.Int, len(U))
-	GBytes := key.G
-	var G *big.Int
-	G = G.SetBytes(GBytes)
+	GBytes := c.G
+	G := new(big.Int).SetBytes(GBytes)
 
 	for i, u_dash := range U {
-		Primes[i] = Hprime(u_dash)
+		prime, err := c.hprime(u_dash)
+		if err != nil {
+			return big.Int{}, err
+		}
+		Primes[i] = prime
 		if u_dash.Cmp(&u) != 0 {
 			G.Exp(G, &Primes[i], new(big.Int).SetBytes(N))
 		}
 	}
-	return *G
+	return *G, nil
 }
 
 // Whenever the set is passed or it changes there is a computation of new witnesses which takes O(nlogn)
 // divide and conquer strategy
 // update for existed elements
-func (witness *WitnessList) Precompute_witness(G_prev big.Int, U []big.Int, accumulator *Accumulator) {
+func (witness *WitnessList) Precompute_witness(G_prev big.Int, U []big.Int, accumulator *Accumulator) error {
 
 	GprevBytes := G_prev.Bytes()
 
 	if len(U) == 1 {
-		witness.List[U[0].String()] = GprevBytes
+		witness.List[witnessKey(&U[0])] = GprevBytes
 		witness.Acc = accumulator.Acc
-		return
+		return nil
 	}
 
 	A := U[:len(U)/2]
@@ -228,30 +312,40 @@ func (witness *WitnessList) Precompute_witness(G_prev big.Int, U []big.Int, accu
 	N := accumulator.N
 
 	for _, u := range B {
-		e1 := Hprime(u)
+		e1, err := accumulator.hprime(u)
+		if err != nil {
+			return err
+		}
 
 		G1.Exp(&G1, &e1, new(big.Int).SetBytes(N))
 	}
 
 	for _, w := range A {
-		e2 := Hprime(w)
+		e2, err := accumulator.hprime(w)
+		if err != nil {
+			return err
+		}
 		G2.Exp(&G2, &e2, new(big.Int).SetBytes(N))
 	}
 	//fmt.Println(G1, G2)
-	witness.Precompute_witness(G1, A, accumulator)
-	witness.Precompute_witness(G2, B, accumulator)
+	if err := witness.Precompute_witness(G1, A, accumulator); err != nil {
+		return err
+	}
+	return witness.Precompute_witness(G2, B, accumulator)
 }
 
 /*
 Adding new member to the set which autometically precomputes the all the Witnesses in O(n) time
 */
-func (c *Accumulator) Add_member(u big.Int, w *WitnessList) {
+func (c *Accumulator) Add_member(u big.Int, w *WitnessList) error {
 
-	e := Hprime(u)
+	e, err := c.hprime(u)
+	if err != nil {
+		return err
+	}
 
 	AccBytes := c.Acc
-	var Acc *big.Int
-	Acc = Acc.SetBytes(AccBytes)
+	Acc := new(big.Int).SetBytes(AccBytes)
 
 	preAcc := Acc
 
@@ -272,39 +366,33 @@ func (c *Accumulator) Add_member(u big.Int, w *WitnessList) {
 	cUBigInt := newSetBigInt
 
 	if len(w.List) == 0 {
-		w.Precompute_witness(*new(big.Int).SetBytes(c.G), cUBigInt, c)
+		return w.Precompute_witness(*new(big.Int).SetBytes(c.G), cUBigInt, c)
 	} else {
-		for _, x := range c.U {
-			tempBytes := w.List[string(x)]
-			var temp *big.Int
-			temp = temp.SetBytes(tempBytes)
+		for _, x := range c.U[:len(c.U)-1] {
+			key := witnessKey(new(big.Int).SetBytes(x))
+			temp := new(big.Int).SetBytes(w.List[key])
 			resInt := *new(big.Int).Exp(temp, &e, new(big.Int).SetBytes(c.N))
-			w.List[string(x)] = resInt.Bytes()
+			w.List[key] = resInt.Bytes()
 		}
-		w.List[u.String()] = preAcc.Bytes()
+		w.List[witnessKey(&u)] = preAcc.Bytes()
 
 	}
 
+	return nil
 }
 
 /*
 Deleting a member from the set in O(nlogn) time
 */
-func (c *Accumulator) Delete_member(u big.Int, w *WitnessList) {
-
-	// var newSet []big.Int
-	var newSet [][]byte
-	var i int
-	for i = 0; i < len(c.U); i++ {
-		var cUi *big.Int
-		cUi = cUi.SetBytes(c.U[i])
-		if cUi.Cmp(&u) == 0 {
-			newSet = append(c.U[:i], c.U[i+1:]...)
-			break
-		}
+func (c *Accumulator) Delete_member(u big.Int, w *WitnessList) error {
+
+	i := c.memberIndex(&u)
+	if i < 0 {
+		return errors.Errorf("%s is not a member of the accumulator", u.String())
 	}
+	newSet := append(c.U[:i], c.U[i+1:]...)
 
-	newAcc := w.List[u.String()]
+	newAcc := w.List[witnessKey(&u)]
 	c.Acc = newAcc
 	c.U = newSet
 	list := make(map[string][]byte, len(c.U))
@@ -319,7 +407,7 @@ func (c *Accumulator) Delete_member(u big.Int, w *WitnessList) {
 	
 	cUBigInt := newSetBigInt
 
-	w.Precompute_witness(*new(big.Int).SetBytes(c.G), cUBigInt, c)
+	return w.Precompute_witness(*new(big.Int).SetBytes(c.G), cUBigInt, c)
 
 }
 
@@ -329,13 +417,74 @@ W^e (mod N) == Acc
 u and W are coming from the prover
 Accumulator and N are stored on chain
 Therefore, args[] should be constructed on chain
+u is mapped to e with the hash-to-prime version of the accumulator
 */
 
-func Verify(args []big.Int) bool {
+func Verify(args []big.Int, version HashToPrimeVersion) bool {
 
 	u, W, Accumulator, N := args[0], args[1], args[2], args[3]
-	e := Hprime(u)
+	e, err := HashToPrime(version, u)
+	if err != nil {
+		return false
+	}
 	Acc_dash := new(big.Int).Exp(&W, &e, &N)
 	return Acc_dash.Cmp(&Accumulator) == 0
 
 }
+
+// VerifyWitness checks W^e (mod N) == Acc, with u mapped to e by the hash-to-prime version of the accumulator
+func (c *Accumulator) VerifyWitness(u big.Int, W big.Int) error {
+	e, err := c.hprime(u)
+	if err != nil {
+		return err
+	}
+	Acc_dash := new(big.Int).Exp(&W, &e, new(big.Int).SetBytes(c.N))
+	if Acc_dash.Cmp(new(big.Int).SetBytes(c.Acc)) != 0 {
+		return errors.Errorf("witness of %s is not valid for the accumulator", u.String())
+	}
+	return nil
+}
+
+/*
+Deleting a member with the trapdoor takes a single exponentiation:
+Acc' = Acc^{1/e} (mod N), where 1/e is computed modulo phi(N).
+Witnesses are not recomputed, members fetch a fresh one with Issue_witness.
+*/
+func (c *Accumulator) Delete_member_trapdoor(u big.Int, td *RsaTrapdoor) error {
+	i := c.memberIndex(&u)
+	if i < 0 {
+		return errors.Errorf("%s is not a member of the accumulator", u.String())
+	}
+
+	e, err := c.hprime(u)
+	if err != nil {
+		return err
+	}
+	newAcc, err := c.rootWithTrapdoor(&e, td)
+	if err != nil {
+		return err
+	}
+
+	newSet := make([][]byte, 0, len(c.U)-1)
+	newSet = append(newSet, c.U[:i]...)
+	c.U = append(newSet, c.U[i+1:]...)
+	c.Acc = newAcc.Bytes()
+
+	return nil
+}
+
+/*
+Issuing a witness with the trapdoor takes a single exponentiation:
+W = Acc^{1/e} (mod N), which satisfies W^e (mod N) == Acc, see Verify
+*/
+func (c *Accumulator) Issue_witness(u big.Int, td *RsaTrapdoor) (*big.Int, error) {
+	if c.memberIndex(&u) < 0 {
+		return nil, errors.Errorf("%s is not a member of the accumulator", u.String())
+	}
+
+	e, err := c.hprime(u)
+	if err != nil {
+		return nil, err
+	}
+	return c.rootWithTrapdoor(&e, td)
+}
@@ -0,0 +1,128 @@
+package psidentity
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func accumulate(t *testing.T, key *RsaKey, members []big.Int) *Accumulator {
+	N := new(big.Int).SetBytes(key.N)
+	Acc := new(big.Int).SetBytes(key.G)
+	U := make([][]byte, len(members))
+	for i, u := range members {
+		e, err := HashToPrime(HashToPrimeV1, u)
+		require.NoError(t, err)
+		Acc.Exp(Acc, &e, N)
+		U[i] = u.Bytes()
+	}
+	return &Accumulator{Acc: Acc.Bytes(), U: U, N: key.N, G: key.G, HashToPrimeVersion: uint32(HashToPrimeV1)}
+}
+
+func TestTrapdoorWitnessAndDelete(t *testing.T) {
+	key, trapdoor, err := RsaKeygenWithTrapdoor(1024)
+	require.NoError(t, err)
+	require.NoError(t, trapdoor.Check(key))
+
+	members := []big.Int{*big.NewInt(11), *big.NewInt(22), *big.NewInt(33)}
+	acc := accumulate(t, key, members)
+
+	// witnesses issued with the trapdoor verify publicly
+	for _, u := range members {
+		W, err := acc.Issue_witness(u, trapdoor)
+		require.NoError(t, err)
+		require.NoError(t, acc.VerifyWitness(u, *W))
+	}
+
+	// deleting with the trapdoor gives the same value as recomputing from scratch
+	require.NoError(t, acc.Delete_member_trapdoor(members[1], trapdoor))
+	expected := accumulate(t, key, []big.Int{members[0], members[2]})
+	require.Equal(t, expected.Acc, acc.Acc)
+	require.Equal(t, expected.U, acc.U)
+
+	_, err = acc.Issue_witness(members[1], trapdoor)
+	require.Error(t, err)
+	require.Error(t, acc.Delete_member_trapdoor(members[1], trapdoor))
+}
+
+func TestTrapdoorMismatch(t *testing.T) {
+	key, _, err := RsaKeygenWithTrapdoor(1024)
+	require.NoError(t, err)
+	_, other, err := RsaKeygenWithTrapdoor(1024)
+	require.NoError(t, err)
+	require.Error(t, other.Check(key))
+}
+
+func TestGeneratedWitnessVersion(t *testing.T) {
+	key, err := RsaKeygen(1024)
+	require.NoError(t, err)
+	members := []big.Int{*big.NewInt(11), *big.NewInt(22), *big.NewInt(33)}
+	acc, err := Generate_Acc(*key, members)
+	require.NoError(t, err)
+	require.Equal(t, uint32(DefaultHashToPrimeVersion), acc.HashToPrimeVersion)
+
+	// witnesses computed from scratch use the hash-to-prime version of the accumulator
+	for _, u := range members {
+		W, err := acc.generate_witness(u, members)
+		require.NoError(t, err)
+		require.NoError(t, acc.VerifyWitness(u, W))
+
+		// the on-chain check maps u with the same version
+		args := []big.Int{u, W, *new(big.Int).SetBytes(acc.Acc), *new(big.Int).SetBytes(acc.N)}
+		require.True(t, Verify(args, HashToPrimeVersion(acc.HashToPrimeVersion)))
+		require.False(t, Verify(args, HashToPrimeV0))
+	}
+
+	// with the legacy version the witness matches an accumulator of legacy primes only
+	legacy := &Accumulator{N: acc.N, G: acc.G, HashToPrimeVersion: uint32(HashToPrimeV0)}
+	W, err := legacy.generate_witness(members[0], members)
+	require.NoError(t, err)
+	require.Error(t, acc.VerifyWitness(members[0], W))
+
+	acc.HashToPrimeVersion = 99
+	_, err = acc.generate_witness(members[0], members)
+	require.Error(t, err)
+}
+
+func TestWitnessListAddDelete(t *testing.T) {
+	key, err := RsaKeygen(1024)
+	require.NoError(t, err)
+	acc, err := Generate_Acc(*key, []big.Int{*big.NewInt(11), *big.NewInt(22)})
+	require.NoError(t, err)
+
+	// the first addition precomputes all witnesses, the next ones update them in place
+	w := acc.Witness_int()
+	for _, u := range []int64{33, 44, 55} {
+		require.NoError(t, acc.Add_member(*big.NewInt(u), w))
+	}
+	for _, u := range []int64{11, 22, 33, 44, 55} {
+		require.NoError(t, acc.VerifyWitness(*big.NewInt(u), *new(big.Int).SetBytes(w.List[witnessKey(big.NewInt(u))])))
+	}
+
+	// the witnesses of the other members are valid for the accumulator after the deletion
+	require.NoError(t, acc.Delete_member(*big.NewInt(22), w))
+	require.Len(t, acc.U, 4)
+	for _, u := range []int64{11, 33, 44, 55} {
+		require.NoError(t, acc.VerifyWitness(*big.NewInt(u), *new(big.Int).SetBytes(w.List[witnessKey(big.NewInt(u))])))
+	}
+	require.Error(t, acc.VerifyWitness(*big.NewInt(22), *new(big.Int).SetBytes(w.List[witnessKey(big.NewInt(11))])))
+	require.Error(t, acc.Delete_member(*big.NewInt(22), w))
+}
+
+func TestSealedTrapdoor(t *testing.T) {
+	key, trapdoor, err := RsaKeygenWithTrapdoor(1024)
+	require.NoError(t, err)
+
+	sealed, err := SealMessage(trapdoor, []byte("passphrase"), revocationTrapdoorLabel, rand.Reader)
+	require.NoError(t, err)
+
+	opened, err := RevocationTrapdoorFromBytes(sealed, []byte("passphrase"), key)
+	require.NoError(t, err)
+	require.Equal(t, trapdoor.P, opened.P)
+	require.Equal(t, trapdoor.Q, opened.Q)
+
+	_, err = RevocationTrapdoorFromBytes(sealed, []byte("wrong"), key)
+	require.Error(t, err)
+}
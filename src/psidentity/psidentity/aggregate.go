@@ -1,30 +1,46 @@
 package psidentity
 
 import (
-	math "github.com/IBM/mathlib"
-	//"github.com/pkg/errors"
+	"context"
 	"io"
 	"time"
-	"log"
+
+	math "github.com/IBM/mathlib"
+	"github.com/pkg/errors"
 )
 
 func (i *Psidentity) NewAggregateCredential(key *UserKey, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader, tr Translator) (*AggregateCredential, error) {
-	return newAggregateCredential(key, ipk, messages, rng, tr, i.Curve)
+	psid := &Psidentity{Curve: i.Curve, Translator: tr, Parallelism: i.Parallelism, NonRevocation: i.NonRevocation}
+	return psid.NewAggregateVerifier(i.Parallelism).NewAggregateCredential(context.Background(), key, ipk, messages, rng)
 }
 
-func newAggregateCredential(key *UserKey, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader, tr Translator, curve *math.Curve) (*AggregateCredential, error) {
-	t11 := time.Now().UnixNano() / int64(time.Millisecond)
-	// check the credential request
-	for i := 0; i < len(messages); i++ {
-		err := messages[i].VerifyDerive(ipk, curve, tr)
-		if err != nil {
-			return nil, err
+// VerifyMessages verifies the derived credentials with the issuer key on the workers, and their non-revocation
+// if the Psidentity of the verifier has a NonRevocationPolicy
+func (v *AggregateVerifier) VerifyMessages(ctx context.Context, pk *PreparedIssuerKey, messages []*DeriveCredential) error {
+	return v.forEach(ctx, len(messages), func(i int) error {
+		if err := messages[i].verifyDerive(pk); err != nil {
+			return err
 		}
+		return v.psid.verifyNonRevocation(messages[i], pk.Ipk)
+	})
+}
+
+// NewAggregateCredential verifies the derived credentials on the workers and aggregates them with the user key
+func (v *AggregateVerifier) NewAggregateCredential(ctx context.Context, key *UserKey, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+	curve, tr := v.psid.Curve, v.psid.Translator
+	if len(messages) > len(key.Usk.GetW()) {
+		return nil, errors.Errorf("user key aggregates at most %d messages, not %d", len(key.Usk.GetW()), len(messages))
+	}
+
+	// check the credential request, with the issuer key decoded once for all workers
+	pk, err := prepareIssuerKey(ipk, curve, tr, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.VerifyMessages(ctx, pk, messages); err != nil {
+		return nil, err
 	}
-	t22 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("DeriveCredential Verify Latency=%v ms.", t22-t11)
 
-	t1 := time.Now().UnixNano() / int64(time.Millisecond)
 	//generate base signature
 	sigma_one := curve.GenG2
 	sigma_two, err := tr.G2FromProto(key.Upk.BBar)
@@ -44,17 +60,15 @@ func newAggregateCredential(key *UserKey, ipk *IssuerPublicKeyPS, messages []*De
 		// 	return nil, errors.WithMessage(err, "failed to VerifyDerive")
 		// }
 		wi := curve.NewZrFromBytes(key.Usk.W[i])
-		Di := curve.NewZrFromBytes([]byte(messages[i].String()))
-		sigma_i := wi.Mul(Di)
-		sigma.Plus(sigma_i)
+		Di, err := messageDigest(messages[i], curve, tr)
+		if err != nil {
+			return nil, err
+		}
+		sigma = sigma.Plus(wi.Mul(Di))
 	}
-	tmp := sigma_one.Mul(sigma)
-	sigma_two.Add(tmp)
-	sigma_two.Mul(k)
-	sigma_twopp := sigma_two
-
-	t2 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("AggregateCredential Latency=%v ms.", t2-t1)
+	// sigma_twopp = (BBar \cdot g_2^{\sum_i w_i D_i})^k
+	sigma_two.Add(sigma_one.Mul(sigma))
+	sigma_twopp := sigma_two.Mul(k)
 
 	return &AggregateCredential{
 		SigmaOnepp:  tr.G2ToProto(sigma_onepp),
@@ -65,12 +79,21 @@ func newAggregateCredential(key *UserKey, ipk *IssuerPublicKeyPS, messages []*De
 
 
 func (i *Psidentity) VerifyAggregate(cred *AggregateCredential, key *UserKey, tr Translator) error {
-	return cred.VerifyAggregate(key.Upk, i.Curve, tr)
+	psid := &Psidentity{Curve: i.Curve, Translator: tr, Parallelism: i.Parallelism, NonRevocation: i.NonRevocation}
+	return psid.NewAggregateVerifier(i.Parallelism).VerifyAggregate(context.Background(), cred, key.Upk)
 }
+
 // VerifyAggregate cryptographically verifies the credential by verifying the signature
 // on the attribute values and user's secret key
 func (cred *AggregateCredential) VerifyAggregate(Upk *UserPublicKey, curve *math.Curve, tr Translator) error {
-	t1 := time.Now().UnixNano() / int64(time.Millisecond)
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	return psid.NewAggregateVerifier(0).VerifyAggregate(context.Background(), cred, Upk)
+}
+
+// VerifyAggregate verifies the aggregate credential, with the powers W_i^{D_i} of its messages computed on the workers
+func (v *AggregateVerifier) VerifyAggregate(ctx context.Context, cred *AggregateCredential, Upk *UserPublicKey) (err error) {
+	defer func(start time.Time) { recordCheck(MetricVerifyAggregateSeconds, MetricVerifyAggregateFailures, start, err) }(time.Now())
+	curve, tr := v.psid.Curve, v.psid.Translator
 
 	sigma_onepp, err := tr.G2FromProto(cred.GetSigmaOnepp())
 	if err != nil {
@@ -86,26 +109,48 @@ func (cred *AggregateCredential) VerifyAggregate(Upk *UserPublicKey, curve *math
 		return err
 	}
 
-	for i := 0; i < len(cred.Messages); i++ {
+	if len(cred.Messages) > len(Upk.GetW()) {
+		return errors.Errorf("user key aggregates at most %d messages, not %d", len(Upk.GetW()), len(cred.Messages))
+	}
+	terms := make([]*math.G1, len(cred.Messages))
+	err = v.forEach(ctx, len(cred.Messages), func(i int) error {
 		Wi, err := tr.G1FromProto(Upk.W[i])
 		if err != nil {
 			return err
 		}
-		Di := curve.NewZrFromBytes([]byte(cred.Messages[i].String()))
-		Wi.Mul(Di)
-		B.Add(Wi)
+		Di, err := messageDigest(cred.Messages[i], curve, tr)
+		if err != nil {
+			return err
+		}
+		terms[i] = Wi.Mul(Di)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-
-	//verify pairing equation
-	left := curve.FExp(curve.Pairing(sigma_onepp, B))
-	right := curve.FExp(curve.Pairing(sigma_twopp, curve.GenG1))
-	if !left.Equals(right) {
-		log.Printf("#######aggregate credential is not cryptographically valid")
-		//return errors.Errorf("credential is not cryptographically valid")
+	for _, term := range terms {
+		B.Add(term)
 	}
 
-	t2 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("AggregateCredential Verify Latency=%v ms.", t2-t1)
+	//verify pairing equation e(sigma_onepp, B) = e(sigma_twopp, g_1)
+	if isG2Identity(curve, sigma_onepp) || !pairingsEqual(curve, sigma_onepp, B, sigma_twopp, curve.GenG1) {
+		return errors.Errorf("credential is not cryptographically valid")
+	}
 
 	return nil
 }
+
+// messageDigest returns the exponent D_i of a derived credential in an aggregate credential, the hash of its
+// compact CBOR encoding, which is deterministic and does not depend on the point encoding of the translator
+func messageDigest(cred *DeriveCredential, curve *math.Curve, tr Translator) (*math.Zr, error) {
+	m, err := deriveCredentialToCBORMap(cred, tr)
+	if err != nil {
+		return nil, err
+	}
+	m[0] = uint64(cborTypeDeriveCredential)
+	raw, err := cborEncode(m)
+	if err != nil {
+		return nil, err
+	}
+	return curve.HashToZr(raw), nil
+}
@@ -0,0 +1,108 @@
+package psidentity
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MessageError is the failure of one message of an aggregate credential
+type MessageError struct {
+	Index int
+	Err   error
+}
+
+func (e *MessageError) Error() string {
+	return fmt.Sprintf("message %d: %s", e.Index, e.Err)
+}
+
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+// MessageErrors are the failures of the messages verified until the verification stopped, in message order
+type MessageErrors []*MessageError
+
+func (e MessageErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid messages: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// AggregateVerifier creates and verifies aggregate credentials with the work on their messages spread over a
+// pool of workers. It stops at the first invalid message and returns the failures of the messages verified
+// until then as MessageErrors, or the error of the context if it is done first. It may be shared by goroutines.
+type AggregateVerifier struct {
+	psid        *Psidentity
+	parallelism int
+}
+
+// NewAggregateVerifier returns a verifier with parallelism workers, runtime.GOMAXPROCS(0) if it is not positive
+func (i *Psidentity) NewAggregateVerifier(parallelism int) *AggregateVerifier {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return &AggregateVerifier{psid: i, parallelism: parallelism}
+}
+
+// Parallelism returns the number of workers of the verifier
+func (v *AggregateVerifier) Parallelism() int {
+	return v.parallelism
+}
+
+// forEach runs work for the messages 0..n-1 on the workers until a message fails or the context is done
+func (v *AggregateVerifier) forEach(ctx context.Context, n int, work func(int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := v.parallelism
+	if workers > n {
+		workers = n
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures MessageErrors
+	)
+	indices := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				if err := work(index); err != nil {
+					mu.Lock()
+					failures = append(failures, &MessageError{Index: index, Err: err})
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for ; dispatched < n && ctx.Err() == nil; dispatched++ {
+		select {
+		case indices <- dispatched:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Slice(failures, func(a, b int) bool { return failures[a].Index < failures[b].Index })
+		return failures
+	}
+	if dispatched < n {
+		return ctx.Err()
+	}
+	return nil
+}
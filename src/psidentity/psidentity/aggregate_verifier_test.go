@@ -0,0 +1,127 @@
+package psidentity
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestAggregateVerifierPool(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	require.Equal(t, 3, psid.NewAggregateVerifier(3).Parallelism())
+	require.True(t, psid.NewAggregateVerifier(0).Parallelism() > 0)
+
+	// one worker stops at the first failure
+	var runs int32
+	err = psid.NewAggregateVerifier(1).forEach(context.Background(), 10, func(i int) error {
+		atomic.AddInt32(&runs, 1)
+		if i == 2 {
+			return errors.New("invalid")
+		}
+		return nil
+	})
+	failures, ok := err.(MessageErrors)
+	require.True(t, ok, "%v", err)
+	require.Len(t, failures, 1)
+	require.Equal(t, 2, failures[0].Index)
+	require.True(t, atomic.LoadInt32(&runs) < 10)
+
+	// the failures of the messages verified before the stop are collected in message order
+	err = psid.NewAggregateVerifier(4).forEach(context.Background(), 4, func(i int) error {
+		return errors.Errorf("invalid %d", i)
+	})
+	failures, ok = err.(MessageErrors)
+	require.True(t, ok, "%v", err)
+	for k := 1; k < len(failures); k++ {
+		require.True(t, failures[k-1].Index < failures[k].Index)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = psid.NewAggregateVerifier(4).forEach(ctx, 4, func(i int) error {
+		t.Fail()
+		return nil
+	})
+	require.Equal(t, context.Canceled, err)
+
+	require.NoError(t, psid.NewAggregateVerifier(4).forEach(context.Background(), 0, nil))
+}
+
+func TestAggregateVerifier(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+
+	messages := make([]*DeriveCredential, len(attrs))
+	for i := range messages {
+		mask := make([]int, len(attrs))
+		mask[i] = 1
+		messages[i], err = psid.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+		require.NoError(t, err)
+	}
+
+	v := psid.NewAggregateVerifier(2)
+	aggregate, err := v.NewAggregateCredential(context.Background(), uk, key.Ipk, messages, rng)
+	require.NoError(t, err)
+	require.NoError(t, v.VerifyAggregate(context.Background(), aggregate, uk.Upk))
+
+	// an invalid message is reported with its index
+	tampered := proto.Clone(messages[2]).(*DeriveCredential)
+	tampered.SigmaTwop = messages[1].SigmaTwop
+	invalid := []*DeriveCredential{messages[0], messages[1], tampered, messages[3]}
+	_, err = v.NewAggregateCredential(context.Background(), uk, key.Ipk, invalid, rng)
+	failures, ok := err.(MessageErrors)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, 2, failures[0].Index)
+	_, err = psid.NewAggregateCredential(uk, key.Ipk, invalid, rng, tr)
+	require.Error(t, err)
+
+	// the verification stops at the first invalid message, the failures until then are in message order
+	first := proto.Clone(messages[0]).(*DeriveCredential)
+	first.SigmaTwop = messages[3].SigmaTwop
+	invalid = []*DeriveCredential{first, messages[1], tampered, messages[3]}
+	_, err = v.NewAggregateCredential(context.Background(), uk, key.Ipk, invalid, rng)
+	failures, ok = err.(MessageErrors)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, 0, failures[0].Index)
+	if len(failures) > 1 {
+		require.Len(t, failures, 2)
+		require.Equal(t, 2, failures[1].Index)
+	}
+
+	// a message of another issuer key is invalid
+	other, otherPrimary := newTestCredential(t, psid, attrs, rng)
+	foreign, err := psid.NewDeriveCredential(attrs, other, otherPrimary, []int{1, 0, 0, 0}, rng, tr)
+	require.NoError(t, err)
+	_, err = v.NewAggregateCredential(context.Background(), uk, key.Ipk, []*DeriveCredential{messages[0], foreign}, rng)
+	failures, ok = err.(MessageErrors)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, 1, failures[0].Index)
+
+	// the aggregate only verifies with its user key
+	otherUk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	require.Error(t, v.VerifyAggregate(context.Background(), aggregate, otherUk.Upk))
+
+	_, err = v.NewAggregateCredential(context.Background(), uk, key.Ipk, append(messages, messages[0]), rng)
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = v.NewAggregateCredential(ctx, uk, key.Ipk, messages, rng)
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, context.Canceled, v.VerifyAggregate(ctx, aggregate, uk.Upk))
+}
@@ -0,0 +1,231 @@
+package psidentity
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// The operations measured by Bench, in the order of the issuance, derivation and aggregation protocol
+const (
+	BenchIssuerKeygen    = "issuer-keygen"
+	BenchUserKeygen      = "user-keygen"
+	BenchRequest         = "request"
+	BenchBlindSign       = "blind-sign"
+	BenchUnblind         = "unblind"
+	BenchDerive          = "derive"
+	BenchAggregate       = "aggregate"
+	BenchVerifyPrimary   = "verify-primary"
+	BenchVerifyDerive    = "verify-derive"
+	BenchVerifyAggregate = "verify-aggregate"
+)
+
+// BenchConfig configures a benchmark run
+type BenchConfig struct {
+	// Attributes is the number of attributes of the primary cred, every other one is disclosed in the derived cred
+	Attributes int
+	// Messages is the number of derived creds in the aggregate cred
+	Messages int
+	// Iterations is the number of times each operation runs
+	Iterations int
+}
+
+// BenchResult holds the latencies of an operation in milliseconds and the size of its serialized output,
+// with uncompressed and with compressed points
+type BenchResult struct {
+	Operation           string  `json:"operation"`
+	Curve               string  `json:"curve"`
+	Attributes          int     `json:"attributes"`
+	Iterations          int     `json:"iterations"`
+	MinMs               float64 `json:"min_ms"`
+	MeanMs              float64 `json:"mean_ms"`
+	P50Ms               float64 `json:"p50_ms"`
+	P90Ms               float64 `json:"p90_ms"`
+	P99Ms               float64 `json:"p99_ms"`
+	MaxMs               float64 `json:"max_ms"`
+	SizeBytes           int     `json:"size_bytes"`
+	CompressedSizeBytes int     `json:"compressed_size_bytes"`
+}
+
+// benchAttributes returns the attribute values and the disclosure mask of a benchmark with n attributes
+func benchAttributes(n int) ([]string, []int) {
+	attrs := make([]string, n)
+	mask := make([]int, n)
+	for j := range attrs {
+		attrs[j] = fmt.Sprintf("attribute-%d", j)
+		mask[j] = 1 - j%2
+	}
+	return attrs, mask
+}
+
+// benchStep is an operation of the protocol, it runs on the outputs of the previous steps
+// and returns its own serialized output, nil for a verification
+type benchStep struct {
+	op  string
+	run func() (proto.Message, error)
+}
+
+// benchSteps returns the steps of the protocol for the configuration
+func (i *Psidentity) benchSteps(cfg BenchConfig, rng io.Reader) []benchStep {
+	tr := i.Translator
+	attrs, mask := benchAttributes(cfg.Attributes)
+
+	var (
+		key       *IssuerKeyPS
+		uk        *UserKey
+		req       *CredRequestPS
+		d         *math.Zr
+		blind     *BlindCredential
+		primary   *PrimaryCredential
+		derived   *DeriveCredential
+		aggregate *AggregateCredential
+	)
+	return []benchStep{
+		{BenchIssuerKeygen, func() (_ proto.Message, err error) {
+			key, err = i.NewIssuerKeyPS(cfg.Attributes, rng, tr)
+			return key.GetIpk(), err
+		}},
+		{BenchUserKeygen, func() (_ proto.Message, err error) {
+			uk, err = i.NewUserKeyPS(cfg.Messages, rng, tr)
+			return uk.GetUpk(), err
+		}},
+		{BenchRequest, func() (_ proto.Message, err error) {
+			req, d, err = i.NewCredRequestPS(attrs, key.Ipk, rng, tr)
+			return req, err
+		}},
+		{BenchBlindSign, func() (_ proto.Message, err error) {
+			blind, err = i.NewBlindCredential(key, req, rng, tr)
+			return blind, err
+		}},
+		{BenchUnblind, func() (_ proto.Message, err error) {
+			primary, err = i.NewPrimaryCredential(attrs, d, key, blind, rng, tr)
+			return primary, err
+		}},
+		{BenchDerive, func() (_ proto.Message, err error) {
+			derived, err = i.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+			return derived, err
+		}},
+		{BenchAggregate, func() (_ proto.Message, err error) {
+			messages := make([]*DeriveCredential, cfg.Messages)
+			for k := range messages {
+				messages[k] = derived
+			}
+			aggregate, err = i.NewAggregateCredential(uk, key.Ipk, messages, rng, tr)
+			return aggregate, err
+		}},
+		{BenchVerifyPrimary, func() (proto.Message, error) {
+			return nil, primary.VerifyPrimary(key.Ipk, i.Curve, tr)
+		}},
+		{BenchVerifyDerive, func() (proto.Message, error) {
+			return nil, derived.VerifyDerive(key.Ipk, i.Curve, tr)
+		}},
+		{BenchVerifyAggregate, func() (proto.Message, error) {
+			return nil, i.VerifyAggregate(aggregate, uk, tr)
+		}},
+	}
+}
+
+// Bench runs every operation of the protocol the configured number of times, each on the output of the
+// previous operations, and returns their latency percentiles and serialized sizes. The outputs are measured in
+// both point encodings, whichever the translator of i uses.
+func (i *Psidentity) Bench(cfg BenchConfig, rng io.Reader) ([]*BenchResult, error) {
+	if cfg.Attributes < 1 || cfg.Messages < 1 || cfg.Iterations < 1 {
+		return nil, errors.Errorf("benchmark needs at least one attribute, message and iteration")
+	}
+	var results []*BenchResult
+	for _, step := range i.benchSteps(cfg, rng) {
+		latencies := make([]time.Duration, cfg.Iterations)
+		var out proto.Message
+		for k := range latencies {
+			start := time.Now()
+			var err error
+			if out, err = step.run(); err != nil {
+				return nil, errors.WithMessagef(err, "%s failed", step.op)
+			}
+			latencies[k] = time.Since(start)
+		}
+		result := newBenchResult(step.op, latencies)
+		result.Curve = CurveName(i.Curve)
+		result.Attributes = cfg.Attributes
+		if out != nil {
+			var err error
+			if result.SizeBytes, err = encodedSize(out, uncompressedTranslator(i.Translator)); err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal the output of %s", step.op)
+			}
+			if result.CompressedSizeBytes, err = encodedSize(out, CompressedTranslator(i.Translator)); err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal the output of %s", step.op)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// newBenchResult computes the statistics of the latencies of an operation
+func newBenchResult(op string, latencies []time.Duration) *BenchResult {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+	var sum time.Duration
+	for _, l := range sorted {
+		sum += l
+	}
+	return &BenchResult{
+		Operation:  op,
+		Iterations: len(sorted),
+		MinMs:      benchMs(sorted[0]),
+		MeanMs:     benchMs(sum / time.Duration(len(sorted))),
+		P50Ms:      benchMs(benchPercentile(sorted, 50)),
+		P90Ms:      benchMs(benchPercentile(sorted, 90)),
+		P99Ms:      benchMs(benchPercentile(sorted, 99)),
+		MaxMs:      benchMs(sorted[len(sorted)-1]),
+	}
+}
+
+// benchPercentile returns the nearest-rank percentile p of the sorted latencies
+func benchPercentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func benchMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// benchCSVHeader is the header row of WriteBenchCSV, named like the JSON fields
+var benchCSVHeader = []string{"operation", "curve", "attributes", "iterations", "min_ms", "mean_ms", "p50_ms", "p90_ms", "p99_ms", "max_ms", "size_bytes", "compressed_size_bytes"}
+
+// WriteBenchCSV writes the results as CSV with a header row
+func WriteBenchCSV(w io.Writer, results []*BenchResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(benchCSVHeader); err != nil {
+		return err
+	}
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, r := range results {
+		row := []string{r.Operation, r.Curve, strconv.Itoa(r.Attributes), strconv.Itoa(r.Iterations),
+			ms(r.MinMs), ms(r.MeanMs), ms(r.P50Ms), ms(r.P90Ms), ms(r.P99Ms), ms(r.MaxMs), strconv.Itoa(r.SizeBytes), strconv.Itoa(r.CompressedSizeBytes)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteBenchJSON writes the results as an indented JSON array
+func WriteBenchJSON(w io.Writer, results []*BenchResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
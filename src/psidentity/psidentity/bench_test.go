@@ -0,0 +1,120 @@
+package psidentity
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	benchCurve      = flag.String("bench.curve", "", "The curve of the protocol benchmarks, every curve if empty")
+	benchAttrs      = flag.Int("bench.attributes", 4, "The number of attributes of the protocol benchmarks")
+	benchAggregated = flag.Int("bench.messages", 1, "The number of derived creds aggregated in the protocol benchmarks")
+)
+
+// BenchmarkProtocol runs every operation of the protocol, e.g.
+// go test -run - -bench Protocol/FP256BN_AMCL/derive -bench.attributes 16
+func BenchmarkProtocol(b *testing.B) {
+	for name := range curveIDs {
+		if *benchCurve != "" && name != *benchCurve {
+			continue
+		}
+		psid, err := NewPsidentityForCurve(name)
+		require.NoError(b, err)
+		rng, err := psid.Curve.Rand()
+		require.NoError(b, err)
+
+		// every step runs once first, so that a filtered benchmark has the outputs of the previous steps
+		b.Run(name, func(b *testing.B) {
+			for _, step := range psid.benchSteps(BenchConfig{Attributes: *benchAttrs, Messages: *benchAggregated}, rng) {
+				_, err := step.run()
+				require.NoError(b, err)
+				b.Run(step.op, func(b *testing.B) {
+					for n := 0; n < b.N; n++ {
+						if _, err := step.run(); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestBench(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	_, err = psid.Bench(BenchConfig{Attributes: 3, Messages: 1}, rng)
+	require.Error(t, err)
+	results, err := psid.Bench(BenchConfig{Attributes: 3, Messages: 2, Iterations: 2}, rng)
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+	for _, r := range results {
+		require.Equal(t, CurveFP256BN_AMCL, r.Curve)
+		require.Equal(t, 3, r.Attributes)
+		require.Equal(t, 2, r.Iterations)
+		require.True(t, r.MinMs <= r.P50Ms && r.P50Ms <= r.P99Ms && r.P99Ms <= r.MaxMs, r.Operation)
+		require.Equal(t, r.Operation[:len("verify")] == "verify", r.SizeBytes == 0, r.Operation)
+		require.Equal(t, r.SizeBytes == 0, r.CompressedSizeBytes == 0, r.Operation)
+		require.LessOrEqual(t, r.CompressedSizeBytes, r.SizeBytes, r.Operation)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBenchCSV(&buf, results))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(results)+1)
+	require.Equal(t, benchCSVHeader, rows[0])
+	require.Equal(t, BenchIssuerKeygen, rows[1][0])
+
+	buf.Reset()
+	require.NoError(t, WriteBenchJSON(&buf, results))
+	var decoded []*BenchResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, results, decoded)
+}
+
+func TestBenchCompressed(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+	cfg := BenchConfig{Attributes: 3, Messages: 1, Iterations: 1}
+	results, err := psid.Bench(cfg, rng)
+	require.NoError(t, err)
+
+	// the sizes do not depend on the encoding the operations run with
+	psid.Translator = CompressedTranslator(psid.Translator)
+	compressed, err := psid.Bench(cfg, rng)
+	require.NoError(t, err)
+	for k, r := range results {
+		require.Equal(t, r.SizeBytes, compressed[k].SizeBytes, r.Operation)
+		require.Equal(t, r.CompressedSizeBytes, compressed[k].CompressedSizeBytes, r.Operation)
+	}
+}
+
+func TestBenchPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for k := range latencies {
+		latencies[k] = time.Duration(100-k) * time.Millisecond
+	}
+	r := newBenchResult("op", latencies)
+	require.Equal(t, 1.0, r.MinMs)
+	require.Equal(t, 50.0, r.P50Ms)
+	require.Equal(t, 90.0, r.P90Ms)
+	require.Equal(t, 99.0, r.P99Ms)
+	require.Equal(t, 100.0, r.MaxMs)
+	require.Equal(t, 50.5, r.MeanMs)
+
+	r = newBenchResult("op", []time.Duration{time.Millisecond})
+	require.Equal(t, fmt.Sprint(r.MinMs), fmt.Sprint(r.P99Ms))
+}
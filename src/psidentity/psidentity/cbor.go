@@ -0,0 +1,337 @@
+package psidentity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// The deterministic CBOR encoding (RFC 8949, section 4.2.1) of the compact presentations: integers and lengths
+// in the shortest form, definite lengths only and map keys in ascending order. Values are uint64, int64 (only
+// for negative integers), []byte, string, []interface{} and cborMap, maps have small unsigned integer keys.
+// The decoder refuses encodings that are not deterministic, so every value has exactly one encoding.
+
+const (
+	cborMajorUint   = 0
+	cborMajorNegInt = 1
+	cborMajorBytes  = 2
+	cborMajorText   = 3
+	cborMajorArray  = 4
+	cborMajorMap    = 5
+	cborMajorTag    = 6
+
+	// cborSelfDescribeTag marks the start of a CBOR item, its encoding 0xd9d9f7 does not start any of our protobuf messages
+	cborSelfDescribeTag = 55799
+
+	// cborMaxDepth bounds the nesting of decoded arrays and maps
+	cborMaxDepth = 16
+)
+
+// cborMap is a CBOR map with unsigned integer keys
+type cborMap map[uint64]interface{}
+
+// cborMagic is the encoding of the self describe tag
+var cborMagic = []byte{0xd9, 0xd9, 0xf7}
+
+// IsCBOR reports whether raw is a CBOR encoded presentation rather than a protobuf message
+func IsCBOR(raw []byte) bool {
+	return bytes.HasPrefix(raw, cborMagic)
+}
+
+// cborEncode encodes a value after the self describe tag
+func cborEncode(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	cborHead(buf, cborMajorTag, cborSelfDescribeTag)
+	if err := cborEncodeValue(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cborHead writes the head of an item in the shortest form
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(m | byte(n))
+	case n <= 0xff:
+		buf.Write([]byte{m | 24, byte(n)})
+	case n <= 0xffff:
+		buf.WriteByte(m | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(m | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(m | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func cborEncodeValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case uint64:
+		cborHead(buf, cborMajorUint, v)
+	case int64:
+		if v >= 0 {
+			cborHead(buf, cborMajorUint, uint64(v))
+		} else {
+			cborHead(buf, cborMajorNegInt, uint64(-1-v))
+		}
+	case []byte:
+		cborHead(buf, cborMajorBytes, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		cborHead(buf, cborMajorText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		cborHead(buf, cborMajorArray, uint64(len(v)))
+		for _, item := range v {
+			if err := cborEncodeValue(buf, item); err != nil {
+				return err
+			}
+		}
+	case cborMap:
+		keys := make([]uint64, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+		cborHead(buf, cborMajorMap, uint64(len(v)))
+		for _, k := range keys {
+			cborHead(buf, cborMajorUint, k)
+			if err := cborEncodeValue(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("cannot encode %T as CBOR", v)
+	}
+	return nil
+}
+
+// cborDecode decodes a value after the self describe tag, raw must hold exactly one value
+func cborDecode(raw []byte) (interface{}, error) {
+	if !IsCBOR(raw) {
+		return nil, errors.Errorf("missing CBOR self describe tag")
+	}
+	d := &cborDecoder{raw: raw, pos: len(cborMagic)}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(raw) {
+		return nil, errors.Errorf("%d trailing bytes after CBOR item", len(raw)-d.pos)
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	raw []byte
+	pos int
+}
+
+// head reads the head of an item and refuses lengths that are not in the shortest form
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.raw) {
+		return 0, 0, errors.Errorf("truncated CBOR item")
+	}
+	b := d.raw[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, errors.Errorf("indefinite length or reserved CBOR item")
+	}
+	size := 1 << (info - 24)
+	if len(d.raw)-d.pos < size {
+		return 0, 0, errors.Errorf("truncated CBOR item")
+	}
+	var n uint64
+	for _, c := range d.raw[d.pos : d.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	d.pos += size
+	if (size == 1 && n < 24) || (size > 1 && n < 1<<(4*size)) {
+		return 0, 0, errors.Errorf("CBOR item is not in the shortest form")
+	}
+	return major, n, nil
+}
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.Errorf("CBOR item nested too deep")
+	}
+	major, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborMajorUint:
+		return n, nil
+	case cborMajorNegInt:
+		if n >= 1<<63 {
+			return nil, errors.Errorf("CBOR integer out of range")
+		}
+		return -1 - int64(n), nil
+	case cborMajorBytes, cborMajorText:
+		if uint64(len(d.raw)-d.pos) < n {
+			return nil, errors.Errorf("truncated CBOR string")
+		}
+		b := d.raw[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		if major == cborMajorText {
+			return string(b), nil
+		}
+		return append([]byte{}, b...), nil
+	case cborMajorArray:
+		if uint64(len(d.raw)-d.pos) < n {
+			return nil, errors.Errorf("truncated CBOR array")
+		}
+		items := make([]interface{}, n)
+		for j := range items {
+			if items[j], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case cborMajorMap:
+		if uint64(len(d.raw)-d.pos) < 2*n {
+			return nil, errors.Errorf("truncated CBOR map")
+		}
+		m := cborMap{}
+		var prev uint64
+		for j := uint64(0); j < n; j++ {
+			keyMajor, key, err := d.head()
+			if err != nil {
+				return nil, err
+			}
+			if keyMajor != cborMajorUint {
+				return nil, errors.Errorf("CBOR map key is not an unsigned integer")
+			}
+			if j > 0 && key <= prev {
+				return nil, errors.Errorf("CBOR map keys are not in ascending order")
+			}
+			prev = key
+			if m[key], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, errors.Errorf("unsupported CBOR major type %d", major)
+}
+
+// The accessors of cborMap return the zero value for a missing key, like the getters of the protobuf messages
+
+func (m cborMap) uint(key uint64) (uint64, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return 0, nil
+	case uint64:
+		return v, nil
+	}
+	return 0, errors.Errorf("CBOR field %d is not an unsigned integer", key)
+}
+
+func (m cborMap) int(key uint64) (int64, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return 0, nil
+	case uint64:
+		if v >= 1<<63 {
+			return 0, errors.Errorf("CBOR field %d out of range", key)
+		}
+		return int64(v), nil
+	case int64:
+		return v, nil
+	}
+	return 0, errors.Errorf("CBOR field %d is not an integer", key)
+}
+
+func (m cborMap) bytes(key uint64) ([]byte, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	}
+	return nil, errors.Errorf("CBOR field %d is not a byte string", key)
+}
+
+func (m cborMap) text(key uint64) (string, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", errors.Errorf("CBOR field %d is not a text string", key)
+}
+
+func (m cborMap) array(key uint64) ([]interface{}, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return v, nil
+	}
+	return nil, errors.Errorf("CBOR field %d is not an array", key)
+}
+
+func (m cborMap) cborMap(key uint64) (cborMap, error) {
+	switch v := m[key].(type) {
+	case nil:
+		return nil, nil
+	case cborMap:
+		return v, nil
+	}
+	return nil, errors.Errorf("CBOR field %d is not a map", key)
+}
+
+// putInt sets a field unless it is zero, as protobuf omits zero fields
+func (m cborMap) putInt(key uint64, v int64) {
+	if v != 0 {
+		m[key] = v
+	}
+}
+
+// putBytes sets a field unless it is empty
+func (m cborMap) putBytes(key uint64, v []byte) {
+	if len(v) != 0 {
+		m[key] = v
+	}
+}
+
+// putBytesArray sets a field to an array of byte strings unless it is empty
+func (m cborMap) putBytesArray(key uint64, v [][]byte) {
+	if len(v) == 0 {
+		return
+	}
+	items := make([]interface{}, len(v))
+	for j := range v {
+		items[j] = v[j]
+	}
+	m[key] = items
+}
+
+// bytesArray returns a field that is an array of byte strings
+func (m cborMap) bytesArray(key uint64) ([][]byte, error) {
+	items, err := m.array(key)
+	if err != nil || items == nil {
+		return nil, err
+	}
+	v := make([][]byte, len(items))
+	for j, item := range items {
+		b, ok := item.([]byte)
+		if !ok {
+			return nil, errors.Errorf("CBOR field %d is not an array of byte strings", key)
+		}
+		v[j] = b
+	}
+	return v, nil
+}
@@ -0,0 +1,400 @@
+package psidentity
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	amcl "psidentity/translator/amcl"
+)
+
+// The compact CBOR encoding of derived and aggregate credentials for constrained devices. A credential is a
+// CBOR map keyed by the protobuf field numbers of its message, points are in the compressed form and zero
+// fields are omitted as in protobuf. Key 0 of the outer map holds the type of the credential.
+
+const (
+	cborTypeDeriveCredential    = 1
+	cborTypeAggregateCredential = 2
+)
+
+// DeriveCredentialToCBOR encodes a derived credential in the compact CBOR encoding
+func (i *Psidentity) DeriveCredentialToCBOR(cred *DeriveCredential) ([]byte, error) {
+	m, err := deriveCredentialToCBORMap(cred, i.Translator)
+	if err != nil {
+		return nil, err
+	}
+	m[0] = uint64(cborTypeDeriveCredential)
+	return cborEncode(m)
+}
+
+// DeriveCredentialFromCBOR decodes a derived credential from the compact CBOR encoding, the points are
+// encoded with the Translator of i as when the credential was derived
+func (i *Psidentity) DeriveCredentialFromCBOR(raw []byte) (*DeriveCredential, error) {
+	m, err := cborCredentialMap(raw, cborTypeDeriveCredential)
+	if err != nil {
+		return nil, err
+	}
+	return deriveCredentialFromCBORMap(m, i.Translator)
+}
+
+// AggregateCredentialToCBOR encodes an aggregate credential in the compact CBOR encoding
+func (i *Psidentity) AggregateCredentialToCBOR(cred *AggregateCredential) ([]byte, error) {
+	m := cborMap{0: uint64(cborTypeAggregateCredential)}
+	var err error
+	if m[1], err = g2ToCBOR(cred.GetSigmaOnepp(), i.Translator); err != nil {
+		return nil, err
+	}
+	if m[2], err = g2ToCBOR(cred.GetSigmaTwopp(), i.Translator); err != nil {
+		return nil, err
+	}
+	messages := make([]interface{}, len(cred.GetMessages()))
+	for j, message := range cred.GetMessages() {
+		if messages[j], err = deriveCredentialToCBORMap(message, i.Translator); err != nil {
+			return nil, err
+		}
+	}
+	m[3] = messages
+	return cborEncode(m)
+}
+
+// AggregateCredentialFromCBOR decodes an aggregate credential from the compact CBOR encoding
+func (i *Psidentity) AggregateCredentialFromCBOR(raw []byte) (*AggregateCredential, error) {
+	m, err := cborCredentialMap(raw, cborTypeAggregateCredential)
+	if err != nil {
+		return nil, err
+	}
+	cred := &AggregateCredential{}
+	if cred.SigmaOnepp, err = g2FromCBORField(m, 1, i.Translator); err != nil {
+		return nil, err
+	}
+	if cred.SigmaTwopp, err = g2FromCBORField(m, 2, i.Translator); err != nil {
+		return nil, err
+	}
+	messages, err := m.array(3)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range messages {
+		mm, ok := item.(cborMap)
+		if !ok {
+			return nil, errors.Errorf("aggregated credential is not a CBOR map")
+		}
+		message, err := deriveCredentialFromCBORMap(mm, i.Translator)
+		if err != nil {
+			return nil, err
+		}
+		cred.Messages = append(cred.Messages, message)
+	}
+	return cred, nil
+}
+
+// VerifyDeriveEncoded verifies a derived credential in the protobuf or in the compact CBOR encoding,
+// and its non-revocation if i has a NonRevocationPolicy
+func (i *Psidentity) VerifyDeriveEncoded(raw []byte, ipk *IssuerPublicKeyPS) error {
+	cred := &DeriveCredential{}
+	if IsCBOR(raw) {
+		var err error
+		if cred, err = i.DeriveCredentialFromCBOR(raw); err != nil {
+			return err
+		}
+	} else if err := proto.Unmarshal(raw, cred); err != nil {
+		return errors.Wrap(err, "failed to unmarshal derived credential")
+	}
+	if err := cred.VerifyDerive(ipk, i.Curve, i.Translator); err != nil {
+		return err
+	}
+	return i.verifyNonRevocation(cred, ipk)
+}
+
+// VerifyAggregateEncoded verifies an aggregate credential in the protobuf or in the compact CBOR encoding
+func (i *Psidentity) VerifyAggregateEncoded(raw []byte, upk *UserPublicKey) error {
+	cred := &AggregateCredential{}
+	if IsCBOR(raw) {
+		var err error
+		if cred, err = i.AggregateCredentialFromCBOR(raw); err != nil {
+			return err
+		}
+	} else if err := proto.Unmarshal(raw, cred); err != nil {
+		return errors.Wrap(err, "failed to unmarshal aggregate credential")
+	}
+	return i.NewAggregateVerifier(i.Parallelism).VerifyAggregate(context.Background(), cred, upk)
+}
+
+// cborCredentialMap decodes the outer map of a credential and checks its type
+func cborCredentialMap(raw []byte, credType uint64) (cborMap, error) {
+	v, err := cborDecode(raw)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(cborMap)
+	if !ok {
+		return nil, errors.Errorf("CBOR credential is not a map")
+	}
+	if t, err := m.uint(0); err != nil || t != credType {
+		return nil, errors.Errorf("CBOR credential is not of type %d", credType)
+	}
+	return m, nil
+}
+
+func deriveCredentialToCBORMap(cred *DeriveCredential, tr Translator) (cborMap, error) {
+	m := cborMap{}
+	var err error
+	for key, p := range map[uint64]*amcl.ECP2{1: cred.GetHp(), 2: cred.GetSp(), 8: cred.GetRevocationEpochPk()} {
+		if p != nil {
+			if m[key], err = g2ToCBOR(p, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for key, p := range map[uint64]*amcl.ECP{3: cred.GetSigmaOnep(), 4: cred.GetSigmaTwop()} {
+		if p != nil {
+			if m[key], err = g1ToCBOR(p, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the disclosed messages keep the empty hidden messages, their positions are the attribute indices
+	indices := make([]interface{}, len(cred.GetDiscloseIndices()))
+	for j, index := range cred.GetDiscloseIndices() {
+		indices[j] = index
+	}
+	messages := make([]interface{}, len(cred.GetDiscloseMsg()))
+	for j, msg := range cred.GetDiscloseMsg() {
+		messages[j] = msg
+	}
+	if len(indices) != 0 {
+		m[5] = indices
+	}
+	if len(messages) != 0 {
+		m[6] = messages
+	}
+
+	m.putInt(7, cred.GetEpoch())
+	m.putBytes(9, cred.GetRevocationPkSig())
+	if nrp := cred.GetNonRevocationProof(); nrp != nil {
+		proof := cborMap{}
+		proof.putInt(1, int64(nrp.GetRevocationAlg()))
+		proof.putBytes(2, nrp.GetNonRevocationProof())
+		m[10] = proof
+	}
+	if vp := cred.GetValidityProof(); vp != nil {
+		proof := cborMap{}
+		proof.putInt(1, vp.GetTime())
+		proof.putBytes(2, vp.GetProofC())
+		proof.putBytes(3, vp.GetProofST())
+		proof.putBytesArray(4, vp.GetProofSAttrs())
+		for key, rp := range map[uint64]*RangeProof{5: vp.GetNotBefore(), 6: vp.GetNotAfter()} {
+			if rp != nil {
+				if proof[key], err = rangeProofToCBORMap(rp, tr); err != nil {
+					return nil, err
+				}
+			}
+		}
+		m[11] = proof
+	}
+	if id := cred.GetIssuerKeyId(); id != "" {
+		m[12] = id
+	}
+	return m, nil
+}
+
+func rangeProofToCBORMap(rp *RangeProof, tr Translator) (cborMap, error) {
+	m := cborMap{}
+	commitments := make([][]byte, len(rp.GetBitCommitments()))
+	for j, c := range rp.GetBitCommitments() {
+		var err error
+		if commitments[j], err = g1ToCBOR(c, tr); err != nil {
+			return nil, err
+		}
+	}
+	m.putBytesArray(1, commitments)
+	m.putBytesArray(2, rp.GetProofC0())
+	m.putBytesArray(3, rp.GetProofZ0())
+	m.putBytesArray(4, rp.GetProofZ1())
+	m.putBytes(5, rp.GetProofSRho())
+	return m, nil
+}
+
+func deriveCredentialFromCBORMap(m cborMap, tr Translator) (*DeriveCredential, error) {
+	cred := &DeriveCredential{}
+	var err error
+	if cred.Hp, err = g2FromCBORField(m, 1, tr); err != nil {
+		return nil, err
+	}
+	if cred.Sp, err = g2FromCBORField(m, 2, tr); err != nil {
+		return nil, err
+	}
+	if cred.SigmaOnep, err = g1FromCBORField(m, 3, tr); err != nil {
+		return nil, err
+	}
+	if cred.SigmaTwop, err = g1FromCBORField(m, 4, tr); err != nil {
+		return nil, err
+	}
+
+	indices, err := m.array(5)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range indices {
+		index, ok := item.(uint64)
+		if !ok || index >= 1<<31 {
+			return nil, errors.Errorf("invalid disclosed index")
+		}
+		cred.DiscloseIndices = append(cred.DiscloseIndices, int64(index))
+	}
+	messages, err := m.array(6)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range messages {
+		msg, ok := item.(string)
+		if !ok {
+			return nil, errors.Errorf("disclosed message is not a text string")
+		}
+		cred.DiscloseMsg = append(cred.DiscloseMsg, msg)
+	}
+
+	if cred.Epoch, err = m.int(7); err != nil {
+		return nil, err
+	}
+	if cred.RevocationEpochPk, err = g2FromCBORField(m, 8, tr); err != nil {
+		return nil, err
+	}
+	if cred.RevocationPkSig, err = m.bytes(9); err != nil {
+		return nil, err
+	}
+
+	nrp, err := m.cborMap(10)
+	if err != nil {
+		return nil, err
+	}
+	if nrp != nil {
+		alg, err := nrp.int(1)
+		if err != nil {
+			return nil, err
+		}
+		cred.NonRevocationProof = &NonRevocationProof{RevocationAlg: int32(alg)}
+		if cred.NonRevocationProof.NonRevocationProof, err = nrp.bytes(2); err != nil {
+			return nil, err
+		}
+	}
+
+	vp, err := m.cborMap(11)
+	if err != nil {
+		return nil, err
+	}
+	if vp != nil {
+		if cred.ValidityProof, err = validityProofFromCBORMap(vp, tr); err != nil {
+			return nil, err
+		}
+	}
+	if cred.IssuerKeyId, err = m.text(12); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func validityProofFromCBORMap(m cborMap, tr Translator) (*ValidityProof, error) {
+	proof := &ValidityProof{}
+	var err error
+	if proof.Time, err = m.int(1); err != nil {
+		return nil, err
+	}
+	if proof.ProofC, err = m.bytes(2); err != nil {
+		return nil, err
+	}
+	if proof.ProofST, err = m.bytes(3); err != nil {
+		return nil, err
+	}
+	if proof.ProofSAttrs, err = m.bytesArray(4); err != nil {
+		return nil, err
+	}
+	for key, rp := range map[uint64]**RangeProof{5: &proof.NotBefore, 6: &proof.NotAfter} {
+		rm, err := m.cborMap(key)
+		if err != nil {
+			return nil, err
+		}
+		if rm != nil {
+			if *rp, err = rangeProofFromCBORMap(rm, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return proof, nil
+}
+
+func rangeProofFromCBORMap(m cborMap, tr Translator) (*RangeProof, error) {
+	rp := &RangeProof{}
+	commitments, err := m.bytesArray(1)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commitments {
+		p, err := g1FromCBOR(c, tr)
+		if err != nil {
+			return nil, err
+		}
+		rp.BitCommitments = append(rp.BitCommitments, p)
+	}
+	if rp.ProofC0, err = m.bytesArray(2); err != nil {
+		return nil, err
+	}
+	if rp.ProofZ0, err = m.bytesArray(3); err != nil {
+		return nil, err
+	}
+	if rp.ProofZ1, err = m.bytesArray(4); err != nil {
+		return nil, err
+	}
+	if rp.ProofSRho, err = m.bytes(5); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// g1ToCBOR returns the compressed encoding of a point
+func g1ToCBOR(p *amcl.ECP, tr Translator) ([]byte, error) {
+	g1, err := tr.G1FromProto(p)
+	if err != nil {
+		return nil, err
+	}
+	return tr.G1ToCompressedProto(g1).GetCompressed(), nil
+}
+
+// g2ToCBOR returns the compressed encoding of a point
+func g2ToCBOR(p *amcl.ECP2, tr Translator) ([]byte, error) {
+	g2, err := tr.G2FromProto(p)
+	if err != nil {
+		return nil, err
+	}
+	return tr.G2ToCompressedProto(g2).GetCompressed(), nil
+}
+
+// g1FromCBOR decodes a compressed point, which the translator checks, to the encoding of the translator
+func g1FromCBOR(b []byte, tr Translator) (*amcl.ECP, error) {
+	g1, err := tr.G1FromProto(&amcl.ECP{Compressed: b})
+	if err != nil {
+		return nil, err
+	}
+	return tr.G1ToProto(g1), nil
+}
+
+func g1FromCBORField(m cborMap, key uint64, tr Translator) (*amcl.ECP, error) {
+	b, err := m.bytes(key)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return g1FromCBOR(b, tr)
+}
+
+func g2FromCBORField(m cborMap, key uint64, tr Translator) (*amcl.ECP2, error) {
+	b, err := m.bytes(key)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	g2, err := tr.G2FromProto(&amcl.ECP2{Compressed: b})
+	if err != nil {
+		return nil, err
+	}
+	return tr.G2ToProto(g2), nil
+}
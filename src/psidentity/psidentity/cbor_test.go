@@ -0,0 +1,92 @@
+package psidentity
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestCBORCredentials(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%v", compressed), func(t *testing.T) {
+			psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+			require.NoError(t, err)
+			if compressed {
+				psid.Translator = CompressedTranslator(psid.Translator)
+			}
+			tr := psid.Translator
+			rng, err := psid.Curve.Rand()
+			require.NoError(t, err)
+
+			now := time.Now()
+			attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+				now.Add(-time.Hour), now.Add(time.Hour), rng)
+			uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+			require.NoError(t, err)
+			derived, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, []int{1, 0, 1, 0, 0, 0}, now, rng, tr)
+			require.NoError(t, err)
+			aggregate, err := psid.NewAggregateCredential(uk, key.Ipk, []*DeriveCredential{derived}, rng, tr)
+			require.NoError(t, err)
+
+			// the derived credential round-trips and is smaller than the protobuf encoding
+			raw, err := psid.DeriveCredentialToCBOR(derived)
+			require.NoError(t, err)
+			require.True(t, IsCBOR(raw))
+			decoded, err := psid.DeriveCredentialFromCBOR(raw)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(derived, decoded))
+			again, err := psid.DeriveCredentialToCBOR(decoded)
+			require.NoError(t, err)
+			require.Equal(t, raw, again)
+			require.NoError(t, decoded.VerifyValidity(key.Ipk, now, psid.Curve, tr))
+
+			protoBytes, err := proto.Marshal(derived)
+			require.NoError(t, err)
+			require.False(t, IsCBOR(protoBytes))
+			require.Less(t, len(raw), len(protoBytes))
+			require.NoError(t, psid.VerifyDeriveEncoded(raw, key.Ipk))
+			require.NoError(t, psid.VerifyDeriveEncoded(protoBytes, key.Ipk))
+
+			// so does the aggregate credential
+			raw, err = psid.AggregateCredentialToCBOR(aggregate)
+			require.NoError(t, err)
+			decodedAggregate, err := psid.AggregateCredentialFromCBOR(raw)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(aggregate, decodedAggregate))
+			protoBytes, err = proto.Marshal(aggregate)
+			require.NoError(t, err)
+			t.Logf("aggregate credential: %d bytes CBOR, %d bytes protobuf", len(raw), len(protoBytes))
+			require.Less(t, len(raw), len(protoBytes))
+			require.NoError(t, psid.VerifyAggregateEncoded(raw, uk.Upk))
+			require.NoError(t, psid.VerifyAggregateEncoded(protoBytes, uk.Upk))
+
+			_, err = psid.DeriveCredentialFromCBOR(raw)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCBORDeterministic(t *testing.T) {
+	raw, err := cborEncode(cborMap{2: []interface{}{uint64(1), "a"}, 1: int64(-300), 3: []byte{1, 2}})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xd9, 0xd9, 0xf7, 0xa3, 0x01, 0x39, 0x01, 0x2b, 0x02, 0x82, 0x01, 0x61, 'a', 0x03, 0x42, 1, 2}, raw)
+	v, err := cborDecode(raw)
+	require.NoError(t, err)
+	require.Equal(t, cborMap{1: int64(-300), 2: []interface{}{uint64(1), "a"}, 3: []byte{1, 2}}, v)
+
+	for _, invalid := range [][]byte{
+		{0xd9, 0xd9, 0xf7, 0x18, 0x01},                   // integer not in the shortest form
+		{0xd9, 0xd9, 0xf7, 0xa2, 0x02, 0x00, 0x01, 0x00}, // map keys not in ascending order
+		{0xd9, 0xd9, 0xf7, 0x5f, 0xff},                   // indefinite length
+		{0xd9, 0xd9, 0xf7, 0x42, 0x01},                   // truncated
+		{0xd9, 0xd9, 0xf7, 0x00, 0x00},                   // trailing bytes
+		{0x00},                                           // no self describe tag
+	} {
+		_, err := cborDecode(invalid)
+		require.Error(t, err, "%x", invalid)
+	}
+}
@@ -0,0 +1,60 @@
+package psidentity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestCompressedCredentials(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	uncompressed := psid.Translator
+	tr := CompressedTranslator(uncompressed)
+	require.True(t, IsCompressedTranslator(tr))
+	require.False(t, IsCompressedTranslator(uncompressed))
+	psid.Translator = tr
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	now := time.Now()
+	attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+		now.Add(-time.Hour), now.Add(time.Hour), rng)
+	mask := []int{1, 0, 1, 0, 0, 0}
+
+	uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, primary.VerifyPrimary(key.Ipk, psid.Curve, tr))
+
+	derived, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now, rng, tr)
+	require.NoError(t, err)
+	require.NotEmpty(t, derived.SigmaOnep.Compressed)
+	require.NoError(t, derived.VerifyValidity(key.Ipk, now, psid.Curve, uncompressed))
+
+	aggregate, err := psid.NewAggregateCredential(uk, key.Ipk, []*DeriveCredential{derived}, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, aggregate.VerifyAggregate(uk.Upk, psid.Curve, uncompressed))
+
+	// the same credential derived with uncompressed points is larger
+	compressedBytes, err := proto.Marshal(aggregate)
+	require.NoError(t, err)
+	uncompressedDerived, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now, rng, uncompressed)
+	require.NoError(t, err)
+	uncompressedAggregate, err := psid.NewAggregateCredential(uk, key.Ipk, []*DeriveCredential{uncompressedDerived}, rng, uncompressed)
+	require.NoError(t, err)
+	uncompressedBytes, err := proto.Marshal(uncompressedAggregate)
+	require.NoError(t, err)
+	t.Logf("aggregate credential: %d bytes compressed, %d bytes uncompressed", len(compressedBytes), len(uncompressedBytes))
+	require.Less(t, len(compressedBytes), len(uncompressedBytes))
+
+	// re-encoding the points of one gives the size of the other
+	size, err := encodedSize(uncompressedAggregate, tr)
+	require.NoError(t, err)
+	require.Equal(t, len(compressedBytes), size)
+	size, err = encodedSize(aggregate, uncompressed)
+	require.NoError(t, err)
+	require.Equal(t, len(uncompressedBytes), size)
+}
@@ -12,7 +12,6 @@ import (
 	//amcl "psidentity/translator/amcl"
 	math "github.com/IBM/mathlib"
 	"github.com/pkg/errors"
-	"log"
 	"time"
 )
 
@@ -215,20 +214,56 @@ func (cred *Credential) Ver(sk *math.Zr, ipk *IssuerPublicKey, curve *math.Curve
 
 //Yunqing new add
 func (i *Psidentity) NewBlindCredential(key *IssuerKeyPS, m *CredRequestPS, rng io.Reader, t Translator) (*BlindCredential, error) {
-	return newBlindCredentialPS(key, m, rng, t, i.Curve)
+	return newBlindCredentialPS(key, m, nil, rng, t, i.Curve)
 }
 
-func newBlindCredentialPS(key *IssuerKeyPS, m *CredRequestPS, rng io.Reader, t Translator, curve *math.Curve) (*BlindCredential, error) {
+// NewBlindCredentialWithValidity blind-signs the credential request like NewBlindCredential, for an issuer key
+// made with NewIssuerKeyPSWithValidity. The request commits to the user attributes only, the issuer adds the
+// validity window it assigns and signs it in clear.
+func (i *Psidentity) NewBlindCredentialWithValidity(key *IssuerKeyPS, m *CredRequestPS, window *ValidityWindow, rng io.Reader, t Translator) (*BlindCredential, error) {
+	if window == nil {
+		return nil, errors.Errorf("no validity window")
+	}
+	return newBlindCredentialPS(key, m, window, rng, t, i.Curve)
+}
+
+func newBlindCredentialPS(key *IssuerKeyPS, m *CredRequestPS, window *ValidityWindow, rng io.Reader, t Translator, curve *math.Curve) (*BlindCredential, error) {
 	// check the credential request
-	t11 := time.Now().UnixNano() / int64(time.Millisecond)
 	err := m.VerifyZeroKnowledgeOne(key.Ipk, curve, t)
 	if err != nil {
 		return nil, err
 	}
-	t22 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("Pre-Sign Verify Latency=%v ms.", t22-t11)
 
-	t1 := time.Now().UnixNano() / int64(time.Millisecond)
+	Com, err := curve.NewG2FromBytes(m.GetCommitment())
+	if err != nil {
+		return nil, err
+	}
+
+	// the validity window is assigned by the issuer, the request must leave the validity attributes out
+	var validity []string
+	if hasValidity(key.Ipk) {
+		if window == nil {
+			return nil, errors.Errorf("the credentials of the issuer key have a validity window, which the issuer assigns")
+		}
+		nbIndex, naIndex, _ := validityIndices(key.Ipk)
+		if len(m.GetRw()) != nbIndex {
+			metrics().IncCounter(MetricCredRequestsRejected)
+			return nil, errors.Errorf("the credential request must commit to the %d user attributes only, got %d", nbIndex, len(m.GetRw()))
+		}
+		if validity, err = window.attributes(); err != nil {
+			return nil, err
+		}
+		for j, index := range []int{nbIndex, naIndex} {
+			YBar, err := t.G2FromProto(key.Ipk.YBar[index])
+			if err != nil {
+				return nil, err
+			}
+			Com.Add(YBar.Mul(attributeZr(key.Ipk, index, validity[j], curve)))
+		}
+	} else if window != nil {
+		return nil, errors.Errorf("the credentials of the issuer key have no validity window")
+	}
+
 	//sign procecss
 	tempU := curve.NewRandomZr(rng)
 	tempU_bytes := tempU.Bytes()
@@ -237,21 +272,20 @@ func newBlindCredentialPS(key *IssuerKeyPS, m *CredRequestPS, rng io.Reader, t T
 	h := curve.GenG2.Mul(u)
 
 	XBar := curve.GenG2.Mul(curve.NewZrFromBytes(key.Isk.X))
-	Com, err := curve.NewG2FromBytes(m.GetCommitment())
-	if err != nil {
-		return nil, err
-	}
 	XBar.Add(Com)
 	s := XBar.Mul(u)
 
-	t2 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("Sign Latency=%v ms.", t2-t1)
+	metrics().IncCounter(MetricCredentialsIssued)
 
-	return &BlindCredential{
+	blind := &BlindCredential{
 		H: t.G2ToProto(h),
 		S: t.G2ToProto(s),
 		C: m.GetCommitment(), //refer to message
-	}, nil
+	}
+	if validity != nil {
+		blind.NotBefore, blind.NotAfter = validity[0], validity[1]
+	}
+	return blind, nil
 }
 
 func (i *Psidentity) NewPrimaryCredential(Attrs []string, d *math.Zr, key *IssuerKeyPS, m *BlindCredential, rng io.Reader, t Translator) (*PrimaryCredential, error) {
@@ -271,32 +305,47 @@ func newPrimaryCredential(Attrs []string, d *math.Zr, key *IssuerKeyPS, m *Blind
 	// temp_bytes := temp.Bytes()
 	// d := curve.NewZrFromBytes(temp_bytes)
 
-	//unblind procecss
+	//unblind procecss, s = s \cdot h^{-d}
 	h, err := t.G2FromProto(m.H)
-	horigin := h
 	if err != nil {
 		return nil, err
 	}
-	d.Neg()
-	h.Mul(d)
+	negD := d.Copy()
+	negD.Neg()
 
 	s, err := t.G2FromProto(m.S)
 	if err != nil {
 		return nil, err
 	}
-	s.Add(h)
+	s.Add(h.Mul(negD))
+
+	// the issuer signed the validity window in clear, it follows the user attributes
+	if hasValidity(key.GetIpk()) {
+		if len(Attrs) != len(key.Ipk.GetY())-2 {
+			return nil, errors.Errorf("the credentials of the issuer key have %d user attributes, got %d", len(key.Ipk.GetY())-2, len(Attrs))
+		}
+		if m.GetNotBefore() == "" || m.GetNotAfter() == "" {
+			return nil, errors.Errorf("the blind credential has no validity window")
+		}
+		Attrs = append(append(make([]string, 0, len(Attrs)+2), Attrs...), m.GetNotBefore(), m.GetNotAfter())
+	}
 
 	return &PrimaryCredential{
-		Attrs: Attrs,
-		H:     t.G2ToProto(horigin),
-		S:     t.G2ToProto(s),
-		C:     m.GetC(),
+		Attrs:       Attrs,
+		H:           t.G2ToProto(h),
+		S:           t.G2ToProto(s),
+		C:           m.GetC(),
+		IssuerKeyId: IssuerKeyID(key.GetIpk()),
 	}, nil
 }
 
 // Verify cryptographically verifies the credential by verifying the signature
 // on the attribute values and user's secret key
-func (cred *PrimaryCredential) VerifyPrimary(ipk *IssuerPublicKeyPS, curve *math.Curve, t Translator) error {
+func (cred *PrimaryCredential) VerifyPrimary(ipk *IssuerPublicKeyPS, curve *math.Curve, t Translator) (err error) {
+	defer func(start time.Time) { recordCheck(MetricVerifyPrimarySeconds, MetricVerifyPrimaryFailures, start, err) }(time.Now())
+	if err := checkIssuerKeyID(cred.GetIssuerKeyId(), ipk); err != nil {
+		return err
+	}
 	// Validate Input
 	h, err := t.G2FromProto(cred.GetH())
 	if err != nil {
@@ -320,19 +369,15 @@ func (cred *PrimaryCredential) VerifyPrimary(ipk *IssuerPublicKeyPS, curve *math
 		if err != nil {
 			return err
 		}
-		X.Add(Yi.Mul(curve.NewZrFromBytes([]byte(cred.Attrs[i]))))
+		X.Add(Yi.Mul(attributeZr(ipk, i, cred.Attrs[i], curve)))
 	}
 
 	// h.Affine()
 	// s.Affine()
 
-	//verify pairing equation
-	left := curve.FExp(curve.Pairing(h, X))
-	right := curve.FExp(curve.Pairing(s, curve.GenG1))
-
-	if !left.Equals(right) {
-		log.Printf("##########primary credential is not cryptographically valid")
-		//return errors.Errorf("credential is not cryptographically valid")
+	//verify pairing equation e(h, X) = e(s, g_1)
+	if isG2Identity(curve, h) || !pairingsEqual(curve, h, X, s, curve.GenG1) {
+		return errors.Errorf("credential is not cryptographically valid")
 	}
 
 	return nil
@@ -0,0 +1,119 @@
+package psidentity
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+// issueTestCredential runs the issuance protocol with the issuer key and returns the primary credential of the attributes
+func issueTestCredential(t testing.TB, psid *Psidentity, key *IssuerKeyPS, attrs []string, rng io.Reader) *PrimaryCredential {
+	req, d, err := psid.NewCredRequestPS(attrs, key.Ipk, rng, psid.Translator)
+	require.NoError(t, err)
+	blind, err := psid.NewBlindCredential(key, req, rng, psid.Translator)
+	require.NoError(t, err)
+	primary, err := psid.NewPrimaryCredential(attrs, d, key, blind, rng, psid.Translator)
+	require.NoError(t, err)
+	return primary
+}
+
+// newTestCredential generates an issuer key for the attributes and issues their primary credential with it
+func newTestCredential(t testing.TB, psid *Psidentity, attrs []string, rng io.Reader) (*IssuerKeyPS, *PrimaryCredential) {
+	key, err := psid.NewIssuerKeyPS(len(attrs), rng, psid.Translator)
+	require.NoError(t, err)
+	return key, issueTestCredential(t, psid, key, attrs, rng)
+}
+
+// newValidityTestCredential generates an issuer key whose credentials have a validity window, and issues the
+// primary credential of the user attributes valid from notBefore to notAfter with it
+func newValidityTestCredential(t testing.TB, psid *Psidentity, userAttrs []string, notBefore, notAfter time.Time, rng io.Reader) ([]string, *IssuerKeyPS, *PrimaryCredential) {
+	key, err := psid.NewIssuerKeyPSWithValidity(len(userAttrs)+2, rng, psid.Translator)
+	require.NoError(t, err)
+	req, d, err := psid.NewCredRequestPS(userAttrs, key.Ipk, rng, psid.Translator)
+	require.NoError(t, err)
+	blind, err := psid.NewBlindCredentialWithValidity(key, req, &ValidityWindow{NotBefore: notBefore, NotAfter: notAfter}, rng, psid.Translator)
+	require.NoError(t, err)
+	primary, err := psid.NewPrimaryCredential(userAttrs, d, key, blind, rng, psid.Translator)
+	require.NoError(t, err)
+	require.NoError(t, primary.VerifyPrimary(key.Ipk, psid.Curve, psid.Translator))
+	return primary.Attrs, key, primary
+}
+
+func TestTamperedCredentials(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	require.NoError(t, primary.VerifyPrimary(key.Ipk, curve, tr))
+	_, other := newTestCredential(t, psid, attrs, rng)
+	identity := tr.G2ToProto(curve.NewG2())
+
+	// primary credentials
+	for name, tamper := range map[string]func(*PrimaryCredential){
+		"attribute":    func(c *PrimaryCredential) { c.Attrs[1] = "companyB" },
+		"swapped":      func(c *PrimaryCredential) { c.Attrs[0], c.Attrs[1] = c.Attrs[1], c.Attrs[0] },
+		"signature":    func(c *PrimaryCredential) { c.S = other.S },
+		"other issuer": func(c *PrimaryCredential) { c.H, c.S = other.H, other.S },
+		"identity":     func(c *PrimaryCredential) { c.H, c.S = identity, identity },
+	} {
+		tampered := proto.Clone(primary).(*PrimaryCredential)
+		tamper(tampered)
+		require.Error(t, tampered.VerifyPrimary(key.Ipk, curve, tr), name)
+	}
+
+	// derived credentials
+	derived, err := psid.NewDeriveCredential(attrs, key, primary, []int{1, 0, 1, 0}, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, derived.VerifyDerive(key.Ipk, curve, tr))
+	otherDerived, err := psid.NewDeriveCredential(attrs, key, primary, []int{1, 1, 0, 0}, rng, tr)
+	require.NoError(t, err)
+	for name, tamper := range map[string]func(*DeriveCredential){
+		"disclosed value": func(c *DeriveCredential) { c.DiscloseMsg[2] = "2099-01-01" },
+		"hidden disclosed": func(c *DeriveCredential) {
+			c.DiscloseIndices = append(c.DiscloseIndices, 1)
+			c.DiscloseMsg[1] = "companyB"
+		},
+		"dropped disclosure": func(c *DeriveCredential) { c.DiscloseIndices = c.DiscloseIndices[:1] },
+		"empty disclosure":   func(c *DeriveCredential) { c.DiscloseMsg[2] = "" },
+		"no disclosure":      func(c *DeriveCredential) { c.DiscloseIndices = nil },
+		"duplicate":          func(c *DeriveCredential) { c.DiscloseIndices = append(c.DiscloseIndices, 0) },
+		"out of range":       func(c *DeriveCredential) { c.DiscloseIndices = append(c.DiscloseIndices, 7) },
+		"signature":          func(c *DeriveCredential) { c.Hp, c.Sp = otherDerived.Hp, otherDerived.Sp },
+		"sigma_onep":         func(c *DeriveCredential) { c.SigmaOnep = otherDerived.SigmaOnep },
+		"sigma_twop":         func(c *DeriveCredential) { c.SigmaTwop = otherDerived.SigmaTwop },
+		"identity":           func(c *DeriveCredential) { c.Hp, c.Sp = identity, identity },
+	} {
+		tampered := proto.Clone(derived).(*DeriveCredential)
+		tamper(tampered)
+		require.Error(t, tampered.VerifyDerive(key.Ipk, curve, tr), name)
+	}
+
+	// aggregate credentials
+	uk, err := psid.NewUserKeyPS(2, rng, tr)
+	require.NoError(t, err)
+	otherUk, err := psid.NewUserKeyPS(2, rng, tr)
+	require.NoError(t, err)
+	aggregate, err := psid.NewAggregateCredential(uk, key.Ipk, []*DeriveCredential{derived, otherDerived}, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, psid.VerifyAggregate(aggregate, uk, tr))
+	require.Error(t, psid.VerifyAggregate(aggregate, otherUk, tr))
+	for name, tamper := range map[string]func(*AggregateCredential){
+		"swapped sigmas":   func(c *AggregateCredential) { c.SigmaOnepp, c.SigmaTwopp = c.SigmaTwopp, c.SigmaOnepp },
+		"message":          func(c *AggregateCredential) { c.Messages[0].DiscloseMsg[2] = "2099-01-01" },
+		"message order":    func(c *AggregateCredential) { c.Messages[0], c.Messages[1] = c.Messages[1], c.Messages[0] },
+		"dropped message":  func(c *AggregateCredential) { c.Messages = c.Messages[:1] },
+		"replaced message": func(c *AggregateCredential) { c.Messages[1] = derived },
+		"identity":         func(c *AggregateCredential) { c.SigmaOnepp, c.SigmaTwopp = identity, identity },
+	} {
+		tampered := proto.Clone(aggregate).(*AggregateCredential)
+		tamper(tampered)
+		require.Error(t, psid.VerifyAggregate(tampered, uk, tr), name)
+	}
+}
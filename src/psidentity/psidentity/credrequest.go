@@ -12,9 +12,6 @@ import (
 	math "github.com/IBM/mathlib"
 	"github.com/pkg/errors"
 
-	"time"
-	"log"
-
 	// "fmt"
 )
 
@@ -137,38 +134,38 @@ func (i *Psidentity) NewCredRequestPS(UserAttributeNames []string, ipk *IssuerPu
 }
 
 func newCredRequestPS(UserAttributeNames []string, ipk *IssuerPublicKeyPS, rng io.Reader, curve *math.Curve, tr Translator) (*CredRequestPS, *math.Zr, error) {
-	t1 := time.Now().UnixNano() / int64(time.Millisecond)
+	return newPreparedCredRequestPS(UserAttributeNames, decodeIssuerKey(ipk, curve, tr), rng)
+}
+
+func newPreparedCredRequestPS(UserAttributeNames []string, pk *PreparedIssuerKey, rng io.Reader) (*CredRequestPS, *math.Zr, error) {
+	ipk, curve := pk.Ipk, pk.curve
+
+	indices := make([]int64, len(UserAttributeNames))
+	attrs := make([]*math.Zr, len(UserAttributeNames))
+	for i := range UserAttributeNames {
+		indices[i] = int64(i)
+		attrs[i] = curve.NewZrFromBytes([]byte(UserAttributeNames[i]))
+	}
 
 	// generage commitment
 	d := curve.NewRandomZr(rng)
 
-	commitment := curve.GenG2.Mul(d)
-
-	for i := 0; i < len(UserAttributeNames); i++ {
-		YBar, err := tr.G2FromProto(ipk.YBar[i])
-		if err != nil {
-			return nil, nil, err
-		}
-		attr := curve.NewZrFromBytes([]byte(UserAttributeNames[i]))
-		if err != nil {
-			return nil, nil, err
-		}
-		tmp := YBar.Mul(attr)
-		commitment.Add(tmp)
+	sk := pk.secret()
+	commitment := sk.g2Mul(d)
+	if err := sk.yBarMulAdd(commitment, indices, attrs); err != nil {
+		return nil, nil, err
 	}
 
 	// generate a zero-knowledge proof of knowledge (ZK PoK) of messages
 	//generate k
 	p := curve.NewRandomZr(rng)
 	w := make([]*math.Zr, len(UserAttributeNames))
-	k := curve.GenG2.Mul(p)
-	for i := 0; i < len(UserAttributeNames); i++ {
+	for i := range w {
 		w[i] = curve.NewRandomZr(rng)
-		YBarI, err := tr.G2FromProto(ipk.YBar[i])
-		if err != nil {
-			return nil, nil, err
-		}
-		k.Add(YBarI.Mul(w[i]))
+	}
+	k := sk.g2Mul(p)
+	if err := sk.yBarMulAdd(k, indices, w); err != nil {
+		return nil, nil, err
 	}
 
 	//compute challenge
@@ -191,14 +188,11 @@ func newCredRequestPS(UserAttributeNames []string, ipk *IssuerPublicKeyPS, rng i
 
 	rw := make([][]byte, len(UserAttributeNames))
 	for i := 0; i < len(UserAttributeNames); i++ {
-		value := w[i].Plus(challenge.Mul(curve.NewZrFromBytes([]byte(UserAttributeNames[i])))) //rw = w[i] + challenge * attributes[i]
+		value := w[i].Plus(challenge.Mul(attrs[i])) //rw = w[i] + challenge * attributes[i]
 		// fmt.Printf("the type of value:%T", value)
 		rw[i] = value.Bytes()
 	}
 
-	t2 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("Pre-Sign Latency=%v ms.", t2-t1)
-
 	// Done
 	return &CredRequestPS{
 		Commitment: commitment.Bytes(),
@@ -211,6 +205,16 @@ func newCredRequestPS(UserAttributeNames []string, ipk *IssuerPublicKeyPS, rng i
 
 // Verify cryptographically verifies the credential request
 func (m *CredRequestPS) VerifyZeroKnowledgeOne(ipk *IssuerPublicKeyPS, curve *math.Curve, tr Translator) error {
+	return m.verifyZeroKnowledgeOne(decodeIssuerKey(ipk, curve, tr))
+}
+
+func (m *CredRequestPS) verifyZeroKnowledgeOne(pk *PreparedIssuerKey) (err error) {
+	defer func() {
+		if err != nil {
+			metrics().IncCounter(MetricCredRequestsRejected)
+		}
+	}()
+	curve := pk.curve
 	commitment, err := curve.NewG2FromBytes(m.GetCommitment())
 	if err != nil {
 		return err
@@ -229,13 +233,15 @@ func (m *CredRequestPS) VerifyZeroKnowledgeOne(ipk *IssuerPublicKeyPS, curve *ma
 
 	// Verify Proof
 	//compute left
-	left := curve.GenG2.Mul(rp)
-	for i := 0; i < len(rw); i++ {
-		YBarI, err := tr.G2FromProto(ipk.YBar[i])
-		if err != nil {
-			return err
-		}
-		left.Add(YBarI.Mul(curve.NewZrFromBytes(rw[i])))
+	indices := make([]int64, len(rw))
+	scalars := make([]*math.Zr, len(rw))
+	for i := range rw {
+		indices[i] = int64(i)
+		scalars[i] = curve.NewZrFromBytes(rw[i])
+	}
+	left := pk.g2Mul(rp)
+	if err := pk.yBarMulAdd(left, indices, scalars); err != nil {
+		return err
 	}
 
 	right := k
@@ -0,0 +1,73 @@
+package psidentity
+
+import (
+	math "github.com/IBM/mathlib"
+	"github.com/pkg/errors"
+	amcl "psidentity/translator/amcl"
+)
+
+// Names of the curves recorded in the artifacts
+const (
+	CurveFP256BN_AMCL        = "FP256BN_AMCL"
+	CurveBN254               = "BN254"
+	CurveFP256BN_AMCL_MIRACL = "FP256BN_AMCL_MIRACL"
+	CurveBLS12_377_GURVY     = "BLS12_377_GURVY"
+	CurveBLS12_381_GURVY     = "BLS12_381_GURVY"
+	CurveBLS12_381           = "BLS12_381"
+)
+
+var curveIDs = map[string]math.CurveID{
+	CurveFP256BN_AMCL:        math.FP256BN_AMCL,
+	CurveBN254:               math.BN254,
+	CurveFP256BN_AMCL_MIRACL: math.FP256BN_AMCL_MIRACL,
+	CurveBLS12_377_GURVY:     math.BLS12_377_GURVY,
+	CurveBLS12_381_GURVY:     math.BLS12_381_GURVY,
+	CurveBLS12_381:           math.BLS12_381,
+}
+
+// NewPsidentityForCurve returns a Psidentity on the named curve, with the translator for its point encoding
+func NewPsidentityForCurve(name string) (*Psidentity, error) {
+	id, ok := curveIDs[name]
+	if !ok {
+		return nil, errors.Errorf("invalid curve [%s]", name)
+	}
+	curve := math.Curves[id]
+
+	var tr Translator
+	switch id {
+	case math.FP256BN_AMCL:
+		tr = &amcl.Fp256bn{C: curve}
+	case math.FP256BN_AMCL_MIRACL:
+		tr = &amcl.Fp256bnMiracl{C: curve}
+	default:
+		tr = &amcl.Gurvy{C: curve}
+	}
+	return &Psidentity{Curve: curve, Translator: tr}, nil
+}
+
+// CurveName returns the name of the curve, or "" for a curve that is not supported
+func CurveName(curve *math.Curve) string {
+	for name, id := range curveIDs {
+		if math.Curves[id] == curve {
+			return name
+		}
+	}
+	return ""
+}
+
+// CheckCurveID checks that an artifact recorded with the curve name actual can be used on the curve expected.
+// Artifacts without a curve name predate the support of other curves and were created on FP256BN_AMCL.
+func CheckCurveID(expected, actual string) error {
+	if actual == "" {
+		actual = CurveFP256BN_AMCL
+	}
+	if actual != expected {
+		return errors.Errorf("artifact was created on curve %s, not on %s", actual, expected)
+	}
+	return nil
+}
+
+// CheckCurve checks that an artifact recorded with the curve name curveID can be used with this Psidentity
+func (i *Psidentity) CheckCurve(curveID string) error {
+	return CheckCurveID(CurveName(i.Curve), curveID)
+}
@@ -0,0 +1,79 @@
+package psidentity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+func TestCurvesEndToEnd(t *testing.T) {
+	for name := range curveIDs {
+		t.Run(name, func(t *testing.T) {
+			psid, err := NewPsidentityForCurve(name)
+			require.NoError(t, err)
+			require.Equal(t, name, CurveName(psid.Curve))
+			tr := psid.Translator
+
+			iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, tr)
+			require.NoError(t, err)
+			key := IssuerKeyPS{Isk: &IssuerPrivateKeyPS{}, Ipk: &IssuerPublicKeyPS{}}
+			require.NoError(t, proto.Unmarshal(iskBytes, key.Isk))
+			require.NoError(t, proto.Unmarshal(ipkBytes, key.Ipk))
+			require.Equal(t, name, key.Ipk.CurveId)
+
+			uskBytes, upkBytes, err := GenerateUserKeyPS(*psid, tr)
+			require.NoError(t, err)
+			uk := UserKey{Usk: &UserPrivateKey{}, Upk: &UserPublicKey{}}
+			require.NoError(t, proto.Unmarshal(uskBytes, uk.Usk))
+			require.NoError(t, proto.Unmarshal(upkBytes, uk.Upk))
+
+			now := time.Now()
+			window := &ValidityWindow{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+			primaryBytes, err := GenerateUserPrimaryCred([]string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+				window, &key, *psid, tr)
+			require.NoError(t, err)
+			conf := &user.UserPrimaryCred{}
+			require.NoError(t, proto.Unmarshal(primaryBytes, conf))
+			require.Equal(t, name, conf.CurveId)
+			primary := &PrimaryCredential{}
+			require.NoError(t, proto.Unmarshal(conf.PrimaryCred, primary))
+			require.NoError(t, primary.VerifyPrimary(key.Ipk, psid.Curve, tr))
+			attrs := primary.Attrs
+
+			rng, err := psid.Curve.Rand()
+			require.NoError(t, err)
+			derived, err := psid.NewDeriveCredential(attrs, &key, primary, []int{1, 0, 1, 0, 0, 0}, rng, tr)
+			require.NoError(t, err)
+			aggregate, err := psid.NewAggregateCredential(&uk, key.Ipk, []*DeriveCredential{derived}, rng, tr)
+			require.NoError(t, err)
+			require.NoError(t, aggregate.VerifyAggregate(uk.Upk, psid.Curve, tr))
+		})
+	}
+}
+
+func TestCurveMismatch(t *testing.T) {
+	bls, err := NewPsidentityForCurve(CurveBLS12_381)
+	require.NoError(t, err)
+	fp, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*bls, bls.Translator)
+	require.NoError(t, err)
+	key := IssuerKeyPS{Isk: &IssuerPrivateKeyPS{}, Ipk: &IssuerPublicKeyPS{}}
+	require.NoError(t, proto.Unmarshal(iskBytes, key.Isk))
+	require.NoError(t, proto.Unmarshal(ipkBytes, key.Ipk))
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+	_, err = GenerateUserPrimaryCred(attrs, &ValidityWindow{NotBefore: time.Now(), NotAfter: time.Now()}, &key, *fp, fp.Translator)
+	require.Error(t, err)
+
+	// artifacts without a curve ID were created on FP256BN_AMCL
+	require.NoError(t, fp.CheckCurve(""))
+	require.Error(t, bls.CheckCurve(""))
+	_, err = NewPsidentityForCurve("P256")
+	require.Error(t, err)
+}
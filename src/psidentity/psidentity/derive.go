@@ -4,7 +4,6 @@ import (
 	math "github.com/IBM/mathlib"
 	"github.com/pkg/errors"
 	"io"
-	"log"
 	"time"
 )
 
@@ -72,79 +71,88 @@ func (i *Psidentity) NewDeriveCredential(Attrs []string, key *IssuerKeyPS, m *Pr
 }
 
 func newDeriveCredential(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, rng io.Reader, tr Translator, curve *math.Curve) (*DeriveCredential, error) {
+	cred, _, err := deriveCredential(Attrs, decodeIssuerKey(key.Ipk, curve, tr), m, Mask, rng)
+	return cred, err
+}
+
+// deriveCredential derives the credential and also returns the randomness t of sigma_onep,
+// which is needed to prove statements about the hidden attributes
+func deriveCredential(Attrs []string, pk *PreparedIssuerKey, m *PrimaryCredential, Mask []int, rng io.Reader) (*DeriveCredential, *math.Zr, error) {
+	defer func(start time.Time) { metrics().ObserveHistogram(MetricDeriveSeconds, time.Since(start).Seconds()) }(time.Now())
+	curve, tr := pk.curve, pk.tr
 
-	t11 := time.Now().UnixNano() / int64(time.Millisecond)
 	// check the credential request
-	err := m.VerifyPrimary(key.Ipk, curve, tr)
+	err := m.VerifyPrimary(pk.Ipk, curve, tr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	t22 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("PrimaryCredential Verify Latency=%v ms.", t22-t11)
-
-	t1 := time.Now().UnixNano() / int64(time.Millisecond)
 
 	r := curve.NewRandomZr(rng)
 	t := curve.NewRandomZr(rng)
 
 	h, err := tr.G2FromProto(m.H)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	h.Mul(r)
-	hp := h  //hp = h^r
+	hp := h.Mul(r) //hp = h^r
 
 	s, err := tr.G2FromProto(m.S)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	s.Mul(r)
-	tmp := h.Mul(t)
-	s.Add(tmp)
-	sp := s
+	sp := s.Mul(r) //sp = s^r \cdot hp^t
+	sp.Add(hp.Mul(t))
 
-	sigma_onep := curve.GenG1.Mul(t)
 	HideIndices := hideIndices(Mask)
-	for j := 0; j < len(HideIndices); j++ {
-		Yi, err := tr.G1FromProto(key.Ipk.Y[HideIndices[j]])
-		if err != nil {
-			return nil, err
-		}
-		// tmp := Yi.Mul(curve.NewZrFromBytes([]byte(Attrs[j])))
-		tmp := Yi.Mul(curve.NewZrFromBytes([]byte(Attrs[HideIndices[j]])))
-		sigma_onep.Add(tmp)
+	HideAttrs := make([]*math.Zr, len(HideIndices))
+	for j, index := range HideIndices {
+		HideAttrs[j] = attributeZr(pk.Ipk, int(index), Attrs[index], curve)
+	}
+	sk := pk.secret()
+	sigma_onep := sk.g1Mul(t)
+	if err := sk.yMulAdd(sigma_onep, HideIndices, HideAttrs); err != nil {
+		return nil, nil, err
 	}
 
 	DiscloseIndices := discloseIndices(Mask)
+	if len(DiscloseIndices) == 0 {
+		return nil, nil, errors.Errorf("derived credential must disclose at least one attribute")
+	}
 	DiscloseMsg := make([]string, len(Attrs))
-	Y, err := tr.G1FromProto(key.Ipk.Y[DiscloseIndices[0]])
+	Y, err := pk.Y(int(DiscloseIndices[0]))
+	if err != nil {
+		return nil, nil, err
+	}
 	DiscloseMsg[DiscloseIndices[0]] = Attrs[DiscloseIndices[0]]
 	for i := 1; i < len(DiscloseIndices); i++ {
-		Yi, err := tr.G1FromProto(key.Ipk.Y[DiscloseIndices[i]])
+		Yi, err := pk.Y(int(DiscloseIndices[i]))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		DiscloseMsg[DiscloseIndices[i]] = Attrs[DiscloseIndices[i]]
 		Y.Add(Yi)
 	}
+
+	// sigma_twop = (\prod_{i \in D} Y_i)^t \cdot \prod_{j \in H} (\prod_{i \in D} Z_ij)^{m_j}
+	// over the disclosed attributes D and the hidden attributes H, one exponentiation per hidden attribute
 	sigma_twop := Y.Mul(t)
-	var Zij *math.G1
-	//二维数组[m,n]坐标到一维数组坐标index的转换：二维[i, j] 可转换成一维的 index = i * n + j;
-	//一维数组坐标index到二维数组[m,n]坐标的转换：一维的 index 可转换成二维[i = index / n, j = index % n]
-	for i := 0; i < len(DiscloseIndices); i++ {
-		for j := 0; j < len(HideIndices); j++ {
-			index := DiscloseIndices[i]*int64(len(HideIndices)) + HideIndices[j]
-			Zij, err = tr.G1FromProto(key.Ipk.ZIj[index])
+	for k, j := range HideIndices {
+		var Zj *math.G1
+		for _, i := range DiscloseIndices {
+			Zij, err := pk.Zij(int(i), int(j))
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			Zij.Mul(curve.NewZrFromBytes([]byte(Attrs[HideIndices[j]])))
+			if Zj == nil {
+				Zj = Zij
+			} else {
+				Zj.Add(Zij)
+			}
+		}
+		if Zj != nil {
+			sigma_twop.Add(Zj.Mul(HideAttrs[k]))
 		}
 	}
-	sigma_twop.Add(Zij)
-
-	t2 := time.Now().UnixNano() / int64(time.Millisecond)
-	log.Printf("Derive Latency=%v ms.", t2-t1)
 
 	return &DeriveCredential{
 		Hp:              tr.G2ToProto(hp),
@@ -153,12 +161,22 @@ func newDeriveCredential(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential,
 		SigmaTwop:       tr.G1ToProto(sigma_twop),
 		DiscloseIndices: DiscloseIndices,
 		DiscloseMsg:     DiscloseMsg,
-	}, nil
+		IssuerKeyId:     IssuerKeyID(pk.Ipk),
+	}, t, nil
 }
 
 // VerifyDerive cryptographically verifies the credential by verifying the signature
 // on the attribute values and user's secret key
 func (cred *DeriveCredential) VerifyDerive(ipk *IssuerPublicKeyPS, curve *math.Curve, tr Translator) error {
+	return cred.verifyDerive(decodeIssuerKey(ipk, curve, tr))
+}
+
+func (cred *DeriveCredential) verifyDerive(pk *PreparedIssuerKey) (err error) {
+	defer func(start time.Time) { recordCheck(MetricVerifyDeriveSeconds, MetricVerifyDeriveFailures, start, err) }(time.Now())
+	ipk, curve, tr := pk.Ipk, pk.curve, pk.tr
+	if err := checkIssuerKeyID(cred.GetIssuerKeyId(), ipk); err != nil {
+		return err
+	}
 	// Validate Input
 	hp, err := tr.G2FromProto(cred.GetHp())
 	if err != nil {
@@ -178,7 +196,7 @@ func (cred *DeriveCredential) VerifyDerive(ipk *IssuerPublicKeyPS, curve *math.C
 		return err
 	}
 
-	X, err := tr.G1FromProto(ipk.X)
+	X, err := pk.X()
 	if err != nil {
 		return err
 	}
@@ -186,31 +204,35 @@ func (cred *DeriveCredential) VerifyDerive(ipk *IssuerPublicKeyPS, curve *math.C
 
 	// var YBarSum *math.G2
 	//方法1
-	temp := curve.NewZrFromInt(0)
-	YBarSum := curve.GenG2.Mul(temp)
+	YBarSum := curve.NewG2()
 
-	for i := 0; i < len(cred.DiscloseIndices); i++ {
-		if cred.DiscloseMsg[cred.DiscloseIndices[i]] == "" {
-			continue
+	// every disclosed attribute is in YBarSum, so that the second equation keeps it out of sigma_onep
+	if len(cred.DiscloseIndices) == 0 {
+		return errors.Errorf("derived credential discloses no attribute")
+	}
+	indices := make([]int64, 0, len(cred.DiscloseIndices))
+	msgs := make([]*math.Zr, 0, len(cred.DiscloseIndices))
+	seen := make(map[int64]bool, len(cred.DiscloseIndices))
+	for _, index := range cred.DiscloseIndices {
+		if index < 0 || int(index) >= len(cred.DiscloseMsg) || seen[index] {
+			return errors.Errorf("derived credential has an invalid disclosed attribute index %d", index)
 		}
-		Yi, err := tr.G1FromProto(ipk.Y[cred.DiscloseIndices[i]])
-		if err != nil {
-			return err
+		seen[index] = true
+		if cred.DiscloseMsg[index] == "" {
+			return errors.Errorf("credential has no value for disclosed attribute %d", index)
 		}
+		indices = append(indices, index)
+		msgs = append(msgs, attributeZr(pk.Ipk, int(index), cred.DiscloseMsg[index], curve))
 
-		X.Add(Yi.Mul(curve.NewZrFromBytes([]byte(cred.DiscloseMsg[cred.DiscloseIndices[i]]))))
-
-		YBarI, err := tr.G2FromProto(ipk.YBar[cred.DiscloseIndices[i]])
+		YBarI, err := pk.YBar(int(index))
 		if err != nil {
 			return err
 		}
 		YBarSum.Add(YBarI)
+	}
 
-		if i == len(cred.DiscloseIndices)-1 {
-			if cred.DiscloseMsg[cred.DiscloseIndices[i]] == "" {
-				return errors.Errorf("credential has no value for attribute %s", cred.DiscloseMsg[cred.DiscloseIndices[i]])
-			}
-		}
+	if err := pk.yMulAdd(X, indices, msgs); err != nil {
+		return err
 	}
 
 	// //方法2：
@@ -247,22 +269,97 @@ func (cred *DeriveCredential) VerifyDerive(ipk *IssuerPublicKeyPS, curve *math.C
 	// 	}
 	// }
 
-	//verify pairing equation
-	left1 := curve.FExp(curve.Pairing(hp, X))
-	right1 := curve.FExp(curve.Pairing(sp, curve.GenG1))
-	if !left1.Equals(right1) {
-		log.Printf("##########11credential is not cryptographically valid")
-		//return errors.Errorf("credential is not cryptographically valid")
+	//verify pairing equations e(hp, X) = e(sp, g_1) and e(YBarSum, sigma_onep) = e(g_2, sigma_twop)
+	if isG2Identity(curve, hp) || !pairingsEqual(curve, hp, X, sp, curve.GenG1) {
+		return errors.Errorf("credential is not cryptographically valid")
 	}
-
-	left2 := curve.FExp(curve.Pairing(YBarSum, sigma_onep))
-	right2 := curve.FExp(curve.Pairing(curve.GenG2, sigma_twop))
-	if !left2.Equals(right2) {
-		log.Printf("##########22credential is not cryptographically valid")
-		//return errors.Errorf("credential is not cryptographically valid")
+	if !pairingsEqual(curve, YBarSum, sigma_onep, curve.GenG2, sigma_twop) {
+		return errors.Errorf("credential is not cryptographically valid")
 	}
 
-	log.Printf("VerifyDerive successful.")
+	logger().Debug("VerifyDerive successful.")
 
 	return nil
 }
+
+// hiddenIndices returns the indices of the attributes the derived credential does not disclose
+func (cred *DeriveCredential) hiddenIndices() []int64 {
+	disclosed := make(map[int64]bool)
+	for _, index := range cred.GetDiscloseIndices() {
+		disclosed[index] = true
+	}
+	HideIndices := make([]int64, 0)
+	for index := range cred.GetDiscloseMsg() {
+		if !disclosed[int64(index)] {
+			HideIndices = append(HideIndices, int64(index))
+		}
+	}
+	return HideIndices
+}
+
+// sigmaOnepCommitment is the first move of a proof of knowledge of the opening of
+// sigma_onep = g_1^t \cdot \prod_j Y_j^{m_j} over the hidden attributes m_j.
+// A proof about a hidden attribute reuses its randomness rAttrs[j] to bind to the credential.
+type sigmaOnepCommitment struct {
+	rT     *math.Zr
+	rAttrs []*math.Zr
+	T1     *math.G1
+}
+
+func newSigmaOnepCommitment(ipk *IssuerPublicKeyPS, HideIndices []int64, rng io.Reader, curve *math.Curve, tr Translator) (*sigmaOnepCommitment, error) {
+	c := &sigmaOnepCommitment{
+		rT:     curve.NewRandomZr(rng),
+		rAttrs: make([]*math.Zr, len(HideIndices)),
+	}
+	c.T1 = curve.GenG1.Mul(c.rT) // T1 = g_1^{r_t} \cdot \prod_j Y_j^{r_j}, covers sigma_onep
+	for j, index := range HideIndices {
+		c.rAttrs[j] = curve.NewRandomZr(rng)
+		Yj, err := tr.G1FromProto(ipk.Y[index])
+		if err != nil {
+			return nil, err
+		}
+		c.T1.Add(Yj.Mul(c.rAttrs[j]))
+	}
+	return c, nil
+}
+
+// respond computes the s-values s_t = r_t + C \cdot t and s_j = r_j + C \cdot m_j
+func (c *sigmaOnepCommitment) respond(C, t *math.Zr, ipk *IssuerPublicKeyPS, Attrs []string, HideIndices []int64, curve *math.Curve) ([]byte, [][]byte) {
+	sAttrs := make([][]byte, len(HideIndices))
+	for j, index := range HideIndices {
+		mj := attributeZr(ipk, int(index), Attrs[index], curve)
+		sAttrs[j] = curve.ModAdd(c.rAttrs[j], curve.ModMul(C, mj, curve.GroupOrder), curve.GroupOrder).Bytes()
+	}
+	sT := curve.ModAdd(c.rT, curve.ModMul(C, t, curve.GroupOrder), curve.GroupOrder)
+	return sT.Bytes(), sAttrs
+}
+
+// recomputeSigmaOnepT recomputes T1 = g_1^{s_t} \cdot \prod_j Y_j^{s_j} \cdot sigma_onep^{-C}
+func recomputeSigmaOnepT(ipk *IssuerPublicKeyPS, HideIndices []int64, sigmaOnep *math.G1, C *math.Zr, sT []byte, sAttrs [][]byte, curve *math.Curve, tr Translator) (*math.G1, error) {
+	if sT == nil || len(sAttrs) != len(HideIndices) {
+		return nil, errors.Errorf("proof has %d attribute s-values, expected %d", len(sAttrs), len(HideIndices))
+	}
+	T1 := curve.GenG1.Mul(curve.NewZrFromBytes(sT))
+	for j, index := range HideIndices {
+		if int(index) >= len(ipk.GetY()) {
+			return nil, errors.Errorf("hidden attribute index %d out of range", index)
+		}
+		Yj, err := tr.G1FromProto(ipk.Y[index])
+		if err != nil {
+			return nil, err
+		}
+		T1.Add(Yj.Mul(curve.NewZrFromBytes(sAttrs[j])))
+	}
+	T1.Sub(sigmaOnep.Mul(C))
+	return T1, nil
+}
+
+// hiddenPosition returns the position of the attribute index among the hidden indices, or -1 if it is disclosed
+func hiddenPosition(HideIndices []int64, index int) int {
+	for j, hidden := range HideIndices {
+		if int(hidden) == index {
+			return j
+		}
+	}
+	return -1
+}
@@ -0,0 +1,650 @@
+package psidentity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DIDMethodPrefix starts the DIDs of the flex method, did:flex:<hex of the hash of the initial controller key>
+	DIDMethodPrefix = "did:flex:"
+	// DIDContext is the JSON-LD context of DID documents
+	DIDContext = "https://www.w3.org/ns/did/v1"
+
+	// DIDIssuerKeyType is the verification method type of an IssuerPublicKeyPS
+	DIDIssuerKeyType = "PSIssuerPublicKey2022"
+	// DIDUserKeyType is the verification method type of a UserPublicKey
+	DIDUserKeyType = "PSUserPublicKey2022"
+	// DIDControllerKeyType is the verification method type of the ECDSA key that controls the DID document
+	DIDControllerKeyType = "EcdsaSecp384r1VerificationKey2019"
+
+	didIssuerKeyFragment     = "#issuer-key"
+	didUserKeyFragment       = "#user-key"
+	didControllerKeyFragment = "#controller"
+
+	// didIdentifierBytes is the length of the hash of the controller key in a DID
+	didIdentifierBytes = 16
+)
+
+// ErrDIDNotFound is returned by a DIDRegistry for a DID it has no document of
+var ErrDIDNotFound = errors.New("DID not found")
+
+// DIDVerificationMethod is a key published in a DID document, PublicKeyBase64 is the serialized key
+type DIDVerificationMethod struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Controller      string `json:"controller"`
+	Curve           string `json:"curve,omitempty"`
+	PublicKeyBase64 string `json:"publicKeyBase64"`
+}
+
+// DIDDocument is the document a DID resolves to. Version is incremented on every update of the document.
+type DIDDocument struct {
+	Context            []string                 `json:"@context"`
+	ID                 string                   `json:"id"`
+	Controller         string                   `json:"controller"`
+	VerificationMethod []*DIDVerificationMethod `json:"verificationMethod"`
+	AssertionMethod    []string                 `json:"assertionMethod,omitempty"`
+	Authentication     []string                 `json:"authentication,omitempty"`
+	Version            int                      `json:"version"`
+	Updated            string                   `json:"updated"`
+	Deactivated        bool                     `json:"deactivated,omitempty"`
+}
+
+// DIDRecord is a DID document with the signature of its controller key, as stored in a DIDRegistry.
+// InitialController is the controller key the DID is derived from, each rotation authorizes the next controller key.
+type DIDRecord struct {
+	Document          *DIDDocument             `json:"document"`
+	Signature         string                   `json:"signature"`
+	InitialController string                   `json:"initialController"`
+	Rotations         []*DIDControllerRotation `json:"rotations,omitempty"`
+}
+
+// DIDControllerRotation is a controller key of the DID from the document version on, PublicKeyBase64 is the
+// serialized key and Signature the signature of the previous controller key on the rotation
+type DIDControllerRotation struct {
+	Version         int    `json:"version"`
+	PublicKeyBase64 string `json:"publicKeyBase64"`
+	Signature       string `json:"signature"`
+}
+
+// DIDRegistry stores the current DID record of each DID. The lifecycle rules are enforced by
+// CreateDID, UpdateDID and DeactivateDID, a registry only stores the records.
+type DIDRegistry interface {
+	// Read returns the record of the DID, or ErrDIDNotFound
+	Read(did string) (*DIDRecord, error)
+	// Write stores the record of the DID of its document
+	Write(record *DIDRecord) error
+}
+
+// DIDVersionTracker is implemented by registries remembering the highest version of each DID that was resolved,
+// a record rolled back to an earlier version is then rejected
+type DIDVersionTracker interface {
+	// LastVersion returns the highest version of the DID that was resolved, 0 if none was
+	LastVersion(did string) (int, error)
+	// SetLastVersion records the highest version of the DID that was resolved
+	SetLastVersion(did string, version int) error
+}
+
+// FileDIDRegistry is a DIDRegistry storing each record as a JSON file in a local directory
+type FileDIDRegistry struct {
+	Dir string
+}
+
+// NewFileDIDRegistry returns a FileDIDRegistry in dir
+func NewFileDIDRegistry(dir string) *FileDIDRegistry {
+	return &FileDIDRegistry{Dir: dir}
+}
+
+func (r *FileDIDRegistry) path(did, ext string) (string, error) {
+	if !strings.HasPrefix(did, DIDMethodPrefix) {
+		return "", errors.Errorf("%s is not a did:flex DID", did)
+	}
+	id := strings.TrimPrefix(did, DIDMethodPrefix)
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 2*didIdentifierBytes {
+		return "", errors.Errorf("invalid did:flex identifier %s", id)
+	}
+	return filepath.Join(r.Dir, id+ext), nil
+}
+
+// Read returns the record of the DID, or ErrDIDNotFound
+func (r *FileDIDRegistry) Read(did string) (*DIDRecord, error) {
+	path, err := r.path(did, ".json")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrDIDNotFound, did)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read DID record %s", path)
+	}
+	record := &DIDRecord{}
+	if err := json.Unmarshal(raw, record); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse DID record %s", path)
+	}
+	return record, nil
+}
+
+// Write stores the record of the DID of its document, its version must be higher than the one of the stored record
+func (r *FileDIDRegistry) Write(record *DIDRecord) error {
+	path, err := r.path(record.Document.ID, ".json")
+	if err != nil {
+		return err
+	}
+	current, err := r.Read(record.Document.ID)
+	if err == nil && current.Document != nil && record.Document.Version <= current.Document.Version {
+		return errors.Errorf("%s version %d is not higher than the stored version %d", record.Document.ID, record.Document.Version, current.Document.Version)
+	} else if err != nil && errors.Cause(err) != ErrDIDNotFound {
+		return err
+	}
+	raw, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal DID record")
+	}
+	if err := os.MkdirAll(r.Dir, 0770); err != nil {
+		return errors.Wrapf(err, "failed to create DID registry %s", r.Dir)
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, raw, 0640), "failed to write DID record %s", path)
+}
+
+// LastVersion returns the highest version of the DID that was resolved, 0 if none was
+func (r *FileDIDRegistry) LastVersion(did string) (int, error) {
+	path, err := r.path(did, ".version")
+	if err != nil {
+		return 0, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read DID version %s", path)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	return version, errors.Wrapf(err, "invalid DID version %s", path)
+}
+
+// SetLastVersion records the highest version of the DID that was resolved
+func (r *FileDIDRegistry) SetLastVersion(did string, version int) error {
+	path, err := r.path(did, ".version")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.Dir, 0770); err != nil {
+		return errors.Wrapf(err, "failed to create DID registry %s", r.Dir)
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, []byte(strconv.Itoa(version)), 0640), "failed to write DID version %s", path)
+}
+
+// GenerateDIDControllerKey generates the key controlling a DID document
+func GenerateDIDControllerKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+}
+
+// NewDIDDocument returns an empty document of the DID of the controller key
+func NewDIDDocument(controller *ecdsa.PublicKey) (*DIDDocument, error) {
+	pkBytes, err := x509.MarshalPKIXPublicKey(controller)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal DID controller key")
+	}
+	did := didOfControllerKey(pkBytes)
+
+	return &DIDDocument{
+		Context:    []string{DIDContext},
+		ID:         did,
+		Controller: did,
+		VerificationMethod: []*DIDVerificationMethod{{
+			ID:              did + didControllerKeyFragment,
+			Type:            DIDControllerKeyType,
+			Controller:      did,
+			PublicKeyBase64: base64.StdEncoding.EncodeToString(pkBytes),
+		}},
+	}, nil
+}
+
+// didOfControllerKey returns the DID derived from the serialized initial controller key
+func didOfControllerKey(pkBytes []byte) string {
+	digest := sha256.Sum256(pkBytes)
+	return DIDMethodPrefix + hex.EncodeToString(digest[:didIdentifierBytes])
+}
+
+// addKey publishes a key as verification method of the document, replacing the key of the same fragment
+func (doc *DIDDocument) addKey(fragment, keyType, curveID string, key proto.Message) error {
+	keyBytes, err := proto.Marshal(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", keyType)
+	}
+	vm := &DIDVerificationMethod{
+		ID:              doc.ID + fragment,
+		Type:            keyType,
+		Controller:      doc.ID,
+		Curve:           curveID,
+		PublicKeyBase64: base64.StdEncoding.EncodeToString(keyBytes),
+	}
+	for j, existing := range doc.VerificationMethod {
+		if existing.ID == vm.ID {
+			doc.VerificationMethod[j] = vm
+			return nil
+		}
+	}
+	doc.VerificationMethod = append(doc.VerificationMethod, vm)
+	return nil
+}
+
+// AddIssuerKey publishes the issuer public key as assertion method, the key credentials are verified with
+func (doc *DIDDocument) AddIssuerKey(ipk *IssuerPublicKeyPS) error {
+	if err := doc.addKey(didIssuerKeyFragment, DIDIssuerKeyType, ipk.GetCurveId(), ipk); err != nil {
+		return err
+	}
+	if !containsString(doc.AssertionMethod, doc.ID+didIssuerKeyFragment) {
+		doc.AssertionMethod = append(doc.AssertionMethod, doc.ID+didIssuerKeyFragment)
+	}
+	return nil
+}
+
+// AddUserKey publishes the user public key as authentication method, the key presentations are verified with
+func (doc *DIDDocument) AddUserKey(upk *UserPublicKey) error {
+	if err := doc.addKey(didUserKeyFragment, DIDUserKeyType, upk.GetCurveId(), upk); err != nil {
+		return err
+	}
+	if !containsString(doc.Authentication, doc.ID+didUserKeyFragment) {
+		doc.Authentication = append(doc.Authentication, doc.ID+didUserKeyFragment)
+	}
+	return nil
+}
+
+// verificationMethod returns the verification method of the fragment and type
+func (doc *DIDDocument) verificationMethod(fragment, keyType string) (*DIDVerificationMethod, []byte, error) {
+	for _, vm := range doc.VerificationMethod {
+		if vm.ID == doc.ID+fragment {
+			if vm.Type != keyType {
+				return nil, nil, errors.Errorf("verification method %s is a %s, not a %s", vm.ID, vm.Type, keyType)
+			}
+			keyBytes, err := base64.StdEncoding.DecodeString(vm.PublicKeyBase64)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid key of verification method %s", vm.ID)
+			}
+			return vm, keyBytes, nil
+		}
+	}
+	return nil, nil, errors.Errorf("%s has no %s", doc.ID, keyType)
+}
+
+// IssuerPublicKey returns the issuer public key published in the document
+func (doc *DIDDocument) IssuerPublicKey() (*IssuerPublicKeyPS, error) {
+	_, keyBytes, err := doc.verificationMethod(didIssuerKeyFragment, DIDIssuerKeyType)
+	if err != nil {
+		return nil, err
+	}
+	ipk := &IssuerPublicKeyPS{}
+	if err := proto.Unmarshal(keyBytes, ipk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	return ipk, nil
+}
+
+// UserPublicKey returns the user public key published in the document
+func (doc *DIDDocument) UserPublicKey() (*UserPublicKey, error) {
+	_, keyBytes, err := doc.verificationMethod(didUserKeyFragment, DIDUserKeyType)
+	if err != nil {
+		return nil, err
+	}
+	upk := &UserPublicKey{}
+	if err := proto.Unmarshal(keyBytes, upk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user public key")
+	}
+	return upk, nil
+}
+
+// controllerKey returns the key controlling the document
+func (doc *DIDDocument) controllerKey() (*ecdsa.PublicKey, error) {
+	_, keyBytes, err := doc.verificationMethod(didControllerKeyFragment, DIDControllerKeyType)
+	if err != nil {
+		return nil, err
+	}
+	return parseDIDControllerKey(keyBytes)
+}
+
+// parseDIDControllerKey parses a serialized DID controller key
+func parseDIDControllerKey(keyBytes []byte) (*ecdsa.PublicKey, error) {
+	pk, err := x509.ParsePKIXPublicKey(keyBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse DID controller key")
+	}
+	ecPk, ok := pk.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("DID controller key is not an ECDSA key")
+	}
+	return ecPk, nil
+}
+
+// digest is the hash of the document the controller signs
+func (doc *DIDDocument) digest() ([]byte, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal DID document")
+	}
+	digest := sha256.Sum256(docBytes)
+	return digest[:], nil
+}
+
+// signDIDDocument signs the document with the controller key. The controller history is taken from the current
+// record, without one the controller key is the initial controller key.
+func signDIDDocument(doc *DIDDocument, current *DIDRecord, key *ecdsa.PrivateKey) (*DIDRecord, error) {
+	digest, err := doc.digest()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := key.Sign(rand.Reader, digest, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign DID document")
+	}
+	record := &DIDRecord{Document: doc, Signature: base64.StdEncoding.EncodeToString(sig)}
+	if current == nil {
+		pkBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal DID controller key")
+		}
+		record.InitialController = base64.StdEncoding.EncodeToString(pkBytes)
+	} else {
+		record.InitialController, record.Rotations = current.InitialController, current.Rotations
+	}
+	return record, nil
+}
+
+// digest is the hash of the rotation of the controller key of the DID the previous controller key signs
+func (rotation *DIDControllerRotation) digest(did string) []byte {
+	digest := sha256.Sum256([]byte(did + "\n" + strconv.Itoa(rotation.Version) + "\n" + rotation.PublicKeyBase64))
+	return digest[:]
+}
+
+// controller returns the current controller key of the record after checking that the DID is derived from the
+// initial controller key and that each rotation is signed by the previous controller key
+func (record *DIDRecord) controller() (*ecdsa.PublicKey, error) {
+	if record.Document == nil {
+		return nil, errors.Errorf("DID record has no document")
+	}
+	did := record.Document.ID
+	keyBytes, err := base64.StdEncoding.DecodeString(record.InitialController)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid initial DID controller key")
+	}
+	if didOfControllerKey(keyBytes) != did {
+		return nil, errors.Errorf("%s is not the DID of its initial controller key", did)
+	}
+	pk, err := parseDIDControllerKey(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	version := 1
+	for _, rotation := range record.Rotations {
+		if rotation.Version <= version || rotation.Version > record.Document.Version {
+			return nil, errors.Errorf("invalid version %d of a controller key rotation of %s", rotation.Version, did)
+		}
+		sig, err := base64.StdEncoding.DecodeString(rotation.Signature)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid DID controller key rotation signature")
+		}
+		if !ecdsa.VerifyASN1(pk, rotation.digest(did), sig) {
+			return nil, errors.Errorf("controller key rotation of %s at version %d is not signed by the previous controller key", did, rotation.Version)
+		}
+		if keyBytes, err = base64.StdEncoding.DecodeString(rotation.PublicKeyBase64); err != nil {
+			return nil, errors.Wrap(err, "invalid DID controller key")
+		}
+		if pk, err = parseDIDControllerKey(keyBytes); err != nil {
+			return nil, err
+		}
+		version = rotation.Version
+	}
+
+	_, docKeyBytes, err := record.Document.verificationMethod(didControllerKeyFragment, DIDControllerKeyType)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(keyBytes, docKeyBytes) {
+		return nil, errors.Errorf("controller key of %s is not the last authorized controller key", did)
+	}
+	return pk, nil
+}
+
+// verify checks the signature of the record with the controller key pk
+func (record *DIDRecord) verify(pk *ecdsa.PublicKey) error {
+	if record.Document == nil {
+		return errors.Errorf("DID record has no document")
+	}
+	digest, err := record.Document.digest()
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(record.Signature)
+	if err != nil {
+		return errors.Wrap(err, "invalid DID record signature")
+	}
+	if !ecdsa.VerifyASN1(pk, digest, sig) {
+		return errors.Errorf("DID document %s is not signed by its controller", record.Document.ID)
+	}
+	return nil
+}
+
+// CreateDID registers the first version of the document, signed with the controller key the DID is derived from
+func CreateDID(registry DIDRegistry, doc *DIDDocument, key *ecdsa.PrivateKey) error {
+	expected, err := NewDIDDocument(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	if doc.ID != expected.ID {
+		return errors.Errorf("%s is not the DID of the controller key", doc.ID)
+	}
+	if _, err := registry.Read(doc.ID); err == nil {
+		return errors.Errorf("%s already exists", doc.ID)
+	} else if errors.Cause(err) != ErrDIDNotFound {
+		return err
+	}
+
+	doc.Version = 1
+	doc.Updated = time.Now().UTC().Format(time.RFC3339)
+	doc.Deactivated = false
+	record, err := signDIDDocument(doc, nil, key)
+	if err != nil {
+		return err
+	}
+	return registry.Write(record)
+}
+
+// UpdateDID registers a new version of the document, signed with the controller key.
+// The controller key is replaced with RotateDIDController.
+func UpdateDID(registry DIDRegistry, doc *DIDDocument, key *ecdsa.PrivateKey) error {
+	current, err := resolveDIDRecord(registry, doc.ID)
+	if err != nil {
+		return err
+	}
+	if current.Document.Deactivated {
+		return errors.Errorf("%s is deactivated", doc.ID)
+	}
+	if err := checkController(current.Document, key); err != nil {
+		return err
+	}
+	if err := checkController(doc, key); err != nil {
+		return errors.WithMessage(err, "the controller key is replaced with RotateDIDController")
+	}
+
+	doc.Version = current.Document.Version + 1
+	doc.Updated = time.Now().UTC().Format(time.RFC3339)
+	doc.Deactivated = false
+	record, err := signDIDDocument(doc, current, key)
+	if err != nil {
+		return err
+	}
+	return registry.Write(record)
+}
+
+// RotateDIDController replaces the controller key of the document, the current controller key authorizes the new one.
+// The DID stays the one derived from the initial controller key.
+func RotateDIDController(registry DIDRegistry, did string, key, newKey *ecdsa.PrivateKey) error {
+	current, err := resolveDIDRecord(registry, did)
+	if err != nil {
+		return err
+	}
+	if current.Document.Deactivated {
+		return errors.Errorf("%s is deactivated", did)
+	}
+	if err := checkController(current.Document, key); err != nil {
+		return err
+	}
+
+	pkBytes, err := x509.MarshalPKIXPublicKey(&newKey.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal DID controller key")
+	}
+	doc := current.Document
+	vm, _, err := doc.verificationMethod(didControllerKeyFragment, DIDControllerKeyType)
+	if err != nil {
+		return err
+	}
+	vm.PublicKeyBase64 = base64.StdEncoding.EncodeToString(pkBytes)
+	doc.Version++
+	doc.Updated = time.Now().UTC().Format(time.RFC3339)
+	rotation := &DIDControllerRotation{Version: doc.Version, PublicKeyBase64: vm.PublicKeyBase64}
+	sig, err := key.Sign(rand.Reader, rotation.digest(did), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign DID controller key rotation")
+	}
+	rotation.Signature = base64.StdEncoding.EncodeToString(sig)
+	record, err := signDIDDocument(doc, current, newKey)
+	if err != nil {
+		return err
+	}
+	record.Rotations = append(append([]*DIDControllerRotation{}, current.Rotations...), rotation)
+	return registry.Write(record)
+}
+
+// DeactivateDID deactivates the DID, its keys are no longer resolved
+func DeactivateDID(registry DIDRegistry, did string, key *ecdsa.PrivateKey) error {
+	current, err := resolveDIDRecord(registry, did)
+	if err != nil {
+		return err
+	}
+	if err := checkController(current.Document, key); err != nil {
+		return err
+	}
+
+	doc := current.Document
+	doc.Deactivated = true
+	doc.Version++
+	doc.Updated = time.Now().UTC().Format(time.RFC3339)
+	record, err := signDIDDocument(doc, current, key)
+	if err != nil {
+		return err
+	}
+	return registry.Write(record)
+}
+
+// checkController checks that key is the controller key of the document
+func checkController(doc *DIDDocument, key *ecdsa.PrivateKey) error {
+	pk, err := doc.controllerKey()
+	if err != nil {
+		return err
+	}
+	if !pk.Equal(&key.PublicKey) {
+		return errors.Errorf("key is not the controller key of %s", doc.ID)
+	}
+	return nil
+}
+
+// resolveDIDRecord reads the record of the DID and verifies it is signed by its controller key, which is the key
+// the DID is derived from or a key it was rotated to. If the registry is a DIDVersionTracker, a record of a version
+// lower than the last one resolved is rejected.
+func resolveDIDRecord(registry DIDRegistry, did string) (*DIDRecord, error) {
+	record, err := registry.Read(did)
+	if err != nil {
+		return nil, err
+	}
+	if record.Document == nil || record.Document.ID != did {
+		return nil, errors.Errorf("registry returned another document for %s", did)
+	}
+	pk, err := record.controller()
+	if err != nil {
+		return nil, err
+	}
+	if err := record.verify(pk); err != nil {
+		return nil, err
+	}
+
+	if tracker, ok := registry.(DIDVersionTracker); ok {
+		last, err := tracker.LastVersion(did)
+		if err != nil {
+			return nil, err
+		}
+		if record.Document.Version < last {
+			return nil, errors.Errorf("%s was rolled back to version %d, version %d was resolved before", did, record.Document.Version, last)
+		}
+		if record.Document.Version > last {
+			if err := tracker.SetLastVersion(did, record.Document.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return record, nil
+}
+
+// ResolveDID returns the current document of the DID, also if it is deactivated
+func ResolveDID(registry DIDRegistry, did string) (*DIDDocument, error) {
+	record, err := resolveDIDRecord(registry, did)
+	if err != nil {
+		return nil, err
+	}
+	return record.Document, nil
+}
+
+// ResolveIssuerKey returns the issuer public key of an active DID
+func ResolveIssuerKey(registry DIDRegistry, did string) (*IssuerPublicKeyPS, error) {
+	doc, err := ResolveDID(registry, did)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Deactivated {
+		return nil, errors.Errorf("%s is deactivated", did)
+	}
+	return doc.IssuerPublicKey()
+}
+
+// ResolveUserKey returns the user public key of an active DID
+func ResolveUserKey(registry DIDRegistry, did string) (*UserPublicKey, error) {
+	doc, err := ResolveDID(registry, did)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Deactivated {
+		return nil, errors.Errorf("%s is deactivated", did)
+	}
+	return doc.UserPublicKey()
+}
+
+// DIDControllerKeyToBytes serializes a DID controller key
+func DIDControllerKeyToBytes(key *ecdsa.PrivateKey) ([]byte, error) {
+	return x509.MarshalECPrivateKey(key)
+}
+
+// DIDControllerKeyFromBytes parses a DID controller key
+func DIDControllerKeyFromBytes(raw []byte) (*ecdsa.PrivateKey, error) {
+	key, err := x509.ParseECPrivateKey(raw)
+	return key, errors.Wrap(err, "failed to parse DID controller key")
+}
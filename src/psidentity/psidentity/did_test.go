@@ -0,0 +1,121 @@
+package psidentity
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDIDLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "did-registry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	registry := NewFileDIDRegistry(dir)
+
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	_, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	ipk := &IssuerPublicKeyPS{}
+	require.NoError(t, proto.Unmarshal(ipkBytes, ipk))
+	_, upkBytes, err := GenerateUserKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	upk := &UserPublicKey{}
+	require.NoError(t, proto.Unmarshal(upkBytes, upk))
+
+	key, err := GenerateDIDControllerKey()
+	require.NoError(t, err)
+	doc, err := NewDIDDocument(&key.PublicKey)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(doc.ID, DIDMethodPrefix))
+	require.NoError(t, doc.AddIssuerKey(ipk))
+	require.NoError(t, CreateDID(registry, doc, key))
+	require.Error(t, CreateDID(registry, doc, key))
+
+	resolved, err := ResolveIssuerKey(registry, doc.ID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(ipk, resolved))
+	path := filepath.Join(dir, strings.TrimPrefix(doc.ID, DIDMethodPrefix)+".json")
+	first, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	_, err = ResolveUserKey(registry, doc.ID)
+	require.Error(t, err)
+
+	// only the controller updates the document
+	other, err := GenerateDIDControllerKey()
+	require.NoError(t, err)
+	require.NoError(t, doc.AddUserKey(upk))
+	require.Error(t, UpdateDID(registry, doc, other))
+	require.NoError(t, UpdateDID(registry, doc, key))
+	resolvedDoc, err := ResolveDID(registry, doc.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, resolvedDoc.Version)
+	resolvedUpk, err := ResolveUserKey(registry, doc.ID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(upk, resolvedUpk))
+
+	// a record rolled back to an earlier version is neither written nor resolved
+	second, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	record := &DIDRecord{}
+	require.NoError(t, json.Unmarshal(first, record))
+	require.Error(t, registry.Write(record))
+	require.NoError(t, ioutil.WriteFile(path, first, 0640))
+	_, err = ResolveDID(registry, doc.ID)
+	require.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(path, second, 0640))
+
+	// after a rotation the new controller key controls the document
+	require.NoError(t, RotateDIDController(registry, doc.ID, key, other))
+	require.Error(t, DeactivateDID(registry, doc.ID, key))
+	require.NoError(t, DeactivateDID(registry, doc.ID, other))
+	_, err = ResolveIssuerKey(registry, doc.ID)
+	require.Error(t, err)
+	resolvedDoc, err = ResolveDID(registry, doc.ID)
+	require.NoError(t, err)
+	require.True(t, resolvedDoc.Deactivated)
+
+	// a tampered record does not resolve
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte(strings.Replace(string(raw), `"deactivated": true`, `"deactivated": false`, 1)), 0640))
+	_, err = ResolveDID(registry, doc.ID)
+	require.Error(t, err)
+
+	_, err = ResolveDID(registry, "did:flex:unknown")
+	require.Error(t, err)
+
+	// a record signed by a key the DID is not derived from, or was not rotated to, does not resolve
+	attacker, err := GenerateDIDControllerKey()
+	require.NoError(t, err)
+	for name, forge := range map[string]func(*DIDRecord){
+		"initial controller": func(r *DIDRecord) { r.Rotations = nil },
+		"rotation": func(r *DIDRecord) {
+			r.InitialController = record.InitialController
+			r.Rotations = []*DIDControllerRotation{{Version: 2, PublicKeyBase64: r.Document.VerificationMethod[0].PublicKeyBase64, Signature: r.Signature}}
+		},
+	} {
+		forged, err := NewDIDDocument(&attacker.PublicKey)
+		require.NoError(t, err)
+		forged.ID = doc.ID
+		forged.VerificationMethod[0].ID = doc.ID + didControllerKeyFragment
+		require.NoError(t, forged.AddIssuerKey(ipk))
+		forged.Version = 10
+		forgedRecord, err := signDIDDocument(forged, nil, attacker)
+		require.NoError(t, err)
+		forge(forgedRecord)
+		forgedDir, err := ioutil.TempDir("", "did-registry")
+		require.NoError(t, err)
+		defer os.RemoveAll(forgedDir)
+		forgedRegistry := NewFileDIDRegistry(forgedDir)
+		require.NoError(t, forgedRegistry.Write(forgedRecord))
+		_, err = ResolveDID(forgedRegistry, doc.ID)
+		require.Error(t, err, name)
+	}
+}
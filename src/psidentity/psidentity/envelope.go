@@ -0,0 +1,300 @@
+package psidentity
+
+import (
+	"bytes"
+	"io"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+// EnvelopeSchemaVersion is the version of the envelope format written by WrapArtifact.
+// Legacy artifacts, bare protobuf without an envelope, are reported with schema version 0.
+const EnvelopeSchemaVersion = 1
+
+// envelopeMagic starts every artifact in the envelope format
+var envelopeMagic = []byte("PSID")
+
+// artifactTypes maps the artifact types to their payload, curveDependent tells whether the payload
+// is created on a curve, issuerBound whether it belongs to an issuer key and secret whether it is sealed at rest
+var artifactTypes = map[string]struct {
+	payload        func() proto.Message
+	curveDependent bool
+	issuerBound    bool
+	secret         bool
+}{
+	psidentity.PsIdentityConfigIssuerPublicKey:       {func() proto.Message { return &IssuerPublicKeyPS{} }, true, true, false},
+	psidentity.PsIdentityConfigIssuerSecretKey:       {func() proto.Message { return &IssuerPrivateKeyPS{} }, true, true, true},
+	psidentity.PsIdentityConfigRevocationKey:         {func() proto.Message { return &RsaKey{} }, false, false, true},
+	psidentity.PsIdentityConfigRevocationTrapdoor:    {nil, false, false, false},
+	psidentity.PsIdentityConfigIssuerKeyRotation:     {func() proto.Message { return &IssuerKeyRotation{} }, true, false, false},
+	psidentity.PsIdentityConfigUserSecretKey:         {func() proto.Message { return &UserPrivateKey{} }, true, false, true},
+	psidentity.PsIdentityConfigUserPublicKey:         {func() proto.Message { return &UserPublicKey{} }, true, false, false},
+	psidentity.PsIdentityConfigPrimaryCred:           {func() proto.Message { return &user.UserPrimaryCred{} }, true, true, false},
+	psidentity.PsIdentityConfigDeriveCred:            {func() proto.Message { return &user.UserDeriveCred{} }, true, true, false},
+	psidentity.PsIdentityConfigAggregateCred:         {func() proto.Message { return &user.UserAggregateCred{} }, true, true, false},
+	psidentity.PsIdentityConfigWitness:               {func() proto.Message { return &PairingAccumulatorWitness{} }, true, false, false},
+	psidentity.PsIdentityConfigRevocationState:       {func() proto.Message { return &RevocationState{} }, true, false, false},
+	psidentity.PsIdentityConfigCRI:                   {func() proto.Message { return &CredentialRevocationInformation{} }, true, false, false},
+	psidentity.PsIdentityConfigLongTermRevocationKey: {nil, false, false, true},
+	psidentity.PsIdentityConfigPairingAccumulatorKey: {func() proto.Message { return &PairingAccumulatorKey{} }, true, false, true},
+	psidentity.PsIdentityConfigPairingAccumulator:    {func() proto.Message { return &PairingAccumulator{} }, true, false, false},
+	psidentity.PsIdentityConfigIssuerDIDKey:          {nil, false, false, true},
+	psidentity.PsIdentityConfigUserDIDKey:            {nil, false, false, true},
+}
+
+// IsEnvelope reports whether raw is an artifact in the envelope format
+func IsEnvelope(raw []byte) bool {
+	return bytes.HasPrefix(raw, envelopeMagic)
+}
+
+// IsSecretArtifact reports whether artifacts of the type hold secret key material, which is sealed at rest
+func IsSecretArtifact(artifactType string) bool {
+	return artifactTypes[artifactType].secret
+}
+
+// IssuerKeyHash returns the hash of a serialized issuer public key, which binds artifacts to the issuer key
+func IssuerKeyHash(ipkBytes []byte) ([]byte, error) {
+	ipk := &IssuerPublicKeyPS{}
+	if err := proto.Unmarshal(ipkBytes, ipk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	return ipk.GetHash(), nil
+}
+
+// WrapArtifact wraps the payload of an artifact in an envelope, see wrapArtifact.
+// The points of the payload are recorded to be compressed if the Translator of i compresses them.
+func (i *Psidentity) WrapArtifact(artifactType string, issuerKeyHash, payload []byte) ([]byte, error) {
+	return wrapArtifact(artifactType, CurveName(i.Curve), IsCompressedTranslator(i.Translator), issuerKeyHash, payload)
+}
+
+// wrapArtifact wraps the payload of an artifact in an envelope.
+// The curve is only recorded for curve dependent artifacts and the issuer key hash for artifacts of an issuer;
+// the issuer key hash of an issuer public key is its own hash.
+func wrapArtifact(artifactType, curveID string, compressed bool, issuerKeyHash, payload []byte) ([]byte, error) {
+	env, err := newEnvelope(artifactType, curveID, compressed, issuerKeyHash, payload)
+	if err != nil {
+		return nil, err
+	}
+	return marshalEnvelope(env)
+}
+
+// newEnvelope returns the envelope of an artifact, see wrapArtifact
+func newEnvelope(artifactType, curveID string, compressed bool, issuerKeyHash, payload []byte) (*Envelope, error) {
+	t, ok := artifactTypes[artifactType]
+	if !ok {
+		return nil, errors.Errorf("unknown artifact type %s", artifactType)
+	}
+	env := &Envelope{
+		Type:          artifactType,
+		SchemaVersion: EnvelopeSchemaVersion,
+		Payload:       payload,
+	}
+	if t.curveDependent {
+		env.CurveId = curveID
+		env.CompressedPoints = compressed
+	}
+	if artifactType == psidentity.PsIdentityConfigIssuerPublicKey {
+		hash, err := IssuerKeyHash(payload)
+		if err != nil {
+			return nil, err
+		}
+		issuerKeyHash = hash
+	}
+	if t.issuerBound {
+		env.IssuerKeyHash = issuerKeyHash
+	}
+	return env, nil
+}
+
+// marshalEnvelope serializes an envelope after the envelope magic
+func marshalEnvelope(env *Envelope) ([]byte, error) {
+	envBytes, err := proto.Marshal(env)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal envelope")
+	}
+	return append(append([]byte{}, envelopeMagic...), envBytes...), nil
+}
+
+// WrapSealedArtifact wraps the payload of a secret artifact in an envelope, with the payload sealed under
+// the passphrase by a key derived with argon2id. The envelope fields are not encrypted.
+func (i *Psidentity) WrapSealedArtifact(artifactType string, issuerKeyHash, payload, passphrase []byte, rng io.Reader) ([]byte, error) {
+	env, err := newEnvelope(artifactType, CurveName(i.Curve), IsCompressedTranslator(i.Translator), issuerKeyHash, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.seal(passphrase, rng); err != nil {
+		return nil, err
+	}
+	return marshalEnvelope(env)
+}
+
+// seal replaces the payload of the envelope by the payload sealed under the passphrase,
+// authenticated together with the envelope header
+func (env *Envelope) seal(passphrase []byte, rng io.Reader) error {
+	if !IsSecretArtifact(env.GetType()) {
+		return errors.Errorf("%s is not a secret artifact", env.GetType())
+	}
+	env.Sealed = true
+	header, err := env.header()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealKey(env.GetPayload(), passphrase, header, SealKDFArgon2id, rng)
+	if err != nil {
+		return err
+	}
+	sealedBytes, err := proto.Marshal(sealed)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal sealed key")
+	}
+	env.Payload = sealedBytes
+	return nil
+}
+
+// header serializes the fields of the envelope other than the payload, so that a sealed payload
+// cannot be opened under another type, schema version, curve or issuer key
+func (env *Envelope) header() ([]byte, error) {
+	header := proto.Clone(env).(*Envelope)
+	header.Payload = nil
+	headerBytes, err := proto.Marshal(header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal envelope header")
+	}
+	return append(append([]byte{}, envelopeMagic...), headerBytes...), nil
+}
+
+// OpenPayload returns the payload of the envelope, opening it with the passphrase if it is sealed
+func (env *Envelope) OpenPayload(passphrase []byte) ([]byte, error) {
+	if !env.GetSealed() {
+		return env.GetPayload(), nil
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.Errorf("%s is sealed and requires a passphrase", env.GetType())
+	}
+	sealed := &SealedKey{}
+	if err := proto.Unmarshal(env.GetPayload(), sealed); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal sealed %s", env.GetType())
+	}
+	header, err := env.header()
+	if err != nil {
+		return nil, err
+	}
+	return sealed.open(passphrase, header, env.GetType())
+}
+
+// ResealArtifact opens a secret artifact with passphrase and seals it again under newPassphrase, an empty
+// newPassphrase stores the payload in plaintext. Plaintext and legacy artifacts are opened without a passphrase,
+// legacy artifacts are converted to the envelope format with issuerKeyHash recorded for artifacts of an issuer.
+func ResealArtifact(raw []byte, artifactType string, issuerKeyHash, passphrase, newPassphrase []byte, rng io.Reader) ([]byte, error) {
+	env, err := openEnvelope(raw, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if env.GetType() != artifactType {
+		return nil, errors.Errorf("artifact is a %s, not a %s", env.GetType(), artifactType)
+	}
+	payload, err := env.OpenPayload(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.GetIssuerKeyHash()) == 0 {
+		env.IssuerKeyHash = issuerKeyHash
+	}
+
+	resealed, err := newEnvelope(artifactType, env.GetCurveId(), env.GetCompressedPoints(), env.GetIssuerKeyHash(), payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(newPassphrase) != 0 {
+		if err := resealed.seal(newPassphrase, rng); err != nil {
+			return nil, err
+		}
+	}
+	return marshalEnvelope(resealed)
+}
+
+// UnwrapArtifact opens the envelope of an artifact, see unwrapArtifact
+func (i *Psidentity) UnwrapArtifact(raw []byte, artifactType string, issuerKeyHash []byte) (*Envelope, error) {
+	return unwrapArtifact(raw, artifactType, issuerKeyHash, i.Curve)
+}
+
+// unwrapArtifact opens the envelope of an artifact and checks that it has the expected type, a known
+// schema version, was created on the curve and, if issuerKeyHash is not nil, belongs to the issuer key.
+// A legacy artifact is returned in an envelope of schema version 0, with the curve recorded in its payload.
+func unwrapArtifact(raw []byte, artifactType string, issuerKeyHash []byte, curve *math.Curve) (*Envelope, error) {
+	env, err := openEnvelope(raw, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if env.GetType() != artifactType {
+		return nil, errors.Errorf("artifact is a %s, not a %s", env.GetType(), artifactType)
+	}
+	if env.GetSchemaVersion() > EnvelopeSchemaVersion {
+		return nil, errors.Errorf("artifact schema version %d is newer than the supported version %d", env.GetSchemaVersion(), EnvelopeSchemaVersion)
+	}
+	if artifactTypes[artifactType].curveDependent {
+		if err := CheckCurveID(CurveName(curve), env.GetCurveId()); err != nil {
+			return nil, err
+		}
+	}
+	if len(issuerKeyHash) != 0 && len(env.GetIssuerKeyHash()) != 0 && !bytes.Equal(issuerKeyHash, env.GetIssuerKeyHash()) {
+		return nil, errors.Errorf("artifact belongs to another issuer key")
+	}
+	return env, nil
+}
+
+// openEnvelope parses an artifact, legacy artifacts are put in an envelope of schema version 0
+func openEnvelope(raw []byte, artifactType string) (*Envelope, error) {
+	if IsEnvelope(raw) {
+		env := &Envelope{}
+		if err := proto.Unmarshal(raw[len(envelopeMagic):], env); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal envelope")
+		}
+		return env, nil
+	}
+
+	t, ok := artifactTypes[artifactType]
+	if !ok {
+		return nil, errors.Errorf("unknown artifact type %s", artifactType)
+	}
+	env := &Envelope{Type: artifactType, Payload: raw}
+	if t.curveDependent {
+		msg := t.payload()
+		if err := proto.Unmarshal(raw, msg); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal legacy %s", artifactType)
+		}
+		// legacy artifacts without a curve ID were created on FP256BN_AMCL
+		env.CurveId = CurveFP256BN_AMCL
+		if c, ok := msg.(interface{ GetCurveId() string }); ok && c.GetCurveId() != "" {
+			env.CurveId = c.GetCurveId()
+		}
+	}
+	if artifactType == psidentity.PsIdentityConfigIssuerPublicKey {
+		hash, err := IssuerKeyHash(raw)
+		if err != nil {
+			return nil, err
+		}
+		env.IssuerKeyHash = hash
+	}
+	return env, nil
+}
+
+// MigrateArtifact converts a legacy artifact to the envelope format, with the curve recorded in its payload.
+// issuerKeyHash is recorded for artifacts of an issuer. Artifacts in the envelope format are returned unchanged.
+func MigrateArtifact(raw []byte, artifactType string, issuerKeyHash []byte) ([]byte, error) {
+	if IsEnvelope(raw) {
+		return raw, nil
+	}
+	env, err := openEnvelope(raw, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.GetIssuerKeyHash()) == 0 {
+		env.IssuerKeyHash = issuerKeyHash
+	}
+	return wrapArtifact(artifactType, env.GetCurveId(), false, env.GetIssuerKeyHash(), env.GetPayload())
+}
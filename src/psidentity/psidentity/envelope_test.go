@@ -0,0 +1,165 @@
+package psidentity
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestEnvelope(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	bls, err := NewPsidentityForCurve(CurveBLS12_381)
+	require.NoError(t, err)
+
+	_, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	ipkHash, err := IssuerKeyHash(ipkBytes)
+	require.NoError(t, err)
+	require.NotEmpty(t, ipkHash)
+
+	raw, err := psid.WrapArtifact(psidentity.PsIdentityConfigIssuerPublicKey, nil, ipkBytes)
+	require.NoError(t, err)
+	require.True(t, IsEnvelope(raw))
+
+	env, err := psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerPublicKey, ipkHash)
+	require.NoError(t, err)
+	require.Equal(t, uint32(EnvelopeSchemaVersion), env.SchemaVersion)
+	require.Equal(t, CurveFP256BN_AMCL, env.CurveId)
+	require.Equal(t, ipkHash, env.IssuerKeyHash)
+	require.Equal(t, ipkBytes, env.Payload)
+
+	// type, curve and issuer key mismatches are detected
+	_, err = psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigUserPublicKey, nil)
+	require.Error(t, err)
+	_, err = bls.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	require.Error(t, err)
+	_, err = psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerPublicKey, []byte("another issuer"))
+	require.Error(t, err)
+
+	// newer schema versions are refused
+	newer := &Envelope{Type: psidentity.PsIdentityConfigIssuerPublicKey, SchemaVersion: EnvelopeSchemaVersion + 1, Payload: ipkBytes}
+	newerBytes, err := proto.Marshal(newer)
+	require.NoError(t, err)
+	_, err = psid.UnwrapArtifact(append([]byte("PSID"), newerBytes...), psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	require.Error(t, err)
+
+	// curve independent artifacts are accepted on any curve
+	rkRaw, err := psid.WrapArtifact(psidentity.PsIdentityConfigRevocationKey, ipkHash, []byte{})
+	require.NoError(t, err)
+	env, err = bls.UnwrapArtifact(rkRaw, psidentity.PsIdentityConfigRevocationKey, ipkHash)
+	require.NoError(t, err)
+	require.Empty(t, env.CurveId)
+	require.Empty(t, env.IssuerKeyHash)
+
+	_, err = psid.WrapArtifact("Unknown", nil, nil)
+	require.Error(t, err)
+}
+
+func TestMigrateArtifact(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+
+	// a legacy issuer public key without a curve ID
+	_, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	ipk := &IssuerPublicKeyPS{}
+	require.NoError(t, proto.Unmarshal(ipkBytes, ipk))
+	ipk.CurveId = ""
+	legacy, err := proto.Marshal(ipk)
+	require.NoError(t, err)
+
+	env, err := psid.UnwrapArtifact(legacy, psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), env.SchemaVersion)
+	require.Equal(t, CurveFP256BN_AMCL, env.CurveId)
+
+	migrated, err := MigrateArtifact(legacy, psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	require.NoError(t, err)
+	require.True(t, IsEnvelope(migrated))
+	env, err = psid.UnwrapArtifact(migrated, psidentity.PsIdentityConfigIssuerPublicKey, ipk.Hash)
+	require.NoError(t, err)
+	require.Equal(t, uint32(EnvelopeSchemaVersion), env.SchemaVersion)
+	require.Equal(t, CurveFP256BN_AMCL, env.CurveId)
+	require.Equal(t, legacy, env.Payload)
+
+	// migration is idempotent
+	again, err := MigrateArtifact(migrated, psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, migrated, again)
+}
+
+func TestSealedArtifact(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	ipkHash, err := IssuerKeyHash(ipkBytes)
+	require.NoError(t, err)
+
+	raw, err := psid.WrapSealedArtifact(psidentity.PsIdentityConfigIssuerSecretKey, ipkHash, iskBytes, []byte("passphrase"), rng)
+	require.NoError(t, err)
+	env, err := psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerSecretKey, ipkHash)
+	require.NoError(t, err)
+	require.True(t, env.Sealed)
+	require.NotEqual(t, iskBytes, env.Payload)
+	payload, err := env.OpenPayload([]byte("passphrase"))
+	require.NoError(t, err)
+	require.Equal(t, iskBytes, payload)
+
+	_, err = env.OpenPayload([]byte("wrong"))
+	require.Error(t, err)
+	_, err = env.OpenPayload(nil)
+	require.Error(t, err)
+	_, err = ArtifactPayload(raw, psidentity.PsIdentityConfigIssuerSecretKey)
+	require.Error(t, err)
+
+	// the sealed payload is bound to the envelope header
+	for name, tamper := range map[string]func(*Envelope){
+		"type":           func(e *Envelope) { e.Type = psidentity.PsIdentityConfigUserSecretKey },
+		"schema version": func(e *Envelope) { e.SchemaVersion = 0 },
+		"curve":          func(e *Envelope) { e.CurveId = CurveBLS12_381 },
+		"compressed":     func(e *Envelope) { e.CompressedPoints = !e.CompressedPoints },
+		"issuer key":     func(e *Envelope) { e.IssuerKeyHash = []byte("other issuer") },
+	} {
+		tampered := proto.Clone(env).(*Envelope)
+		tamper(tampered)
+		_, err = tampered.OpenPayload([]byte("passphrase"))
+		require.Error(t, err, name)
+	}
+
+	// the argon2id memory of a sealed payload is bounded
+	sealed := &SealedKey{}
+	require.NoError(t, proto.Unmarshal(env.Payload, sealed))
+	sealed.Argon2Memory = 4 * 1024 * 1024
+	_, err = sealed.Open([]byte("passphrase"), psidentity.PsIdentityConfigIssuerSecretKey)
+	require.Error(t, err)
+
+	// public artifacts are not sealed
+	_, err = psid.WrapSealedArtifact(psidentity.PsIdentityConfigIssuerPublicKey, ipkHash, ipkBytes, []byte("passphrase"), rng)
+	require.Error(t, err)
+
+	// resealing rotates the passphrase and seals plaintext artifacts
+	resealed, err := ResealArtifact(raw, psidentity.PsIdentityConfigIssuerSecretKey, nil, []byte("passphrase"), []byte("new passphrase"), rng)
+	require.NoError(t, err)
+	_, err = ResealArtifact(resealed, psidentity.PsIdentityConfigIssuerSecretKey, nil, []byte("passphrase"), []byte("other"), rng)
+	require.Error(t, err)
+	env, err = psid.UnwrapArtifact(resealed, psidentity.PsIdentityConfigIssuerSecretKey, ipkHash)
+	require.NoError(t, err)
+	require.Equal(t, ipkHash, env.IssuerKeyHash)
+	payload, err = env.OpenPayload([]byte("new passphrase"))
+	require.NoError(t, err)
+	require.Equal(t, iskBytes, payload)
+
+	resealed, err = ResealArtifact(iskBytes, psidentity.PsIdentityConfigIssuerSecretKey, ipkHash, nil, []byte("passphrase"), rng)
+	require.NoError(t, err)
+	env, err = psid.UnwrapArtifact(resealed, psidentity.PsIdentityConfigIssuerSecretKey, ipkHash)
+	require.NoError(t, err)
+	require.True(t, env.Sealed)
+	require.Equal(t, ipkHash, env.IssuerKeyHash)
+}
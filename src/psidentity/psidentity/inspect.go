@@ -0,0 +1,472 @@
+package psidentity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+// Verification status of the checks in an InspectReport
+const (
+	InspectValid   = "valid"
+	InspectSkipped = "skipped"
+)
+
+// InspectKeys are the keys InspectArtifact verifies artifacts with, nil keys are not used
+type InspectKeys struct {
+	Ipk        *IssuerPublicKeyPS
+	Upk        *UserPublicKey
+	Revocation *RevocationState
+	// RevocationPk is the long term revocation public key the CRIs are signed with
+	RevocationPk *ecdsa.PublicKey
+	// CRI is the CRI of the current epoch the accumulator witnesses are checked against
+	CRI *CredentialRevocationInformation
+	// Now is the time validity windows are checked at, time.Now() if zero
+	Now time.Time
+}
+
+// InspectAttribute is an attribute of a credential, Time is set for the validity window attributes
+type InspectAttribute struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Time  string `json:"time,omitempty"`
+}
+
+// InspectReport is the human readable content of an artifact.
+// Detected is set when the type of a legacy artifact was guessed from its content.
+// Verification maps the checks that were made to InspectValid, InspectSkipped with the reason, or the error.
+type InspectReport struct {
+	Type             string             `json:"type"`
+	Detected         bool               `json:"detected,omitempty"`
+	SchemaVersion    uint32             `json:"schema_version"`
+	Curve            string             `json:"curve,omitempty"`
+	CompressedPoints bool               `json:"compressed_points,omitempty"`
+	IssuerKeyHash    string             `json:"issuer_key_hash,omitempty"`
+	Sealed           bool               `json:"sealed,omitempty"`
+	Attributes       []InspectAttribute `json:"attributes,omitempty"`
+	DisclosedIndices []int64            `json:"disclosed_indices,omitempty"`
+	Verification     map[string]string  `json:"verification,omitempty"`
+	Content          json.RawMessage    `json:"content"`
+}
+
+// detectors recognize the legacy artifacts whose type is not known, most specific first.
+// The secret keys are not told apart from each other and are not detected.
+var detectors = []struct {
+	artifactType string
+	detect       func(raw []byte) bool
+}{
+	{psidentity.PsIdentityConfigPrimaryCred, func(raw []byte) bool {
+		conf, cred := &user.UserPrimaryCred{}, &PrimaryCredential{}
+		return strictUnmarshal(raw, conf) && strictUnmarshal(conf.GetPrimaryCred(), cred) && len(cred.GetAttrs()) > 0 && cred.GetH() != nil
+	}},
+	{psidentity.PsIdentityConfigDeriveCred, func(raw []byte) bool {
+		conf, cred := &user.UserDeriveCred{}, &DeriveCredential{}
+		return strictUnmarshal(raw, conf) && strictUnmarshal(conf.GetDeriveCred(), cred) && cred.GetHp() != nil && cred.GetSigmaOnep() != nil
+	}},
+	{psidentity.PsIdentityConfigAggregateCred, func(raw []byte) bool {
+		conf, cred := &user.UserAggregateCred{}, &AggregateCredential{}
+		return strictUnmarshal(raw, conf) && strictUnmarshal(conf.GetAggregateCred(), cred) && cred.GetSigmaOnepp() != nil && len(cred.GetMessages()) > 0
+	}},
+	{psidentity.PsIdentityConfigIssuerPublicKey, func(raw []byte) bool {
+		ipk := &IssuerPublicKeyPS{}
+		return strictUnmarshal(raw, ipk) && ipk.GetX() != nil && len(ipk.GetY()) > 0 && len(ipk.GetHash()) > 0
+	}},
+	{psidentity.PsIdentityConfigUserPublicKey, func(raw []byte) bool {
+		upk := &UserPublicKey{}
+		return strictUnmarshal(raw, upk) && upk.GetB() != nil && upk.GetBBar() != nil
+	}},
+	{psidentity.PsIdentityConfigRevocationState, func(raw []byte) bool {
+		state := &RevocationState{}
+		return strictUnmarshal(raw, state) && state.GetAccumulator().GetV() != nil
+	}},
+	{psidentity.PsIdentityConfigWitness, func(raw []byte) bool {
+		w := &PairingAccumulatorWitness{}
+		return strictUnmarshal(raw, w) && len(w.GetY()) > 0 && len(w.GetW().GetX()) > 0
+	}},
+	{psidentity.PsIdentityConfigRevocationKey, func(raw []byte) bool {
+		rk := &RsaKey{}
+		return strictUnmarshal(raw, rk) && len(rk.GetN()) > 0 && len(rk.GetG()) > 0
+	}},
+}
+
+// strictUnmarshal unmarshals raw into msg and reports whether all of raw are fields of msg
+func strictUnmarshal(raw []byte, msg proto.Message) bool {
+	if len(raw) == 0 || proto.Unmarshal(raw, msg) != nil {
+		return false
+	}
+	m, ok := msg.(protoreflect.ProtoMessage)
+	return ok && len(m.ProtoReflect().GetUnknown()) == 0
+}
+
+// protoJSON renders a message as JSON, bytes and points as base64
+func protoJSON(msg protoreflect.ProtoMessage) (json.RawMessage, error) {
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+}
+
+// ArtifactPayload returns the payload of an artifact of the given type without checking its curve or issuer key,
+// legacy artifacts are returned unchanged
+func ArtifactPayload(raw []byte, artifactType string) ([]byte, error) {
+	env, err := openEnvelope(raw, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if env.GetType() != artifactType {
+		return nil, errors.Errorf("artifact is a %s, not a %s", env.GetType(), artifactType)
+	}
+	if env.GetSealed() {
+		return nil, errors.Errorf("%s is sealed under a passphrase", artifactType)
+	}
+	return env.GetPayload(), nil
+}
+
+// InspectArtifact returns the human readable content of an artifact and verifies it with the keys that are available.
+// The type is read from the envelope; for a legacy artifact it is typeHint if that is an artifact type, or
+// detected from the content otherwise.
+func InspectArtifact(raw []byte, typeHint string, keys *InspectKeys) (*InspectReport, error) {
+	if keys == nil {
+		keys = &InspectKeys{}
+	}
+	report := &InspectReport{Verification: map[string]string{}}
+
+	artifactType := typeHint
+	if _, ok := artifactTypes[typeHint]; !IsEnvelope(raw) && !ok {
+		for _, d := range detectors {
+			if d.detect(raw) {
+				artifactType = d.artifactType
+				report.Detected = true
+				break
+			}
+		}
+		if !report.Detected {
+			return nil, errors.Errorf("artifact type cannot be detected")
+		}
+	}
+
+	env, err := openEnvelope(raw, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := artifactTypes[env.GetType()]; !ok {
+		return nil, errors.Errorf("unknown artifact type %s", env.GetType())
+	}
+	report.Type = env.GetType()
+	report.SchemaVersion = env.GetSchemaVersion()
+	report.Curve = env.GetCurveId()
+	report.CompressedPoints = env.GetCompressedPoints()
+	report.IssuerKeyHash = hex.EncodeToString(env.GetIssuerKeyHash())
+
+	// a sealed secret key is not opened, only its size is shown
+	if env.GetSealed() {
+		report.Sealed = true
+		report.Verification["public_key"] = InspectSkipped + ": sealed under a passphrase"
+		if report.Content, err = json.Marshal(map[string]interface{}{"sealed_size": len(env.GetPayload())}); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	var psid *Psidentity
+	if env.GetCurveId() != "" {
+		if psid, err = NewPsidentityForCurve(env.GetCurveId()); err != nil {
+			return nil, err
+		}
+	}
+
+	// the issuer public key is only used for artifacts of the same curve and issuer key
+	ipk := keys.Ipk
+	if ipk != nil {
+		if err := CheckCurveID(env.GetCurveId(), ipk.GetCurveId()); psid != nil && err != nil {
+			report.Verification["issuer_key"] = InspectSkipped + ": " + err.Error()
+			ipk = nil
+		} else if len(env.GetIssuerKeyHash()) != 0 && !bytes.Equal(env.GetIssuerKeyHash(), ipk.GetHash()) {
+			report.Verification["issuer_key"] = "artifact belongs to another issuer key"
+			ipk = nil
+		}
+	}
+	upk := keys.Upk
+	if upk != nil && psid != nil && CheckCurveID(env.GetCurveId(), upk.GetCurveId()) != nil {
+		upk = nil
+	}
+	now := keys.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	payload := env.GetPayload()
+	switch env.GetType() {
+	case psidentity.PsIdentityConfigIssuerPublicKey:
+		key := &IssuerPublicKeyPS{}
+		if err := proto.Unmarshal(payload, key); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+		}
+		report.Verification["hash"] = status(checkIssuerKeyHash(key, psid))
+		report.Content, err = protoJSON(key)
+
+	case psidentity.PsIdentityConfigIssuerSecretKey:
+		key := &IssuerPrivateKeyPS{}
+		if err := proto.Unmarshal(payload, key); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal issuer secret key")
+		}
+		report.Verification["public_key"] = InspectSkipped + ": no issuer public key"
+		if ipk != nil {
+			X, err := psid.Translator.G1FromProto(ipk.GetX())
+			if err == nil && !psid.Curve.GenG1.Mul(psid.Curve.NewZrFromBytes(key.GetX())).Equals(X) {
+				err = errors.Errorf("issuer secret key does not match the issuer public key")
+			}
+			report.Verification["public_key"] = status(err)
+		}
+		report.Content, err = json.Marshal(redacted(len(key.GetY())))
+
+	case psidentity.PsIdentityConfigUserSecretKey:
+		key := &UserPrivateKey{}
+		if err := proto.Unmarshal(payload, key); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal user secret key")
+		}
+		report.Verification["public_key"] = InspectSkipped + ": no user public key"
+		if upk != nil {
+			B, err := psid.Translator.G1FromProto(upk.GetB())
+			if err == nil && !psid.Curve.GenG1.Mul(psid.Curve.NewZrFromBytes(key.GetB())).Equals(B) {
+				err = errors.Errorf("user secret key does not match the user public key")
+			}
+			report.Verification["public_key"] = status(err)
+		}
+		report.Content, err = json.Marshal(redacted(len(key.GetW())))
+
+	case psidentity.PsIdentityConfigUserPublicKey:
+		key := &UserPublicKey{}
+		if err := proto.Unmarshal(payload, key); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal user public key")
+		}
+		report.Content, err = protoJSON(key)
+
+	case psidentity.PsIdentityConfigRevocationKey:
+		key := &RsaKey{}
+		if err := proto.Unmarshal(payload, key); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal revocation key")
+		}
+		report.Content, err = protoJSON(key)
+
+	case psidentity.PsIdentityConfigIssuerKeyRotation:
+		rotation := &IssuerKeyRotation{}
+		if err := proto.Unmarshal(payload, rotation); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal issuer key rotation")
+		}
+		// only the signature of the new key can be checked, against the active issuer key
+		report.Verification["new_key"] = InspectSkipped + ": no issuer public key"
+		if ipk != nil && bytes.Equal(ipk.GetHash(), rotation.GetNewKeyHash()) {
+			digest, err := rotation.digest()
+			if err == nil {
+				err = verifyIssuerKeySignature(rotation.GetNewKeySignature(), ipk, digest, psid.Curve, psid.Translator)
+			}
+			report.Verification["new_key"] = status(err)
+		} else if ipk != nil {
+			report.Verification["new_key"] = InspectSkipped + ": not a rotation to the issuer public key"
+		}
+		report.Content, err = protoJSON(rotation)
+
+	case psidentity.PsIdentityConfigRevocationTrapdoor:
+		report.Content, err = json.Marshal(map[string]interface{}{"sealed_size": len(payload)})
+
+	case psidentity.PsIdentityConfigPrimaryCred:
+		err = inspectPrimaryCred(report, payload, psid, ipk, keys.Revocation, now)
+
+	case psidentity.PsIdentityConfigDeriveCred:
+		err = inspectDeriveCred(report, payload, psid, ipk, keys, now)
+
+	case psidentity.PsIdentityConfigAggregateCred:
+		conf, cred := &user.UserAggregateCred{}, &AggregateCredential{}
+		if err := proto.Unmarshal(payload, conf); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal user aggregate cred")
+		}
+		if err := proto.Unmarshal(conf.GetAggregateCred(), cred); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal aggregate credential")
+		}
+		report.Verification["aggregate"] = InspectSkipped + ": no user public key"
+		if upk != nil {
+			report.Verification["aggregate"] = status(cred.VerifyAggregate(upk, psid.Curve, psid.Translator))
+		}
+		report.Content, err = wrapperJSON(cred, conf.GetAggregateCri(), conf.GetCriVersion())
+
+	case psidentity.PsIdentityConfigWitness:
+		w := &PairingAccumulatorWitness{}
+		if err := proto.Unmarshal(payload, w); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal witness")
+		}
+		report.Verification["witness"] = InspectSkipped + ": no CRI of the pairing accumulator"
+		if cri := keys.CRI; cri.GetRevocationAlg() == int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+			acc := &PairingAccumulator{}
+			err := proto.Unmarshal(cri.GetRevocationData(), acc)
+			if err == nil {
+				err = w.Verify(acc, cri.GetEpochPk(), psid.Curve, psid.Translator)
+			}
+			report.Verification["witness"] = status(errors.WithMessagef(err, "epoch %d", cri.GetEpoch()))
+		}
+		report.Content, err = protoJSON(w)
+
+	case psidentity.PsIdentityConfigCRI:
+		cri := &CredentialRevocationInformation{}
+		if err := proto.Unmarshal(payload, cri); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal CRI")
+		}
+		report.Verification["signature"] = InspectSkipped + ": no long term revocation public key"
+		if keys.RevocationPk != nil {
+			report.Verification["signature"] = status(verifyCRI(keys.RevocationPk, cri))
+		}
+		report.Content, err = protoJSON(cri)
+
+	case psidentity.PsIdentityConfigRevocationState:
+		state := &RevocationState{}
+		if err := proto.Unmarshal(payload, state); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal accumulator state")
+		}
+		report.Content, err = protoJSON(state)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func inspectPrimaryCred(report *InspectReport, payload []byte, psid *Psidentity, ipk *IssuerPublicKeyPS, revocation *RevocationState, now time.Time) error {
+	conf, cred := &user.UserPrimaryCred{}, &PrimaryCredential{}
+	if err := proto.Unmarshal(payload, conf); err != nil {
+		return errors.Wrap(err, "failed to unmarshal user primary cred")
+	}
+	if err := proto.Unmarshal(conf.GetPrimaryCred(), cred); err != nil {
+		return errors.Wrap(err, "failed to unmarshal primary credential")
+	}
+
+	// without the issuer public key, the last two attributes are decoded if they hold a validity window
+	window := ipk == nil || hasValidity(ipk)
+	for j, attr := range cred.GetAttrs() {
+		report.Attributes = append(report.Attributes, inspectAttribute(j, len(cred.GetAttrs()), attr, window))
+	}
+
+	report.Verification["signature"] = InspectSkipped + ": no issuer public key"
+	if ipk != nil {
+		report.Verification["signature"] = status(cred.VerifyPrimary(ipk, psid.Curve, psid.Translator))
+	}
+	report.Verification["validity"] = InspectSkipped + ": no validity window"
+	if notBefore, notAfter, err := cred.Validity(); window && err == nil {
+		report.Verification["validity"] = status(checkValidity(notBefore, notAfter, now))
+	}
+	report.Verification["revocation"] = InspectSkipped + ": no revocation state"
+	if rhIndex := psidentity.AttributeIndexRevocationHandle; revocation != nil && rhIndex < len(cred.GetAttrs()) {
+		report.Verification["revocation"] = revocation.Status(revocationHandle(cred.GetAttrs()[rhIndex], psid.Curve))
+	}
+
+	content, err := wrapperJSON(cred, conf.GetPrimaryCri(), conf.GetCriVersion())
+	report.Content = content
+	return err
+}
+
+func inspectDeriveCred(report *InspectReport, payload []byte, psid *Psidentity, ipk *IssuerPublicKeyPS, keys *InspectKeys, now time.Time) error {
+	conf, cred := &user.UserDeriveCred{}, &DeriveCredential{}
+	if err := proto.Unmarshal(payload, conf); err != nil {
+		return errors.Wrap(err, "failed to unmarshal user derive cred")
+	}
+	if err := proto.Unmarshal(conf.GetDeriveCred(), cred); err != nil {
+		return errors.Wrap(err, "failed to unmarshal derived credential")
+	}
+
+	report.DisclosedIndices = cred.GetDiscloseIndices()
+	window := ipk == nil || hasValidity(ipk)
+	for _, j := range cred.GetDiscloseIndices() {
+		if n := len(cred.GetDiscloseMsg()); int(j) < n {
+			report.Attributes = append(report.Attributes, inspectAttribute(int(j), n, cred.GetDiscloseMsg()[j], window))
+		}
+	}
+
+	report.Verification["derive"] = InspectSkipped + ": no issuer public key"
+	report.Verification["validity"] = InspectSkipped + ": no validity proof"
+	if ipk != nil {
+		report.Verification["derive"] = status(cred.VerifyDerive(ipk, psid.Curve, psid.Translator))
+		if cred.GetValidityProof() != nil {
+			report.Verification["validity"] = status(cred.VerifyValidity(ipk, now, psid.Curve, psid.Translator))
+		}
+	}
+	if cred.GetNonRevocationProof() != nil {
+		report.Verification["non-revocation"] = InspectSkipped + ": no CRI and long term revocation public key"
+		if ipk != nil && keys.CRI != nil && keys.RevocationPk != nil {
+			report.Verification["non-revocation"] = status(inspectNonRevocation(cred, ipk, keys, psid))
+		}
+	}
+
+	content, err := wrapperJSON(cred, conf.GetDeriveCri(), conf.GetCriVersion())
+	report.Content = content
+	return err
+}
+
+// inspectAttribute names the attribute at index j of n, and decodes it if it is a bound of the validity window,
+// which the credentials have if window is set: the start of the not-before day and the end of the not-after day
+func inspectAttribute(j, n int, value string, window bool) InspectAttribute {
+	attr := InspectAttribute{Index: j, Name: attributeName(j), Value: value}
+	if window && j >= n-2 {
+		if t, err := DecodeTimeAttribute(value); err == nil {
+			if j == n-1 {
+				t = endOfDay(t)
+			}
+			attr.Time = t.UTC().Format(time.RFC3339)
+		}
+	}
+	return attr
+}
+
+// wrapperJSON renders a credential with its CRI
+func wrapperJSON(cred protoreflect.ProtoMessage, cri []byte, criVersion uint32) (json.RawMessage, error) {
+	credJSON, err := protoJSON(cred)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Credential json.RawMessage `json:"credential"`
+		CRI        string          `json:"cri"`
+		CRIVersion uint32          `json:"cri_version"`
+	}{credJSON, hex.EncodeToString(cri), criVersion})
+}
+
+// redacted is the content shown for secret keys
+func redacted(attributes int) interface{} {
+	return map[string]interface{}{"secret": "redacted", "attributes": attributes}
+}
+
+// checkIssuerKeyHash recomputes the hash of an issuer public key
+// inspectNonRevocation verifies the non-revocation proof of the derived credential for the CRI of the keys
+func inspectNonRevocation(cred *DeriveCredential, ipk *IssuerPublicKeyPS, keys *InspectKeys, psid *Psidentity) error {
+	rhIndex := psidentity.AttributeIndexRevocationHandle
+	if keys.CRI.GetRevocationAlg() == int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		return cred.VerifyAccumulatorNonRevocation(ipk, keys.RevocationPk, keys.CRI, rhIndex, psid.Curve, psid.Translator)
+	}
+	return cred.VerifyNonRevocation(ipk, keys.RevocationPk, int(keys.CRI.GetEpoch()), rhIndex, psid.Curve, psid.Translator)
+}
+
+func checkIssuerKeyHash(ipk *IssuerPublicKeyPS, psid *Psidentity) error {
+	unhashed := proto.Clone(ipk).(*IssuerPublicKeyPS)
+	unhashed.Hash = nil
+	serialized, err := proto.Marshal(unhashed)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal issuer public key")
+	}
+	if !bytes.Equal(psid.Curve.HashToZr(serialized).Bytes(), ipk.GetHash()) {
+		return errors.Errorf("issuer public key hash does not match")
+	}
+	return nil
+}
+
+// status renders the result of a check
+func status(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return InspectValid
+}
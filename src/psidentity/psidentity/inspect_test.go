@@ -0,0 +1,81 @@
+package psidentity
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestInspectArtifact(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, tr)
+	require.NoError(t, err)
+	key := IssuerKeyPS{Isk: &IssuerPrivateKeyPS{}, Ipk: &IssuerPublicKeyPS{}}
+	require.NoError(t, proto.Unmarshal(iskBytes, key.Isk))
+	require.NoError(t, proto.Unmarshal(ipkBytes, key.Ipk))
+
+	now := time.Now()
+	attrs := psidentity.IssuerAttributeNames
+	primaryBytes, err := GenerateUserPrimaryCred([]string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+		&ValidityWindow{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}, &key, *psid, tr)
+	require.NoError(t, err)
+	primaryRaw, err := psid.WrapArtifact(psidentity.PsIdentityConfigPrimaryCred, key.Ipk.Hash, primaryBytes)
+	require.NoError(t, err)
+
+	// the issuer public key hash is checked
+	report, err := InspectArtifact(ipkBytes, "", nil)
+	require.NoError(t, err)
+	require.True(t, report.Detected)
+	require.Equal(t, psidentity.PsIdentityConfigIssuerPublicKey, report.Type)
+	require.Equal(t, hex.EncodeToString(key.Ipk.Hash), report.IssuerKeyHash)
+	require.Equal(t, InspectValid, report.Verification["hash"])
+
+	// attributes are named and the credential is verified with the issuer public key
+	report, err = InspectArtifact(primaryRaw, "", &InspectKeys{Ipk: key.Ipk, Now: now})
+	require.NoError(t, err)
+	require.False(t, report.Detected)
+	require.Equal(t, psidentity.PsIdentityConfigPrimaryCred, report.Type)
+	require.Equal(t, CurveFP256BN_AMCL, report.Curve)
+	require.Len(t, report.Attributes, len(attrs))
+	require.Equal(t, psidentity.IssuerAttributeNotAfter, report.Attributes[len(attrs)-1].Name)
+	require.NotEmpty(t, report.Attributes[len(attrs)-1].Time)
+	require.Equal(t, InspectValid, report.Verification["signature"])
+	require.Equal(t, InspectValid, report.Verification["validity"])
+
+	report, err = InspectArtifact(primaryRaw, "", &InspectKeys{Ipk: key.Ipk, Now: now.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	require.NotEqual(t, InspectValid, report.Verification["validity"])
+
+	// legacy primary creds are detected, and are not verified without the issuer public key
+	report, err = InspectArtifact(primaryBytes, "", nil)
+	require.NoError(t, err)
+	require.True(t, report.Detected)
+	require.Equal(t, psidentity.PsIdentityConfigPrimaryCred, report.Type)
+	require.Contains(t, report.Verification["signature"], InspectSkipped)
+
+	// secret keys are redacted and checked against the public key
+	report, err = InspectArtifact(iskBytes, psidentity.PsIdentityConfigIssuerSecretKey, &InspectKeys{Ipk: key.Ipk})
+	require.NoError(t, err)
+	require.Equal(t, InspectValid, report.Verification["public_key"])
+	require.NotContains(t, string(report.Content), hex.EncodeToString(key.Isk.X))
+
+	// artifacts of another issuer key are not verified
+	_, otherIpkBytes, err := GenerateIssuerKeyPS(*psid, tr)
+	require.NoError(t, err)
+	other := &IssuerPublicKeyPS{}
+	require.NoError(t, proto.Unmarshal(otherIpkBytes, other))
+	report, err = InspectArtifact(primaryRaw, "", &InspectKeys{Ipk: other})
+	require.NoError(t, err)
+	require.NotEqual(t, InspectValid, report.Verification["issuer_key"])
+	require.Contains(t, report.Verification["signature"], InspectSkipped)
+
+	_, err = InspectArtifact([]byte("not an artifact"), "", nil)
+	require.Error(t, err)
+}
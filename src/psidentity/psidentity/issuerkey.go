@@ -2,6 +2,7 @@ package psidentity
 
 import (
 	"io"
+	"time"
 
 	amcl "psidentity/translator/amcl"
 	math "github.com/IBM/mathlib"
@@ -226,6 +227,35 @@ func (i *Psidentity) NewIssuerKeyPS(n int, rng io.Reader, t Translator) (*Issuer
 }
 
 func newIssuerKeyPS(n int, rng io.Reader, curve *math.Curve, t Translator) (*IssuerKeyPS, error) {
+	return newVersionedIssuerKeyPS(n, false, nil, 1, time.Now(), nil, rng, curve, t)
+}
+
+// NewIssuerKeyPSWithValidity generates an issuer key of n attributes whose credentials end with the validity
+// window of AppendValidity, which the key records in ValidityWindow
+func (i *Psidentity) NewIssuerKeyPSWithValidity(n int, rng io.Reader, t Translator) (*IssuerKeyPS, error) {
+	return newVersionedIssuerKeyPS(n, true, nil, 1, time.Now(), nil, rng, i.Curve, t)
+}
+
+// NewIssuerKeyPSWithSchema generates an issuer key of the attributes of the schema, which the key records in
+// Schema and AttributeNames. Its credentials end with a validity window if the schema ends with the
+// IssuerAttributeNotBefore and IssuerAttributeNotAfter attributes.
+func (i *Psidentity) NewIssuerKeyPSWithSchema(schema *CredentialSchema, rng io.Reader, t Translator) (*IssuerKeyPS, error) {
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+	return newVersionedIssuerKeyPS(len(schema.Attributes), schema.hasValidity(), schema, 1, time.Now(), nil, rng, i.Curve, t)
+}
+
+// newVersionedIssuerKeyPS generates an issuer key of the version issuing credentials from notBefore,
+// predecessorHash is the hash of the key it replaces or nil for the first key of an issuer.
+// With validityWindow the last two of the n attributes are the validity window. schema is nil or names the n attributes.
+func newVersionedIssuerKeyPS(n int, validityWindow bool, schema *CredentialSchema, version uint32, notBefore time.Time, predecessorHash []byte, rng io.Reader, curve *math.Curve, t Translator) (*IssuerKeyPS, error) {
+	if validityWindow && n < 3 {
+		return nil, errors.Errorf("an issuer key with a validity window needs at least one user attribute, got %d attributes", n)
+	}
+	if schema != nil && len(schema.Attributes) != n {
+		return nil, errors.Errorf("schema %s names %d attributes, the issuer key has %d", schema.Name, len(schema.Attributes), n)
+	}
 	// validate inputs
 
 	// check for duplicated attributes
@@ -253,8 +283,18 @@ func newIssuerKeyPS(n int, rng io.Reader, curve *math.Curve, t Translator) (*Iss
 	key := new(IssuerKeyPS)
 
 	// generate issuer secret key
-	key.Isk = new(IssuerPrivateKeyPS)
-	key.Ipk = new(IssuerPublicKeyPS)
+	key.Isk = &IssuerPrivateKeyPS{CurveId: CurveName(curve)}
+	key.Ipk = &IssuerPublicKeyPS{
+		CurveId:         CurveName(curve),
+		Version:         version,
+		NotBefore:       notBefore.Unix(),
+		PredecessorHash: predecessorHash,
+		ValidityWindow:  validityWindow,
+	}
+	if schema != nil {
+		key.Ipk.Schema = schema.Name
+		key.Ipk.AttributeNames = append([]string(nil), schema.Attributes...)
+	}
 
 	tempX := curve.NewRandomZr(rng)
 	tempX_bytes := tempX.Bytes()
@@ -285,15 +325,13 @@ func newIssuerKeyPS(n int, rng io.Reader, curve *math.Curve, t Translator) (*Iss
 	X := curve.GenG1.Mul(tempXX)
 	key.Ipk.X = t.G1ToProto(X)
 
-	// generate Z_ij
+	// generate Z_ij = g_1^{y_i y_j} for i < j only, see zijIndex
+	key.Ipk.ZIj = make([]*amcl.ECP, 0, n*(n-1)/2)
 	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			if i != j {
-				mult := curve.NewZrFromBytes(key.Isk.Y[i]).Mul(curve.NewZrFromBytes(key.Isk.Y[j]))
-				Z_ij := curve.GenG1.Mul(mult)
-				//key.Ipk.ZIj[i*j] = t.G1ToProto(Z_ij)
-				key.Ipk.ZIj = append(key.Ipk.ZIj,t.G1ToProto(Z_ij))
-			}
+		y_i := curve.NewZrFromBytes(key.Isk.Y[i])
+		for j := i + 1; j < n; j++ {
+			Z_ij := curve.GenG1.Mul(y_i.Mul(curve.NewZrFromBytes(key.Isk.Y[j])))
+			key.Ipk.ZIj = append(key.Ipk.ZIj, t.G1ToProto(Z_ij))
 		}
 	}
 
@@ -308,6 +346,36 @@ func newIssuerKeyPS(n int, rng io.Reader, curve *math.Curve, t Translator) (*Iss
 	return key, nil
 }
 
+// zijIndex returns the position of Z_ij, i != j, in the Z_ij of an issuer key of n attributes holding count points.
+// Z_ij = Z_ji, so keys store it for i < j only, row by row: Z_01, ..., Z_0(n-1), Z_12, ..., Z_(n-2)(n-1).
+// Keys generated before stored all n(n-1) points of i != j row by row, they are told apart by the count.
+func zijIndex(i, j, n, count int) (int, error) {
+	if i == j || i < 0 || j < 0 || i >= n || j >= n {
+		return 0, errors.Errorf("no Z_ij for attributes %d and %d of %d", i, j, n)
+	}
+	switch count {
+	case n * (n - 1) / 2:
+		if i > j {
+			i, j = j, i
+		}
+		return i*n - i*(i+1)/2 + j - i - 1, nil
+	case n * (n - 1):
+		if j > i {
+			j--
+		}
+		return i*(n-1) + j, nil
+	}
+	return 0, errors.Errorf("issuer key has %d Z_ij for %d attributes", count, n)
+}
+
+// zij returns Z_ij = g_1^{y_i y_j} of the issuer key, i != j
+func (IPk *IssuerPublicKeyPS) zij(i, j int, t Translator) (*math.G1, error) {
+	index, err := zijIndex(i, j, len(IPk.GetY()), len(IPk.GetZIj()))
+	if err != nil {
+		return nil, err
+	}
+	return t.G1FromProto(IPk.GetZIj()[index])
+}
 
 // func (IPk *IssuerPublicKeyPS) CheckPS(curve *math.Curve, t Translator) error {
 // 	// Unmarshall the public key
@@ -0,0 +1,80 @@
+package psidentity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZijIndex(t *testing.T) {
+	for _, n := range []int{2, 3, 7, 64} {
+		// both layouts map the pairs i != j onto all positions, the symmetric one with Z_ij = Z_ji
+		for _, count := range []int{n * (n - 1) / 2, n * (n - 1)} {
+			seen := make(map[int]bool)
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					if i == j {
+						_, err := zijIndex(i, j, n, count)
+						require.Error(t, err)
+						continue
+					}
+					index, err := zijIndex(i, j, n, count)
+					require.NoError(t, err)
+					require.True(t, index >= 0 && index < count)
+					if count == n*(n-1) {
+						require.False(t, seen[index], "n=%d i=%d j=%d", n, i, j)
+					} else if i > j {
+						require.True(t, seen[index])
+					}
+					seen[index] = true
+				}
+			}
+			require.Len(t, seen, count)
+		}
+		_, err := zijIndex(0, 1, n, n*n)
+		require.Error(t, err)
+	}
+}
+
+func TestDeriveManyAttributes(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	n := 70
+	attrs := make([]string, n)
+	mask := make([]int, n)
+	for j := range attrs {
+		attrs[j] = fmt.Sprintf("attribute-%d", j)
+		mask[j] = j % 3 % 2
+	}
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	require.Len(t, key.Ipk.ZIj, n*(n-1)/2)
+
+	// sigma_twop sums the cross terms of all disclosed and hidden attributes
+	derived, err := psid.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, derived.VerifyDerive(key.Ipk, psid.Curve, tr))
+
+	// the last attributes are bound like the first ones
+	tampered := proto.Clone(derived).(*DeriveCredential)
+	tampered.DiscloseMsg[n-3] = "attribute-0"
+	require.Error(t, tampered.VerifyDerive(key.Ipk, psid.Curve, tr))
+	truncated := proto.Clone(key.Ipk).(*IssuerPublicKeyPS)
+	truncated.ZIj = truncated.ZIj[:len(truncated.ZIj)-1]
+	_, err = psid.NewDeriveCredential(attrs, &IssuerKeyPS{Ipk: truncated}, primary, mask, rng, tr)
+	require.Error(t, err)
+
+	sigmaTwop, err := tr.G1FromProto(derived.SigmaTwop)
+	require.NoError(t, err)
+	sigmaTwop.Add(psid.Curve.GenG1)
+	derived.SigmaTwop = tr.G1ToProto(sigmaTwop)
+	require.Error(t, derived.VerifyDerive(key.Ipk, psid.Curve, tr))
+
+	_, err = psid.NewDeriveCredential(attrs, key, primary, make([]int, n), rng, tr)
+	require.Error(t, err)
+}
@@ -0,0 +1,491 @@
+package psidentity
+
+import (
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+)
+
+// ErrKeyNotFound is returned by a KeyStore for a key it does not hold
+var ErrKeyNotFound = errors.New("key not found")
+
+// keyTypes are the artifact types a KeyStore holds
+var keyTypes = []string{
+	psidentity.PsIdentityConfigIssuerPublicKey,
+	psidentity.PsIdentityConfigIssuerSecretKey,
+	psidentity.PsIdentityConfigRevocationKey,
+	psidentity.PsIdentityConfigUserPublicKey,
+	psidentity.PsIdentityConfigUserSecretKey,
+}
+
+// KeyID names key material in a KeyStore. Type is the artifact type of the key and Name the key set it
+// belongs to, the secret and public key of a key pair share the name.
+type KeyID struct {
+	Name string
+	Type string
+}
+
+// KeyStore stores serialized key material. BlindSign and Aggregate use the secret keys inside the store,
+// so that issuing and aggregating never hand the secret keys to the caller. Get still returns secret keys,
+// which the key rotation and the revocation key need.
+type KeyStore interface {
+	// Get returns the key, secret keys included, or ErrKeyNotFound
+	Get(id KeyID) ([]byte, error)
+	// Put stores the key, replacing the key of the same id. Issuer secret keys are stored with PutIssuerKey.
+	Put(id KeyID, raw []byte) error
+	// PutIssuerKey stores the issuer key pair of the name, replacing the pair of the same name. The secret key
+	// is bound to the public key, and the pair is refused if the secret key is not the secret key of the public key.
+	PutIssuerKey(name string, ipk, isk []byte) error
+	// List returns the ids of the keys in the store, sorted by name and type
+	List() ([]KeyID, error)
+	// Delete removes the key, or returns ErrKeyNotFound
+	Delete(id KeyID) error
+
+	// BlindSign blind-signs a credential request with the issuer key pair of the name, adding the validity
+	// window if the credentials of the key have one
+	BlindSign(name string, m *CredRequestPS, window *ValidityWindow, rng io.Reader) (*BlindCredential, error)
+	// Aggregate aggregates derived credentials of the issuer key with the user key pair of the name
+	Aggregate(name string, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error)
+}
+
+// checkKeyID checks that the type of the id is a key type and its name can be used as a directory name
+func checkKeyID(id KeyID) error {
+	if id.Name == "" || id.Name == "." || id.Name == ".." || strings.ContainsAny(id.Name, `/\`) {
+		return errors.Errorf("invalid key name %q", id.Name)
+	}
+	for _, t := range keyTypes {
+		if id.Type == t {
+			return nil
+		}
+	}
+	return errors.Errorf("%s is not a key type", id.Type)
+}
+
+// checkKey checks that raw is a key of the type of the id on the curve of psid
+func checkKey(id KeyID, raw []byte, psid *Psidentity) error {
+	if err := checkKeyID(id); err != nil {
+		return err
+	}
+	msg := artifactTypes[id.Type].payload()
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", id.Type)
+	}
+	if c, ok := msg.(interface{ GetCurveId() string }); ok {
+		return errors.WithMessage(psid.CheckCurve(c.GetCurveId()), id.Type)
+	}
+	return nil
+}
+
+func sortKeyIDs(ids []KeyID) {
+	sort.Slice(ids, func(a, b int) bool {
+		if ids[a].Name != ids[b].Name {
+			return ids[a].Name < ids[b].Name
+		}
+		return ids[a].Type < ids[b].Type
+	})
+}
+
+// isIssuerKeyPair tells whether the serialized issuer secret key is the secret key of the issuer public key
+func isIssuerKeyPair(iskBytes []byte, ipk *IssuerPublicKeyPS, psid *Psidentity) bool {
+	isk := &IssuerPrivateKeyPS{}
+	if err := proto.Unmarshal(iskBytes, isk); err != nil {
+		return false
+	}
+	X, err := psid.Translator.G1FromProto(ipk.GetX())
+	if err != nil {
+		return false
+	}
+	return psid.Curve.GenG1.Mul(psid.Curve.NewZrFromBytes(isk.GetX())).Equals(X)
+}
+
+// checkIssuerKeyPair checks the serialized issuer key pair of the name and returns its public key
+func checkIssuerKeyPair(name string, ipkBytes, iskBytes []byte, psid *Psidentity) (*IssuerPublicKeyPS, error) {
+	if err := checkKey(KeyID{name, psidentity.PsIdentityConfigIssuerPublicKey}, ipkBytes, psid); err != nil {
+		return nil, err
+	}
+	if err := checkKey(KeyID{name, psidentity.PsIdentityConfigIssuerSecretKey}, iskBytes, psid); err != nil {
+		return nil, err
+	}
+	ipk := &IssuerPublicKeyPS{}
+	if err := proto.Unmarshal(ipkBytes, ipk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	if !isIssuerKeyPair(iskBytes, ipk, psid) {
+		return nil, errors.Errorf("issuer secret key of %s is not the secret key of its public key", name)
+	}
+	return ipk, nil
+}
+
+// storedIssuerKey reads the issuer key pair of the name with get
+func storedIssuerKey(get func(KeyID) ([]byte, error), name string) (*IssuerKeyPS, error) {
+	ipkBytes, err := get(KeyID{name, psidentity.PsIdentityConfigIssuerPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	iskBytes, err := get(KeyID{name, psidentity.PsIdentityConfigIssuerSecretKey})
+	if err != nil {
+		return nil, err
+	}
+	key := &IssuerKeyPS{Ipk: &IssuerPublicKeyPS{}, Isk: &IssuerPrivateKeyPS{}}
+	if err := proto.Unmarshal(ipkBytes, key.Ipk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	if err := proto.Unmarshal(iskBytes, key.Isk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer secret key")
+	}
+	return key, nil
+}
+
+// storedUserKey reads the user key pair of the name with get
+func storedUserKey(get func(KeyID) ([]byte, error), name string) (*UserKey, error) {
+	upkBytes, err := get(KeyID{name, psidentity.PsIdentityConfigUserPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	uskBytes, err := get(KeyID{name, psidentity.PsIdentityConfigUserSecretKey})
+	if err != nil {
+		return nil, err
+	}
+	key := &UserKey{Upk: &UserPublicKey{}, Usk: &UserPrivateKey{}}
+	if err := proto.Unmarshal(upkBytes, key.Upk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user public key")
+	}
+	if err := proto.Unmarshal(uskBytes, key.Usk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user secret key")
+	}
+	return key, nil
+}
+
+// StoredIssuerPublicKey returns the issuer public key of the name in the store
+func StoredIssuerPublicKey(store KeyStore, name string) (*IssuerPublicKeyPS, error) {
+	raw, err := store.Get(KeyID{name, psidentity.PsIdentityConfigIssuerPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	ipk := &IssuerPublicKeyPS{}
+	return ipk, errors.Wrap(proto.Unmarshal(raw, ipk), "failed to unmarshal issuer public key")
+}
+
+// StoredUserPublicKey returns the user public key of the name in the store
+func StoredUserPublicKey(store KeyStore, name string) (*UserPublicKey, error) {
+	raw, err := store.Get(KeyID{name, psidentity.PsIdentityConfigUserPublicKey})
+	if err != nil {
+		return nil, err
+	}
+	upk := &UserPublicKey{}
+	return upk, errors.Wrap(proto.Unmarshal(raw, upk), "failed to unmarshal user public key")
+}
+
+// blindSign blind-signs with the issuer key pair of the name read with get, the key does not leave the function
+func blindSign(get func(KeyID) ([]byte, error), psid *Psidentity, name string, m *CredRequestPS, window *ValidityWindow, rng io.Reader) (*BlindCredential, error) {
+	key, err := storedIssuerKey(get, name)
+	if err != nil {
+		return nil, err
+	}
+	return newBlindCredentialPS(key, m, window, rng, psid.Translator, psid.Curve)
+}
+
+// aggregate aggregates with the user key pair of the name read with get, the key does not leave the function
+func aggregate(get func(KeyID) ([]byte, error), psid *Psidentity, name string, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+	key, err := storedUserKey(get, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > len(key.Usk.GetW()) {
+		return nil, errors.Errorf("user key aggregates at most %d credentials", len(key.Usk.GetW()))
+	}
+	return psid.NewAggregateCredential(key, ipk, messages, rng, psid.Translator)
+}
+
+// MemoryKeyStore is a KeyStore holding the keys in memory, for tests and short lived processes
+type MemoryKeyStore struct {
+	psid *Psidentity
+	mu   sync.RWMutex
+	keys map[KeyID][]byte
+}
+
+// NewMemoryKeyStore returns an empty MemoryKeyStore for keys on the curve of psid
+func NewMemoryKeyStore(psid *Psidentity) *MemoryKeyStore {
+	return &MemoryKeyStore{psid: psid, keys: map[KeyID][]byte{}}
+}
+
+// Get returns a copy of the key
+func (s *MemoryKeyStore) Get(id KeyID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	raw, ok := s.keys[id]
+	if !ok {
+		return nil, errors.Wrapf(ErrKeyNotFound, "%s/%s", id.Name, id.Type)
+	}
+	return append([]byte{}, raw...), nil
+}
+
+// Put stores a copy of the key
+func (s *MemoryKeyStore) Put(id KeyID, raw []byte) error {
+	if id.Type == psidentity.PsIdentityConfigIssuerSecretKey {
+		return errors.Errorf("issuer secret keys are stored with their public key by PutIssuerKey")
+	}
+	if err := checkKey(id, raw, s.psid); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = append([]byte{}, raw...)
+	return nil
+}
+
+// PutIssuerKey stores copies of the issuer key pair under one lock
+func (s *MemoryKeyStore) PutIssuerKey(name string, ipk, isk []byte) error {
+	if _, err := checkIssuerKeyPair(name, ipk, isk, s.psid); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[KeyID{name, psidentity.PsIdentityConfigIssuerPublicKey}] = append([]byte{}, ipk...)
+	s.keys[KeyID{name, psidentity.PsIdentityConfigIssuerSecretKey}] = append([]byte{}, isk...)
+	return nil
+}
+
+// List returns the ids of the keys in the store
+func (s *MemoryKeyStore) List() ([]KeyID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]KeyID, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sortKeyIDs(ids)
+	return ids, nil
+}
+
+// Delete removes the key
+func (s *MemoryKeyStore) Delete(id KeyID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[id]; !ok {
+		return errors.Wrapf(ErrKeyNotFound, "%s/%s", id.Name, id.Type)
+	}
+	delete(s.keys, id)
+	return nil
+}
+
+// BlindSign blind-signs a credential request with the issuer key pair of the name
+func (s *MemoryKeyStore) BlindSign(name string, m *CredRequestPS, window *ValidityWindow, rng io.Reader) (*BlindCredential, error) {
+	return blindSign(s.Get, s.psid, name, m, window, rng)
+}
+
+// Aggregate aggregates derived credentials with the user key pair of the name
+func (s *MemoryKeyStore) Aggregate(name string, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+	return aggregate(s.Get, s.psid, name, ipk, messages, rng)
+}
+
+// FileKeyStore is a KeyStore storing each key as an artifact in the envelope format, in the file
+// <Dir>/<name>/<type>. This is the layout of the issuer-key and user-key directories of the command line tool.
+// With a passphrase the secret keys are sealed at rest, see NewEncryptedFileKeyStore.
+type FileKeyStore struct {
+	Dir string
+
+	psid       *Psidentity
+	passphrase func() ([]byte, error)
+}
+
+// NewFileKeyStore returns a FileKeyStore in dir for keys on the curve of psid, which stores the secret keys in plaintext
+func NewFileKeyStore(dir string, psid *Psidentity) *FileKeyStore {
+	return &FileKeyStore{Dir: dir, psid: psid}
+}
+
+// NewEncryptedFileKeyStore returns a FileKeyStore in dir for keys on the curve of psid, which seals the secret keys
+// under a passphrase. passphrase is only called when a secret key is sealed or opened, so that it may prompt for it.
+func NewEncryptedFileKeyStore(dir string, psid *Psidentity, passphrase func() ([]byte, error)) *FileKeyStore {
+	return &FileKeyStore{Dir: dir, psid: psid, passphrase: passphrase}
+}
+
+func (s *FileKeyStore) path(id KeyID) (string, error) {
+	if err := checkKeyID(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, id.Name, id.Type), nil
+}
+
+// Get returns the key, sealed secret keys are opened with the passphrase
+func (s *FileKeyStore) Get(id KeyID) ([]byte, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(ErrKeyNotFound, "%s/%s", id.Name, id.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key %s", path)
+	}
+	env, err := s.psid.UnwrapArtifact(raw, id.Type, nil)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid key %s", path)
+	}
+	if !env.GetSealed() {
+		return env.GetPayload(), nil
+	}
+	if s.passphrase == nil {
+		return nil, errors.Errorf("key %s is sealed and the key store has no passphrase", path)
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := env.OpenPayload(passphrase)
+	return payload, errors.WithMessagef(err, "failed to open key %s", path)
+}
+
+// Put writes the key, secret keys are sealed if the store has a passphrase
+func (s *FileKeyStore) Put(id KeyID, raw []byte) error {
+	if id.Type == psidentity.PsIdentityConfigIssuerSecretKey {
+		return errors.Errorf("issuer secret keys are stored with their public key by PutIssuerKey")
+	}
+	if err := checkKey(id, raw, s.psid); err != nil {
+		return err
+	}
+	tmp, err := s.stage(id, nil, raw)
+	if err != nil {
+		return err
+	}
+	return s.commit(id, tmp)
+}
+
+// PutIssuerKey writes the issuer key pair, the secret key bound to the hash of the public key. Both keys are
+// written to temporary files first and then renamed in place, the secret key before the public key, so that
+// a failed write leaves the stored pair unchanged and a public key is never stored without its secret key.
+func (s *FileKeyStore) PutIssuerKey(name string, ipk, isk []byte) error {
+	key, err := checkIssuerKeyPair(name, ipk, isk, s.psid)
+	if err != nil {
+		return err
+	}
+	ipkID := KeyID{name, psidentity.PsIdentityConfigIssuerPublicKey}
+	iskID := KeyID{name, psidentity.PsIdentityConfigIssuerSecretKey}
+	ipkTmp, err := s.stage(ipkID, nil, ipk)
+	if err != nil {
+		return err
+	}
+	iskTmp, err := s.stage(iskID, key.GetHash(), isk)
+	if err != nil {
+		os.Remove(ipkTmp)
+		return err
+	}
+	if err := s.commit(iskID, iskTmp); err != nil {
+		os.Remove(ipkTmp)
+		return err
+	}
+	return s.commit(ipkID, ipkTmp)
+}
+
+// stage wraps the key, sealed if it is secret and the store has a passphrase, and writes it to a temporary file
+// next to the file of the key. commit renames the temporary file in place.
+func (s *FileKeyStore) stage(id KeyID, ipkHash, raw []byte) (string, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return "", err
+	}
+
+	var wrapped []byte
+	if IsSecretArtifact(id.Type) && s.passphrase != nil {
+		passphrase, err := s.passphrase()
+		if err != nil {
+			return "", err
+		}
+		wrapped, err = s.psid.WrapSealedArtifact(id.Type, ipkHash, raw, passphrase, rand.Reader)
+		if err != nil {
+			return "", err
+		}
+	} else if wrapped, err = s.psid.WrapArtifact(id.Type, ipkHash, raw); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+		return "", errors.Wrapf(err, "failed to create key directory %s", filepath.Dir(path))
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+id.Type+"-")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write key %s", path)
+	}
+	_, err = f.Write(wrapped)
+	if err == nil {
+		err = f.Chmod(0640)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrapf(err, "failed to write key %s", path)
+	}
+	return f.Name(), nil
+}
+
+func (s *FileKeyStore) commit(id KeyID, tmp string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, "failed to write key %s", path)
+	}
+	return nil
+}
+
+// List returns the ids of the keys in the directories of the store
+func (s *FileKeyStore) List() ([]KeyID, error) {
+	entries, err := ioutil.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key store %s", s.Dir)
+	}
+	var ids []KeyID
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		for _, t := range keyTypes {
+			if info, err := os.Stat(filepath.Join(s.Dir, entry.Name(), t)); err == nil && info.Mode().IsRegular() {
+				ids = append(ids, KeyID{entry.Name(), t})
+			}
+		}
+	}
+	sortKeyIDs(ids)
+	return ids, nil
+}
+
+// Delete removes the file of the key
+func (s *FileKeyStore) Delete(id KeyID) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return errors.Wrapf(ErrKeyNotFound, "%s/%s", id.Name, id.Type)
+	}
+	return errors.Wrapf(err, "failed to delete key %s", path)
+}
+
+// BlindSign blind-signs a credential request with the issuer key pair of the name
+func (s *FileKeyStore) BlindSign(name string, m *CredRequestPS, window *ValidityWindow, rng io.Reader) (*BlindCredential, error) {
+	return blindSign(s.Get, s.psid, name, m, window, rng)
+}
+
+// Aggregate aggregates derived credentials with the user key pair of the name
+func (s *FileKeyStore) Aggregate(name string, ipk *IssuerPublicKeyPS, messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+	return aggregate(s.Get, s.psid, name, ipk, messages, rng)
+}
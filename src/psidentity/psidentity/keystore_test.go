@@ -0,0 +1,116 @@
+package psidentity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+func TestKeyStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, tr)
+	require.NoError(t, err)
+	uskBytes, upkBytes, err := GenerateUserKeyPS(*psid, tr)
+	require.NoError(t, err)
+
+	passphrase := func() ([]byte, error) { return []byte("passphrase"), nil }
+	stores := map[string]KeyStore{
+		"memory":    NewMemoryKeyStore(psid),
+		"file":      NewFileKeyStore(filepath.Join(dir, "plain"), psid),
+		"encrypted": NewEncryptedFileKeyStore(filepath.Join(dir, "sealed"), psid, passphrase),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			issuer := KeyID{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerSecretKey}
+			_, err := store.Get(issuer)
+			require.Equal(t, ErrKeyNotFound, errors.Cause(err))
+
+			otherIsk, otherIpk, err := GenerateIssuerKeyPS(*psid, tr)
+			require.NoError(t, err)
+			require.Error(t, store.Put(issuer, iskBytes))
+			require.Error(t, store.PutIssuerKey(psidentity.PsIdentityDirIssuerKey, ipkBytes, otherIsk))
+			require.Error(t, store.PutIssuerKey(psidentity.PsIdentityDirIssuerKey, otherIpk, iskBytes))
+			_, err = store.Get(issuer)
+			require.Equal(t, ErrKeyNotFound, errors.Cause(err))
+			require.NoError(t, store.PutIssuerKey(psidentity.PsIdentityDirIssuerKey, ipkBytes, iskBytes))
+			require.NoError(t, store.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey}, upkBytes))
+			require.NoError(t, store.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey}, uskBytes))
+			require.Error(t, store.Put(KeyID{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigPrimaryCred}, iskBytes))
+			require.Error(t, store.Put(KeyID{"../escape", psidentity.PsIdentityConfigIssuerSecretKey}, iskBytes))
+			require.Error(t, store.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey}, []byte("not a key")))
+
+			raw, err := store.Get(issuer)
+			require.NoError(t, err)
+			require.Equal(t, iskBytes, raw)
+			ids, err := store.List()
+			require.NoError(t, err)
+			require.Len(t, ids, 4)
+			require.Equal(t, KeyID{psidentity.PsIdentityDirIssuerKey, psidentity.PsIdentityConfigIssuerPublicKey}, ids[0])
+
+			// a primary cred is issued and derived with the secret keys used inside the store
+			now := time.Now()
+			userAttrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+			window := &ValidityWindow{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+			attrs, err := AppendValidity(userAttrs, window.NotBefore, window.NotAfter)
+			require.NoError(t, err)
+			// the issuer assigns the validity window, the holder does not commit to it
+			_, err = GenerateUserPrimaryCredWithStore(userAttrs, nil, store, psidentity.PsIdentityDirIssuerKey, *psid, tr)
+			require.Error(t, err)
+			_, err = GenerateUserPrimaryCredWithStore(attrs, window, store, psidentity.PsIdentityDirIssuerKey, *psid, tr)
+			require.Error(t, err)
+			primaryBytes, err := GenerateUserPrimaryCredWithStore(userAttrs, window, store, psidentity.PsIdentityDirIssuerKey, *psid, tr)
+			require.NoError(t, err)
+			conf, primary := &user.UserPrimaryCred{}, &PrimaryCredential{}
+			require.NoError(t, proto.Unmarshal(primaryBytes, conf))
+			require.NoError(t, proto.Unmarshal(conf.PrimaryCred, primary))
+			ipk, err := StoredIssuerPublicKey(store, psidentity.PsIdentityDirIssuerKey)
+			require.NoError(t, err)
+			require.NoError(t, primary.VerifyPrimary(ipk, psid.Curve, tr))
+			require.Equal(t, attrs, primary.Attrs)
+
+			_, aggregateBytes, err := GenerateUserDeriveCredWithStore(attrs, primary, ipk, store, psidentity.PsIdentityDirUserKey, nil, *psid, tr)
+			require.NoError(t, err)
+			aggregateConf, aggregate := &user.UserAggregateCred{}, &AggregateCredential{}
+			require.NoError(t, proto.Unmarshal(aggregateBytes, aggregateConf))
+			require.NoError(t, proto.Unmarshal(aggregateConf.AggregateCred, aggregate))
+			upk, err := StoredUserPublicKey(store, psidentity.PsIdentityDirUserKey)
+			require.NoError(t, err)
+			require.NoError(t, aggregate.VerifyAggregate(upk, psid.Curve, tr))
+
+			require.NoError(t, store.Delete(issuer))
+			require.Equal(t, ErrKeyNotFound, errors.Cause(store.Delete(issuer)))
+			_, err = store.BlindSign(psidentity.PsIdentityDirIssuerKey, nil, nil, nil)
+			require.Equal(t, ErrKeyNotFound, errors.Cause(err))
+		})
+	}
+
+	// the encrypted store seals the secret keys only, they do not open without the passphrase
+	path := filepath.Join(dir, "sealed", psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey)
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	env, err := psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigUserSecretKey, nil)
+	require.NoError(t, err)
+	require.True(t, env.Sealed)
+	id := KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey}
+	_, err = NewFileKeyStore(filepath.Join(dir, "sealed"), psid).Get(id)
+	require.Error(t, err)
+	wrong := func() ([]byte, error) { return []byte("wrong"), nil }
+	_, err = NewEncryptedFileKeyStore(filepath.Join(dir, "sealed"), psid, wrong).Get(id)
+	require.Error(t, err)
+	_, err = NewFileKeyStore(filepath.Join(dir, "sealed"), psid).Get(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey})
+	require.NoError(t, err)
+}
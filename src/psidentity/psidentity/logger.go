@@ -0,0 +1,118 @@
+package psidentity
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// RedactedValue replaces the values of secret and credential fields in the log
+const RedactedValue = "[REDACTED]"
+
+// redactedKeys are the attribute keys whose values are secret keys or credential material
+var redactedKeys = map[string]bool{
+	"sk":             true,
+	"isk":            true,
+	"usk":            true,
+	"secret":         true,
+	"passphrase":     true,
+	"trapdoor":       true,
+	"attrs":          true,
+	"cred":           true,
+	"credential":     true,
+	"request":        true,
+	"blind_cred":     true,
+	"primary_cred":   true,
+	"derive_cred":    true,
+	"aggregate_cred": true,
+}
+
+// libraryLogger is the logger of the library, it discards the records until SetLogger is called
+var libraryLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	libraryLogger.Store(slog.New(slog.DiscardHandler))
+}
+
+// SetLogger sets the logger of the library, with its handler wrapped by NewRedactingHandler.
+// The library is silent until a logger is set, and again after SetLogger(nil).
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		libraryLogger.Store(slog.New(slog.DiscardHandler))
+		return
+	}
+	libraryLogger.Store(slog.New(NewRedactingHandler(l.Handler())))
+}
+
+// logger returns the logger of the library
+func logger() *slog.Logger {
+	return libraryLogger.Load()
+}
+
+// redactingHandler replaces the values of secret and credential fields before passing the records on
+type redactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler returns a handler passing the records to next with RedactedValue in place of the values
+// of the redactedKeys, of byte slices and of protobuf messages, which hold keys and credentials
+func NewRedactingHandler(next slog.Handler) slog.Handler {
+	if _, ok := next.(*redactingHandler); ok {
+		return next
+	}
+	return &redactingHandler{next: next}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr returns the attribute with RedactedValue in place of a secret or credential value
+func redactAttr(a slog.Attr) slog.Attr {
+	if redactedKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, RedactedValue)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch value := v.Any().(type) {
+		case []byte:
+			return slog.String(a.Key, fmt.Sprintf("%s %d bytes", RedactedValue, len(value)))
+		case proto.Message:
+			return slog.String(a.Key, RedactedValue)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
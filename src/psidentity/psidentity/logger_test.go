@@ -0,0 +1,55 @@
+package psidentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerRedaction(t *testing.T) {
+	require.False(t, logger().Enabled(context.Background(), slog.LevelError))
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	cred := &PrimaryCredential{Attrs: []string{"secret attribute"}}
+	logger().With("usk", "secret key").Info("record",
+		"ms", 3,
+		"passphrase", "hunter2",
+		"raw", []byte("credential bytes"),
+		"primary", cred,
+		slog.Group("nested", "isk", "issuer secret", "version", 2),
+	)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "record", record["msg"])
+	require.Equal(t, 3.0, record["ms"])
+	require.Equal(t, RedactedValue, record["usk"])
+	require.Equal(t, RedactedValue, record["passphrase"])
+	require.Equal(t, RedactedValue+" 16 bytes", record["raw"])
+	require.Equal(t, RedactedValue, record["primary"])
+	require.Equal(t, map[string]interface{}{"isk": RedactedValue, "version": 2.0}, record["nested"])
+	require.NotContains(t, buf.String(), "secret")
+
+	// the library logs the credentials of the protocol only in redacted form
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	buf.Reset()
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	require.NotEmpty(t, iskBytes)
+	require.NotEmpty(t, ipkBytes)
+	require.True(t, strings.Contains(buf.String(), "Generate Issuer key success!"))
+	require.Contains(t, buf.String(), `"attrs":"`+RedactedValue+`"`)
+
+	SetLogger(nil)
+	buf.Reset()
+	logger().Error("dropped")
+	require.Zero(t, buf.Len())
+}
@@ -0,0 +1,206 @@
+package psidentity
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The counters and histograms of the issuer, derive and verify code paths
+const (
+	MetricCredentialsIssued       = "psidentity_credentials_issued_total"
+	MetricCredRequestsRejected    = "psidentity_cred_requests_rejected_total"
+	MetricDeriveSeconds           = "psidentity_derive_seconds"
+	MetricVerifyPrimarySeconds    = "psidentity_verify_primary_seconds"
+	MetricVerifyPrimaryFailures   = "psidentity_verify_primary_failures_total"
+	MetricVerifyDeriveSeconds     = "psidentity_verify_derive_seconds"
+	MetricVerifyDeriveFailures    = "psidentity_verify_derive_failures_total"
+	MetricVerifyAggregateSeconds  = "psidentity_verify_aggregate_seconds"
+	MetricVerifyAggregateFailures = "psidentity_verify_aggregate_failures_total"
+	MetricRevocationChecks        = "psidentity_revocation_checks_total"
+	MetricRevocationCheckFailures = "psidentity_revocation_check_failures_total"
+)
+
+// metricHelp is the help text of the metrics in the Prometheus exposition
+var metricHelp = map[string]string{
+	MetricCredentialsIssued:       "Blind credentials signed by the issuer.",
+	MetricCredRequestsRejected:    "Credential requests rejected by the zero-knowledge proof verification.",
+	MetricDeriveSeconds:           "Latency of deriving a credential, including the verification of the primary credential.",
+	MetricVerifyPrimarySeconds:    "Latency of verifying a primary credential.",
+	MetricVerifyPrimaryFailures:   "Primary credentials that failed verification.",
+	MetricVerifyDeriveSeconds:     "Latency of verifying a derived credential.",
+	MetricVerifyDeriveFailures:    "Derived credentials that failed verification.",
+	MetricVerifyAggregateSeconds:  "Latency of verifying an aggregate credential.",
+	MetricVerifyAggregateFailures: "Aggregate credentials that failed verification.",
+	MetricRevocationChecks:        "Non-revocation proofs and accumulator witnesses checked.",
+	MetricRevocationCheckFailures: "Non-revocation proofs and accumulator witnesses that failed the check.",
+}
+
+// Metrics receives the counters and histograms of the library
+type Metrics interface {
+	// IncCounter adds one to the counter of the name
+	IncCounter(name string)
+	// ObserveHistogram records a value in the histogram of the name
+	ObserveHistogram(name string, value float64)
+}
+
+// noopMetrics drops everything, it is the default of the library
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(string)                {}
+func (noopMetrics) ObserveHistogram(string, float64) {}
+
+// libraryMetrics holds the Metrics of the library in a metricsHolder, atomic.Value needs a single concrete type
+var libraryMetrics atomic.Value
+
+type metricsHolder struct {
+	Metrics
+}
+
+func init() {
+	libraryMetrics.Store(metricsHolder{noopMetrics{}})
+}
+
+// SetMetrics sets the metrics of the library, nil restores the default that drops everything
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = noopMetrics{}
+	}
+	libraryMetrics.Store(metricsHolder{m})
+}
+
+// metrics returns the metrics of the library
+func metrics() Metrics {
+	return libraryMetrics.Load().(metricsHolder).Metrics
+}
+
+// recordCheck records the latency of a check since start in the histogram and counts it in failures if it failed
+func recordCheck(histogram, failures string, start time.Time, err error) {
+	m := metrics()
+	m.ObserveHistogram(histogram, time.Since(start).Seconds())
+	if err != nil {
+		m.IncCounter(failures)
+	}
+}
+
+// recordRevocationCheck counts a revocation check and its failure
+func recordRevocationCheck(err error) {
+	m := metrics()
+	m.IncCounter(MetricRevocationChecks)
+	if err != nil {
+		m.IncCounter(MetricRevocationCheckFailures)
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the histogram buckets of a MetricsRegistry
+var DefaultLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// histogram counts the values up to each bucket bound, the last count is for +Inf
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// MetricsRegistry keeps the counters and histograms in memory and writes them in the Prometheus text
+// exposition format, it serves them over HTTP for the /metrics endpoint of a daemon
+type MetricsRegistry struct {
+	mu         sync.Mutex
+	buckets    []float64
+	counters   map[string]uint64
+	histograms map[string]*histogram
+}
+
+// NewMetricsRegistry returns an empty registry with the DefaultLatencyBuckets
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		buckets:    DefaultLatencyBuckets,
+		counters:   map[string]uint64{},
+		histograms: map[string]*histogram{},
+	}
+}
+
+func (r *MetricsRegistry) IncCounter(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[name]++
+}
+
+func (r *MetricsRegistry) ObserveHistogram(name string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets)+1)}
+		r.histograms[name] = h
+	}
+	b := sort.SearchFloat64s(r.buckets, value)
+	h.counts[b]++
+	h.sum += value
+	h.count++
+}
+
+// Counter returns the value of the counter of the name
+func (r *MetricsRegistry) Counter(name string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[name]
+}
+
+// WritePrometheus writes the counters and histograms in the Prometheus text exposition format, sorted by name
+func (r *MetricsRegistry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	header := func(name, kind string) {
+		if help, ok := metricHelp[name]; ok {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, help)
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+	}
+	float := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	counters := make([]string, 0, len(r.counters))
+	for name := range r.counters {
+		counters = append(counters, name)
+	}
+	sort.Strings(counters)
+	histograms := make([]string, 0, len(r.histograms))
+	for name := range r.histograms {
+		histograms = append(histograms, name)
+	}
+	sort.Strings(histograms)
+
+	for _, name := range counters {
+		header(name, "counter")
+		fmt.Fprintf(bw, "%s %d\n", name, r.counters[name])
+	}
+	for _, name := range histograms {
+		h := r.histograms[name]
+		header(name, "histogram")
+		var cumulative uint64
+		for b, bound := range r.buckets {
+			cumulative += h.counts[b]
+			fmt.Fprintf(bw, "%s_bucket{le=\"%s\"} %d\n", name, float(bound), cumulative)
+		}
+		fmt.Fprintf(bw, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+		fmt.Fprintf(bw, "%s_sum %s\n", name, float(h.sum))
+		fmt.Fprintf(bw, "%s_count %d\n", name, h.count)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := r.WritePrometheus(w); err != nil {
+		logger().Warn("cannot write metrics", "err", err)
+	}
+}
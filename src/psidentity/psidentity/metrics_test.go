@@ -0,0 +1,110 @@
+package psidentity
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	r := NewMetricsRegistry()
+	r.IncCounter(MetricCredentialsIssued)
+	r.IncCounter(MetricCredentialsIssued)
+	r.IncCounter("custom_total")
+	r.ObserveHistogram(MetricDeriveSeconds, 0.003)
+	r.ObserveHistogram(MetricDeriveSeconds, 0.01)
+	r.ObserveHistogram(MetricDeriveSeconds, 7)
+	require.Equal(t, uint64(2), r.Counter(MetricCredentialsIssued))
+	require.Zero(t, r.Counter(MetricCredRequestsRejected))
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WritePrometheus(&buf))
+	out := buf.String()
+	require.True(t, strings.Index(out, "custom_total 1\n") < strings.Index(out, MetricCredentialsIssued+" 2\n"))
+	require.Contains(t, out, "# HELP "+MetricCredentialsIssued+" "+metricHelp[MetricCredentialsIssued]+"\n")
+	require.Contains(t, out, "# TYPE "+MetricCredentialsIssued+" counter\n")
+	require.NotContains(t, out, "# HELP custom_total")
+	require.Contains(t, out, "# TYPE "+MetricDeriveSeconds+" histogram\n")
+	require.Contains(t, out, MetricDeriveSeconds+`_bucket{le="0.0025"} 0`+"\n")
+	require.Contains(t, out, MetricDeriveSeconds+`_bucket{le="0.005"} 1`+"\n")
+	// a value on a bound is counted in its bucket
+	require.Contains(t, out, MetricDeriveSeconds+`_bucket{le="0.01"} 2`+"\n")
+	require.Contains(t, out, MetricDeriveSeconds+`_bucket{le="5"} 2`+"\n")
+	require.Contains(t, out, MetricDeriveSeconds+`_bucket{le="+Inf"} 3`+"\n")
+	require.Contains(t, out, MetricDeriveSeconds+"_sum 7.013\n")
+	require.Contains(t, out, MetricDeriveSeconds+"_count 3\n")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, out, rec.Body.String())
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+}
+
+func TestLibraryMetrics(t *testing.T) {
+	require.Equal(t, noopMetrics{}, metrics())
+	r := NewMetricsRegistry()
+	SetMetrics(r)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	for _, step := range psid.benchSteps(BenchConfig{Attributes: 4, Messages: 2}, rng) {
+		_, err := step.run()
+		require.NoError(t, err, step.op)
+	}
+	require.Equal(t, uint64(1), r.Counter(MetricCredentialsIssued))
+	require.Zero(t, r.Counter(MetricCredRequestsRejected))
+	require.Zero(t, r.Counter(MetricVerifyDeriveFailures))
+	require.Zero(t, r.Counter(MetricVerifyAggregateFailures))
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WritePrometheus(&buf))
+	for _, name := range []string{MetricDeriveSeconds, MetricVerifyPrimarySeconds, MetricVerifyDeriveSeconds, MetricVerifyAggregateSeconds} {
+		require.Contains(t, buf.String(), "# TYPE "+name+" histogram\n")
+	}
+
+	// a request with the challenge of another request is rejected
+	attrs := []string{"a", "b"}
+	key, err := psid.NewIssuerKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	req, _, err := psid.NewCredRequestPS(attrs, key.Ipk, rng, tr)
+	require.NoError(t, err)
+	other, _, err := psid.NewCredRequestPS(attrs, key.Ipk, rng, tr)
+	require.NoError(t, err)
+	tampered := proto.Clone(req).(*CredRequestPS)
+	tampered.Challenge = other.Challenge
+	_, err = psid.NewBlindCredential(key, tampered, rng, tr)
+	require.Error(t, err)
+	require.Equal(t, uint64(1), r.Counter(MetricCredRequestsRejected))
+	require.Equal(t, uint64(1), r.Counter(MetricCredentialsIssued))
+
+	// a derived cred without a non-revocation proof fails the revocation check
+	primary := issueTestCredential(t, psid, key, attrs, rng)
+	require.Equal(t, uint64(2), r.Counter(MetricCredentialsIssued))
+	derived, err := psid.NewDeriveCredential(attrs, key, primary, []int{1, 0}, rng, tr)
+	require.NoError(t, err)
+	require.Error(t, derived.VerifyNonRevocation(key.Ipk, nil, 0, 1, psid.Curve, tr))
+	require.Equal(t, uint64(1), r.Counter(MetricRevocationChecks))
+	require.Equal(t, uint64(1), r.Counter(MetricRevocationCheckFailures))
+
+	// a tampered derived cred fails the derive verification
+	tamperedDerived := proto.Clone(derived).(*DeriveCredential)
+	tamperedDerived.DiscloseMsg[0] = "c"
+	require.Error(t, tamperedDerived.VerifyDerive(key.Ipk, psid.Curve, tr))
+	require.Equal(t, uint64(1), r.Counter(MetricVerifyDeriveFailures))
+
+	// nothing is recorded once the registry is removed
+	SetMetrics(nil)
+	require.Equal(t, noopMetrics{}, metrics())
+	_, err = psid.NewBlindCredential(key, req, rng, tr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), r.Counter(MetricCredentialsIssued))
+}
@@ -0,0 +1,406 @@
+package psidentity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	psidentity "psidentity"
+)
+
+// nonRevocationLabel is the label used in the zero-knowledge proof of non-revocation for an epoch
+const nonRevocationLabel = "epochNonRevocation"
+
+// accNonRevocationLabel is the label used in the zero-knowledge proof that the revocation handle is a member
+// of the pairing accumulator
+const accNonRevocationLabel = "accumulatorNonRevocation"
+
+// NonRevocationPolicy is the non-revocation the verifiers of a Psidentity require from every derived credential:
+// a proof for Epoch, with the epoch key signed by the long term revocation key RevocationPk, that the revocation
+// handle hidden at HandleIndex is not revoked. With an AccumulatorCRI, the CRI of Epoch created with
+// CreateAccumulatorCRI, the proof is that the handle is a member of the pairing accumulator of the CRI.
+type NonRevocationPolicy struct {
+	RevocationPk   *ecdsa.PublicKey
+	Epoch          int
+	HandleIndex    int
+	AccumulatorCRI *CredentialRevocationInformation
+}
+
+// verifyNonRevocation verifies the non-revocation proof of the derived credential if the Psidentity has a NonRevocationPolicy
+func (i *Psidentity) verifyNonRevocation(cred *DeriveCredential, ipk *IssuerPublicKeyPS) error {
+	p := i.NonRevocation
+	if p == nil {
+		return nil
+	}
+	if p.AccumulatorCRI != nil {
+		if p.AccumulatorCRI.GetEpoch() != int64(p.Epoch) {
+			return errors.Errorf("accumulator CRI is for epoch %d, current epoch is %d", p.AccumulatorCRI.GetEpoch(), p.Epoch)
+		}
+		return cred.VerifyAccumulatorNonRevocation(ipk, p.RevocationPk, p.AccumulatorCRI, p.HandleIndex, i.Curve, i.Translator)
+	}
+	return cred.VerifyNonRevocation(ipk, p.RevocationPk, p.Epoch, p.HandleIndex, i.Curve, i.Translator)
+}
+
+// RevocationHandle maps the value of the revocation handle attribute to the handle the revocation authority signs
+func (i *Psidentity) RevocationHandle(attr string) *math.Zr {
+	return revocationHandle(attr, i.Curve)
+}
+
+func revocationHandle(attr string, curve *math.Curve) *math.Zr {
+	rh := curve.NewZrFromBytes([]byte(attr))
+	rh.Mod(curve.GroupOrder)
+	return rh
+}
+
+// createEpochRevocationData signs every unrevoked handle with the epoch key
+func createEpochRevocationData(epochSk *math.Zr, unrevokedHandles []*math.Zr, curve *math.Curve, t Translator) ([]byte, error) {
+	data := &EpochRevocationData{
+		Credentials: make([]*EpochNonRevocationCredential, len(unrevokedHandles)),
+	}
+	for j, rh := range unrevokedHandles {
+		rh = rh.Copy()
+		rh.Mod(curve.GroupOrder)
+		data.Credentials[j] = &EpochNonRevocationCredential{
+			Handle: rh.Bytes(),
+			Sig:    t.G1ToProto(wbbSign(curve, epochSk, rh)),
+		}
+	}
+	return proto.Marshal(data)
+}
+
+// NonRevocationCredential looks up the non-revocation material of a revocation handle in a CRI with ALG_EPOCH_SIGNATURE.
+// An error is returned when the handle is revoked in the epoch of the CRI.
+func (cri *CredentialRevocationInformation) NonRevocationCredential(rh *math.Zr, curve *math.Curve, t Translator) (*math.G1, error) {
+	if cri.GetRevocationAlg() != int32(psidentity.ALG_EPOCH_SIGNATURE) {
+		return nil, errors.Errorf("CRI does not use epoch signatures")
+	}
+	data := &EpochRevocationData{}
+	if err := proto.Unmarshal(cri.GetRevocationData(), data); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal epoch revocation data")
+	}
+	handle := rh.Copy()
+	handle.Mod(curve.GroupOrder)
+	for _, nrc := range data.GetCredentials() {
+		if !bytes.Equal(nrc.GetHandle(), handle.Bytes()) {
+			continue
+		}
+		sig, err := t.G1FromProto(nrc.GetSig())
+		if err != nil {
+			return nil, err
+		}
+		epochPk, err := t.G2FromProto(cri.GetEpochPk())
+		if err != nil {
+			return nil, err
+		}
+		if err := wbbVerify(curve, epochPk, sig, handle); err != nil {
+			return nil, errors.Wrap(err, "non-revocation credential is invalid")
+		}
+		return sig, nil
+	}
+	return nil, errors.Errorf("revocation handle is revoked in epoch %d", cri.GetEpoch())
+}
+
+// NewDeriveCredentialWithNonRevocation derives a credential like NewDeriveCredential and adds a proof that
+// the revocation handle, the hidden attribute at rhIndex, is not revoked in the epoch of the CRI.
+// A hidden validity window is proven to cover the current time, like with NewDeriveCredentialWithValidity.
+func (i *Psidentity) NewDeriveCredentialWithNonRevocation(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, rhIndex int, cri *CredentialRevocationInformation, rng io.Reader, tr Translator) (*DeriveCredential, error) {
+	return newDeriveCredentialWithNonRevocation(Attrs, key, m, Mask, rhIndex, cri, rng, tr, i.Curve)
+}
+
+func newDeriveCredentialWithNonRevocation(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, rhIndex int, cri *CredentialRevocationInformation, rng io.Reader, tr Translator, curve *math.Curve) (*DeriveCredential, error) {
+	if err := checkNonRevocationMask(Attrs, Mask, rhIndex, cri); err != nil {
+		return nil, err
+	}
+	// the handle is looked up before deriving, a revoked handle is reported as such
+	if _, err := cri.NonRevocationCredential(revocationHandle(Attrs[rhIndex], curve), curve, tr); err != nil {
+		return nil, err
+	}
+	cred, t, err := deriveCredentialAt(Attrs, decodeIssuerKey(key.Ipk, curve, tr), m, Mask, time.Now(), rng)
+	if err != nil {
+		return nil, err
+	}
+	if err := addNonRevocationProof(cred, t, Attrs, key.Ipk, Mask, rhIndex, cri, rng, curve, tr); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// NewDeriveCredentialWithMembership derives a credential like NewDeriveCredential and adds a proof that the
+// revocation handle, the hidden attribute at rhIndex, is a member of the pairing accumulator of the CRI,
+// with the witness wit of the handle for the accumulator value of the CRI. A hidden validity window is proven
+// to cover the current time, like with NewDeriveCredentialWithValidity.
+func (i *Psidentity) NewDeriveCredentialWithMembership(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, rhIndex int, cri *CredentialRevocationInformation, wit *PairingAccumulatorWitness, rng io.Reader, tr Translator) (*DeriveCredential, error) {
+	return newDeriveCredentialWithMembership(Attrs, key, m, Mask, rhIndex, cri, wit, rng, tr, i.Curve)
+}
+
+func newDeriveCredentialWithMembership(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, rhIndex int, cri *CredentialRevocationInformation, wit *PairingAccumulatorWitness, rng io.Reader, tr Translator, curve *math.Curve) (*DeriveCredential, error) {
+	if err := checkNonRevocationMask(Attrs, Mask, rhIndex, cri); err != nil {
+		return nil, err
+	}
+	// the witness is checked before deriving, a revoked handle or a stale witness is reported as such
+	W, V, err := accumulatorWitness(Attrs, rhIndex, cri, wit, curve, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, t, err := deriveCredentialAt(Attrs, decodeIssuerKey(key.Ipk, curve, tr), m, Mask, time.Now(), rng)
+	if err != nil {
+		return nil, err
+	}
+	if err := addMembershipProof(cred, t, Attrs, key.Ipk, Mask, rhIndex, cri, W, V, accNonRevocationLabel, rng, curve, tr); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// accumulatorWitness checks that wit is the witness of the revocation handle at rhIndex for the pairing accumulator
+// of the CRI, and returns the witness W and the accumulator value V
+func accumulatorWitness(Attrs []string, rhIndex int, cri *CredentialRevocationInformation, wit *PairingAccumulatorWitness, curve *math.Curve, tr Translator) (*math.G1, *math.G1, error) {
+	if cri.GetRevocationAlg() != int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		return nil, nil, errors.Errorf("CRI does not use the pairing accumulator")
+	}
+	acc := &PairingAccumulator{}
+	if err := proto.Unmarshal(cri.GetRevocationData(), acc); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal accumulator")
+	}
+	rh := revocationHandle(Attrs[rhIndex], curve)
+	if wit == nil || !curve.NewZrFromBytes(wit.GetY()).Equals(rh) {
+		return nil, nil, errors.Errorf("the witness is not for the revocation handle")
+	}
+	if err := wit.Verify(acc, cri.GetEpochPk(), curve, tr); err != nil {
+		return nil, nil, errors.WithMessagef(err, "epoch %d", cri.GetEpoch())
+	}
+	W, err := tr.G1FromProto(wit.GetW())
+	if err != nil {
+		return nil, nil, err
+	}
+	V, err := tr.G1FromProto(acc.GetV())
+	if err != nil {
+		return nil, nil, err
+	}
+	return W, V, nil
+}
+
+// NonRevocationWitness is what a holder proves the non-revocation of a derived credential with: the CRI of the
+// current epoch, with the epoch signature of every unrevoked handle or the pairing accumulator, and for the
+// latter the Witness of the revocation handle, the hidden attribute at HandleIndex
+type NonRevocationWitness struct {
+	CRI         *CredentialRevocationInformation
+	Witness     *PairingAccumulatorWitness
+	HandleIndex int
+}
+
+// addProof adds to the derived credential, with sigma_onep randomized by t, the proof of non-revocation for the CRI
+func (nr *NonRevocationWitness) addProof(cred *DeriveCredential, t *math.Zr, Attrs []string, ipk *IssuerPublicKeyPS, Mask []int, rng io.Reader, curve *math.Curve, tr Translator) error {
+	if err := checkNonRevocationMask(Attrs, Mask, nr.HandleIndex, nr.CRI); err != nil {
+		return err
+	}
+	switch nr.CRI.GetRevocationAlg() {
+	case int32(psidentity.ALG_PAIRING_ACCUMULATOR):
+		W, V, err := accumulatorWitness(Attrs, nr.HandleIndex, nr.CRI, nr.Witness, curve, tr)
+		if err != nil {
+			return err
+		}
+		return addMembershipProof(cred, t, Attrs, ipk, Mask, nr.HandleIndex, nr.CRI, W, V, accNonRevocationLabel, rng, curve, tr)
+	case int32(psidentity.ALG_EPOCH_SIGNATURE):
+		return addNonRevocationProof(cred, t, Attrs, ipk, Mask, nr.HandleIndex, nr.CRI, rng, curve, tr)
+	default:
+		return errors.Errorf("revocation algorithm %d not supported", nr.CRI.GetRevocationAlg())
+	}
+}
+
+// checkNonRevocationMask checks that the revocation handle at rhIndex is hidden by the mask
+func checkNonRevocationMask(Attrs []string, Mask []int, rhIndex int, cri *CredentialRevocationInformation) error {
+	if cri == nil {
+		return errors.Errorf("no CRI to prove non-revocation against")
+	}
+	if rhIndex < 0 || rhIndex >= len(Attrs) || rhIndex >= len(Mask) {
+		return errors.Errorf("revocation handle index %d out of range", rhIndex)
+	}
+	if Mask[rhIndex] != 0 {
+		return errors.Errorf("the revocation handle must not be disclosed")
+	}
+	return nil
+}
+
+// addNonRevocationProof adds to the derived credential, with sigma_onep randomized by t, the proof that the
+// revocation handle hidden at rhIndex is not revoked in the epoch of the CRI
+func addNonRevocationProof(cred *DeriveCredential, t *math.Zr, Attrs []string, ipk *IssuerPublicKeyPS, Mask []int, rhIndex int, cri *CredentialRevocationInformation, rng io.Reader, curve *math.Curve, tr Translator) error {
+	if err := checkNonRevocationMask(Attrs, Mask, rhIndex, cri); err != nil {
+		return err
+	}
+	rh := revocationHandle(Attrs[rhIndex], curve)
+	sig, err := cri.NonRevocationCredential(rh, curve, tr)
+	if err != nil {
+		return err
+	}
+	return addMembershipProof(cred, t, Attrs, ipk, Mask, rhIndex, cri, sig, curve.GenG1, nonRevocationLabel, rng, curve, tr)
+}
+
+// addMembershipProof adds to the derived credential the proof that the revocation handle hidden at rhIndex has
+// the witness W for V under the public key of the CRI, e(W, g_2^{rh} \cdot EpochPk) = e(V, g_2): the weak
+// Boneh-Boyen signature of the epoch with V = g_1, or the witness of the pairing accumulator V.
+// The proof shares the s-value of the handle with the proof of knowledge of the opening of sigma_onep.
+func addMembershipProof(cred *DeriveCredential, t *math.Zr, Attrs []string, ipk *IssuerPublicKeyPS, Mask []int, rhIndex int, cri *CredentialRevocationInformation, W, V *math.G1, label string, rng io.Reader, curve *math.Curve, tr Translator) error {
+	rh := revocationHandle(Attrs[rhIndex], curve)
+	HideIndices := hideIndices(Mask)
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return err
+	}
+
+	// the randomness of the revocation handle is shared with the proof of knowledge of the non-revocation credential
+	sc, err := newSigmaOnepCommitment(ipk, HideIndices, rng, curve, tr)
+	if err != nil {
+		return err
+	}
+	rRh := sc.rAttrs[hiddenPosition(HideIndices, rhIndex)]
+
+	nrc := newMembershipCommitment(W, V, rh, rRh, rng, curve)
+
+	proofC := nonRevocationChallenge(label, sigmaOnep, sc.T1, nrc.WPrime, nrc.VBar, nrc.T, V, cri.GetEpoch(), curve)
+
+	proof := &EpochNonRevocationProof{
+		WPrime:  tr.G1ToProto(nrc.WPrime),
+		VBar:    tr.G1ToProto(nrc.VBar),
+		ProofC:  proofC.Bytes(),
+		ProofSR: nrc.respond(proofC, curve).Bytes(),
+	}
+	proof.ProofST, proof.ProofSAttrs = sc.respond(proofC, t, ipk, Attrs, HideIndices, curve)
+
+	proofBytes, err := proto.Marshal(proof)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal non-revocation proof")
+	}
+
+	cred.Epoch = cri.GetEpoch()
+	cred.RevocationEpochPk = cri.GetEpochPk()
+	cred.RevocationPkSig = cri.GetEpochPkSig()
+	cred.NonRevocationProof = &NonRevocationProof{
+		RevocationAlg:      cri.GetRevocationAlg(),
+		NonRevocationProof: proofBytes,
+	}
+	return nil
+}
+
+// nonRevocationChallenge computes the Fiat-Shamir challenge of the non-revocation proof with the label of its algorithm
+func nonRevocationChallenge(label string, sigmaOnep, T1, WPrime, VBar, T, V *math.G1, epoch int64, curve *math.Curve) *math.Zr {
+	proofData := make([]byte, len([]byte(label))+2*curve.G1ByteSize+membershipProofDataSize(curve)+curve.ScalarByteSize)
+	index := appendBytesString(proofData, 0, label)
+	index = appendBytesG1(proofData, index, sigmaOnep)
+	index = appendBytesG1(proofData, index, T1)
+	index = appendMembershipProofData(proofData, index, WPrime, VBar, T, V)
+	appendBytesBig(proofData, index, curve.NewZrFromInt(epoch))
+	return curve.HashToZr(proofData)
+}
+
+// VerifyNonRevocation verifies that the derived credential proves non-revocation in the given (current) epoch.
+// The epoch key is checked to be signed by the revocation authority with VerifyEpochPK,
+// and the proof to be made for the revocation handle hidden at rhIndex.
+func (cred *DeriveCredential) VerifyNonRevocation(ipk *IssuerPublicKeyPS, revPk *ecdsa.PublicKey, epoch int, rhIndex int, curve *math.Curve, tr Translator) (err error) {
+	defer func() { recordRevocationCheck(err) }()
+	if cred.GetNonRevocationProof() == nil {
+		return errors.Errorf("credential has no non-revocation proof")
+	}
+	if cred.GetNonRevocationProof().GetRevocationAlg() != int32(psidentity.ALG_EPOCH_SIGNATURE) {
+		return errors.Errorf("revocation algorithm %d not supported", cred.GetNonRevocationProof().GetRevocationAlg())
+	}
+	if cred.GetEpoch() != int64(epoch) {
+		return errors.Errorf("non-revocation proof is for epoch %d, current epoch is %d", cred.GetEpoch(), epoch)
+	}
+	err = verifyEpochPK(revPk, cred.GetRevocationEpochPk(), cred.GetRevocationPkSig(), epoch, psidentity.ALG_EPOCH_SIGNATURE)
+	if err != nil {
+		return err
+	}
+	epochPk, err := tr.G2FromProto(cred.GetRevocationEpochPk())
+	if err != nil {
+		return err
+	}
+	return cred.verifyMembershipProof(ipk, rhIndex, epochPk, curve.GenG1, nonRevocationLabel, curve, tr)
+}
+
+// VerifyAccumulatorNonRevocation verifies that the derived credential proves that its revocation handle, hidden at
+// rhIndex, is a member of the pairing accumulator of the CRI. The CRI is checked to be signed by the revocation
+// authority with VerifyAccumulatorCRI, and the credential to be derived for its epoch and accumulator public key.
+func (cred *DeriveCredential) VerifyAccumulatorNonRevocation(ipk *IssuerPublicKeyPS, revPk *ecdsa.PublicKey, cri *CredentialRevocationInformation, rhIndex int, curve *math.Curve, tr Translator) (err error) {
+	defer func() { recordRevocationCheck(err) }()
+	if cred.GetNonRevocationProof() == nil {
+		return errors.Errorf("credential has no non-revocation proof")
+	}
+	if cred.GetNonRevocationProof().GetRevocationAlg() != int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		return errors.Errorf("credential does not prove membership in the pairing accumulator")
+	}
+	acc, err := verifyAccumulatorCRI(revPk, cri)
+	if err != nil {
+		return err
+	}
+	if cred.GetEpoch() != cri.GetEpoch() {
+		return errors.Errorf("non-revocation proof is for epoch %d, current epoch is %d", cred.GetEpoch(), cri.GetEpoch())
+	}
+	if !proto.Equal(cred.GetRevocationEpochPk(), cri.GetEpochPk()) {
+		return errors.Errorf("non-revocation proof is for another accumulator key")
+	}
+	Q, err := tr.G2FromProto(cri.GetEpochPk())
+	if err != nil {
+		return err
+	}
+	V, err := tr.G1FromProto(acc.GetV())
+	if err != nil {
+		return err
+	}
+	return cred.verifyMembershipProof(ipk, rhIndex, Q, V, accNonRevocationLabel, curve, tr)
+}
+
+// verifyMembershipProof verifies the proof of addMembershipProof for the public key Q of the CRI and the value V
+func (cred *DeriveCredential) verifyMembershipProof(ipk *IssuerPublicKeyPS, rhIndex int, Q *math.G2, V *math.G1, label string, curve *math.Curve, tr Translator) error {
+	proof := &EpochNonRevocationProof{}
+	if err := proto.Unmarshal(cred.GetNonRevocationProof().GetNonRevocationProof(), proof); err != nil {
+		return errors.Wrap(err, "failed to unmarshal non-revocation proof")
+	}
+
+	HideIndices := cred.hiddenIndices()
+	rhPosition := hiddenPosition(HideIndices, rhIndex)
+	if rhPosition < 0 {
+		return errors.Errorf("the revocation handle at index %d is not hidden", rhIndex)
+	}
+	if proof.GetProofC() == nil || proof.GetProofSR() == nil {
+		return errors.Errorf("one of the proof values is undefined")
+	}
+
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return err
+	}
+	WPrime, err := tr.G1FromProto(proof.GetWPrime())
+	if err != nil {
+		return err
+	}
+	VBar, err := tr.G1FromProto(proof.GetVBar())
+	if err != nil {
+		return err
+	}
+
+	if err := checkMembershipPairing(WPrime, VBar, Q, curve); err != nil {
+		return errors.Wrap(err, "non-revocation credential")
+	}
+
+	proofC := curve.NewZrFromBytes(proof.GetProofC())
+
+	T1, err := recomputeSigmaOnepT(ipk, HideIndices, sigmaOnep, proofC, proof.GetProofST(), proof.GetProofSAttrs(), curve, tr)
+	if err != nil {
+		return errors.Wrap(err, "non-revocation proof")
+	}
+	sRh := curve.NewZrFromBytes(proof.GetProofSAttrs()[rhPosition])
+
+	T := recomputeMembershipT(WPrime, VBar, V, proofC, sRh, curve.NewZrFromBytes(proof.GetProofSR()), curve)
+
+	if !proofC.Equals(nonRevocationChallenge(label, sigmaOnep, T1, WPrime, VBar, T, V, cred.GetEpoch(), curve)) {
+		return errors.Errorf("non-revocation proof is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package psidentity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestEpochNonRevocation(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, "handle-1"}
+	mask := []int{1, 0, 1, 0}
+	rhIndex := 3
+	key, primary := newTestCredential(t, psid, attrs, rng)
+
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	unrevoked := []*math.Zr{psid.RevocationHandle("handle-0"), psid.RevocationHandle(attrs[rhIndex])}
+	cri, err := psid.CreateCRI(revKey, unrevoked, 2, psidentity.ALG_EPOCH_SIGNATURE, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, psid.VerifyEpochPK(&revKey.PublicKey, cri.EpochPk, cri.EpochPkSig, 2, psidentity.ALG_EPOCH_SIGNATURE))
+
+	cred, err := psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, mask, rhIndex, cri, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 2, rhIndex, curve, tr))
+
+	// the proof is only valid in its epoch and for the hidden revocation handle
+	require.Error(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 3, rhIndex, curve, tr))
+	require.Error(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 2, 1, curve, tr))
+
+	// a disclosed revocation handle is refused
+	_, err = psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, []int{1, 0, 1, 1}, rhIndex, cri, rng, tr)
+	require.Error(t, err)
+
+	// in the next epoch the handle is revoked
+	next, err := psid.CreateCRI(revKey, unrevoked[:1], 3, psidentity.ALG_EPOCH_SIGNATURE, rng, tr)
+	require.NoError(t, err)
+	_, err = psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, mask, rhIndex, next, rng, tr)
+	require.Error(t, err)
+
+	// a credential whose handle was never in the CRI cannot prove its non-revocation
+	otherAttrs := append(append([]string{}, attrs[:rhIndex]...), "handle-2")
+	otherPrimary := issueTestCredential(t, psid, key, otherAttrs, rng)
+	_, err = psid.NewDeriveCredentialWithNonRevocation(otherAttrs, key, otherPrimary, mask, rhIndex, cri, rng, tr)
+	require.Error(t, err)
+
+	// the proof is bound to the derived credential, the issuer key and the long term revocation key
+	otherCred, err := psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, mask, rhIndex, cri, rng, tr)
+	require.NoError(t, err)
+	for name, tamper := range map[string]func(*DeriveCredential){
+		"proof":        func(c *DeriveCredential) { c.NonRevocationProof = otherCred.NonRevocationProof },
+		"no proof":     func(c *DeriveCredential) { c.NonRevocationProof = nil },
+		"no signature": func(c *DeriveCredential) { c.RevocationPkSig = nil },
+		"sigma_onep":   func(c *DeriveCredential) { c.SigmaOnep = otherCred.SigmaOnep },
+	} {
+		tampered := proto.Clone(cred).(*DeriveCredential)
+		tamper(tampered)
+		require.Error(t, tampered.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 2, rhIndex, curve, tr), name)
+	}
+	otherKey, _ := newTestCredential(t, psid, attrs, rng)
+	require.Error(t, cred.VerifyNonRevocation(otherKey.Ipk, &revKey.PublicKey, 2, rhIndex, curve, tr))
+	otherRevKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	require.Error(t, cred.VerifyNonRevocation(key.Ipk, &otherRevKey.PublicKey, 2, rhIndex, curve, tr))
+
+	// the proof does not verify with the epoch key of another epoch
+	cred.RevocationEpochPk = next.EpochPk
+	cred.RevocationPkSig = next.EpochPkSig
+	require.Error(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 2, rhIndex, curve, tr))
+}
+
+func TestEpochNonRevocationValidity(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	now := time.Now()
+	attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, "handle-1"}, now.Add(-48*time.Hour), now.Add(48*time.Hour), rng)
+	rhIndex := 3
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	cri, err := psid.CreateCRI(revKey, []*math.Zr{psid.RevocationHandle(attrs[rhIndex])}, 1, psidentity.ALG_EPOCH_SIGNATURE, rng, tr)
+	require.NoError(t, err)
+
+	// the hidden validity window is proven alongside the non-revocation
+	cred, err := psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, []int{1, 0, 1, 0, 0, 0}, rhIndex, cri, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 1, rhIndex, curve, tr))
+	require.NoError(t, cred.VerifyValidity(key.Ipk, now, curve, tr))
+	require.Error(t, cred.VerifyValidity(key.Ipk, now.Add(96*time.Hour), curve, tr))
+}
+
+func TestNonRevocationPolicy(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, "handle-1"}
+	mask := []int{1, 0, 1, 0}
+	rhIndex := 3
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	key.Ipk.CurveId = CurveFP256BN_AMCL
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	cri, err := psid.CreateCRI(revKey, []*math.Zr{psid.RevocationHandle(attrs[rhIndex])}, 2, psidentity.ALG_EPOCH_SIGNATURE, rng, tr)
+	require.NoError(t, err)
+	withProof, err := psid.NewDeriveCredentialWithNonRevocation(attrs, key, primary, mask, rhIndex, cri, rng, tr)
+	require.NoError(t, err)
+	withoutProof, err := psid.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+	require.NoError(t, err)
+
+	verifiers := map[string]func(*DeriveCredential) error{
+		"encoded": func(cred *DeriveCredential) error {
+			raw, err := proto.Marshal(cred)
+			require.NoError(t, err)
+			return psid.VerifyDeriveEncoded(raw, key.Ipk)
+		},
+		"vc": func(cred *DeriveCredential) error {
+			vc, err := psid.DeriveCredentialToVC(cred, key.Ipk)
+			require.NoError(t, err)
+			return psid.VerifyVC(vc, key.Ipk, time.Now())
+		},
+		"aggregate": func(cred *DeriveCredential) error {
+			return psid.NewAggregateVerifier(0).VerifyMessages(context.Background(), decodeIssuerKey(key.Ipk, curve, tr), []*DeriveCredential{cred})
+		},
+	}
+	for name, verify := range verifiers {
+		// without a policy the proof is not required
+		psid.NonRevocation = nil
+		require.NoError(t, verify(withoutProof), name)
+
+		psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &revKey.PublicKey, Epoch: 2, HandleIndex: rhIndex}
+		require.NoError(t, verify(withProof), name)
+		require.Error(t, verify(withoutProof), name)
+		psid.NonRevocation.Epoch = 3
+		require.Error(t, verify(withProof), name)
+		psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &revKey.PublicKey, Epoch: 2, HandleIndex: 1}
+		require.Error(t, verify(withProof), name)
+	}
+}
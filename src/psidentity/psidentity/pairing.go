@@ -0,0 +1,20 @@
+package psidentity
+
+import (
+	math "github.com/IBM/mathlib"
+)
+
+// pairingsEqual checks e(a, b) = e(c, d) as the product e(a, b) \cdot e(c, d^{-1}) = 1, with both Miller loops
+// in one pass and one final exponentiation. Pairing normalizes its arguments in place, so it is computed on copies
+// and the shared generators may be passed.
+func pairingsEqual(curve *math.Curve, a *math.G2, b *math.G1, c *math.G2, d *math.G1) bool {
+	dInv := d.Copy()
+	dInv.Neg()
+	return curve.FExp(curve.Pairing2(a.Copy(), b.Copy(), c.Copy(), dInv)).IsUnity()
+}
+
+// isG2Identity reports whether the point is the identity of G2, which satisfies the PS pairing equations
+// for any message and must be refused as the h of a signature
+func isG2Identity(curve *math.Curve, g *math.G2) bool {
+	return g.Equals(curve.NewG2())
+}
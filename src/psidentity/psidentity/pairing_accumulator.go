@@ -0,0 +1,353 @@
+package psidentity
+
+import (
+	"io"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+	amcl "psidentity/translator/amcl"
+)
+
+// accMembershipLabel is the label used in zero-knowledge proof (ZKP) to identify that this ZKP is an accumulator membership proof
+const accMembershipLabel = "accMembership"
+
+/*
+	Pairing-based accumulator (Nguyen, "Accumulators from Bilinear Pairings and Applications", CT-RSA 2005),
+	in the variant of Vitto and Biryukov ("Dynamic Universal Accumulator with Batch Update over Bilinear Groups").
+
+	The accumulator lives in the same groups as the PS credentials:
+	V = P^{\prod (y_i + alpha)} in G1, public key Q = g_2^alpha in G2,
+	and a witness W = V^{1/(y + alpha)} for a member y satisfies e(W, g_2^y \cdot Q) = e(V, g_2).
+
+	Members are elements of Zr, so a revocation handle attribute can be accumulated as is
+	and the membership proof shares its response for y with the proof over the credential.
+*/
+
+// NewPairingAccumulator creates a new accumulator key and an empty accumulator
+func (i *Psidentity) NewPairingAccumulator(rng io.Reader, t Translator) (*PairingAccumulatorKey, *PairingAccumulator) {
+	return newPairingAccumulator(rng, i.Curve, t)
+}
+
+func newPairingAccumulator(rng io.Reader, curve *math.Curve, t Translator) (*PairingAccumulatorKey, *PairingAccumulator) {
+	alpha := curve.NewRandomZr(rng)
+	key := &PairingAccumulatorKey{
+		Alpha: alpha.Bytes(),
+		Q:     t.G2ToProto(curve.GenG2.Mul(alpha)),
+	}
+
+	// the empty accumulator is a random base P
+	P := curve.GenG1.Mul(curve.NewRandomZr(rng))
+	return key, &PairingAccumulator{V: t.G1ToProto(P)}
+}
+
+// SealPairingAccumulatorKey wraps the accumulator key in an envelope, with alpha sealed under the passphrase,
+// so that the manager reloads it with OpenPairingAccumulatorKey for later additions, deletions and witnesses
+func (i *Psidentity) SealPairingAccumulatorKey(key *PairingAccumulatorKey, passphrase []byte, rng io.Reader) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.Errorf("the accumulator key is sealed and requires a passphrase")
+	}
+	keyBytes, err := proto.Marshal(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal accumulator key")
+	}
+	return i.WrapSealedArtifact(psidentity.PsIdentityConfigPairingAccumulatorKey, nil, keyBytes, passphrase, rng)
+}
+
+// OpenPairingAccumulatorKey opens an accumulator key sealed with SealPairingAccumulatorKey
+// and checks that its public key Q is g_2^alpha
+func (i *Psidentity) OpenPairingAccumulatorKey(raw, passphrase []byte) (*PairingAccumulatorKey, error) {
+	env, err := i.UnwrapArtifact(raw, psidentity.PsIdentityConfigPairingAccumulatorKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !env.GetSealed() {
+		return nil, errors.Errorf("the accumulator key is not sealed")
+	}
+	keyBytes, err := env.OpenPayload(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key := &PairingAccumulatorKey{}
+	if err := proto.Unmarshal(keyBytes, key); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal accumulator key")
+	}
+	Q, err := i.Translator.G2FromProto(key.GetQ())
+	if err != nil {
+		return nil, err
+	}
+	if !Q.Equals(i.Curve.GenG2.Mul(i.Curve.NewZrFromBytes(key.GetAlpha()))) {
+		return nil, errors.Errorf("accumulator public key does not match alpha")
+	}
+	return key, nil
+}
+
+// WrapPairingAccumulator wraps the accumulator value in an envelope, the state the manager updates
+func (i *Psidentity) WrapPairingAccumulator(acc *PairingAccumulator) ([]byte, error) {
+	accBytes, err := proto.Marshal(acc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal accumulator")
+	}
+	return i.WrapArtifact(psidentity.PsIdentityConfigPairingAccumulator, nil, accBytes)
+}
+
+// UnwrapPairingAccumulator reads an accumulator value written with WrapPairingAccumulator
+func (i *Psidentity) UnwrapPairingAccumulator(raw []byte) (*PairingAccumulator, error) {
+	env, err := i.UnwrapArtifact(raw, psidentity.PsIdentityConfigPairingAccumulator, nil)
+	if err != nil {
+		return nil, err
+	}
+	acc := &PairingAccumulator{}
+	if err := proto.Unmarshal(env.GetPayload(), acc); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal accumulator")
+	}
+	if _, err := i.Translator.G1FromProto(acc.GetV()); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// Add adds y to the accumulator, V' = V^{y + alpha}
+func (acc *PairingAccumulator) Add(key *PairingAccumulatorKey, y *math.Zr, curve *math.Curve, t Translator) error {
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return err
+	}
+	exp := curve.ModAdd(y, curve.NewZrFromBytes(key.GetAlpha()), curve.GroupOrder)
+	acc.V = t.G1ToProto(V.Mul(exp))
+	return nil
+}
+
+// Delete removes y from the accumulator, V' = V^{1/(y + alpha)}
+func (acc *PairingAccumulator) Delete(key *PairingAccumulatorKey, y *math.Zr, curve *math.Curve, t Translator) error {
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return err
+	}
+	acc.V = t.G1ToProto(V.Mul(inverseShift(key, y, curve)))
+	return nil
+}
+
+// Witness issues a witness for the member y, W = V^{1/(y + alpha)}
+func (acc *PairingAccumulator) Witness(key *PairingAccumulatorKey, y *math.Zr, curve *math.Curve, t Translator) (*PairingAccumulatorWitness, error) {
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return nil, err
+	}
+	return &PairingAccumulatorWitness{
+		Y: y.Bytes(),
+		W: t.G1ToProto(V.Mul(inverseShift(key, y, curve))),
+	}, nil
+}
+
+// inverseShift computes 1/(y + alpha)
+func inverseShift(key *PairingAccumulatorKey, y *math.Zr, curve *math.Curve) *math.Zr {
+	exp := curve.ModAdd(y, curve.NewZrFromBytes(key.GetAlpha()), curve.GroupOrder)
+	exp.InvModP(curve.GroupOrder)
+	return exp
+}
+
+// Verify checks the witness against the accumulator value V and the accumulator public key Q,
+// e(W, g_2^y \cdot Q) = e(V, g_2)
+func (wit *PairingAccumulatorWitness) Verify(acc *PairingAccumulator, Q *amcl.ECP2, curve *math.Curve, t Translator) error {
+	W, err := t.G1FromProto(wit.GetW())
+	if err != nil {
+		return err
+	}
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return err
+	}
+	PkQ, err := t.G2FromProto(Q)
+	if err != nil {
+		return err
+	}
+
+	a := curve.GenG2.Mul(curve.NewZrFromBytes(wit.GetY()))
+	a.Add(PkQ)
+	a.Affine()
+
+	if !pairingsEqual(curve, a, W, curve.GenG2, V) {
+		return errors.Errorf("accumulator witness is not valid")
+	}
+	return nil
+}
+
+// UpdateOnAdd updates the witness after y' was added to the accumulator,
+// W' = V \cdot W^{y' - y}, where V is the accumulator value before the addition
+func (wit *PairingAccumulatorWitness) UpdateOnAdd(added *math.Zr, oldAcc *PairingAccumulator, curve *math.Curve, t Translator) error {
+	W, err := t.G1FromProto(wit.GetW())
+	if err != nil {
+		return err
+	}
+	V, err := t.G1FromProto(oldAcc.GetV())
+	if err != nil {
+		return err
+	}
+	V.Add(W.Mul(curve.ModSub(added, curve.NewZrFromBytes(wit.GetY()), curve.GroupOrder)))
+	wit.W = t.G1ToProto(V)
+	return nil
+}
+
+// UpdateOnDelete updates the witness after y' was removed from the accumulator,
+// W' = (W / V')^{1/(y' - y)}, where V' is the accumulator value after the deletion
+func (wit *PairingAccumulatorWitness) UpdateOnDelete(deleted *math.Zr, newAcc *PairingAccumulator, curve *math.Curve, t Translator) error {
+	exp := curve.ModSub(deleted, curve.NewZrFromBytes(wit.GetY()), curve.GroupOrder)
+	if exp.Equals(curve.NewZrFromInt(0)) {
+		return errors.Errorf("the member of this witness was deleted")
+	}
+	exp.InvModP(curve.GroupOrder)
+
+	W, err := t.G1FromProto(wit.GetW())
+	if err != nil {
+		return err
+	}
+	V, err := t.G1FromProto(newAcc.GetV())
+	if err != nil {
+		return err
+	}
+	W.Sub(V)
+	wit.W = t.G1ToProto(W.Mul(exp))
+	return nil
+}
+
+// A membership proof is a zero-knowledge proof of knowledge of y and W such that e(W, g_2^y \cdot Q) = e(V, g_2).
+// The prover randomizes the witness with r:
+// W' = W^r and VBar = W'^{-y} \cdot V^r, so that e(VBar, g_2) = e(W', Q),
+// and proves knowledge of (y, r) in VBar = W'^{-y} \cdot V^r.
+// When composed with a proof over a credential, the randomness for y is shared with the credential proof,
+// so that both s-values for y are equal.
+
+// membershipCommitment holds the prover state between the first message and the response
+type membershipCommitment struct {
+	r      *math.Zr
+	rR     *math.Zr
+	WPrime *math.G1
+	VBar   *math.G1
+	T      *math.G1
+}
+
+func newMembershipCommitment(W, V *math.G1, y, rY *math.Zr, rng io.Reader, curve *math.Curve) *membershipCommitment {
+	r := curve.NewRandomZr(rng)
+	rR := curve.NewRandomZr(rng)
+
+	WPrime := W.Mul(r)
+	VBar := WPrime.Mul2(curve.ModNeg(y, curve.GroupOrder), V, r) // VBar = W'^{-y} \cdot V^r
+	T := WPrime.Mul2(curve.ModNeg(rY, curve.GroupOrder), V, rR)  // T = W'^{-r_y} \cdot V^{r_r}, cover VBar
+
+	return &membershipCommitment{r: r, rR: rR, WPrime: WPrime, VBar: VBar, T: T}
+}
+
+// membershipProofDataSize is the number of bytes appendMembershipProofData adds to the challenge data
+func membershipProofDataSize(curve *math.Curve) int {
+	return len([]byte(accMembershipLabel)) + 4*curve.G1ByteSize
+}
+
+func appendMembershipProofData(data []byte, index int, WPrime, VBar, T, V *math.G1) int {
+	index = appendBytesString(data, index, accMembershipLabel)
+	index = appendBytesG1(data, index, WPrime)
+	index = appendBytesG1(data, index, VBar)
+	index = appendBytesG1(data, index, T)
+	index = appendBytesG1(data, index, V)
+	return index
+}
+
+// respond computes the s-value for r, s_r = r_r + C \cdot r
+func (m *membershipCommitment) respond(C *math.Zr, curve *math.Curve) *math.Zr {
+	return curve.ModAdd(m.rR, curve.ModMul(C, m.r, curve.GroupOrder), curve.GroupOrder)
+}
+
+// recomputeMembershipT recomputes the t-value from the s-values, T = W'^{-s_y} \cdot V^{s_r} \cdot VBar^{-C}
+func recomputeMembershipT(WPrime, VBar, V *math.G1, C, sY, sR *math.Zr, curve *math.Curve) *math.G1 {
+	T := WPrime.Mul2(curve.ModNeg(sY, curve.GroupOrder), V, sR)
+	T.Sub(VBar.Mul(C))
+	return T
+}
+
+// checkMembershipPairing checks e(VBar, g_2) = e(W', Q) for a non-trivial W'
+func checkMembershipPairing(WPrime, VBar *math.G1, Q *math.G2, curve *math.Curve) error {
+	if WPrime.IsInfinity() {
+		return errors.Errorf("randomized witness is the identity")
+	}
+	if !pairingsEqual(curve, curve.GenG2, VBar, Q, WPrime) {
+		return errors.Errorf("randomized witness is not valid for the accumulator")
+	}
+	return nil
+}
+
+// NewMembershipProof creates a standalone zero-knowledge proof that the holder of the witness is a member of the accumulator
+func (i *Psidentity) NewMembershipProof(wit *PairingAccumulatorWitness, acc *PairingAccumulator, nonce []byte, rng io.Reader, t Translator) (*AccumulatorMembershipProof, error) {
+	return newMembershipProof(wit, acc, nonce, rng, i.Curve, t)
+}
+
+func newMembershipProof(wit *PairingAccumulatorWitness, acc *PairingAccumulator, nonce []byte, rng io.Reader, curve *math.Curve, t Translator) (*AccumulatorMembershipProof, error) {
+	W, err := t.G1FromProto(wit.GetW())
+	if err != nil {
+		return nil, err
+	}
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return nil, err
+	}
+	y := curve.NewZrFromBytes(wit.GetY())
+	rY := curve.NewRandomZr(rng)
+
+	m := newMembershipCommitment(W, V, y, rY, rng, curve)
+
+	proofData := make([]byte, membershipProofDataSize(curve)+len(nonce))
+	index := appendMembershipProofData(proofData, 0, m.WPrime, m.VBar, m.T, V)
+	appendBytes(proofData, index, nonce)
+	proofC := curve.HashToZr(proofData)
+
+	proofSY := curve.ModAdd(rY, curve.ModMul(proofC, y, curve.GroupOrder), curve.GroupOrder) // s_y = r_y + C \cdot y
+
+	return &AccumulatorMembershipProof{
+		WPrime:  t.G1ToProto(m.WPrime),
+		VBar:    t.G1ToProto(m.VBar),
+		ProofC:  proofC.Bytes(),
+		ProofSY: proofSY.Bytes(),
+		ProofSR: m.respond(proofC, curve).Bytes(),
+		Nonce:   nonce,
+	}, nil
+}
+
+// Ver verifies a standalone membership proof against the accumulator and its public key Q
+func (proof *AccumulatorMembershipProof) Ver(acc *PairingAccumulator, Q *amcl.ECP2, curve *math.Curve, t Translator) (err error) {
+	defer func() { recordRevocationCheck(err) }()
+	WPrime, err := t.G1FromProto(proof.GetWPrime())
+	if err != nil {
+		return err
+	}
+	VBar, err := t.G1FromProto(proof.GetVBar())
+	if err != nil {
+		return err
+	}
+	V, err := t.G1FromProto(acc.GetV())
+	if err != nil {
+		return err
+	}
+	PkQ, err := t.G2FromProto(Q)
+	if err != nil {
+		return err
+	}
+	if proof.GetProofC() == nil || proof.GetProofSY() == nil || proof.GetProofSR() == nil {
+		return errors.Errorf("one of the proof values is undefined")
+	}
+
+	if err := checkMembershipPairing(WPrime, VBar, PkQ, curve); err != nil {
+		return err
+	}
+
+	proofC := curve.NewZrFromBytes(proof.GetProofC())
+	T := recomputeMembershipT(WPrime, VBar, V, proofC, curve.NewZrFromBytes(proof.GetProofSY()), curve.NewZrFromBytes(proof.GetProofSR()), curve)
+
+	proofData := make([]byte, membershipProofDataSize(curve)+len(proof.GetNonce()))
+	index := appendMembershipProofData(proofData, 0, WPrime, VBar, T, V)
+	appendBytes(proofData, index, proof.GetNonce())
+
+	if !proofC.Equals(curve.HashToZr(proofData)) {
+		return errors.Errorf("accumulator membership proof is invalid")
+	}
+	return nil
+}
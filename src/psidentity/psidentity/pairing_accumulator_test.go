@@ -0,0 +1,220 @@
+package psidentity
+
+import (
+	"testing"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+	amcl "psidentity/translator/amcl"
+)
+
+func TestPairingAccumulator(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	key, acc := psid.NewPairingAccumulator(rng, tr)
+	members := []*math.Zr{curve.NewRandomZr(rng), curve.NewRandomZr(rng), curve.NewRandomZr(rng)}
+	for _, y := range members {
+		require.NoError(t, acc.Add(key, y, curve, tr))
+	}
+
+	wit, err := acc.Witness(key, members[0], curve, tr)
+	require.NoError(t, err)
+	require.NoError(t, wit.Verify(acc, key.Q, curve, tr))
+
+	proof, err := psid.NewMembershipProof(wit, acc, []byte("nonce"), rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, proof.Ver(acc, key.Q, curve, tr))
+
+	// the proof does not verify for another nonce
+	proof.Nonce = []byte("other")
+	require.Error(t, proof.Ver(acc, key.Q, curve, tr))
+
+	// adding a member, the holder updates its witness without the secret key
+	oldAcc := &PairingAccumulator{V: acc.V}
+	added := curve.NewRandomZr(rng)
+	require.NoError(t, acc.Add(key, added, curve, tr))
+	require.Error(t, wit.Verify(acc, key.Q, curve, tr))
+	require.NoError(t, wit.UpdateOnAdd(added, oldAcc, curve, tr))
+	require.NoError(t, wit.Verify(acc, key.Q, curve, tr))
+
+	// deleting another member, the holder updates its witness without the secret key
+	require.NoError(t, acc.Delete(key, members[1], curve, tr))
+	require.NoError(t, wit.UpdateOnDelete(members[1], acc, curve, tr))
+	require.NoError(t, wit.Verify(acc, key.Q, curve, tr))
+
+	// a deleted member can no longer prove membership
+	deleted, err := acc.Witness(key, members[2], curve, tr)
+	require.NoError(t, err)
+	require.NoError(t, acc.Delete(key, members[2], curve, tr))
+	require.Error(t, deleted.Verify(acc, key.Q, curve, tr))
+	proof, err = psid.NewMembershipProof(deleted, acc, nil, rng, tr)
+	require.NoError(t, err)
+	require.Error(t, proof.Ver(acc, key.Q, curve, tr))
+}
+
+func TestAccumulatorCRI(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	key, acc := psid.NewPairingAccumulator(rng, tr)
+	require.NoError(t, acc.Add(key, curve.NewRandomZr(rng), curve, tr))
+
+	_, err = psid.CreateCRI(revKey, nil, 1, psidentity.ALG_PAIRING_ACCUMULATOR, rng, tr)
+	require.Error(t, err)
+
+	cri, err := psid.CreateAccumulatorCRI(revKey, key, acc, 1)
+	require.NoError(t, err)
+
+	published, err := psid.VerifyAccumulatorCRI(&revKey.PublicKey, cri)
+	require.NoError(t, err)
+	require.Equal(t, acc.V.X, published.V.X)
+	require.Equal(t, acc.V.Y, published.V.Y)
+
+	// the accumulator value is covered by the signature
+	cri.RevocationData = cri.RevocationData[:len(cri.RevocationData)-1]
+	_, err = psid.VerifyAccumulatorCRI(&revKey.PublicKey, cri)
+	require.Error(t, err)
+
+	noRevocation, err := psid.CreateCRI(revKey, nil, 1, psidentity.ALG_NO_REVOCATION, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, psid.VerifyEpochPK(&revKey.PublicKey, noRevocation.EpochPk, noRevocation.EpochPkSig, 1, psidentity.ALG_NO_REVOCATION))
+}
+
+func TestPairingAccumulatorPersistence(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+	passphrase := []byte("accumulator passphrase")
+
+	key, acc := psid.NewPairingAccumulator(rng, tr)
+	member := curve.NewRandomZr(rng)
+	require.NoError(t, acc.Add(key, member, curve, tr))
+	wit, err := acc.Witness(key, member, curve, tr)
+	require.NoError(t, err)
+
+	sealed, err := psid.SealPairingAccumulatorKey(key, passphrase, rng)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), string(key.Alpha))
+	state, err := psid.WrapPairingAccumulator(acc)
+	require.NoError(t, err)
+
+	// the manager reloads the key and the state for a later update
+	reloaded, err := psid.OpenPairingAccumulatorKey(sealed, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, key.Alpha, reloaded.Alpha)
+	oldAcc, err := psid.UnwrapPairingAccumulator(state)
+	require.NoError(t, err)
+	newAcc, err := psid.UnwrapPairingAccumulator(state)
+	require.NoError(t, err)
+	added := curve.NewRandomZr(rng)
+	require.NoError(t, newAcc.Add(reloaded, added, curve, tr))
+	require.NoError(t, wit.UpdateOnAdd(added, oldAcc, curve, tr))
+	require.NoError(t, wit.Verify(newAcc, reloaded.Q, curve, tr))
+
+	_, err = psid.SealPairingAccumulatorKey(key, nil, rng)
+	require.Error(t, err)
+	_, err = psid.OpenPairingAccumulatorKey(sealed, []byte("wrong passphrase"))
+	require.Error(t, err)
+	_, err = psid.OpenPairingAccumulatorKey(state, passphrase)
+	require.Error(t, err)
+
+	// a key whose public key does not match alpha is refused
+	forged := proto.Clone(key).(*PairingAccumulatorKey)
+	forged.Alpha = curve.NewRandomZr(rng).Bytes()
+	forgedSealed, err := psid.SealPairingAccumulatorKey(forged, passphrase, rng)
+	require.NoError(t, err)
+	_, err = psid.OpenPairingAccumulatorKey(forgedSealed, passphrase)
+	require.Error(t, err)
+}
+
+func TestAccumulatorNonRevocation(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, "handle-1"}
+	mask := []int{1, 0, 1, 0}
+	rhIndex := 3
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	otherKey, otherPrimary := newTestCredential(t, psid, []string{attrs[0], attrs[1], attrs[2], "handle-2"}, rng)
+
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	accKey, acc := psid.NewPairingAccumulator(rng, tr)
+	require.NoError(t, acc.Add(accKey, psid.RevocationHandle("handle-0"), curve, tr))
+	require.NoError(t, acc.Add(accKey, psid.RevocationHandle(attrs[rhIndex]), curve, tr))
+	wit, err := acc.Witness(accKey, psid.RevocationHandle(attrs[rhIndex]), curve, tr)
+	require.NoError(t, err)
+	cri, err := psid.CreateAccumulatorCRI(revKey, accKey, acc, 2)
+	require.NoError(t, err)
+
+	cred, err := psid.NewDeriveCredentialWithMembership(attrs, key, primary, mask, rhIndex, cri, wit, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, cred.VerifyDerive(key.Ipk, curve, tr))
+	require.NoError(t, cred.VerifyAccumulatorNonRevocation(key.Ipk, &revKey.PublicKey, cri, rhIndex, curve, tr))
+
+	// the proof is only valid for the hidden revocation handle, the issuer key of the credential,
+	// the accumulator CRI it was made for and not as an epoch signature proof
+	require.Error(t, cred.VerifyAccumulatorNonRevocation(key.Ipk, &revKey.PublicKey, cri, 1, curve, tr))
+	require.Error(t, cred.VerifyAccumulatorNonRevocation(otherKey.Ipk, &revKey.PublicKey, cri, rhIndex, curve, tr))
+	require.Error(t, cred.VerifyNonRevocation(key.Ipk, &revKey.PublicKey, 2, rhIndex, curve, tr))
+	otherRevKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	require.Error(t, cred.VerifyAccumulatorNonRevocation(key.Ipk, &otherRevKey.PublicKey, cri, rhIndex, curve, tr))
+
+	// the witness of another handle, a disclosed handle and an epoch CRI are refused
+	_, err = psid.NewDeriveCredentialWithMembership(attrs, key, primary, mask, rhIndex, cri, nil, rng, tr)
+	require.Error(t, err)
+	_, err = psid.NewDeriveCredentialWithMembership([]string{attrs[0], attrs[1], attrs[2], "handle-2"}, otherKey, otherPrimary, mask, rhIndex, cri, wit, rng, tr)
+	require.Error(t, err)
+	_, err = psid.NewDeriveCredentialWithMembership(attrs, key, primary, []int{1, 0, 1, 1}, rhIndex, cri, wit, rng, tr)
+	require.Error(t, err)
+	epochCRI, err := psid.CreateCRI(revKey, []*math.Zr{psid.RevocationHandle(attrs[rhIndex])}, 2, psidentity.ALG_EPOCH_SIGNATURE, rng, tr)
+	require.NoError(t, err)
+	_, err = psid.NewDeriveCredentialWithMembership(attrs, key, primary, mask, rhIndex, epochCRI, wit, rng, tr)
+	require.Error(t, err)
+
+	// the proof is tied to the credential: it does not verify on another derivation of it
+	rederived, err := psid.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+	require.NoError(t, err)
+	rederived.Epoch, rederived.RevocationEpochPk, rederived.RevocationPkSig = cred.Epoch, cred.RevocationEpochPk, cred.RevocationPkSig
+	rederived.NonRevocationProof = cred.NonRevocationProof
+	require.Error(t, rederived.VerifyAccumulatorNonRevocation(key.Ipk, &revKey.PublicKey, cri, rhIndex, curve, tr))
+
+	// after the handle is deleted, the proof does not verify against the CRI of the next epoch
+	// and the stale witness is refused
+	require.NoError(t, acc.Delete(accKey, psid.RevocationHandle(attrs[rhIndex]), curve, tr))
+	next, err := psid.CreateAccumulatorCRI(revKey, accKey, acc, 3)
+	require.NoError(t, err)
+	require.Error(t, cred.VerifyAccumulatorNonRevocation(key.Ipk, &revKey.PublicKey, next, rhIndex, curve, tr))
+	cred.Epoch = 3
+	require.Error(t, cred.VerifyAccumulatorNonRevocation(key.Ipk, &revKey.PublicKey, next, rhIndex, curve, tr))
+	_, err = psid.NewDeriveCredentialWithMembership(attrs, key, primary, mask, rhIndex, next, wit, rng, tr)
+	require.Error(t, err)
+	cred.Epoch = 2
+
+	// the verifiers require the membership proof with an accumulator CRI in their policy
+	raw, err := proto.Marshal(cred)
+	require.NoError(t, err)
+	psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &revKey.PublicKey, Epoch: 2, HandleIndex: rhIndex, AccumulatorCRI: cri}
+	require.NoError(t, psid.VerifyDeriveEncoded(raw, key.Ipk))
+	psid.NonRevocation.AccumulatorCRI = next
+	require.Error(t, psid.VerifyDeriveEncoded(raw, key.Ipk))
+	psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &revKey.PublicKey, Epoch: 2, HandleIndex: rhIndex}
+	require.Error(t, psid.VerifyDeriveEncoded(raw, key.Ipk))
+}
@@ -0,0 +1,31 @@
+package psidentity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairingsEqual(t *testing.T) {
+	for name := range curveIDs {
+		psid, err := NewPsidentityForCurve(name)
+		require.NoError(t, err)
+		curve := psid.Curve
+		rng, err := curve.Rand()
+		require.NoError(t, err)
+
+		// e(g_2^{ab}, g_1^c) = e(g_2^a, g_1^{bc})
+		a, b, c := curve.NewRandomZr(rng), curve.NewRandomZr(rng), curve.NewRandomZr(rng)
+		P, Q := curve.GenG2.Mul(curve.ModMul(a, b, curve.GroupOrder)), curve.GenG1.Mul(c)
+		R, S := curve.GenG2.Mul(a), curve.GenG1.Mul(curve.ModMul(b, c, curve.GroupOrder))
+		require.True(t, pairingsEqual(curve, P, Q, R, S), name)
+		require.True(t, curve.FExp(curve.Pairing(P, Q)).Equals(curve.FExp(curve.Pairing(R, S))), name)
+		require.False(t, pairingsEqual(curve, P, Q, R, Q), name)
+
+		// the arguments are left untouched
+		S2 := S.Copy()
+		require.True(t, pairingsEqual(curve, P, Q, R, S), name)
+		require.True(t, S.Equals(S2), name)
+		require.True(t, pairingsEqual(curve, curve.GenG2, curve.GenG1, curve.GenG2, curve.GenG1), name)
+	}
+}
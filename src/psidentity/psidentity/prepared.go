@@ -0,0 +1,403 @@
+package psidentity
+
+import (
+	"io"
+	"math/big"
+
+	math "github.com/IBM/mathlib"
+	"github.com/pkg/errors"
+)
+
+// fixedBaseWindow is the width in bits of the digits of a scalar in a fixed-base table
+const fixedBaseWindow = 4
+
+// fixedBaseCurves are the curves on which a fixed-base table is faster than the scalar multiplication of the
+// curve. The gurvy curves add points in affine coordinates, which makes the table lookups slower there.
+var fixedBaseCurves = map[string]bool{
+	CurveFP256BN_AMCL:        true,
+	CurveFP256BN_AMCL_MIRACL: true,
+	CurveBLS12_381:           true,
+}
+
+// scalarDigits returns the base 2^fixedBaseWindow digits of z reduced modulo the group order, least significant first
+func scalarDigits(z *math.Zr, windows int) []uint {
+	s := new(big.Int).SetBytes(z.Bytes())
+	digits := make([]uint, windows)
+	for k := range digits {
+		for b := 0; b < fixedBaseWindow; b++ {
+			digits[k] |= s.Bit(k*fixedBaseWindow+b) << uint(b)
+		}
+	}
+	return digits
+}
+
+// fixedBaseWindows returns the number of digits of the scalars of the curve
+func fixedBaseWindows(curve *math.Curve) int {
+	bits := new(big.Int).SetBytes(curve.GroupOrder.Bytes()).BitLen()
+	return (bits + fixedBaseWindow - 1) / fixedBaseWindow
+}
+
+// g1Table holds P^{d \cdot 2^{4k}} for every digit position k and digit d = 1..15 of a base P,
+// so that P^z is the product of one entry per nonzero digit of z. The entries read and the additions
+// skipped depend on the digits, so the tables are only used for public scalars.
+type g1Table [][]*math.G1
+
+func newG1Table(P *math.G1, curve *math.Curve) g1Table {
+	t := make(g1Table, fixedBaseWindows(curve))
+	base := P.Copy()
+	for k := range t {
+		t[k] = make([]*math.G1, 1<<fixedBaseWindow-1)
+		acc := base.Copy()
+		t[k][0] = acc.Copy()
+		for d := 1; d < len(t[k]); d++ {
+			acc.Add(base)
+			t[k][d] = acc.Copy()
+		}
+		acc.Add(base) // 2^{4(k+1)} P, the base of the next position
+		base = acc
+	}
+	return t
+}
+
+// mulAdd multiplies acc by P^z
+func (t g1Table) mulAdd(acc *math.G1, z *math.Zr) {
+	for k, d := range scalarDigits(z, len(t)) {
+		if d != 0 {
+			acc.Add(t[k][d-1])
+		}
+	}
+}
+
+// msmSpan returns the number of digits of the tables in a digit of the bucket method for a product of n powers
+// of bases with tables of windows digits, 1 if looking up every power in its table is cheaper. A lookup costs
+// an addition per nonzero digit, about n windows 15/16 in all. With digits of c table digits, the bucket method
+// costs an addition per digit, n windows / c, but for the first digit of every bucket, which is copied, and
+// 2 (2^{4c} - 1) to combine the buckets: about n windows / c + 2^{4c} - 1.
+func msmSpan(n, windows int) int {
+	best, span := n*windows*15/16, 1
+	for c := 2; c <= 3; c++ {
+		cost := n*((windows+c-1)/c) + 1<<uint(fixedBaseWindow*c) - 1
+		if cost < best {
+			best, span = cost, c
+		}
+	}
+	return span
+}
+
+// msmDigits returns the digits of z for the bucket method, span table digits each, least significant first
+func msmDigits(z *math.Zr, windows, span int) []uint {
+	digits := scalarDigits(z, windows)
+	wide := make([]uint, (windows+span-1)/span)
+	for k, d := range digits {
+		wide[k/span] |= d << uint(fixedBaseWindow*(k%span))
+	}
+	return wide
+}
+
+// g1MSM multiplies acc by \prod_i P_i^{z_i} for the bases P_i of the tables, with the bucket method of Pippenger.
+// The table entry t[span j][0] = P^{2^{4 span j}} is the base of the j-th digit, so that the powers of every base
+// and digit position go to the same buckets, B_d being the product of the bases of the digits d, and the product
+// is \prod_d B_d^d, computed with running products without doublings. As with mulAdd, the scalars are public.
+func g1MSM(acc *math.G1, tables []g1Table, scalars []*math.Zr, span int, curve *math.Curve) {
+	buckets := make([]*math.G1, 1<<uint(fixedBaseWindow*span)-1)
+	for i, t := range tables {
+		for j, d := range msmDigits(scalars[i], len(t), span) {
+			if d == 0 {
+				continue
+			}
+			if buckets[d-1] == nil {
+				buckets[d-1] = t[span*j][0].Copy()
+			} else {
+				buckets[d-1].Add(t[span*j][0])
+			}
+		}
+	}
+	running, sum := curve.NewG1(), curve.NewG1()
+	for d := len(buckets) - 1; d >= 0; d-- {
+		if buckets[d] != nil {
+			running.Add(buckets[d])
+		}
+		sum.Add(running)
+	}
+	acc.Add(sum)
+}
+
+// g2Table is the g1Table of a base in G2
+type g2Table [][]*math.G2
+
+func newG2Table(P *math.G2, curve *math.Curve) g2Table {
+	t := make(g2Table, fixedBaseWindows(curve))
+	base := P.Copy()
+	for k := range t {
+		t[k] = make([]*math.G2, 1<<fixedBaseWindow-1)
+		acc := base.Copy()
+		t[k][0] = acc.Copy()
+		for d := 1; d < len(t[k]); d++ {
+			acc.Add(base)
+			t[k][d] = acc.Copy()
+		}
+		acc.Add(base)
+		base = acc
+	}
+	return t
+}
+
+// mulAdd multiplies acc by P^z
+func (t g2Table) mulAdd(acc *math.G2, z *math.Zr) {
+	for k, d := range scalarDigits(z, len(t)) {
+		if d != 0 {
+			acc.Add(t[k][d-1])
+		}
+	}
+}
+
+// g2MSM is g1MSM in G2
+func g2MSM(acc *math.G2, tables []g2Table, scalars []*math.Zr, span int, curve *math.Curve) {
+	buckets := make([]*math.G2, 1<<uint(fixedBaseWindow*span)-1)
+	for i, t := range tables {
+		for j, d := range msmDigits(scalars[i], len(t), span) {
+			if d == 0 {
+				continue
+			}
+			if buckets[d-1] == nil {
+				buckets[d-1] = t[span*j][0].Copy()
+			} else {
+				buckets[d-1].Add(t[span*j][0])
+			}
+		}
+	}
+	running, sum := curve.NewG2(), curve.NewG2()
+	for d := len(buckets) - 1; d >= 0; d-- {
+		if buckets[d] != nil {
+			running.Add(buckets[d])
+		}
+		sum.Add(running)
+	}
+	acc.Add(sum)
+}
+
+// PreparedIssuerKey is an issuer public key decoded once for repeated issuance, derivation and verification.
+// On the fixedBaseCurves it holds fixed-base tables of g_1, g_2, Y_i and YBar_i, and products of powers of
+// the attribute bases with public scalars, as in the verifications, are computed from the tables, see yMulAdd. It is not modified after PrepareIssuerKey and
+// may be shared by goroutines.
+type PreparedIssuerKey struct {
+	Ipk *IssuerPublicKeyPS
+
+	curve *math.Curve
+	tr    Translator
+
+	// the decoded points, nil in the key of decodeIssuerKey
+	x    *math.G1
+	y    []*math.G1
+	yBar []*math.G2
+	zij  []*math.G1
+
+	// the fixed-base tables, nil on the other curves and without tables
+	g1Table    g1Table
+	g2Table    g2Table
+	yTables    []g1Table
+	yBarTables []g2Table
+}
+
+// PrepareIssuerKey decodes the issuer public key and computes its fixed-base tables
+func (i *Psidentity) PrepareIssuerKey(ipk *IssuerPublicKeyPS) (*PreparedIssuerKey, error) {
+	if err := i.CheckCurve(ipk.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	return prepareIssuerKey(ipk, i.Curve, i.Translator, true)
+}
+
+// prepareIssuerKey decodes the issuer public key, and computes its fixed-base tables if tables is set
+func prepareIssuerKey(ipk *IssuerPublicKeyPS, curve *math.Curve, tr Translator, tables bool) (*PreparedIssuerKey, error) {
+	pk := decodeIssuerKey(ipk, curve, tr)
+
+	var err error
+	if pk.x, err = tr.G1FromProto(ipk.GetX()); err != nil {
+		return nil, err
+	}
+	if len(ipk.GetYBar()) != len(ipk.GetY()) {
+		return nil, errors.Errorf("issuer key has %d Y and %d YBar", len(ipk.GetY()), len(ipk.GetYBar()))
+	}
+	pk.y = make([]*math.G1, len(ipk.GetY()))
+	pk.yBar = make([]*math.G2, len(ipk.GetYBar()))
+	for i := range pk.y {
+		if pk.y[i], err = tr.G1FromProto(ipk.GetY()[i]); err != nil {
+			return nil, err
+		}
+		if pk.yBar[i], err = tr.G2FromProto(ipk.GetYBar()[i]); err != nil {
+			return nil, err
+		}
+	}
+	pk.zij = make([]*math.G1, len(ipk.GetZIj()))
+	for i := range pk.zij {
+		if pk.zij[i], err = tr.G1FromProto(ipk.GetZIj()[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if !tables || !fixedBaseCurves[CurveName(curve)] {
+		return pk, nil
+	}
+	pk.g1Table = newG1Table(curve.GenG1, curve)
+	pk.g2Table = newG2Table(curve.GenG2, curve)
+	pk.yTables = make([]g1Table, len(pk.y))
+	pk.yBarTables = make([]g2Table, len(pk.yBar))
+	for i := range pk.y {
+		pk.yTables[i] = newG1Table(pk.y[i], curve)
+		pk.yBarTables[i] = newG2Table(pk.yBar[i], curve)
+	}
+	return pk, nil
+}
+
+// decodeIssuerKey returns the issuer public key for a single use, its points are decoded when they are needed
+func decodeIssuerKey(ipk *IssuerPublicKeyPS, curve *math.Curve, tr Translator) *PreparedIssuerKey {
+	return &PreparedIssuerKey{Ipk: ipk, curve: curve, tr: tr}
+}
+
+// X returns a copy of X
+func (pk *PreparedIssuerKey) X() (*math.G1, error) {
+	if pk.x != nil {
+		return pk.x.Copy(), nil
+	}
+	return pk.tr.G1FromProto(pk.Ipk.GetX())
+}
+
+// Y returns a copy of Y_i
+func (pk *PreparedIssuerKey) Y(i int) (*math.G1, error) {
+	if i < 0 || i >= len(pk.Ipk.GetY()) {
+		return nil, errors.Errorf("attribute index %d out of range", i)
+	}
+	if pk.y != nil {
+		return pk.y[i].Copy(), nil
+	}
+	return pk.tr.G1FromProto(pk.Ipk.GetY()[i])
+}
+
+// YBar returns a copy of YBar_i
+func (pk *PreparedIssuerKey) YBar(i int) (*math.G2, error) {
+	if i < 0 || i >= len(pk.Ipk.GetYBar()) {
+		return nil, errors.Errorf("attribute index %d out of range", i)
+	}
+	if pk.yBar != nil {
+		return pk.yBar[i].Copy(), nil
+	}
+	return pk.tr.G2FromProto(pk.Ipk.GetYBar()[i])
+}
+
+// Zij returns a copy of Z_ij = g_1^{y_i y_j}, i != j
+func (pk *PreparedIssuerKey) Zij(i, j int) (*math.G1, error) {
+	if pk.zij == nil {
+		return pk.Ipk.zij(i, j, pk.tr)
+	}
+	index, err := zijIndex(i, j, len(pk.y), len(pk.zij))
+	if err != nil {
+		return nil, err
+	}
+	return pk.zij[index].Copy(), nil
+}
+
+// secret returns the key without its fixed-base tables, for the products of powers with secret scalars
+// (blinding factors, randomizers, nonces and hidden attributes), whose table lookups would leak the digits
+func (pk *PreparedIssuerKey) secret() *PreparedIssuerKey {
+	return &PreparedIssuerKey{Ipk: pk.Ipk, curve: pk.curve, tr: pk.tr, x: pk.x, y: pk.y, yBar: pk.yBar, zij: pk.zij}
+}
+
+// g1Mul returns g_1^z
+func (pk *PreparedIssuerKey) g1Mul(z *math.Zr) *math.G1 {
+	if pk.g1Table == nil {
+		return pk.curve.GenG1.Mul(z)
+	}
+	acc := pk.curve.NewG1()
+	pk.g1Table.mulAdd(acc, z)
+	return acc
+}
+
+// g2Mul returns g_2^z
+func (pk *PreparedIssuerKey) g2Mul(z *math.Zr) *math.G2 {
+	if pk.g2Table == nil {
+		return pk.curve.GenG2.Mul(z)
+	}
+	acc := pk.curve.NewG2()
+	pk.g2Table.mulAdd(acc, z)
+	return acc
+}
+
+// yMulAdd multiplies acc by \prod_k Y_{indices[k]}^{scalars[k]}, with the bucket method over the tables
+// if msmSpan finds it cheaper than a lookup per power
+func (pk *PreparedIssuerKey) yMulAdd(acc *math.G1, indices []int64, scalars []*math.Zr) error {
+	var tables []g1Table
+	var tableScalars []*math.Zr
+	for k, index := range indices {
+		if pk.yTables != nil && index >= 0 && int(index) < len(pk.yTables) {
+			tables = append(tables, pk.yTables[index])
+			tableScalars = append(tableScalars, scalars[k])
+			continue
+		}
+		Y, err := pk.Y(int(index))
+		if err != nil {
+			return err
+		}
+		acc.Add(Y.Mul(scalars[k]))
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	if span := msmSpan(len(tables), len(tables[0])); span > 1 {
+		g1MSM(acc, tables, tableScalars, span, pk.curve)
+		return nil
+	}
+	for k, t := range tables {
+		t.mulAdd(acc, tableScalars[k])
+	}
+	return nil
+}
+
+// yBarMulAdd multiplies acc by \prod_k YBar_{indices[k]}^{scalars[k]}, as yMulAdd
+func (pk *PreparedIssuerKey) yBarMulAdd(acc *math.G2, indices []int64, scalars []*math.Zr) error {
+	var tables []g2Table
+	var tableScalars []*math.Zr
+	for k, index := range indices {
+		if pk.yBarTables != nil && index >= 0 && int(index) < len(pk.yBarTables) {
+			tables = append(tables, pk.yBarTables[index])
+			tableScalars = append(tableScalars, scalars[k])
+			continue
+		}
+		YBar, err := pk.YBar(int(index))
+		if err != nil {
+			return err
+		}
+		acc.Add(YBar.Mul(scalars[k]))
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	if span := msmSpan(len(tables), len(tables[0])); span > 1 {
+		g2MSM(acc, tables, tableScalars, span, pk.curve)
+		return nil
+	}
+	for k, t := range tables {
+		t.mulAdd(acc, tableScalars[k])
+	}
+	return nil
+}
+
+// NewCredRequest creates a credential request for the attributes, see NewCredRequestPS
+func (pk *PreparedIssuerKey) NewCredRequest(UserAttributeNames []string, rng io.Reader) (*CredRequestPS, *math.Zr, error) {
+	return newPreparedCredRequestPS(UserAttributeNames, pk, rng)
+}
+
+// VerifyCredRequest verifies the zero-knowledge proof of a credential request, see VerifyZeroKnowledgeOne
+func (pk *PreparedIssuerKey) VerifyCredRequest(m *CredRequestPS) error {
+	return m.verifyZeroKnowledgeOne(pk)
+}
+
+// NewDeriveCredential derives a credential disclosing the attributes of the mask, see NewDeriveCredential
+func (pk *PreparedIssuerKey) NewDeriveCredential(Attrs []string, m *PrimaryCredential, Mask []int, rng io.Reader) (*DeriveCredential, error) {
+	cred, _, err := deriveCredential(Attrs, pk, m, Mask, rng)
+	return cred, err
+}
+
+// VerifyDerive verifies a derived credential, see DeriveCredential.VerifyDerive
+func (pk *PreparedIssuerKey) VerifyDerive(cred *DeriveCredential) error {
+	return cred.verifyDerive(pk)
+}
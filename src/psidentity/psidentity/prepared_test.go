@@ -0,0 +1,202 @@
+package psidentity
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestFixedBaseTable(t *testing.T) {
+	for _, name := range []string{CurveFP256BN_AMCL, CurveBLS12_381, CurveBN254} {
+		psid, err := NewPsidentityForCurve(name)
+		require.NoError(t, err)
+		curve := psid.Curve
+		rng, err := curve.Rand()
+		require.NoError(t, err)
+
+		P := curve.GenG1.Mul(curve.NewRandomZr(rng))
+		Q := curve.GenG2.Mul(curve.NewRandomZr(rng))
+		t1, t2 := newG1Table(P, curve), newG2Table(Q, curve)
+		for _, z := range []*math.Zr{curve.NewZrFromInt(0), curve.NewZrFromInt(1), curve.NewZrFromInt(17), curve.NewRandomZr(rng), curve.NewRandomZr(rng)} {
+			acc1 := curve.NewG1()
+			t1.mulAdd(acc1, z)
+			require.True(t, acc1.Equals(P.Mul(z)), "%s", name)
+			acc2 := curve.NewG2()
+			t2.mulAdd(acc2, z)
+			require.True(t, acc2.Equals(Q.Mul(z)), "%s", name)
+		}
+	}
+}
+
+func TestFixedBaseMSM(t *testing.T) {
+	for _, name := range []string{CurveFP256BN_AMCL, CurveBLS12_381} {
+		psid, err := NewPsidentityForCurve(name)
+		require.NoError(t, err)
+		curve := psid.Curve
+		rng, err := curve.Rand()
+		require.NoError(t, err)
+
+		scalars := []*math.Zr{curve.NewZrFromInt(0), curve.NewZrFromInt(1), curve.NewZrFromInt(17), curve.NewRandomZr(rng), curve.NewRandomZr(rng)}
+		t1, t2 := make([]g1Table, len(scalars)), make([]g2Table, len(scalars))
+		want1, want2 := curve.NewG1(), curve.NewG2()
+		for i, z := range scalars {
+			P := curve.GenG1.Mul(curve.NewRandomZr(rng))
+			Q := curve.GenG2.Mul(curve.NewRandomZr(rng))
+			t1[i], t2[i] = newG1Table(P, curve), newG2Table(Q, curve)
+			want1.Add(P.Mul(z))
+			want2.Add(Q.Mul(z))
+		}
+		for span := 1; span <= 3; span++ {
+			acc1 := curve.NewG1()
+			g1MSM(acc1, t1, scalars, span, curve)
+			require.True(t, acc1.Equals(want1), "%s span %d", name, span)
+			acc2 := curve.NewG2()
+			g2MSM(acc2, t2, scalars, span, curve)
+			require.True(t, acc2.Equals(want2), "%s span %d", name, span)
+		}
+	}
+
+	// the bucket method only pays off for many powers, with wider digits for even more
+	windows := fixedBaseWindows(math.Curves[math.FP256BN_AMCL])
+	require.Equal(t, 1, msmSpan(8, windows))
+	require.Equal(t, 2, msmSpan(12, windows))
+	require.Equal(t, 3, msmSpan(1024, windows))
+}
+
+func TestPreparedIssuerKey(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+	mask := []int{1, 0, 1, 0}
+	key, err := psid.NewIssuerKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	pk, err := psid.PrepareIssuerKey(key.Ipk)
+	require.NoError(t, err)
+	require.NotNil(t, pk.yTables)
+
+	// secret scalars are never looked up in the tables
+	sk := pk.secret()
+	require.Nil(t, sk.g1Table)
+	require.Nil(t, sk.g2Table)
+	require.Nil(t, sk.yTables)
+	require.Nil(t, sk.yBarTables)
+	z := psid.Curve.NewRandomZr(rng)
+	require.True(t, sk.g1Mul(z).Equals(pk.g1Mul(z)))
+	require.True(t, sk.g2Mul(z).Equals(pk.g2Mul(z)))
+
+	// prepared and decoded keys accept each other's requests and credentials
+	req, d, err := pk.NewCredRequest(attrs, rng)
+	require.NoError(t, err)
+	require.NoError(t, req.VerifyZeroKnowledgeOne(key.Ipk, psid.Curve, tr))
+	blind, err := psid.NewBlindCredential(key, req, rng, tr)
+	require.NoError(t, err)
+	primary, err := psid.NewPrimaryCredential(attrs, d, key, blind, rng, tr)
+	require.NoError(t, err)
+
+	req, _, err = psid.NewCredRequestPS(attrs, key.Ipk, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, pk.VerifyCredRequest(req))
+	req.Rp = psid.Curve.NewRandomZr(rng).Bytes()
+	require.Error(t, pk.VerifyCredRequest(req))
+
+	derived, err := pk.NewDeriveCredential(attrs, primary, mask, rng)
+	require.NoError(t, err)
+	require.NoError(t, derived.VerifyDerive(key.Ipk, psid.Curve, tr))
+	derived, err = psid.NewDeriveCredential(attrs, key, primary, mask, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, pk.VerifyDerive(derived))
+
+	// the prepared key refuses tampered credentials and credentials of another key
+	tampered := proto.Clone(derived).(*DeriveCredential)
+	tampered.DiscloseMsg[2] = "2099-01-01"
+	require.Error(t, pk.VerifyDerive(tampered))
+	other, otherPrimary := newTestCredential(t, psid, attrs, rng)
+	otherDerived, err := psid.NewDeriveCredential(attrs, other, otherPrimary, mask, rng, tr)
+	require.NoError(t, err)
+	require.Error(t, pk.VerifyDerive(otherDerived))
+	_, err = pk.NewDeriveCredential(attrs, otherPrimary, mask, rng)
+	require.Error(t, err)
+
+	_, err = pk.Y(len(attrs))
+	require.Error(t, err)
+	_, _, err = pk.NewCredRequest(append(attrs, "extra"), rng)
+	require.Error(t, err)
+
+	// the prepared key is shared by concurrent derivations and verifications
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for w := 0; w < cap(errs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng, err := psid.Curve.Rand()
+			if err != nil {
+				errs <- err
+				return
+			}
+			derived, err := pk.NewDeriveCredential(attrs, primary, mask, rng)
+			if err == nil {
+				err = pk.VerifyDerive(derived)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+// BenchmarkPreparedMSM compares the products of powers of the attribute bases computed with a lookup per power,
+// with the bucket method and with the scalar multiplication of the curve, e.g.
+// go test -run - -bench PreparedMSM/64
+func BenchmarkPreparedMSM(b *testing.B) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(b, err)
+	curve := psid.Curve
+	rng, err := curve.Rand()
+	require.NoError(b, err)
+
+	for _, n := range []int{4, 16, 64} {
+		key, err := psid.NewIssuerKeyPS(n, rng, psid.Translator)
+		require.NoError(b, err)
+		pk, err := psid.PrepareIssuerKey(key.Ipk)
+		require.NoError(b, err)
+		scalars := make([]*math.Zr, n)
+		for i := range scalars {
+			scalars[i] = curve.NewRandomZr(rng)
+		}
+
+		b.Run(fmt.Sprintf("%d/lookup", n), func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				acc := curve.NewG1()
+				for i, t := range pk.yTables {
+					t.mulAdd(acc, scalars[i])
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%d/buckets", n), func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				g1MSM(curve.NewG1(), pk.yTables, scalars, 2, curve)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/mul", n), func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				acc := curve.NewG1()
+				for i, Y := range pk.y {
+					acc.Add(Y.Mul(scalars[i]))
+				}
+			}
+		})
+	}
+}
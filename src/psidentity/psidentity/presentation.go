@@ -0,0 +1,571 @@
+package psidentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	psidentity "psidentity"
+)
+
+// presentationLabel is the label used in the zero-knowledge proof that binds a derived credential to the nonce
+// of a presentation request and proves the predicates on its hidden attributes
+const presentationLabel = "presentation"
+
+// presentationNonceBytes is the length of the random nonce of NewPresentationRequest
+const presentationNonceBytes = 16
+
+// The operators of an AttributePredicate
+const (
+	PredicateGreaterOrEqual = ">="
+	PredicateLessOrEqual    = "<="
+)
+
+// maxPredicateValueBytes is the length of the longest predicate value, whose integer fits the range proofs
+const maxPredicateValueBytes = attributeRangeBits / 8
+
+// AttributePredicate is a statement about a hidden attribute, proven without disclosing it. The attribute and
+// the value are compared as the big-endian integers of their bytes, so numbers must be zero-padded to a common
+// width, and values have at most maxPredicateValueBytes bytes. The validity attributes are compared as days.
+type AttributePredicate struct {
+	Attribute string `json:"attribute" yaml:"attribute"`
+	Op        string `json:"op" yaml:"op"`
+	Value     string `json:"value" yaml:"value"`
+}
+
+// ParseAttributePredicate parses a predicate written as <attribute>>=<value> or <attribute><=<value>
+func ParseAttributePredicate(s string) (*AttributePredicate, error) {
+	for _, op := range []string{PredicateGreaterOrEqual, PredicateLessOrEqual} {
+		if k := strings.Index(s, op); k > 0 {
+			p := &AttributePredicate{Attribute: s[:k], Op: op, Value: s[k+len(op):]}
+			return p, p.validate()
+		}
+	}
+	return nil, errors.Errorf("predicate %q is not of the form <attribute>%s<value> or <attribute>%s<value>", s, PredicateGreaterOrEqual, PredicateLessOrEqual)
+}
+
+func (p *AttributePredicate) String() string {
+	return p.Attribute + p.Op + p.Value
+}
+
+func (p *AttributePredicate) validate() error {
+	if p.Attribute == "" {
+		return errors.Errorf("predicate has no attribute")
+	}
+	if p.Op != PredicateGreaterOrEqual && p.Op != PredicateLessOrEqual {
+		return errors.Errorf("predicate %s has the unknown operator %q", p, p.Op)
+	}
+	if p.Value == "" || len(p.Value) > maxPredicateValueBytes {
+		return errors.Errorf("predicate %s must have a value of 1 to %d bytes", p, maxPredicateValueBytes)
+	}
+	return nil
+}
+
+// upper reports whether the value is an upper bound of the attribute, see newRangeCommitment
+func (p *AttributePredicate) upper() bool {
+	return p.Op == PredicateLessOrEqual
+}
+
+// PresentationRequest is written by a verifier and names the creds it accepts, the attributes the holder
+// must disclose and the predicates on hidden attributes it must prove. Issuers are issuer key IDs, any
+// trusted issuer key if empty. Schemas are the accepted schemas, the DefaultSchema with and without the
+// validity attributes if empty.
+// The presentation is bound to the nonce and must be verified before Expires.
+type PresentationRequest struct {
+	Nonce      string                `json:"nonce" yaml:"nonce"`
+	Expires    time.Time             `json:"expires" yaml:"expires"`
+	Issuers    []string              `json:"issuers,omitempty" yaml:"issuers,omitempty"`
+	Schemas    []*CredentialSchema   `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Disclose   []string              `json:"disclose" yaml:"disclose"`
+	Predicates []*AttributePredicate `json:"predicates,omitempty" yaml:"predicates,omitempty"`
+}
+
+// NewPresentationRequest returns a request for the attributes of creds of the schemas with a random nonce,
+// which expires after ttl
+func NewPresentationRequest(schemas []*CredentialSchema, disclose []string, predicates []*AttributePredicate, ttl time.Duration, rng io.Reader) (*PresentationRequest, error) {
+	nonce := make([]byte, presentationNonceBytes)
+	if _, err := io.ReadFull(rng, nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate the nonce")
+	}
+	req := &PresentationRequest{
+		Nonce:      hex.EncodeToString(nonce),
+		Expires:    time.Now().Add(ttl).UTC().Truncate(time.Second),
+		Schemas:    schemas,
+		Disclose:   disclose,
+		Predicates: predicates,
+	}
+	return req, req.Validate()
+}
+
+// ParsePresentationRequest parses a request in JSON or YAML
+func ParsePresentationRequest(raw []byte) (*PresentationRequest, error) {
+	req := &PresentationRequest{}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) != 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(raw, req); err != nil {
+			return nil, errors.Wrap(err, "failed to parse presentation request")
+		}
+	} else if err := yaml.Unmarshal(raw, req); err != nil {
+		return nil, errors.Wrap(err, "failed to parse presentation request")
+	}
+	return req, req.Validate()
+}
+
+// Validate checks that the request is complete and that its attributes are named by its schemas
+func (r *PresentationRequest) Validate() error {
+	if r.Nonce == "" {
+		return errors.Errorf("presentation request has no nonce")
+	}
+	if r.Expires.IsZero() {
+		return errors.Errorf("presentation request has no expiry")
+	}
+	if len(r.Disclose) == 0 {
+		return errors.Errorf("presentation request discloses no attribute")
+	}
+	for _, s := range r.Schemas {
+		if s.Name == "" || len(s.Attributes) == 0 {
+			return errors.Errorf("presentation request has a schema without a name or attributes")
+		}
+	}
+	disclosed := map[string]bool{}
+	for _, name := range r.Disclose {
+		if !r.names(name) {
+			return errors.Errorf("no schema of the presentation request has the attribute %s", name)
+		}
+		disclosed[name] = true
+	}
+	for _, p := range r.Predicates {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if !r.names(p.Attribute) {
+			return errors.Errorf("no schema of the presentation request has the attribute %s", p.Attribute)
+		}
+		if disclosed[p.Attribute] {
+			return errors.Errorf("predicate %s is on a disclosed attribute", p)
+		}
+	}
+	return nil
+}
+
+// schemas returns the accepted schemas
+func (r *PresentationRequest) schemas() []*CredentialSchema {
+	if len(r.Schemas) != 0 {
+		return r.Schemas
+	}
+	return []*CredentialSchema{
+		{Name: DefaultSchema, Attributes: psidentity.IssuerAttributeNames[:len(psidentity.IssuerAttributeNames)-2]},
+		{Name: DefaultSchema, Attributes: psidentity.IssuerAttributeNames},
+	}
+}
+
+// schema returns the accepted schema of the name with n attributes, nil if it is not accepted
+func (r *PresentationRequest) schema(name string, n int) *CredentialSchema {
+	for _, s := range r.schemas() {
+		if s.Name == name && len(s.Attributes) == n {
+			return s
+		}
+	}
+	return nil
+}
+
+// names reports whether an accepted schema has the attribute
+func (r *PresentationRequest) names(attribute string) bool {
+	for _, s := range r.schemas() {
+		if s.index(attribute) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// issuer reports whether the request accepts the creds of the issuer key ID
+func (r *PresentationRequest) issuer(id string) bool {
+	if len(r.Issuers) == 0 {
+		return true
+	}
+	for _, issuer := range r.Issuers {
+		if issuer == id {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts reports whether the cred in the wallet is of an accepted issuer and schema
+func (r *PresentationRequest) accepts(e *WalletEntry) bool {
+	s := r.schema(e.Schema, len(e.Attributes))
+	if s == nil || !r.issuer(e.IssuerKeyID) {
+		return false
+	}
+	for j, name := range s.Attributes {
+		if e.Attributes[j] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// Presentation answers a PresentationRequest with aggregate creds, one per issuer key
+type Presentation struct {
+	Nonce      string                `json:"nonce"`
+	Aggregates []*PresentedAggregate `json:"aggregates"`
+}
+
+// PresentedAggregate is a serialized AggregateCredential with the schema and the PresentationProof of each message
+type PresentedAggregate struct {
+	Credential []byte               `json:"credential"`
+	Schemas    []string             `json:"schemas"`
+	Proofs     []*PresentationProof `json:"proofs"`
+}
+
+// PresentationProof proves the knowledge of the hidden attributes of a derived credential for the nonce of
+// the request, and the predicates on them
+type PresentationProof struct {
+	ProofC      []byte            `json:"proof_c"`
+	ProofST     []byte            `json:"proof_s_t"`
+	ProofSAttrs [][]byte          `json:"proof_s_attrs"`
+	Predicates  []*PredicateProof `json:"predicates,omitempty"`
+}
+
+// PredicateProof is the serialized RangeProof of a predicate
+type PredicateProof struct {
+	AttributePredicate
+	Range []byte `json:"range"`
+}
+
+// presentationChallenge computes the Fiat-Shamir challenge of a presentation proof, over the nonce, the disclosed
+// attributes of the derived credential and the predicates
+func presentationChallenge(nonce string, cred *DeriveCredential, sigmaOnep, T1 *math.G1, predicates []*AttributePredicate, transcripts []*rangeTranscript, curve *math.Curve) *math.Zr {
+	var proofData []byte
+	appendString := func(s string) {
+		proofData = binary.BigEndian.AppendUint32(proofData, uint32(len(s)))
+		proofData = append(proofData, s...)
+	}
+	appendString(presentationLabel)
+	appendString(nonce)
+	proofData = binary.BigEndian.AppendUint32(proofData, uint32(len(cred.GetDiscloseMsg())))
+	for _, index := range cred.GetDiscloseIndices() {
+		proofData = binary.BigEndian.AppendUint32(proofData, uint32(index))
+		if index >= 0 && int(index) < len(cred.GetDiscloseMsg()) {
+			appendString(cred.DiscloseMsg[index])
+		}
+	}
+	proofData = append(proofData, sigmaOnep.Bytes()...)
+	proofData = append(proofData, T1.Bytes()...)
+	for k, rt := range transcripts {
+		appendString(predicates[k].String())
+		for i := range rt.C {
+			proofData = append(proofData, rt.C[i].Bytes()...)
+			proofData = append(proofData, rt.A0[i].Bytes()...)
+			proofData = append(proofData, rt.A1[i].Bytes()...)
+		}
+		proofData = append(proofData, rt.TLink.Bytes()...)
+	}
+	return curve.HashToZr(proofData)
+}
+
+// newPresentationProof proves the predicates on the hidden attributes of the derived credential for the nonce,
+// t is the randomness of its sigma_onep
+func newPresentationProof(cred *DeriveCredential, t *math.Zr, Attrs []string, schema *CredentialSchema, predicates []*AttributePredicate, nonce string, pk *PreparedIssuerKey, rng io.Reader) (*PresentationProof, error) {
+	curve, tr := pk.curve, pk.tr
+	HideIndices := cred.hiddenIndices()
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return nil, err
+	}
+	sc, err := newSigmaOnepCommitment(pk.Ipk, HideIndices, rng, curve, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	h := validityGenerator(curve)
+	commitments := make([]*rangeCommitment, len(predicates))
+	transcripts := make([]*rangeTranscript, len(predicates))
+	for k, p := range predicates {
+		index := schema.index(p.Attribute)
+		position := hiddenPosition(HideIndices, index)
+		if position < 0 {
+			return nil, errors.Errorf("predicate %s is on a disclosed attribute", p)
+		}
+		// the randomness of the attribute is shared with sc
+		delta := new(big.Int).Sub(attributeValue(pk.Ipk, index, Attrs[index]), attributeValue(pk.Ipk, index, p.Value))
+		if p.upper() {
+			delta.Neg(delta)
+		}
+		if commitments[k], err = newRangeCommitment(delta, rangeBits(pk.Ipk, index), p.upper(), sc.rAttrs[position], h, rng, curve); err != nil {
+			return nil, errors.Errorf("the credential does not satisfy the predicate %s", p)
+		}
+		transcripts[k] = &commitments[k].rangeTranscript
+	}
+
+	proofC := presentationChallenge(nonce, cred, sigmaOnep, sc.T1, predicates, transcripts, curve)
+	proof := &PresentationProof{ProofC: proofC.Bytes()}
+	proof.ProofST, proof.ProofSAttrs = sc.respond(proofC, t, pk.Ipk, Attrs, HideIndices, curve)
+	for k, p := range predicates {
+		rangeBytes, err := proto.Marshal(commitments[k].respond(proofC, curve, tr))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal range proof")
+		}
+		proof.Predicates = append(proof.Predicates, &PredicateProof{AttributePredicate: *p, Range: rangeBytes})
+	}
+	return proof, nil
+}
+
+// verifyPresentationProof verifies the presentation proof of the derived credential for the nonce
+func verifyPresentationProof(cred *DeriveCredential, proof *PresentationProof, schema *CredentialSchema, nonce string, pk *PreparedIssuerKey) error {
+	curve, tr := pk.curve, pk.tr
+	if proof == nil || proof.ProofC == nil {
+		return errors.Errorf("credential has no presentation proof")
+	}
+	HideIndices := cred.hiddenIndices()
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return err
+	}
+	proofC := curve.NewZrFromBytes(proof.ProofC)
+	T1, err := recomputeSigmaOnepT(pk.Ipk, HideIndices, sigmaOnep, proofC, proof.ProofST, proof.ProofSAttrs, curve, tr)
+	if err != nil {
+		return errors.Wrap(err, "presentation proof")
+	}
+
+	h := validityGenerator(curve)
+	predicates := make([]*AttributePredicate, len(proof.Predicates))
+	transcripts := make([]*rangeTranscript, len(proof.Predicates))
+	for k, pp := range proof.Predicates {
+		p := &pp.AttributePredicate
+		if err := p.validate(); err != nil {
+			return err
+		}
+		index := schema.index(p.Attribute)
+		position := hiddenPosition(HideIndices, index)
+		if position < 0 {
+			return errors.Errorf("predicate %s is not on a hidden attribute", p)
+		}
+		rp := &RangeProof{}
+		if err := proto.Unmarshal(pp.Range, rp); err != nil {
+			return errors.Wrap(err, "failed to unmarshal range proof")
+		}
+		v := attributeZr(pk.Ipk, index, p.Value, curve)
+		if transcripts[k], err = recomputeRangeTranscript(rp, rangeBits(pk.Ipk, index), p.upper(), v, curve.NewZrFromBytes(proof.ProofSAttrs[position]), proofC, h, curve, tr); err != nil {
+			return errors.Wrapf(err, "predicate %s", p)
+		}
+		predicates[k] = p
+	}
+
+	if !proofC.Equals(presentationChallenge(nonce, cred, sigmaOnep, T1, predicates, transcripts, curve)) {
+		return errors.Errorf("presentation proof is invalid")
+	}
+	return nil
+}
+
+// ResolvePresentation answers the request with the creds of the wallet valid at time now: it picks the creds
+// with Select, derives each with the mask of the requested attributes and the proofs of the predicates on its
+// hidden attributes, and aggregates the derived creds of each issuer key with the user key pair of the name
+// in the store. Every picked cred must disclose at least one requested attribute.
+func (w *Wallet) ResolvePresentation(req *PresentationRequest, store KeyStore, name string, now time.Time, rng io.Reader) (*Presentation, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if !now.Before(req.Expires) {
+		return nil, errors.Errorf("presentation request expired at %s", req.Expires.UTC().Format(time.RFC3339))
+	}
+	listed, err := w.List(WalletQuery{ValidAt: now})
+	if err != nil {
+		return nil, err
+	}
+	var entries []*WalletEntry
+	for _, e := range listed {
+		if req.accepts(e) {
+			entries = append(entries, e)
+		}
+	}
+	attributes := append([]string(nil), req.Disclose...)
+	for _, p := range req.Predicates {
+		attributes = append(attributes, p.Attribute)
+	}
+	selections, err := selectEntries(entries, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	disclose := map[string]bool{}
+	for _, a := range req.Disclose {
+		disclose[a] = true
+	}
+	p := &Presentation{Nonce: req.Nonce}
+	aggregates := map[string]int{}
+	var messages [][]*DeriveCredential
+	var ipks []*IssuerPublicKeyPS
+	for _, s := range selections {
+		schema := req.schema(s.Entry.Schema, len(s.Entry.Attributes))
+		var disclosed []string
+		var predicates []*AttributePredicate
+		for _, a := range s.Attributes {
+			if disclose[a] {
+				disclosed = append(disclosed, a)
+			}
+		}
+		for _, pred := range req.Predicates {
+			if containsString(s.Attributes, pred.Attribute) {
+				predicates = append(predicates, pred)
+			}
+		}
+		if len(disclosed) == 0 {
+			return nil, errors.Errorf("credential %s only proves predicates, a derived credential discloses at least one attribute", s.Entry.ID)
+		}
+		mask, err := schema.Mask(disclosed)
+		if err != nil {
+			return nil, err
+		}
+
+		cred, ipk, err := w.Credential(s.Entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		pk := decodeIssuerKey(ipk, w.psid.Curve, w.psid.Translator)
+		derived, t, err := deriveCredentialAt(cred.GetAttrs(), pk, cred, mask, now, rng)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to derive credential %s", s.Entry.ID)
+		}
+		if cri, ok := w.CRIs[s.Entry.IssuerKeyID]; ok {
+			err := addNonRevocationProof(derived, t, cred.GetAttrs(), ipk, mask, w.RevocationHandleIndex, cri, rng, w.psid.Curve, w.psid.Translator)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "credential %s", s.Entry.ID)
+			}
+		}
+		proof, err := newPresentationProof(derived, t, cred.GetAttrs(), schema, predicates, req.Nonce, pk, rng)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "credential %s", s.Entry.ID)
+		}
+
+		// the derived creds are aggregated by issuer key
+		k, ok := aggregates[s.Entry.IssuerKeyID]
+		if !ok {
+			k = len(p.Aggregates)
+			aggregates[s.Entry.IssuerKeyID] = k
+			p.Aggregates = append(p.Aggregates, &PresentedAggregate{})
+			messages = append(messages, nil)
+			ipks = append(ipks, ipk)
+		}
+		messages[k] = append(messages[k], derived)
+		p.Aggregates[k].Schemas = append(p.Aggregates[k].Schemas, schema.Name)
+		p.Aggregates[k].Proofs = append(p.Aggregates[k].Proofs, proof)
+	}
+
+	for k, a := range p.Aggregates {
+		aggregate, err := store.Aggregate(name, ipks[k], messages[k], rng)
+		if err != nil {
+			return nil, err
+		}
+		if a.Credential, err = proto.Marshal(aggregate); err != nil {
+			return nil, errors.Wrap(err, "failed to marshal aggregate credential")
+		}
+	}
+	return p, nil
+}
+
+// VerifyPresentation checks at time now that the presentation satisfies the request: every cred is signed by
+// one of the trusted issuer keys accepted by the request, valid and bound to its nonce, the requested attributes
+// are disclosed and the predicates proven, and the creds are aggregated with the user public key. The attributes
+// are named by the IssuerSchema of the issuer key of each cred, and creds disclosing different values of an
+// attribute are refused. If i has a NonRevocationPolicy, every cred must also prove its non-revocation.
+// It returns the disclosed attributes by name.
+func (i *Psidentity) VerifyPresentation(req *PresentationRequest, p *Presentation, trusted []*IssuerPublicKeyPS, upk *UserPublicKey, now time.Time) (map[string]string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if !now.Before(req.Expires) {
+		return nil, errors.Errorf("presentation request expired at %s", req.Expires.UTC().Format(time.RFC3339))
+	}
+	if p.Nonce != req.Nonce {
+		return nil, errors.Errorf("presentation is not for the nonce of the request")
+	}
+	keys := map[string]*IssuerPublicKeyPS{}
+	for _, ipk := range trusted {
+		keys[IssuerKeyID(ipk)] = ipk
+	}
+
+	disclosed := map[string]string{}
+	proven := map[string]bool{}
+	verifier := i.NewAggregateVerifier(i.Parallelism)
+	for _, a := range p.Aggregates {
+		aggregate := &AggregateCredential{}
+		if err := proto.Unmarshal(a.Credential, aggregate); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal aggregate credential")
+		}
+		if len(a.Schemas) != len(aggregate.GetMessages()) || len(a.Proofs) != len(aggregate.GetMessages()) {
+			return nil, errors.Errorf("aggregate credential has %d messages, %d schemas and %d proofs", len(aggregate.GetMessages()), len(a.Schemas), len(a.Proofs))
+		}
+		if err := verifier.VerifyAggregate(context.Background(), aggregate, upk); err != nil {
+			return nil, err
+		}
+
+		for k, cred := range aggregate.GetMessages() {
+			ipk, ok := keys[cred.GetIssuerKeyId()]
+			if !ok || !req.issuer(cred.GetIssuerKeyId()) {
+				return nil, errors.Errorf("credential of issuer key %s is not accepted", cred.GetIssuerKeyId())
+			}
+			// the schema is the one the issuer key records, the presentation only names it
+			schema, err := IssuerSchema(ipk, len(cred.GetDiscloseMsg()))
+			if err != nil {
+				return nil, err
+			}
+			if a.Schemas[k] != schema.Name {
+				return nil, errors.Errorf("credential of issuer key %s is of schema %s, not %s", cred.GetIssuerKeyId(), schema.Name, a.Schemas[k])
+			}
+			if accepted := req.schema(schema.Name, len(schema.Attributes)); accepted == nil || !accepted.equal(schema) {
+				return nil, errors.Errorf("credential of schema %s is not accepted", schema.Name)
+			}
+			pk := decodeIssuerKey(ipk, i.Curve, i.Translator)
+			if err := cred.verifyDerive(pk); err != nil {
+				return nil, err
+			}
+			if err := i.verifyNonRevocation(cred, ipk); err != nil {
+				return nil, err
+			}
+			if hasValidity(ipk) {
+				if err := cred.VerifyValidity(ipk, now, i.Curve, i.Translator); err != nil {
+					return nil, err
+				}
+			}
+			if err := verifyPresentationProof(cred, a.Proofs[k], schema, req.Nonce, pk); err != nil {
+				return nil, err
+			}
+
+			for _, index := range cred.GetDiscloseIndices() {
+				if int(index) < len(cred.GetDiscloseMsg()) && cred.DiscloseMsg[index] != "" {
+					name, value := schema.Attributes[index], cred.DiscloseMsg[index]
+					if v, ok := disclosed[name]; ok && v != value {
+						return nil, errors.Errorf("credentials disclose conflicting values of attribute %s", name)
+					}
+					disclosed[name] = value
+				}
+			}
+			for _, pp := range a.Proofs[k].Predicates {
+				proven[pp.AttributePredicate.String()] = true
+			}
+		}
+	}
+
+	for _, name := range req.Disclose {
+		if _, ok := disclosed[name]; !ok {
+			return nil, errors.Errorf("attribute %s is not disclosed", name)
+		}
+	}
+	for _, pred := range req.Predicates {
+		if !proven[pred.String()] {
+			return nil, errors.Errorf("predicate %s is not proven", pred)
+		}
+	}
+	return disclosed, nil
+}
@@ -0,0 +1,298 @@
+package psidentity
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestParsePresentationRequest(t *testing.T) {
+	p, err := ParseAttributePredicate("Birth<=20080101")
+	require.NoError(t, err)
+	require.Equal(t, &AttributePredicate{Attribute: "Birth", Op: PredicateLessOrEqual, Value: "20080101"}, p)
+	for _, invalid := range []string{"Birth", ">=1", "Birth>=", "Birth>=0000000000001"} {
+		_, err := ParseAttributePredicate(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	yamlRequest := `
+nonce: 6e6f6e6365
+expires: 2030-01-02T03:04:05Z
+issuers: [0102]
+schemas:
+  - name: license
+    attributes: [Number, Class, Birth, Holder]
+disclose: [Class]
+predicates:
+  - {attribute: Birth, op: "<=", value: "20080101"}
+`
+	req, err := ParsePresentationRequest([]byte(yamlRequest))
+	require.NoError(t, err)
+	require.Equal(t, "6e6f6e6365", req.Nonce)
+	require.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), req.Expires.UTC())
+	require.Equal(t, []string{"0102"}, req.Issuers)
+	require.Equal(t, []*AttributePredicate{p}, req.Predicates)
+	require.NotNil(t, req.schema("license", 4))
+	require.Nil(t, req.schema(DefaultSchema, len(psidentity.IssuerAttributeNames)))
+
+	jsonRequest, err := json.Marshal(req)
+	require.NoError(t, err)
+	parsed, err := ParsePresentationRequest(jsonRequest)
+	require.NoError(t, err)
+	require.Equal(t, req.Expires.Unix(), parsed.Expires.Unix())
+	require.Equal(t, req.Schemas, parsed.Schemas)
+
+	// without schemas the attributes of the default schema are requested
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+	_, err = NewPresentationRequest(nil, []string{"Class"}, nil, time.Minute, rng)
+	require.Error(t, err)
+	_, err = NewPresentationRequest(nil, []string{psidentity.IssuerAttributeOne}, []*AttributePredicate{{Attribute: psidentity.IssuerAttributeOne, Op: PredicateGreaterOrEqual, Value: "1"}}, time.Minute, rng)
+	require.Error(t, err)
+	req, err = NewPresentationRequest(nil, []string{psidentity.IssuerAttributeOne}, nil, time.Minute, rng)
+	require.NoError(t, err)
+	require.Len(t, req.Nonce, 2*presentationNonceBytes)
+	require.True(t, req.Expires.After(time.Now()))
+}
+
+func TestPresentation(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	store := NewMemoryKeyStore(psid)
+	uskBytes, upkBytes, err := GenerateUserKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey}, upkBytes))
+	require.NoError(t, store.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey}, uskBytes))
+	upk, err := StoredUserPublicKey(store, psidentity.PsIdentityDirUserKey)
+	require.NoError(t, err)
+
+	// a device cred with a validity window and a license cred of another issuer
+	w := NewWallet(t.TempDir(), psid)
+	license := &CredentialSchema{Name: "license", Attributes: []string{"Number", "Class", "Birth", "Holder"}}
+	deviceIpk, issueDevice := newWalletIssuer(t, psid, nil)
+	licenseIpk, issueLicense := newWalletIssuer(t, psid, license)
+	now := time.Now()
+	deviceAttrs, err := AppendValidity([]string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+		now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = w.Import(issueDevice(deviceAttrs), deviceIpk, nil)
+	require.NoError(t, err)
+	_, err = w.Import(issueLicense([]string{"42", "B", "20010203", "holder"}), licenseIpk, license)
+	require.NoError(t, err)
+
+	newRequest := func(disclose []string, predicates ...string) *PresentationRequest {
+		var parsed []*AttributePredicate
+		for _, s := range predicates {
+			p, err := ParseAttributePredicate(s)
+			require.NoError(t, err)
+			parsed = append(parsed, p)
+		}
+		req, err := NewPresentationRequest([]*CredentialSchema{{Name: DefaultSchema, Attributes: psidentity.IssuerAttributeNames}, license}, disclose, parsed, time.Minute, rng)
+		require.NoError(t, err)
+		return req
+	}
+	req := newRequest([]string{psidentity.IssuerAttributeTwo, "Class"}, "Birth<=20080101", "Birth>=19000101")
+
+	p, err := w.ResolvePresentation(req, store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.NoError(t, err)
+	require.Len(t, p.Aggregates, 2)
+	trusted := []*IssuerPublicKeyPS{deviceIpk, licenseIpk}
+	disclosed, err := psid.VerifyPresentation(req, p, trusted, upk, now)
+	require.NoError(t, err)
+	require.Equal(t, psidentity.UserAttributeManufacturer, disclosed[psidentity.IssuerAttributeTwo])
+	require.Equal(t, "B", disclosed["Class"])
+	require.NotContains(t, disclosed, "Birth")
+	require.NotContains(t, disclosed, psidentity.IssuerAttributeNotAfter)
+
+	// the presentation survives a round trip through JSON
+	raw, err := json.Marshal(p)
+	require.NoError(t, err)
+	decoded := &Presentation{}
+	require.NoError(t, json.Unmarshal(raw, decoded))
+	_, err = psid.VerifyPresentation(req, decoded, trusted, upk, now)
+	require.NoError(t, err)
+
+	// the presentation is bound to the request
+	other := newRequest([]string{psidentity.IssuerAttributeTwo, "Class"}, "Birth<=20080101", "Birth>=19000101")
+	_, err = psid.VerifyPresentation(other, p, trusted, upk, now)
+	require.Error(t, err)
+	other.Nonce = req.Nonce
+	other.Predicates = other.Predicates[:1]
+	_, err = psid.VerifyPresentation(other, p, trusted, upk, now)
+	require.NoError(t, err)
+	// a replayed proof does not verify for another nonce
+	other.Nonce = newRequest([]string{"Class"}).Nonce
+	p.Nonce = other.Nonce
+	_, err = psid.VerifyPresentation(other, p, trusted, upk, now)
+	require.EqualError(t, err, "presentation proof is invalid")
+	p.Nonce = req.Nonce
+	_, err = psid.VerifyPresentation(req, p, trusted, upk, req.Expires)
+	require.Error(t, err)
+	_, err = psid.VerifyPresentation(req, p, trusted[:1], upk, now)
+	require.Error(t, err)
+	_, err = psid.VerifyPresentation(newRequest([]string{psidentity.IssuerAttributeOne, "Class"}), p, trusted, upk, now)
+	require.Error(t, err)
+
+	// a tampered predicate does not verify
+	tampered := &Presentation{}
+	require.NoError(t, json.Unmarshal(raw, tampered))
+	for _, a := range tampered.Aggregates {
+		for _, proof := range a.Proofs {
+			for _, pp := range proof.Predicates {
+				if pp.Op == PredicateLessOrEqual {
+					pp.Value = "20000101"
+				}
+			}
+		}
+	}
+	_, err = psid.VerifyPresentation(req, tampered, trusted, upk, now)
+	require.Error(t, err)
+
+	// the schema of a cred is the one of its issuer key, whatever schema the presentation names
+	for _, name := range []string{DefaultSchema, license.Name} {
+		tampered := &Presentation{}
+		require.NoError(t, json.Unmarshal(raw, tampered))
+		for _, a := range tampered.Aggregates {
+			for k := range a.Schemas {
+				a.Schemas[k] = name
+			}
+		}
+		_, err = psid.VerifyPresentation(req, tampered, trusted, upk, now)
+		require.Error(t, err)
+	}
+
+	// creds disclosing different values of an attribute are refused
+	conflicting := NewWallet(t.TempDir(), psid)
+	conflictingAttrs := append([]string(nil), deviceAttrs...)
+	conflictingAttrs[1] = "companyB"
+	_, err = conflicting.Import(issueDevice(conflictingAttrs), deviceIpk, nil)
+	require.NoError(t, err)
+	twoReq := newRequest([]string{psidentity.IssuerAttributeTwo})
+	for _, wallet := range []*Wallet{w, conflicting} {
+		first, err := w.ResolvePresentation(twoReq, store, psidentity.PsIdentityDirUserKey, now, rng)
+		require.NoError(t, err)
+		second, err := wallet.ResolvePresentation(twoReq, store, psidentity.PsIdentityDirUserKey, now, rng)
+		require.NoError(t, err)
+		first.Aggregates = append(first.Aggregates, second.Aggregates...)
+		disclosed, err := psid.VerifyPresentation(twoReq, first, trusted, upk, now)
+		if wallet == w {
+			require.NoError(t, err)
+			require.Equal(t, psidentity.UserAttributeManufacturer, disclosed[psidentity.IssuerAttributeTwo])
+		} else {
+			require.EqualError(t, err, "credentials disclose conflicting values of attribute "+psidentity.IssuerAttributeTwo)
+		}
+	}
+
+	// a disclosed attribute changed in the presented aggregate does not verify, even when the holder signs the
+	// aggregate again with its own user key
+	usk := &UserPrivateKey{}
+	require.NoError(t, proto.Unmarshal(uskBytes, usk))
+	for _, value := range []string{"B", "A"} {
+		tampered := &Presentation{}
+		require.NoError(t, json.Unmarshal(raw, tampered))
+		for _, a := range tampered.Aggregates {
+			aggregate := &AggregateCredential{}
+			require.NoError(t, proto.Unmarshal(a.Credential, aggregate))
+			for k, message := range aggregate.Messages {
+				if a.Schemas[k] == license.Name {
+					message.DiscloseMsg[1] = value
+				}
+			}
+			resignAggregate(t, psid, usk, upk, aggregate, rng)
+			a.Credential, err = proto.Marshal(aggregate)
+			require.NoError(t, err)
+		}
+		disclosed, err = psid.VerifyPresentation(req, tampered, trusted, upk, now)
+		if value == "B" {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+			require.Nil(t, disclosed)
+		}
+	}
+
+	// a presentation aggregated with another user key does not verify with the user public key
+	anotherStore := NewMemoryKeyStore(psid)
+	anotherUsk, anotherUpk, err := GenerateUserKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	require.NoError(t, anotherStore.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserPublicKey}, anotherUpk))
+	require.NoError(t, anotherStore.Put(KeyID{psidentity.PsIdentityDirUserKey, psidentity.PsIdentityConfigUserSecretKey}, anotherUsk))
+	foreign, err := w.ResolvePresentation(req, anotherStore, psidentity.PsIdentityDirUserKey, now, rng)
+	require.NoError(t, err)
+	_, err = psid.VerifyPresentation(req, foreign, trusted, upk, now)
+	require.Error(t, err)
+
+	// the holder cannot answer requests its creds do not satisfy
+	_, err = w.ResolvePresentation(newRequest([]string{"Class"}, "Birth>=20100101"), store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.Error(t, err)
+	_, err = w.ResolvePresentation(req, store, psidentity.PsIdentityDirUserKey, now.Add(48*time.Hour), rng)
+	require.Error(t, err)
+	restricted := newRequest([]string{"Class"})
+	restricted.Issuers = []string{IssuerKeyID(deviceIpk)}
+	_, err = w.ResolvePresentation(restricted, store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.Error(t, err)
+	_, err = w.ResolvePresentation(newRequest([]string{"Class"}, "NotAfter>=000000000001"), store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.Error(t, err)
+
+	// the derived cred of a device cred has the validity proof, a predicate on the validity window is proven with it
+	req = newRequest([]string{psidentity.IssuerAttributeOne}, psidentity.IssuerAttributeNotAfter+">="+EncodeTimeAttribute(now.Add(30*time.Minute)))
+	p, err = w.ResolvePresentation(req, store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.NoError(t, err)
+	require.Len(t, p.Aggregates, 1)
+	aggregate := &AggregateCredential{}
+	require.NoError(t, proto.Unmarshal(p.Aggregates[0].Credential, aggregate))
+	require.NotNil(t, aggregate.Messages[0].GetValidityProof())
+	disclosed, err = psid.VerifyPresentation(req, p, trusted, upk, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{psidentity.IssuerAttributeOne: psidentity.UserAttributeNumber}, disclosed)
+
+	// with a non-revocation policy the creds must prove that their revocation handle is not revoked
+	revKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	unrevoked := []*math.Zr{psid.RevocationHandle(psidentity.UserAttributeLevel), psid.RevocationHandle("holder")}
+	cri, err := psid.CreateCRI(revKey, unrevoked, 2, psidentity.ALG_EPOCH_SIGNATURE, rng, psid.Translator)
+	require.NoError(t, err)
+	req = newRequest([]string{psidentity.IssuerAttributeTwo, "Class"})
+	withoutProof, err := w.ResolvePresentation(req, store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.NoError(t, err)
+	w.CRIs = map[string]*CredentialRevocationInformation{IssuerKeyID(deviceIpk): cri, IssuerKeyID(licenseIpk): cri}
+	withProof, err := w.ResolvePresentation(req, store, psidentity.PsIdentityDirUserKey, now, rng)
+	require.NoError(t, err)
+	psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &revKey.PublicKey, Epoch: 2, HandleIndex: psidentity.AttributeIndexRevocationHandle}
+	defer func() { psid.NonRevocation = nil }()
+	_, err = psid.VerifyPresentation(req, withProof, trusted, upk, now)
+	require.NoError(t, err)
+	_, err = psid.VerifyPresentation(req, withoutProof, trusted, upk, now)
+	require.Error(t, err)
+	psid.NonRevocation.Epoch = 3
+	_, err = psid.VerifyPresentation(req, withProof, trusted, upk, now)
+	require.Error(t, err)
+}
+
+// resignAggregate signs the messages of the aggregate credential with the user key, without verifying them
+func resignAggregate(t *testing.T, psid *Psidentity, usk *UserPrivateKey, upk *UserPublicKey, cred *AggregateCredential, rng io.Reader) {
+	curve, tr := psid.Curve, psid.Translator
+	sigma := curve.NewZrFromInt(0)
+	for i, message := range cred.Messages {
+		Di, err := messageDigest(message, curve, tr)
+		require.NoError(t, err)
+		sigma = sigma.Plus(curve.NewZrFromBytes(usk.W[i]).Mul(Di))
+	}
+	BBar, err := tr.G2FromProto(upk.BBar)
+	require.NoError(t, err)
+	BBar.Add(curve.GenG2.Mul(sigma))
+	k := curve.NewRandomZr(rng)
+	cred.SigmaOnepp = tr.G2ToProto(curve.GenG2.Mul(k))
+	cred.SigmaTwopp = tr.G2ToProto(BBar.Mul(k))
+}
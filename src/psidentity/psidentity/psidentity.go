@@ -2,12 +2,19 @@ package psidentity
 
 import (
 	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	amcl "psidentity/translator/amcl"
 )
 
 type Psidentity struct {
 	Curve      *math.Curve
 	Translator Translator
+	// Parallelism is the number of workers verifying the messages of aggregate credentials,
+	// runtime.GOMAXPROCS(0) if it is not positive
+	Parallelism int
+	// NonRevocation, if set, is the non-revocation the verifiers require from every derived credential
+	NonRevocation *NonRevocationPolicy
 }
 
 type Translator interface {
@@ -16,5 +23,93 @@ type Translator interface {
 	G1FromRawBytes([]byte) (*math.G1, error)
 	G2ToProto(*math.G2) *amcl.ECP2
 	G2FromProto(*amcl.ECP2) (*math.G2, error)
+	G1ToCompressedProto(*math.G1) *amcl.ECP
+	G2ToCompressedProto(*math.G2) *amcl.ECP2
+}
+
+// compressedTranslator encodes points in the compressed form, it decodes both forms
+type compressedTranslator struct {
+	Translator
+}
+
+// CompressedTranslator returns a translator that encodes points like t but in the compressed form
+func CompressedTranslator(t Translator) Translator {
+	if IsCompressedTranslator(t) {
+		return t
+	}
+	return &compressedTranslator{Translator: t}
+}
+
+// IsCompressedTranslator reports whether t encodes points in the compressed form
+func IsCompressedTranslator(t Translator) bool {
+	_, ok := t.(*compressedTranslator)
+	return ok
+}
+
+// uncompressedTranslator returns a translator that encodes points like t but in the uncompressed form
+func uncompressedTranslator(t Translator) Translator {
+	if c, ok := t.(*compressedTranslator); ok {
+		return c.Translator
+	}
+	return t
+}
+
+// encodedSize returns the serialized size of m with its points encoded by t
+func encodedSize(m proto.Message, t Translator) (int, error) {
+	c := proto.Clone(m)
+	if err := encodePoints(proto.MessageReflect(c), t); err != nil {
+		return 0, err
+	}
+	raw, err := proto.Marshal(c)
+	return len(raw), err
+}
+
+// encodePoints encodes the points of m, in either form, with t
+func encodePoints(m protoreflect.Message, t Translator) error {
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Kind() != protoreflect.MessageKind || fd.IsMap():
+		case fd.IsList():
+			for k := 0; k < v.List().Len() && err == nil; k++ {
+				err = encodePoint(v.List().Get(k).Message(), t)
+			}
+		default:
+			err = encodePoint(v.Message(), t)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// encodePoint encodes m with t if it is a point, the points of m otherwise
+func encodePoint(m protoreflect.Message, t Translator) error {
+	switch p := m.Interface().(type) {
+	case *amcl.ECP:
+		g1, err := t.G1FromProto(p)
+		if err != nil {
+			return err
+		}
+		e := t.G1ToProto(g1)
+		p.X, p.Y, p.Compressed = e.X, e.Y, e.Compressed
+	case *amcl.ECP2:
+		g2, err := t.G2FromProto(p)
+		if err != nil {
+			return err
+		}
+		e := t.G2ToProto(g2)
+		p.Xa, p.Xb, p.Ya, p.Yb, p.Compressed = e.Xa, e.Xb, e.Ya, e.Yb, e.Compressed
+	default:
+		return encodePoints(m, t)
+	}
+	return nil
+}
+
+func (c *compressedTranslator) G1ToProto(g1 *math.G1) *amcl.ECP {
+	return c.Translator.G1ToCompressedProto(g1)
+}
+
+func (c *compressedTranslator) G2ToProto(g2 *math.G2) *amcl.ECP2 {
+	return c.Translator.G2ToCompressedProto(g2)
 }
 
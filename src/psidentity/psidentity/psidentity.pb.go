@@ -908,9 +908,25 @@ type IssuerPublicKeyPS struct {
 	X    *amcl.ECP    `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
 	Y    []*amcl.ECP  `protobuf:"bytes,2,rep,name=Y,proto3" json:"Y,omitempty"`
 	YBar []*amcl.ECP2 `protobuf:"bytes,3,rep,name=YBar,proto3" json:"YBar,omitempty"`
-	ZIj  []*amcl.ECP  `protobuf:"bytes,4,rep,name=Z_ij,json=ZIj,proto3" json:"Z_ij,omitempty"`
+	// Z_ij = g_1^{y_i y_j} for i < j, row by row, see zijIndex
+	ZIj []*amcl.ECP `protobuf:"bytes,4,rep,name=Z_ij,json=ZIj,proto3" json:"Z_ij,omitempty"`
 	//repeated repeated amcl.ECP Z_ij = 4;
 	Hash []byte `protobuf:"bytes,5,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	// curve_id is the curve the key was generated on, see CurveName
+	CurveId string `protobuf:"bytes,6,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
+	// version numbers the keys of an issuer from 1, 0 for keys created before key rotation.
+	// not_before is the unix time the key issues credentials from and predecessor_hash the hash
+	// of the key it replaces. They are covered by Hash, see IssuerKeyID for the key ID.
+	Version         uint32 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	NotBefore       int64  `protobuf:"varint,8,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	PredecessorHash []byte `protobuf:"bytes,9,opt,name=predecessor_hash,json=predecessorHash,proto3" json:"predecessor_hash,omitempty"`
+	// validity_window tells whether the credentials of the key end with the not-before and not-after
+	// attributes of their validity window, see AppendValidity. It is covered by Hash.
+	ValidityWindow bool `protobuf:"varint,10,opt,name=validity_window,json=validityWindow,proto3" json:"validity_window,omitempty"`
+	// schema names the credentials of the key and attribute_names their attributes, in order, see
+	// CredentialSchema. They are covered by Hash. Keys without a schema issue credentials of the DefaultSchema.
+	Schema         string   `protobuf:"bytes,11,opt,name=schema,proto3" json:"schema,omitempty"`
+	AttributeNames []string `protobuf:"bytes,12,rep,name=attribute_names,json=attributeNames,proto3" json:"attribute_names,omitempty"`
 }
 
 func (x *IssuerPublicKeyPS) Reset() {
@@ -980,13 +996,63 @@ func (x *IssuerPublicKeyPS) GetHash() []byte {
 	return nil
 }
 
+func (x *IssuerPublicKeyPS) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
+func (x *IssuerPublicKeyPS) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *IssuerPublicKeyPS) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *IssuerPublicKeyPS) GetPredecessorHash() []byte {
+	if x != nil {
+		return x.PredecessorHash
+	}
+	return nil
+}
+
+func (x *IssuerPublicKeyPS) GetValidityWindow() bool {
+	if x != nil {
+		return x.ValidityWindow
+	}
+	return false
+}
+
+func (x *IssuerPublicKeyPS) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *IssuerPublicKeyPS) GetAttributeNames() []string {
+	if x != nil {
+		return x.AttributeNames
+	}
+	return nil
+}
+
 type IssuerPrivateKeyPS struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	X []byte   `protobuf:"bytes,1,opt,name=x,proto3" json:"x,omitempty"`
-	Y [][]byte `protobuf:"bytes,2,rep,name=y,proto3" json:"y,omitempty"`
+	X       []byte   `protobuf:"bytes,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y       [][]byte `protobuf:"bytes,2,rep,name=y,proto3" json:"y,omitempty"`
+	CurveId string   `protobuf:"bytes,3,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *IssuerPrivateKeyPS) Reset() {
@@ -1035,6 +1101,13 @@ func (x *IssuerPrivateKeyPS) GetY() [][]byte {
 	return nil
 }
 
+func (x *IssuerPrivateKeyPS) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 type IssuerKeyPS struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1174,9 +1247,11 @@ type BlindCredential struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	H *amcl.ECP2 `protobuf:"bytes,1,opt,name=h,proto3" json:"h,omitempty"`
-	S *amcl.ECP2 `protobuf:"bytes,2,opt,name=s,proto3" json:"s,omitempty"`
-	C []byte     `protobuf:"bytes,3,opt,name=c,proto3" json:"c,omitempty"`
+	H         *amcl.ECP2 `protobuf:"bytes,1,opt,name=h,proto3" json:"h,omitempty"`
+	S         *amcl.ECP2 `protobuf:"bytes,2,opt,name=s,proto3" json:"s,omitempty"`
+	C         []byte     `protobuf:"bytes,3,opt,name=c,proto3" json:"c,omitempty"`
+	NotBefore string     `protobuf:"bytes,4,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter  string     `protobuf:"bytes,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
 }
 
 func (x *BlindCredential) Reset() {
@@ -1232,6 +1307,20 @@ func (x *BlindCredential) GetC() []byte {
 	return nil
 }
 
+func (x *BlindCredential) GetNotBefore() string {
+	if x != nil {
+		return x.NotBefore
+	}
+	return ""
+}
+
+func (x *BlindCredential) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
 type PrimaryCredential struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1241,6 +1330,8 @@ type PrimaryCredential struct {
 	H     *amcl.ECP2 `protobuf:"bytes,2,opt,name=h,proto3" json:"h,omitempty"`
 	S     *amcl.ECP2 `protobuf:"bytes,3,opt,name=s,proto3" json:"s,omitempty"`
 	C     []byte     `protobuf:"bytes,4,opt,name=c,proto3" json:"c,omitempty"`
+	// issuer_key_id is the ID of the issuer key that signed the credential, see IssuerKeyID
+	IssuerKeyId string `protobuf:"bytes,5,opt,name=issuer_key_id,json=issuerKeyId,proto3" json:"issuer_key_id,omitempty"`
 }
 
 func (x *PrimaryCredential) Reset() {
@@ -1303,6 +1394,13 @@ func (x *PrimaryCredential) GetC() []byte {
 	return nil
 }
 
+func (x *PrimaryCredential) GetIssuerKeyId() string {
+	if x != nil {
+		return x.IssuerKeyId
+	}
+	return ""
+}
+
 type DeriveCredential struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1314,6 +1412,15 @@ type DeriveCredential struct {
 	SigmaTwop       *amcl.ECP  `protobuf:"bytes,4,opt,name=sigma_twop,json=sigmaTwop,proto3" json:"sigma_twop,omitempty"`
 	DiscloseIndices []int64    `protobuf:"varint,5,rep,packed,name=disclose_indices,json=discloseIndices,proto3" json:"disclose_indices,omitempty"`
 	DiscloseMsg     []string   `protobuf:"bytes,6,rep,name=disclose_msg,json=discloseMsg,proto3" json:"disclose_msg,omitempty"`
+	// epoch, revocation_epoch_pk, revocation_pk_sig are taken from the CRI of the epoch
+	// the non-revocation proof is made for
+	Epoch              int64               `protobuf:"varint,7,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	RevocationEpochPk  *amcl.ECP2          `protobuf:"bytes,8,opt,name=revocation_epoch_pk,json=revocationEpochPk,proto3" json:"revocation_epoch_pk,omitempty"`
+	RevocationPkSig    []byte              `protobuf:"bytes,9,opt,name=revocation_pk_sig,json=revocationPkSig,proto3" json:"revocation_pk_sig,omitempty"`
+	NonRevocationProof *NonRevocationProof `protobuf:"bytes,10,opt,name=non_revocation_proof,json=nonRevocationProof,proto3" json:"non_revocation_proof,omitempty"`
+	ValidityProof      *ValidityProof      `protobuf:"bytes,11,opt,name=validity_proof,json=validityProof,proto3" json:"validity_proof,omitempty"`
+	// issuer_key_id is the ID of the issuer key that signed the primary credential, see IssuerKeyID
+	IssuerKeyId string `protobuf:"bytes,12,opt,name=issuer_key_id,json=issuerKeyId,proto3" json:"issuer_key_id,omitempty"`
 }
 
 func (x *DeriveCredential) Reset() {
@@ -1390,6 +1497,48 @@ func (x *DeriveCredential) GetDiscloseMsg() []string {
 	return nil
 }
 
+func (x *DeriveCredential) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *DeriveCredential) GetRevocationEpochPk() *amcl.ECP2 {
+	if x != nil {
+		return x.RevocationEpochPk
+	}
+	return nil
+}
+
+func (x *DeriveCredential) GetRevocationPkSig() []byte {
+	if x != nil {
+		return x.RevocationPkSig
+	}
+	return nil
+}
+
+func (x *DeriveCredential) GetNonRevocationProof() *NonRevocationProof {
+	if x != nil {
+		return x.NonRevocationProof
+	}
+	return nil
+}
+
+func (x *DeriveCredential) GetValidityProof() *ValidityProof {
+	if x != nil {
+		return x.ValidityProof
+	}
+	return nil
+}
+
+func (x *DeriveCredential) GetIssuerKeyId() string {
+	if x != nil {
+		return x.IssuerKeyId
+	}
+	return ""
+}
+
 type UserKey struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1450,8 +1599,9 @@ type UserPrivateKey struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	B []byte   `protobuf:"bytes,1,opt,name=b,proto3" json:"b,omitempty"`
-	W [][]byte `protobuf:"bytes,2,rep,name=w,proto3" json:"w,omitempty"`
+	B       []byte   `protobuf:"bytes,1,opt,name=b,proto3" json:"b,omitempty"`
+	W       [][]byte `protobuf:"bytes,2,rep,name=w,proto3" json:"w,omitempty"`
+	CurveId string   `protobuf:"bytes,3,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *UserPrivateKey) Reset() {
@@ -1500,6 +1650,13 @@ func (x *UserPrivateKey) GetW() [][]byte {
 	return nil
 }
 
+func (x *UserPrivateKey) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 type UserPublicKey struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1510,6 +1667,8 @@ type UserPublicKey struct {
 	W    []*amcl.ECP  `protobuf:"bytes,3,rep,name=w,proto3" json:"w,omitempty"`
 	WBar []*amcl.ECP2 `protobuf:"bytes,4,rep,name=w_bar,json=wBar,proto3" json:"w_bar,omitempty"`
 	Hash []byte       `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	// curve_id is the curve the key was generated on, see CurveName
+	CurveId string `protobuf:"bytes,6,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *UserPublicKey) Reset() {
@@ -1579,6 +1738,13 @@ func (x *UserPublicKey) GetHash() []byte {
 	return nil
 }
 
+func (x *UserPublicKey) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 type AggregateCredential struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1706,6 +1872,8 @@ type Accumulator struct {
 	U   [][]byte `protobuf:"bytes,2,rep,name=U,proto3" json:"U,omitempty"`
 	N   []byte   `protobuf:"bytes,3,opt,name=N,proto3" json:"N,omitempty"`
 	G   []byte   `protobuf:"bytes,4,opt,name=G,proto3" json:"G,omitempty"`
+	// hash_to_prime_version is the HashToPrimeVersion members are mapped to primes with, 0 for Hprime
+	HashToPrimeVersion uint32 `protobuf:"varint,5,opt,name=hash_to_prime_version,json=hashToPrimeVersion,proto3" json:"hash_to_prime_version,omitempty"`
 }
 
 func (x *Accumulator) Reset() {
@@ -1768,6 +1936,13 @@ func (x *Accumulator) GetG() []byte {
 	return nil
 }
 
+func (x *Accumulator) GetHashToPrimeVersion() uint32 {
+	if x != nil {
+		return x.HashToPrimeVersion
+	}
+	return 0
+}
+
 type WitnessList struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1823,260 +1998,1598 @@ func (x *WitnessList) GetList() map[string][]byte {
 	return nil
 }
 
-var File_psidentity_proto protoreflect.FileDescriptor
+// RsaTrapdoor is the factorization of the accumulator modulus N = P * Q.
+// It is only held by the revocation authority and is never published.
+type RsaTrapdoor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_psidentity_proto_rawDesc = []byte{
-	0x0a, 0x10, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x0a, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x1a, 0x1a,
-	0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x61, 0x6d, 0x63, 0x6c, 0x2f,
-	0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc2, 0x02, 0x0a, 0x0f, 0x49,
-	0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x27,
-	0x0a, 0x0f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x04, 0x68, 0x5f, 0x73, 0x6b, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
-	0x52, 0x03, 0x68, 0x53, 0x6b, 0x12, 0x20, 0x0a, 0x06, 0x68, 0x5f, 0x72, 0x61, 0x6e, 0x64, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
-	0x52, 0x05, 0x68, 0x52, 0x61, 0x6e, 0x64, 0x12, 0x22, 0x0a, 0x07, 0x68, 0x5f, 0x61, 0x74, 0x74,
-	0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
-	0x45, 0x43, 0x50, 0x52, 0x06, 0x68, 0x41, 0x74, 0x74, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x01, 0x77,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
-	0x50, 0x32, 0x52, 0x01, 0x77, 0x12, 0x20, 0x0a, 0x06, 0x62, 0x61, 0x72, 0x5f, 0x67, 0x31, 0x18,
-	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
-	0x52, 0x05, 0x62, 0x61, 0x72, 0x47, 0x31, 0x12, 0x20, 0x0a, 0x06, 0x62, 0x61, 0x72, 0x5f, 0x67,
-	0x32, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
-	0x43, 0x50, 0x52, 0x05, 0x62, 0x61, 0x72, 0x47, 0x32, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f,
-	0x6f, 0x66, 0x5f, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f,
-	0x66, 0x43, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x18, 0x09, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x12, 0x12, 0x0a, 0x04, 0x68,
-	0x61, 0x73, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22,
-	0x4c, 0x0a, 0x09, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03,
-	0x69, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x69, 0x73, 0x6b, 0x12, 0x2d,
-	0x0a, 0x03, 0x69, 0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x73,
-	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50,
-	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x69, 0x70, 0x6b, 0x22, 0x70, 0x0a,
-	0x0a, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x17, 0x0a, 0x01, 0x61,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
-	0x50, 0x52, 0x01, 0x61, 0x12, 0x17, 0x0a, 0x01, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x62, 0x12, 0x0c, 0x0a,
-	0x01, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x73,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x74, 0x74,
-	0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x22,
-	0x7f, 0x0a, 0x0b, 0x43, 0x72, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b,
-	0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d,
-	0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x21, 0x0a, 0x0c, 0x69,
-	0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x17,
-	0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66,
-	0x5f, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53,
-	0x22, 0x45, 0x0a, 0x06, 0x45, 0x49, 0x44, 0x4e, 0x79, 0x6d, 0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79,
-	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
-	0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x1e, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66,
-	0x5f, 0x73, 0x5f, 0x65, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x72,
-	0x6f, 0x6f, 0x66, 0x53, 0x45, 0x69, 0x64, 0x22, 0x42, 0x0a, 0x05, 0x52, 0x48, 0x4e, 0x79, 0x6d,
-	0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e,
-	0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x1c, 0x0a,
-	0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x68, 0x22, 0xca, 0x05, 0x0a, 0x09,
-	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x22, 0x0a, 0x07, 0x61, 0x5f, 0x70,
-	0x72, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63,
-	0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x06, 0x61, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a,
-	0x05, 0x61, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61,
-	0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x04, 0x61, 0x42, 0x61, 0x72, 0x12, 0x22, 0x0a,
-	0x07, 0x62, 0x5f, 0x70, 0x72, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
-	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x06, 0x62, 0x50, 0x72, 0x69, 0x6d,
-	0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72,
-	0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
-	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x53, 0x6b, 0x12, 0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f,
-	0x66, 0x5f, 0x73, 0x5f, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f,
-	0x6f, 0x66, 0x53, 0x45, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f,
-	0x72, 0x32, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53,
-	0x52, 0x32, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x33,
-	0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x33,
-	0x12, 0x25, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x5f, 0x70, 0x72,
-	0x69, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x6f, 0x66,
-	0x53, 0x53, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66,
-	0x5f, 0x73, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0b,
-	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x41, 0x74, 0x74, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e,
-	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
-	0x65, 0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
-	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x21,
-	0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x5f, 0x6e, 0x79, 0x6d, 0x18,
-	0x0d, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x4e, 0x79,
-	0x6d, 0x12, 0x3a, 0x0a, 0x13, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
-	0x65, 0x70, 0x6f, 0x63, 0x68, 0x5f, 0x70, 0x6b, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
-	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x11, 0x72, 0x65, 0x76, 0x6f,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x12, 0x2a, 0x0a,
-	0x11, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x6b, 0x5f, 0x73,
-	0x69, 0x67, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6b, 0x53, 0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f,
-	0x63, 0x68, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12,
-	0x50, 0x0a, 0x14, 0x6e, 0x6f, 0x6e, 0x5f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
-	0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x4e, 0x6f, 0x6e, 0x52, 0x65,
-	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x12, 0x6e,
-	0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f,
-	0x66, 0x12, 0x2b, 0x0a, 0x07, 0x65, 0x69, 0x64, 0x5f, 0x6e, 0x79, 0x6d, 0x18, 0x12, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e,
-	0x45, 0x49, 0x44, 0x4e, 0x79, 0x6d, 0x52, 0x06, 0x65, 0x69, 0x64, 0x4e, 0x79, 0x6d, 0x12, 0x28,
-	0x0a, 0x06, 0x72, 0x68, 0x5f, 0x6e, 0x79, 0x6d, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
-	0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x52, 0x48, 0x4e, 0x79,
-	0x6d, 0x52, 0x05, 0x72, 0x68, 0x4e, 0x79, 0x6d, 0x22, 0x6d, 0x0a, 0x12, 0x4e, 0x6f, 0x6e, 0x52,
-	0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x25,
-	0x0a, 0x0e, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x6c, 0x67,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x41, 0x6c, 0x67, 0x12, 0x30, 0x0a, 0x14, 0x6e, 0x6f, 0x6e, 0x5f, 0x72, 0x65, 0x76,
-	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x12, 0x6e, 0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0x7e, 0x0a, 0x0c, 0x4e, 0x79, 0x6d, 0x53, 0x69,
-	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66,
-	0x5f, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43,
-	0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x6b, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x53, 0x6b, 0x12, 0x21,
-	0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x5f, 0x6e, 0x79, 0x6d, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x4e, 0x79,
-	0x6d, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x22, 0xd0, 0x01, 0x0a, 0x1f, 0x43, 0x72, 0x65, 0x64,
-	0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x49, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x65,
-	0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63,
-	0x68, 0x12, 0x25, 0x0a, 0x08, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x5f, 0x70, 0x6b, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52,
-	0x07, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x12, 0x20, 0x0a, 0x0c, 0x65, 0x70, 0x6f, 0x63,
-	0x68, 0x5f, 0x70, 0x6b, 0x5f, 0x73, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a,
-	0x65, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x53, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65,
-	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x6c, 0x67, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x6c,
-	0x67, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
-	0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x72, 0x65, 0x76, 0x6f,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x22, 0x97, 0x01, 0x0a, 0x11, 0x49,
-	0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x50, 0x53,
-	0x12, 0x17, 0x0a, 0x01, 0x58, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d,
-	0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x58, 0x12, 0x17, 0x0a, 0x01, 0x59, 0x18, 0x02,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52,
-	0x01, 0x59, 0x12, 0x1e, 0x0a, 0x04, 0x59, 0x42, 0x61, 0x72, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x04, 0x59, 0x42,
-	0x61, 0x72, 0x12, 0x1c, 0x0a, 0x04, 0x5a, 0x5f, 0x69, 0x6a, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x5a, 0x49, 0x6a,
-	0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x48, 0x61, 0x73, 0x68, 0x22, 0x30, 0x0a, 0x12, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x72,
-	0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50, 0x53, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20,
-	0x03, 0x28, 0x0c, 0x52, 0x01, 0x79, 0x22, 0x70, 0x0a, 0x0b, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72,
-	0x4b, 0x65, 0x79, 0x50, 0x53, 0x12, 0x30, 0x0a, 0x03, 0x69, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e,
-	0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79,
-	0x50, 0x53, 0x52, 0x03, 0x69, 0x73, 0x6b, 0x12, 0x2f, 0x0a, 0x03, 0x69, 0x70, 0x6b, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74,
-	0x79, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65,
-	0x79, 0x50, 0x53, 0x52, 0x03, 0x69, 0x70, 0x6b, 0x22, 0x7b, 0x0a, 0x0d, 0x43, 0x72, 0x65, 0x64,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x50, 0x53, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d,
-	0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x63,
-	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x6b, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c,
-	0x65, 0x6e, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c,
-	0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x72, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x02, 0x72, 0x70, 0x12, 0x0e, 0x0a, 0x02, 0x72, 0x77, 0x18, 0x05, 0x20, 0x03, 0x28,
-	0x0c, 0x52, 0x02, 0x72, 0x77, 0x22, 0x53, 0x0a, 0x0f, 0x42, 0x6c, 0x69, 0x6e, 0x64, 0x43, 0x72,
-	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x18, 0x0a, 0x01, 0x68, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52,
-	0x01, 0x68, 0x12, 0x18, 0x0a, 0x01, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e,
-	0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01, 0x73, 0x12, 0x0c, 0x0a, 0x01,
-	0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x63, 0x22, 0x6b, 0x0a, 0x11, 0x50, 0x72,
-	0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12,
-	0x14, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
-	0x61, 0x74, 0x74, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x01, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01, 0x68, 0x12,
-	0x18, 0x0a, 0x01, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63,
-	0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01, 0x73, 0x12, 0x0c, 0x0a, 0x01, 0x63, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x63, 0x22, 0xec, 0x01, 0x0a, 0x10, 0x44, 0x65, 0x72, 0x69,
-	0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x02,
-	0x68, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
-	0x45, 0x43, 0x50, 0x32, 0x52, 0x02, 0x68, 0x70, 0x12, 0x1a, 0x0a, 0x02, 0x73, 0x70, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32,
-	0x52, 0x02, 0x73, 0x70, 0x12, 0x28, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x5f, 0x6f, 0x6e,
-	0x65, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
-	0x45, 0x43, 0x50, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x4f, 0x6e, 0x65, 0x70, 0x12, 0x28,
-	0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x5f, 0x74, 0x77, 0x6f, 0x70, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x09, 0x73,
-	0x69, 0x67, 0x6d, 0x61, 0x54, 0x77, 0x6f, 0x70, 0x12, 0x29, 0x0a, 0x10, 0x64, 0x69, 0x73, 0x63,
-	0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03,
-	0x28, 0x03, 0x52, 0x0f, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x49, 0x6e, 0x64, 0x69,
-	0x63, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f,
-	0x6d, 0x73, 0x67, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x63, 0x6c,
-	0x6f, 0x73, 0x65, 0x4d, 0x73, 0x67, 0x22, 0x64, 0x0a, 0x07, 0x55, 0x73, 0x65, 0x72, 0x4b, 0x65,
-	0x79, 0x12, 0x2c, 0x0a, 0x03, 0x75, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
-	0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x55, 0x73, 0x65, 0x72,
-	0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x75, 0x73, 0x6b, 0x12,
-	0x2b, 0x0a, 0x03, 0x75, 0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70,
-	0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x50, 0x75,
-	0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x75, 0x70, 0x6b, 0x22, 0x2c, 0x0a, 0x0e,
-	0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x0c,
-	0x0a, 0x01, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x62, 0x12, 0x0c, 0x0a, 0x01,
-	0x77, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x01, 0x77, 0x22, 0x97, 0x01, 0x0a, 0x0d, 0x55,
-	0x73, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x17, 0x0a, 0x01,
-	0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
-	0x43, 0x50, 0x52, 0x01, 0x62, 0x12, 0x1f, 0x0a, 0x05, 0x62, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32,
-	0x52, 0x04, 0x62, 0x42, 0x61, 0x72, 0x12, 0x17, 0x0a, 0x01, 0x77, 0x18, 0x03, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x77, 0x12,
-	0x1f, 0x0a, 0x05, 0x77, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a,
-	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x04, 0x77, 0x42, 0x61, 0x72,
-	0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x68, 0x61, 0x73, 0x68, 0x22, 0xa9, 0x01, 0x0a, 0x13, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
-	0x74, 0x65, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x2b, 0x0a, 0x0b,
-	0x73, 0x69, 0x67, 0x6d, 0x61, 0x5f, 0x6f, 0x6e, 0x65, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x0a, 0x73,
-	0x69, 0x67, 0x6d, 0x61, 0x4f, 0x6e, 0x65, 0x70, 0x70, 0x12, 0x2b, 0x0a, 0x0b, 0x73, 0x69, 0x67,
-	0x6d, 0x61, 0x5f, 0x74, 0x77, 0x6f, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
-	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6d,
-	0x61, 0x54, 0x77, 0x6f, 0x70, 0x70, 0x12, 0x38, 0x0a, 0x08, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65,
-	0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64,
-	0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x08, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73,
-	0x22, 0x24, 0x0a, 0x06, 0x52, 0x73, 0x61, 0x4b, 0x65, 0x79, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x47, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x01, 0x47, 0x22, 0x49, 0x0a, 0x0b, 0x41, 0x63, 0x63, 0x75, 0x6d, 0x75,
-	0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x41, 0x63, 0x63, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x03, 0x41, 0x63, 0x63, 0x12, 0x0c, 0x0a, 0x01, 0x55, 0x18, 0x02, 0x20, 0x03,
-	0x28, 0x0c, 0x52, 0x01, 0x55, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x01, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x47, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01,
-	0x47, 0x22, 0x8f, 0x01, 0x0a, 0x0b, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x41, 0x63, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
-	0x41, 0x63, 0x63, 0x12, 0x35, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x18, 0x02, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x21, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x57,
-	0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x1a, 0x37, 0x0a, 0x09, 0x4c, 0x69,
-	0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
-	0x02, 0x38, 0x01, 0x42, 0x26, 0x5a, 0x24, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65,
-	0x6e, 0x74, 0x69, 0x74, 0x79, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
-	0x3b, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	P []byte `protobuf:"bytes,1,opt,name=P,proto3" json:"P,omitempty"`
+	Q []byte `protobuf:"bytes,2,opt,name=Q,proto3" json:"Q,omitempty"`
 }
 
-var (
-	file_psidentity_proto_rawDescOnce sync.Once
-	file_psidentity_proto_rawDescData = file_psidentity_proto_rawDesc
-)
+func (x *RsaTrapdoor) Reset() {
+	*x = RsaTrapdoor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_psidentity_proto_rawDescGZIP() []byte {
-	file_psidentity_proto_rawDescOnce.Do(func() {
-		file_psidentity_proto_rawDescData = protoimpl.X.CompressGZIP(file_psidentity_proto_rawDescData)
-	})
-	return file_psidentity_proto_rawDescData
+func (x *RsaTrapdoor) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_psidentity_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
-var file_psidentity_proto_goTypes = []interface{}{
-	(*IssuerPublicKey)(nil),                 // 0: psidentity.IssuerPublicKey
-	(*IssuerKey)(nil),                       // 1: psidentity.IssuerKey
-	(*Credential)(nil),                      // 2: psidentity.Credential
-	(*CredRequest)(nil),                     // 3: psidentity.CredRequest
-	(*EIDNym)(nil),                          // 4: psidentity.EIDNym
+func (*RsaTrapdoor) ProtoMessage() {}
+
+func (x *RsaTrapdoor) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RsaTrapdoor.ProtoReflect.Descriptor instead.
+func (*RsaTrapdoor) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *RsaTrapdoor) GetP() []byte {
+	if x != nil {
+		return x.P
+	}
+	return nil
+}
+
+func (x *RsaTrapdoor) GetQ() []byte {
+	if x != nil {
+		return x.Q
+	}
+	return nil
+}
+
+// SealedKey holds secret key material encrypted under a passphrase.
+// The encryption key is derived with scrypt(passphrase, salt, 2^log_n, r, p)
+// and the payload is sealed with AES-256-GCM.
+type SealedKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Salt       []byte `protobuf:"bytes,1,opt,name=salt,proto3" json:"salt,omitempty"`
+	LogN       uint32 `protobuf:"varint,2,opt,name=log_n,json=logN,proto3" json:"log_n,omitempty"`
+	R          uint32 `protobuf:"varint,3,opt,name=r,proto3" json:"r,omitempty"`
+	P          uint32 `protobuf:"varint,4,opt,name=p,proto3" json:"p,omitempty"`
+	Nonce      []byte `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Ciphertext []byte `protobuf:"bytes,6,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	// kdf is the key derivation function of the sealing key, see SealKDFScrypt and SealKDFArgon2id.
+	// log_n, r and p are the scrypt parameters, argon2_time, argon2_memory and argon2_threads the argon2id parameters.
+	Kdf           uint32 `protobuf:"varint,7,opt,name=kdf,proto3" json:"kdf,omitempty"`
+	Argon2Time    uint32 `protobuf:"varint,8,opt,name=argon2_time,json=argon2Time,proto3" json:"argon2_time,omitempty"`
+	Argon2Memory  uint32 `protobuf:"varint,9,opt,name=argon2_memory,json=argon2Memory,proto3" json:"argon2_memory,omitempty"`
+	Argon2Threads uint32 `protobuf:"varint,10,opt,name=argon2_threads,json=argon2Threads,proto3" json:"argon2_threads,omitempty"`
+}
+
+func (x *SealedKey) Reset() {
+	*x = SealedKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SealedKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SealedKey) ProtoMessage() {}
+
+func (x *SealedKey) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SealedKey.ProtoReflect.Descriptor instead.
+func (*SealedKey) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SealedKey) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *SealedKey) GetLogN() uint32 {
+	if x != nil {
+		return x.LogN
+	}
+	return 0
+}
+
+func (x *SealedKey) GetR() uint32 {
+	if x != nil {
+		return x.R
+	}
+	return 0
+}
+
+func (x *SealedKey) GetP() uint32 {
+	if x != nil {
+		return x.P
+	}
+	return 0
+}
+
+func (x *SealedKey) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *SealedKey) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *SealedKey) GetKdf() uint32 {
+	if x != nil {
+		return x.Kdf
+	}
+	return 0
+}
+
+func (x *SealedKey) GetArgon2Time() uint32 {
+	if x != nil {
+		return x.Argon2Time
+	}
+	return 0
+}
+
+func (x *SealedKey) GetArgon2Memory() uint32 {
+	if x != nil {
+		return x.Argon2Memory
+	}
+	return 0
+}
+
+func (x *SealedKey) GetArgon2Threads() uint32 {
+	if x != nil {
+		return x.Argon2Threads
+	}
+	return 0
+}
+
+// PairingAccumulatorKey is the key of a pairing-based accumulator that consists of
+// alpha - the secret key of the accumulator manager
+// Q - the public key g_2^alpha
+type PairingAccumulatorKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Alpha []byte     `protobuf:"bytes,1,opt,name=alpha,proto3" json:"alpha,omitempty"`
+	Q     *amcl.ECP2 `protobuf:"bytes,2,opt,name=Q,proto3" json:"Q,omitempty"`
+}
+
+func (x *PairingAccumulatorKey) Reset() {
+	*x = PairingAccumulatorKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairingAccumulatorKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairingAccumulatorKey) ProtoMessage() {}
+
+func (x *PairingAccumulatorKey) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairingAccumulatorKey.ProtoReflect.Descriptor instead.
+func (*PairingAccumulatorKey) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PairingAccumulatorKey) GetAlpha() []byte {
+	if x != nil {
+		return x.Alpha
+	}
+	return nil
+}
+
+func (x *PairingAccumulatorKey) GetQ() *amcl.ECP2 {
+	if x != nil {
+		return x.Q
+	}
+	return nil
+}
+
+// PairingAccumulator is the public value V = P^{\prod (y + alpha)} of a pairing-based accumulator
+// over the members y
+type PairingAccumulator struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	V *amcl.ECP `protobuf:"bytes,1,opt,name=V,proto3" json:"V,omitempty"`
+}
+
+func (x *PairingAccumulator) Reset() {
+	*x = PairingAccumulator{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairingAccumulator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairingAccumulator) ProtoMessage() {}
+
+func (x *PairingAccumulator) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairingAccumulator.ProtoReflect.Descriptor instead.
+func (*PairingAccumulator) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PairingAccumulator) GetV() *amcl.ECP {
+	if x != nil {
+		return x.V
+	}
+	return nil
+}
+
+// PairingAccumulatorWitness proves that y is a member of the accumulator V, with W = V^{1/(y + alpha)}
+type PairingAccumulatorWitness struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Y []byte    `protobuf:"bytes,1,opt,name=y,proto3" json:"y,omitempty"`
+	W *amcl.ECP `protobuf:"bytes,2,opt,name=W,proto3" json:"W,omitempty"`
+}
+
+func (x *PairingAccumulatorWitness) Reset() {
+	*x = PairingAccumulatorWitness{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PairingAccumulatorWitness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairingAccumulatorWitness) ProtoMessage() {}
+
+func (x *PairingAccumulatorWitness) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairingAccumulatorWitness.ProtoReflect.Descriptor instead.
+func (*PairingAccumulatorWitness) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PairingAccumulatorWitness) GetY() []byte {
+	if x != nil {
+		return x.Y
+	}
+	return nil
+}
+
+func (x *PairingAccumulatorWitness) GetW() *amcl.ECP {
+	if x != nil {
+		return x.W
+	}
+	return nil
+}
+
+// AccumulatorMembershipProof is a zero-knowledge proof of knowledge of a member y and a witness for y
+// w_prime, v_bar - the randomized witness and the corresponding randomized accumulator
+// proof_c, proof_s_y, proof_s_r - a zero-knowledge proof that v_bar = w_prime^{-y} \cdot V^r
+type AccumulatorMembershipProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WPrime  *amcl.ECP `protobuf:"bytes,1,opt,name=w_prime,json=wPrime,proto3" json:"w_prime,omitempty"`
+	VBar    *amcl.ECP `protobuf:"bytes,2,opt,name=v_bar,json=vBar,proto3" json:"v_bar,omitempty"`
+	ProofC  []byte    `protobuf:"bytes,3,opt,name=proof_c,json=proofC,proto3" json:"proof_c,omitempty"`
+	ProofSY []byte    `protobuf:"bytes,4,opt,name=proof_s_y,json=proofSY,proto3" json:"proof_s_y,omitempty"`
+	ProofSR []byte    `protobuf:"bytes,5,opt,name=proof_s_r,json=proofSR,proto3" json:"proof_s_r,omitempty"`
+	Nonce   []byte    `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (x *AccumulatorMembershipProof) Reset() {
+	*x = AccumulatorMembershipProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccumulatorMembershipProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccumulatorMembershipProof) ProtoMessage() {}
+
+func (x *AccumulatorMembershipProof) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccumulatorMembershipProof.ProtoReflect.Descriptor instead.
+func (*AccumulatorMembershipProof) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *AccumulatorMembershipProof) GetWPrime() *amcl.ECP {
+	if x != nil {
+		return x.WPrime
+	}
+	return nil
+}
+
+func (x *AccumulatorMembershipProof) GetVBar() *amcl.ECP {
+	if x != nil {
+		return x.VBar
+	}
+	return nil
+}
+
+func (x *AccumulatorMembershipProof) GetProofC() []byte {
+	if x != nil {
+		return x.ProofC
+	}
+	return nil
+}
+
+func (x *AccumulatorMembershipProof) GetProofSY() []byte {
+	if x != nil {
+		return x.ProofSY
+	}
+	return nil
+}
+
+func (x *AccumulatorMembershipProof) GetProofSR() []byte {
+	if x != nil {
+		return x.ProofSR
+	}
+	return nil
+}
+
+func (x *AccumulatorMembershipProof) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+// EpochNonRevocationCredential is the non-revocation material of one revocation handle for an epoch,
+// a weak Boneh-Boyen signature sig = g_1^{1/(sk + handle)} under the epoch key
+type EpochNonRevocationCredential struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Handle []byte    `protobuf:"bytes,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	Sig    *amcl.ECP `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+}
+
+func (x *EpochNonRevocationCredential) Reset() {
+	*x = EpochNonRevocationCredential{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EpochNonRevocationCredential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EpochNonRevocationCredential) ProtoMessage() {}
+
+func (x *EpochNonRevocationCredential) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EpochNonRevocationCredential.ProtoReflect.Descriptor instead.
+func (*EpochNonRevocationCredential) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *EpochNonRevocationCredential) GetHandle() []byte {
+	if x != nil {
+		return x.Handle
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationCredential) GetSig() *amcl.ECP {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+// EpochRevocationData is the revocation data of a CRI with ALG_EPOCH_SIGNATURE
+type EpochRevocationData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Credentials []*EpochNonRevocationCredential `protobuf:"bytes,1,rep,name=credentials,proto3" json:"credentials,omitempty"`
+}
+
+func (x *EpochRevocationData) Reset() {
+	*x = EpochRevocationData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EpochRevocationData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EpochRevocationData) ProtoMessage() {}
+
+func (x *EpochRevocationData) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EpochRevocationData.ProtoReflect.Descriptor instead.
+func (*EpochRevocationData) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *EpochRevocationData) GetCredentials() []*EpochNonRevocationCredential {
+	if x != nil {
+		return x.Credentials
+	}
+	return nil
+}
+
+// EpochNonRevocationProof is a zero-knowledge proof of knowledge of a non-revocation credential
+// for the revocation handle hidden in a derived credential
+// w_prime, v_bar - the randomized signature and the corresponding randomized base
+// proof_c - the Fiat-Shamir challenge
+// proof_s_r, proof_s_t - s-values of the signature randomness and the derive randomness
+// proof_s_attrs - s-values of the hidden attributes, in the order of the hidden indices
+type EpochNonRevocationProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WPrime      *amcl.ECP `protobuf:"bytes,1,opt,name=w_prime,json=wPrime,proto3" json:"w_prime,omitempty"`
+	VBar        *amcl.ECP `protobuf:"bytes,2,opt,name=v_bar,json=vBar,proto3" json:"v_bar,omitempty"`
+	ProofC      []byte    `protobuf:"bytes,3,opt,name=proof_c,json=proofC,proto3" json:"proof_c,omitempty"`
+	ProofSR     []byte    `protobuf:"bytes,4,opt,name=proof_s_r,json=proofSR,proto3" json:"proof_s_r,omitempty"`
+	ProofST     []byte    `protobuf:"bytes,5,opt,name=proof_s_t,json=proofST,proto3" json:"proof_s_t,omitempty"`
+	ProofSAttrs [][]byte  `protobuf:"bytes,6,rep,name=proof_s_attrs,json=proofSAttrs,proto3" json:"proof_s_attrs,omitempty"`
+}
+
+func (x *EpochNonRevocationProof) Reset() {
+	*x = EpochNonRevocationProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EpochNonRevocationProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EpochNonRevocationProof) ProtoMessage() {}
+
+func (x *EpochNonRevocationProof) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EpochNonRevocationProof.ProtoReflect.Descriptor instead.
+func (*EpochNonRevocationProof) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *EpochNonRevocationProof) GetWPrime() *amcl.ECP {
+	if x != nil {
+		return x.WPrime
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationProof) GetVBar() *amcl.ECP {
+	if x != nil {
+		return x.VBar
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationProof) GetProofC() []byte {
+	if x != nil {
+		return x.ProofC
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationProof) GetProofSR() []byte {
+	if x != nil {
+		return x.ProofSR
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationProof) GetProofST() []byte {
+	if x != nil {
+		return x.ProofST
+	}
+	return nil
+}
+
+func (x *EpochNonRevocationProof) GetProofSAttrs() [][]byte {
+	if x != nil {
+		return x.ProofSAttrs
+	}
+	return nil
+}
+
+// RangeProof proves that the value committed to by the Pedersen commitments to its bits
+// is in [0, 2^len(bit_commitments)), with an OR-proof for every bit that it is 0 or 1
+// proof_c0, proof_z0, proof_z1 - per bit, the challenge of the 0-branch and the s-values of both branches
+// proof_s_rho - s-value of the randomness of the commitment to the whole value
+type RangeProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BitCommitments []*amcl.ECP `protobuf:"bytes,1,rep,name=bit_commitments,json=bitCommitments,proto3" json:"bit_commitments,omitempty"`
+	ProofC0        [][]byte    `protobuf:"bytes,2,rep,name=proof_c0,json=proofC0,proto3" json:"proof_c0,omitempty"`
+	ProofZ0        [][]byte    `protobuf:"bytes,3,rep,name=proof_z0,json=proofZ0,proto3" json:"proof_z0,omitempty"`
+	ProofZ1        [][]byte    `protobuf:"bytes,4,rep,name=proof_z1,json=proofZ1,proto3" json:"proof_z1,omitempty"`
+	ProofSRho      []byte      `protobuf:"bytes,5,opt,name=proof_s_rho,json=proofSRho,proto3" json:"proof_s_rho,omitempty"`
+}
+
+func (x *RangeProof) Reset() {
+	*x = RangeProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RangeProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeProof) ProtoMessage() {}
+
+func (x *RangeProof) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeProof.ProtoReflect.Descriptor instead.
+func (*RangeProof) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RangeProof) GetBitCommitments() []*amcl.ECP {
+	if x != nil {
+		return x.BitCommitments
+	}
+	return nil
+}
+
+func (x *RangeProof) GetProofC0() [][]byte {
+	if x != nil {
+		return x.ProofC0
+	}
+	return nil
+}
+
+func (x *RangeProof) GetProofZ0() [][]byte {
+	if x != nil {
+		return x.ProofZ0
+	}
+	return nil
+}
+
+func (x *RangeProof) GetProofZ1() [][]byte {
+	if x != nil {
+		return x.ProofZ1
+	}
+	return nil
+}
+
+func (x *RangeProof) GetProofSRho() []byte {
+	if x != nil {
+		return x.ProofSRho
+	}
+	return nil
+}
+
+// ValidityProof proves that the hidden not-before and not-after attributes of a derived credential
+// enclose time (unix seconds), without revealing them
+type ValidityProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time        int64       `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	ProofC      []byte      `protobuf:"bytes,2,opt,name=proof_c,json=proofC,proto3" json:"proof_c,omitempty"`
+	ProofST     []byte      `protobuf:"bytes,3,opt,name=proof_s_t,json=proofST,proto3" json:"proof_s_t,omitempty"`
+	ProofSAttrs [][]byte    `protobuf:"bytes,4,rep,name=proof_s_attrs,json=proofSAttrs,proto3" json:"proof_s_attrs,omitempty"`
+	NotBefore   *RangeProof `protobuf:"bytes,5,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter    *RangeProof `protobuf:"bytes,6,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+}
+
+func (x *ValidityProof) Reset() {
+	*x = ValidityProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidityProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidityProof) ProtoMessage() {}
+
+func (x *ValidityProof) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidityProof.ProtoReflect.Descriptor instead.
+func (*ValidityProof) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ValidityProof) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ValidityProof) GetProofC() []byte {
+	if x != nil {
+		return x.ProofC
+	}
+	return nil
+}
+
+func (x *ValidityProof) GetProofST() []byte {
+	if x != nil {
+		return x.ProofST
+	}
+	return nil
+}
+
+func (x *ValidityProof) GetProofSAttrs() [][]byte {
+	if x != nil {
+		return x.ProofSAttrs
+	}
+	return nil
+}
+
+func (x *ValidityProof) GetNotBefore() *RangeProof {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *ValidityProof) GetNotAfter() *RangeProof {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+// RevocationState is the state the revocation authority keeps between epochs
+// epoch - incremented with every change of the accumulator value
+// revoked - the revocation handles that were revoked, they cannot be added again
+// accumulator - the pairing accumulator of the revocation handles of the unrevoked creds
+// members - the revocation handles in the accumulator
+type RevocationState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Epoch       int64               `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Revoked     [][]byte            `protobuf:"bytes,3,rep,name=revoked,proto3" json:"revoked,omitempty"`
+	Accumulator *PairingAccumulator `protobuf:"bytes,4,opt,name=accumulator,proto3" json:"accumulator,omitempty"`
+	Members     [][]byte            `protobuf:"bytes,5,rep,name=members,proto3" json:"members,omitempty"`
+}
+
+func (x *RevocationState) Reset() {
+	*x = RevocationState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevocationState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevocationState) ProtoMessage() {}
+
+func (x *RevocationState) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevocationState.ProtoReflect.Descriptor instead.
+func (*RevocationState) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RevocationState) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *RevocationState) GetRevoked() [][]byte {
+	if x != nil {
+		return x.Revoked
+	}
+	return nil
+}
+
+func (x *RevocationState) GetAccumulator() *PairingAccumulator {
+	if x != nil {
+		return x.Accumulator
+	}
+	return nil
+}
+
+func (x *RevocationState) GetMembers() [][]byte {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+// Envelope is the self-describing format of the key and credential files, written after the magic bytes "PSID"
+// type - the kind of artifact in payload, the name of its file (IssuerPublicKey, PrimaryCred, ...)
+// schema_version - the version of the envelope and payload format
+// curve_id - the curve the payload was created on, empty for artifacts that do not depend on a curve
+// issuer_key_hash - the hash of the issuer public key the payload belongs to, empty for artifacts of no issuer
+// compressed_points - whether the points in payload are in the compressed form, both forms are decoded
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type             string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	SchemaVersion    uint32 `protobuf:"varint,2,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	CurveId          string `protobuf:"bytes,3,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
+	IssuerKeyHash    []byte `protobuf:"bytes,4,opt,name=issuer_key_hash,json=issuerKeyHash,proto3" json:"issuer_key_hash,omitempty"`
+	Payload          []byte `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	CompressedPoints bool   `protobuf:"varint,6,opt,name=compressed_points,json=compressedPoints,proto3" json:"compressed_points,omitempty"`
+	// sealed tells that the payload is a SealedKey sealed under a passphrase, with the type as label
+	Sealed bool `protobuf:"varint,7,opt,name=sealed,proto3" json:"sealed,omitempty"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Envelope) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Envelope) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Envelope) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
+func (x *Envelope) GetIssuerKeyHash() []byte {
+	if x != nil {
+		return x.IssuerKeyHash
+	}
+	return nil
+}
+
+func (x *Envelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetCompressedPoints() bool {
+	if x != nil {
+		return x.CompressedPoints
+	}
+	return false
+}
+
+func (x *Envelope) GetSealed() bool {
+	if x != nil {
+		return x.Sealed
+	}
+	return false
+}
+
+// IssuerKeySignature is a Schnorr signature with the secret x of an issuer key, verified with its public X
+type IssuerKeySignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	C []byte `protobuf:"bytes,1,opt,name=c,proto3" json:"c,omitempty"`
+	S []byte `protobuf:"bytes,2,opt,name=s,proto3" json:"s,omitempty"`
+}
+
+func (x *IssuerKeySignature) Reset() {
+	*x = IssuerKeySignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IssuerKeySignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuerKeySignature) ProtoMessage() {}
+
+func (x *IssuerKeySignature) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuerKeySignature.ProtoReflect.Descriptor instead.
+func (*IssuerKeySignature) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *IssuerKeySignature) GetC() []byte {
+	if x != nil {
+		return x.C
+	}
+	return nil
+}
+
+func (x *IssuerKeySignature) GetS() []byte {
+	if x != nil {
+		return x.S
+	}
+	return nil
+}
+
+// IssuerKeyRotation is the statement linking a new issuer key to the key it replaces.
+// The old key signs the statement to endorse the new key, and the new key signs it to prove its possession.
+// The old key is retiring until old_key_retires_at, verifiers accept it until then if configured to.
+type IssuerKeyRotation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OldKeyHash      []byte              `protobuf:"bytes,1,opt,name=old_key_hash,json=oldKeyHash,proto3" json:"old_key_hash,omitempty"`
+	NewKeyHash      []byte              `protobuf:"bytes,2,opt,name=new_key_hash,json=newKeyHash,proto3" json:"new_key_hash,omitempty"`
+	NewKeyVersion   uint32              `protobuf:"varint,3,opt,name=new_key_version,json=newKeyVersion,proto3" json:"new_key_version,omitempty"`
+	RotatedAt       int64               `protobuf:"varint,4,opt,name=rotated_at,json=rotatedAt,proto3" json:"rotated_at,omitempty"`
+	OldKeyRetiresAt int64               `protobuf:"varint,5,opt,name=old_key_retires_at,json=oldKeyRetiresAt,proto3" json:"old_key_retires_at,omitempty"`
+	CurveId         string              `protobuf:"bytes,6,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
+	OldKeySignature *IssuerKeySignature `protobuf:"bytes,7,opt,name=old_key_signature,json=oldKeySignature,proto3" json:"old_key_signature,omitempty"`
+	NewKeySignature *IssuerKeySignature `protobuf:"bytes,8,opt,name=new_key_signature,json=newKeySignature,proto3" json:"new_key_signature,omitempty"`
+}
+
+func (x *IssuerKeyRotation) Reset() {
+	*x = IssuerKeyRotation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_psidentity_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IssuerKeyRotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuerKeyRotation) ProtoMessage() {}
+
+func (x *IssuerKeyRotation) ProtoReflect() protoreflect.Message {
+	mi := &file_psidentity_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuerKeyRotation.ProtoReflect.Descriptor instead.
+func (*IssuerKeyRotation) Descriptor() ([]byte, []int) {
+	return file_psidentity_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *IssuerKeyRotation) GetOldKeyHash() []byte {
+	if x != nil {
+		return x.OldKeyHash
+	}
+	return nil
+}
+
+func (x *IssuerKeyRotation) GetNewKeyHash() []byte {
+	if x != nil {
+		return x.NewKeyHash
+	}
+	return nil
+}
+
+func (x *IssuerKeyRotation) GetNewKeyVersion() uint32 {
+	if x != nil {
+		return x.NewKeyVersion
+	}
+	return 0
+}
+
+func (x *IssuerKeyRotation) GetRotatedAt() int64 {
+	if x != nil {
+		return x.RotatedAt
+	}
+	return 0
+}
+
+func (x *IssuerKeyRotation) GetOldKeyRetiresAt() int64 {
+	if x != nil {
+		return x.OldKeyRetiresAt
+	}
+	return 0
+}
+
+func (x *IssuerKeyRotation) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
+func (x *IssuerKeyRotation) GetOldKeySignature() *IssuerKeySignature {
+	if x != nil {
+		return x.OldKeySignature
+	}
+	return nil
+}
+
+func (x *IssuerKeyRotation) GetNewKeySignature() *IssuerKeySignature {
+	if x != nil {
+		return x.NewKeySignature
+	}
+	return nil
+}
+
+var File_psidentity_proto protoreflect.FileDescriptor
+
+var file_psidentity_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0a, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x1a, 0x1a,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x61, 0x6d, 0x63, 0x6c, 0x2f,
+	0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc2, 0x02, 0x0a, 0x0f, 0x49,
+	0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x27,
+	0x0a, 0x0f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x04, 0x68, 0x5f, 0x73, 0x6b, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x52, 0x03, 0x68, 0x53, 0x6b, 0x12, 0x20, 0x0a, 0x06, 0x68, 0x5f, 0x72, 0x61, 0x6e, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x52, 0x05, 0x68, 0x52, 0x61, 0x6e, 0x64, 0x12, 0x22, 0x0a, 0x07, 0x68, 0x5f, 0x61, 0x74, 0x74,
+	0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
+	0x45, 0x43, 0x50, 0x52, 0x06, 0x68, 0x41, 0x74, 0x74, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x01, 0x77,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
+	0x50, 0x32, 0x52, 0x01, 0x77, 0x12, 0x20, 0x0a, 0x06, 0x62, 0x61, 0x72, 0x5f, 0x67, 0x31, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x52, 0x05, 0x62, 0x61, 0x72, 0x47, 0x31, 0x12, 0x20, 0x0a, 0x06, 0x62, 0x61, 0x72, 0x5f, 0x67,
+	0x32, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
+	0x43, 0x50, 0x52, 0x05, 0x62, 0x61, 0x72, 0x47, 0x32, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x5f, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f,
+	0x66, 0x43, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x12, 0x12, 0x0a, 0x04, 0x68,
+	0x61, 0x73, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22,
+	0x4c, 0x0a, 0x09, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x69, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x69, 0x73, 0x6b, 0x12, 0x2d,
+	0x0a, 0x03, 0x69, 0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x73,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x69, 0x70, 0x6b, 0x22, 0x70, 0x0a,
+	0x0a, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x17, 0x0a, 0x01, 0x61,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
+	0x50, 0x52, 0x01, 0x61, 0x12, 0x17, 0x0a, 0x01, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x62, 0x12, 0x0c, 0x0a,
+	0x01, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x74, 0x74,
+	0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x22,
+	0x7f, 0x0a, 0x0b, 0x43, 0x72, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b,
+	0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d,
+	0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x21, 0x0a, 0x0c, 0x69,
+	0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x17,
+	0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5f, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53,
+	0x22, 0x45, 0x0a, 0x06, 0x45, 0x49, 0x44, 0x4e, 0x79, 0x6d, 0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
+	0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x1e, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5f, 0x73, 0x5f, 0x65, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x72,
+	0x6f, 0x6f, 0x66, 0x53, 0x45, 0x69, 0x64, 0x22, 0x42, 0x0a, 0x05, 0x52, 0x48, 0x4e, 0x79, 0x6d,
+	0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e,
+	0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x1c, 0x0a,
+	0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x68, 0x22, 0xca, 0x05, 0x0a, 0x09,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x22, 0x0a, 0x07, 0x61, 0x5f, 0x70,
+	0x72, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63,
+	0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x06, 0x61, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a,
+	0x05, 0x61, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61,
+	0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x04, 0x61, 0x42, 0x61, 0x72, 0x12, 0x22, 0x0a,
+	0x07, 0x62, 0x5f, 0x70, 0x72, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x06, 0x62, 0x50, 0x72, 0x69, 0x6d,
+	0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72,
+	0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08,
+	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x53, 0x6b, 0x12, 0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f,
+	0x66, 0x5f, 0x73, 0x5f, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x53, 0x45, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f,
+	0x72, 0x32, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53,
+	0x52, 0x32, 0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x33,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x33,
+	0x12, 0x25, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x5f, 0x70, 0x72,
+	0x69, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x53, 0x53, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5f, 0x73, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0b,
+	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x41, 0x74, 0x74, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x12, 0x1b, 0x0a, 0x03, 0x6e, 0x79, 0x6d, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x6e, 0x79, 0x6d, 0x12, 0x21,
+	0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x5f, 0x6e, 0x79, 0x6d, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x4e, 0x79,
+	0x6d, 0x12, 0x3a, 0x0a, 0x13, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x5f, 0x70, 0x6b, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x11, 0x72, 0x65, 0x76, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x12, 0x2a, 0x0a,
+	0x11, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x6b, 0x5f, 0x73,
+	0x69, 0x67, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6b, 0x53, 0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12,
+	0x50, 0x0a, 0x14, 0x6e, 0x6f, 0x6e, 0x5f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x4e, 0x6f, 0x6e, 0x52, 0x65,
+	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x12, 0x6e,
+	0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f,
+	0x66, 0x12, 0x2b, 0x0a, 0x07, 0x65, 0x69, 0x64, 0x5f, 0x6e, 0x79, 0x6d, 0x18, 0x12, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e,
+	0x45, 0x49, 0x44, 0x4e, 0x79, 0x6d, 0x52, 0x06, 0x65, 0x69, 0x64, 0x4e, 0x79, 0x6d, 0x12, 0x28,
+	0x0a, 0x06, 0x72, 0x68, 0x5f, 0x6e, 0x79, 0x6d, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x52, 0x48, 0x4e, 0x79,
+	0x6d, 0x52, 0x05, 0x72, 0x68, 0x4e, 0x79, 0x6d, 0x22, 0x6d, 0x0a, 0x12, 0x4e, 0x6f, 0x6e, 0x52,
+	0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x25,
+	0x0a, 0x0e, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x6c, 0x67,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x41, 0x6c, 0x67, 0x12, 0x30, 0x0a, 0x14, 0x6e, 0x6f, 0x6e, 0x5f, 0x72, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x12, 0x6e, 0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0x7e, 0x0a, 0x0c, 0x4e, 0x79, 0x6d, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5f, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43,
+	0x12, 0x1c, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x73, 0x6b, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x53, 0x6b, 0x12, 0x21,
+	0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x5f, 0x6e, 0x79, 0x6d, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x4e, 0x79,
+	0x6d, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x22, 0xd0, 0x01, 0x0a, 0x1f, 0x43, 0x72, 0x65, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x49, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63,
+	0x68, 0x12, 0x25, 0x0a, 0x08, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x5f, 0x70, 0x6b, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52,
+	0x07, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x12, 0x20, 0x0a, 0x0c, 0x65, 0x70, 0x6f, 0x63,
+	0x68, 0x5f, 0x70, 0x6b, 0x5f, 0x73, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x53, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65,
+	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x6c, 0x67, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x6c,
+	0x67, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x72, 0x65, 0x76, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x22, 0x80, 0x03, 0x0a, 0x11, 0x49,
+	0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x50, 0x53,
+	0x12, 0x17, 0x0a, 0x01, 0x58, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d,
+	0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x58, 0x12, 0x17, 0x0a, 0x01, 0x59, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52,
+	0x01, 0x59, 0x12, 0x1e, 0x0a, 0x04, 0x59, 0x42, 0x61, 0x72, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x04, 0x59, 0x42,
+	0x61, 0x72, 0x12, 0x1c, 0x0a, 0x04, 0x5a, 0x5f, 0x69, 0x6a, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x03, 0x5a, 0x49, 0x6a,
+	0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x6e, 0x6f, 0x74,
+	0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6e,
+	0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x65, 0x64,
+	0x65, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0f, 0x70, 0x72, 0x65, 0x64, 0x65, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x5f,
+	0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x4b, 0x0a,
+	0x12, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65,
+	0x79, 0x50, 0x53, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01,
+	0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x01, 0x79, 0x12,
+	0x19, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x63, 0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x22, 0x70, 0x0a, 0x0b, 0x49, 0x73,
+	0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x50, 0x53, 0x12, 0x30, 0x0a, 0x03, 0x69, 0x73, 0x6b,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74,
+	0x65, 0x4b, 0x65, 0x79, 0x50, 0x53, 0x52, 0x03, 0x69, 0x73, 0x6b, 0x12, 0x2f, 0x0a, 0x03, 0x69,
+	0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x4b, 0x65, 0x79, 0x50, 0x53, 0x52, 0x03, 0x69, 0x70, 0x6b, 0x22, 0x7b, 0x0a, 0x0d,
+	0x43, 0x72, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x50, 0x53, 0x12, 0x1e, 0x0a,
+	0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0c, 0x0a,
+	0x01, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x63,
+	0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x72, 0x70, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x72, 0x70, 0x12, 0x0e, 0x0a, 0x02, 0x72, 0x77, 0x18,
+	0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x02, 0x72, 0x77, 0x22, 0x8f, 0x01, 0x0a, 0x0f, 0x42, 0x6c,
+	0x69, 0x6e, 0x64, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x18, 0x0a,
+	0x01, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
+	0x45, 0x43, 0x50, 0x32, 0x52, 0x01, 0x68, 0x12, 0x18, 0x0a, 0x01, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01,
+	0x73, 0x12, 0x0c, 0x0a, 0x01, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x63, 0x12,
+	0x1d, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x1b,
+	0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x8f, 0x01, 0x0a, 0x11,
+	0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61,
+	0x6c, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x01, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01,
+	0x68, 0x12, 0x18, 0x0a, 0x01, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61,
+	0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x01, 0x73, 0x12, 0x0c, 0x0a, 0x01, 0x63,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x63, 0x12, 0x22, 0x0a, 0x0d, 0x69, 0x73, 0x73,
+	0x75, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x49, 0x64, 0x22, 0xa2, 0x04,
+	0x0a, 0x10, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x02, 0x68, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x02, 0x68, 0x70, 0x12, 0x1a,
+	0x0a, 0x02, 0x73, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63,
+	0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x02, 0x73, 0x70, 0x12, 0x28, 0x0a, 0x0a, 0x73, 0x69,
+	0x67, 0x6d, 0x61, 0x5f, 0x6f, 0x6e, 0x65, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6d, 0x61,
+	0x4f, 0x6e, 0x65, 0x70, 0x12, 0x28, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x5f, 0x74, 0x77,
+	0x6f, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
+	0x45, 0x43, 0x50, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x54, 0x77, 0x6f, 0x70, 0x12, 0x29,
+	0x0a, 0x10, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x69, 0x63,
+	0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x03, 0x52, 0x0f, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x6f,
+	0x73, 0x65, 0x49, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x73,
+	0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x6d, 0x73, 0x67, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0b, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x12, 0x3a, 0x0a, 0x13, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x5f, 0x70, 0x6b, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x11, 0x72, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x50, 0x6b, 0x12, 0x2a,
+	0x0a, 0x11, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x6b, 0x5f,
+	0x73, 0x69, 0x67, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x72, 0x65, 0x76, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6b, 0x53, 0x69, 0x67, 0x12, 0x50, 0x0a, 0x14, 0x6e, 0x6f,
+	0x6e, 0x5f, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x4e, 0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x12, 0x6e, 0x6f, 0x6e, 0x52, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x40, 0x0a, 0x0e,
+	0x76, 0x61, 0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x5f, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74,
+	0x79, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52,
+	0x0d, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x22,
+	0x0a, 0x0d, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x69, 0x64, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79,
+	0x49, 0x64, 0x22, 0x64, 0x0a, 0x07, 0x55, 0x73, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x2c, 0x0a,
+	0x03, 0x75, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x73, 0x69,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76,
+	0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x75, 0x73, 0x6b, 0x12, 0x2b, 0x0a, 0x03, 0x75,
+	0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x4b, 0x65, 0x79, 0x52, 0x03, 0x75, 0x70, 0x6b, 0x22, 0x47, 0x0a, 0x0e, 0x55, 0x73, 0x65, 0x72,
+	0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x0c, 0x0a, 0x01, 0x62, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x62, 0x12, 0x0c, 0x0a, 0x01, 0x77, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x01, 0x77, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x76, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x75, 0x72, 0x76, 0x65, 0x49,
+	0x64, 0x22, 0xb2, 0x01, 0x0a, 0x0d, 0x55, 0x73, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x4b, 0x65, 0x79, 0x12, 0x17, 0x0a, 0x01, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x62, 0x12, 0x1f, 0x0a, 0x05,
+	0x62, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d,
+	0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x04, 0x62, 0x42, 0x61, 0x72, 0x12, 0x17, 0x0a,
+	0x01, 0x77, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
+	0x45, 0x43, 0x50, 0x52, 0x01, 0x77, 0x12, 0x1f, 0x0a, 0x05, 0x77, 0x5f, 0x62, 0x61, 0x72, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x32, 0x52, 0x04, 0x77, 0x42, 0x61, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x22, 0xa9, 0x01, 0x0a, 0x13, 0x41, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x2b,
+	0x0a, 0x0b, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x5f, 0x6f, 0x6e, 0x65, 0x70, 0x70, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52,
+	0x0a, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x4f, 0x6e, 0x65, 0x70, 0x70, 0x12, 0x2b, 0x0a, 0x0b, 0x73,
+	0x69, 0x67, 0x6d, 0x61, 0x5f, 0x74, 0x77, 0x6f, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50, 0x32, 0x52, 0x0a, 0x73, 0x69,
+	0x67, 0x6d, 0x61, 0x54, 0x77, 0x6f, 0x70, 0x70, 0x12, 0x38, 0x0a, 0x08, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x73, 0x69,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72,
+	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x08, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x73, 0x22, 0x24, 0x0a, 0x06, 0x52, 0x73, 0x61, 0x4b, 0x65, 0x79, 0x12, 0x0c, 0x0a, 0x01,
+	0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x47, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x47, 0x22, 0x7c, 0x0a, 0x0b, 0x41, 0x63, 0x63, 0x75,
+	0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x41, 0x63, 0x63, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x41, 0x63, 0x63, 0x12, 0x0c, 0x0a, 0x01, 0x55, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x01, 0x55, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x01, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x47, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x01, 0x47, 0x12, 0x31, 0x0a, 0x15, 0x68, 0x61, 0x73, 0x68, 0x5f, 0x74, 0x6f, 0x5f, 0x70,
+	0x72, 0x69, 0x6d, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x12, 0x68, 0x61, 0x73, 0x68, 0x54, 0x6f, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x8f, 0x01, 0x0a, 0x0b, 0x57, 0x69, 0x74, 0x6e, 0x65,
+	0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x41, 0x63, 0x63, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x03, 0x41, 0x63, 0x63, 0x12, 0x35, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x2e, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x1a,
+	0x37, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x29, 0x0a, 0x0b, 0x52, 0x73, 0x61, 0x54,
+	0x72, 0x61, 0x70, 0x64, 0x6f, 0x6f, 0x72, 0x12, 0x0c, 0x0a, 0x01, 0x50, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x01, 0x50, 0x12, 0x0c, 0x0a, 0x01, 0x51, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x01, 0x51, 0x22, 0x85, 0x02, 0x0a, 0x09, 0x53, 0x65, 0x61, 0x6c, 0x65, 0x64, 0x4b, 0x65,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x61, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x73, 0x61, 0x6c, 0x74, 0x12, 0x13, 0x0a, 0x05, 0x6c, 0x6f, 0x67, 0x5f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6c, 0x6f, 0x67, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x01, 0x72, 0x12, 0x0c, 0x0a, 0x01, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x01, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
+	0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x74, 0x65, 0x78, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0a, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x74, 0x65, 0x78, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x64, 0x66, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x64, 0x66, 0x12, 0x1f,
+	0x0a, 0x0b, 0x61, 0x72, 0x67, 0x6f, 0x6e, 0x32, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0a, 0x61, 0x72, 0x67, 0x6f, 0x6e, 0x32, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x23, 0x0a, 0x0d, 0x61, 0x72, 0x67, 0x6f, 0x6e, 0x32, 0x5f, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x61, 0x72, 0x67, 0x6f, 0x6e, 0x32, 0x4d, 0x65,
+	0x6d, 0x6f, 0x72, 0x79, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x72, 0x67, 0x6f, 0x6e, 0x32, 0x5f, 0x74,
+	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x61, 0x72,
+	0x67, 0x6f, 0x6e, 0x32, 0x54, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x22, 0x47, 0x0a, 0x15, 0x50,
+	0x61, 0x69, 0x72, 0x69, 0x6e, 0x67, 0x41, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f,
+	0x72, 0x4b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x12, 0x18, 0x0a, 0x01, 0x51, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x32, 0x52, 0x01, 0x51, 0x22, 0x2d, 0x0a, 0x12, 0x50, 0x61, 0x69, 0x72, 0x69, 0x6e, 0x67, 0x41,
+	0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x17, 0x0a, 0x01, 0x56, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43, 0x50,
+	0x52, 0x01, 0x56, 0x22, 0x42, 0x0a, 0x19, 0x50, 0x61, 0x69, 0x72, 0x69, 0x6e, 0x67, 0x41, 0x63,
+	0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73,
+	0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x79, 0x12, 0x17,
+	0x0a, 0x01, 0x57, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c,
+	0x2e, 0x45, 0x43, 0x50, 0x52, 0x01, 0x57, 0x22, 0xc7, 0x01, 0x0a, 0x1a, 0x41, 0x63, 0x63, 0x75,
+	0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x68, 0x69,
+	0x70, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x22, 0x0a, 0x07, 0x77, 0x5f, 0x70, 0x72, 0x69, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45,
+	0x43, 0x50, 0x52, 0x06, 0x77, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x05, 0x76, 0x5f,
+	0x62, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c,
+	0x2e, 0x45, 0x43, 0x50, 0x52, 0x04, 0x76, 0x42, 0x61, 0x72, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72,
+	0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x43, 0x12, 0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x79,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x59, 0x12,
+	0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x22, 0x53, 0x0a, 0x1c, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x4e, 0x6f, 0x6e, 0x52, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61,
+	0x6c, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x06, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x1b, 0x0a, 0x03, 0x73, 0x69, 0x67,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
+	0x50, 0x52, 0x03, 0x73, 0x69, 0x67, 0x22, 0x61, 0x0a, 0x13, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x52,
+	0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x4a, 0x0a,
+	0x0b, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x28, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e,
+	0x45, 0x70, 0x6f, 0x63, 0x68, 0x4e, 0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x0b, 0x63, 0x72,
+	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x22, 0xd2, 0x01, 0x0a, 0x17, 0x45, 0x70,
+	0x6f, 0x63, 0x68, 0x4e, 0x6f, 0x6e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x22, 0x0a, 0x07, 0x77, 0x5f, 0x70, 0x72, 0x69, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
+	0x50, 0x52, 0x06, 0x77, 0x50, 0x72, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x05, 0x76, 0x5f, 0x62,
+	0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e,
+	0x45, 0x43, 0x50, 0x52, 0x04, 0x76, 0x42, 0x61, 0x72, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x5f, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f,
+	0x66, 0x43, 0x12, 0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52, 0x12, 0x1a,
+	0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x54, 0x12, 0x22, 0x0a, 0x0d, 0x70, 0x72,
+	0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x0c, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x41, 0x74, 0x74, 0x72, 0x73, 0x22, 0xb1,
+	0x01, 0x0a, 0x0a, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x32, 0x0a,
+	0x0f, 0x62, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x45, 0x43,
+	0x50, 0x52, 0x0e, 0x62, 0x69, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x63, 0x30, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x43, 0x30, 0x12, 0x19, 0x0a, 0x08,
+	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x7a, 0x30, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x07,
+	0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5a, 0x30, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5f, 0x7a, 0x31, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x5a, 0x31, 0x12, 0x1e, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x72, 0x68,
+	0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x52,
+	0x68, 0x6f, 0x22, 0xe8, 0x01, 0x0a, 0x0d, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x69, 0x74, 0x79, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6f,
+	0x66, 0x5f, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x6f, 0x6f, 0x66,
+	0x43, 0x12, 0x1a, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x54, 0x12, 0x22, 0x0a,
+	0x0d, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x5f, 0x73, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x53, 0x41, 0x74, 0x74, 0x72,
+	0x73, 0x12, 0x35, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x74, 0x79, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x09, 0x6e,
+	0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x33, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f,
+	0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x73,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0xa3, 0x01,
+	0x0a, 0x0f, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x07, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x64, 0x12, 0x40, 0x0a, 0x0b, 0x61, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x2e, 0x50, 0x61, 0x69, 0x72, 0x69, 0x6e, 0x67, 0x41, 0x63, 0x63, 0x75, 0x6d,
+	0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x0b, 0x61, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61,
+	0x74, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x4a, 0x04, 0x08,
+	0x01, 0x10, 0x02, 0x22, 0xe7, 0x01, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72,
+	0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0d, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x12, 0x18,
+	0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x10, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x61, 0x6c, 0x65, 0x64, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x65, 0x61, 0x6c, 0x65, 0x64, 0x22, 0x30, 0x0a,
+	0x12, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01,
+	0x63, 0x12, 0x0c, 0x0a, 0x01, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x73, 0x22,
+	0xfe, 0x02, 0x0a, 0x11, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x52, 0x6f, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0c, 0x6f, 0x6c, 0x64, 0x5f, 0x6b, 0x65, 0x79,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6f, 0x6c, 0x64,
+	0x4b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x12, 0x20, 0x0a, 0x0c, 0x6e, 0x65, 0x77, 0x5f, 0x6b,
+	0x65, 0x79, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6e,
+	0x65, 0x77, 0x4b, 0x65, 0x79, 0x48, 0x61, 0x73, 0x68, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x77,
+	0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0d, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x2b, 0x0a, 0x12, 0x6f, 0x6c, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x72, 0x65, 0x74, 0x69,
+	0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6f, 0x6c,
+	0x64, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x74, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x19, 0x0a,
+	0x08, 0x63, 0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x12, 0x4a, 0x0a, 0x11, 0x6f, 0x6c, 0x64, 0x5f,
+	0x6b, 0x65, 0x79, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x52, 0x0f, 0x6f, 0x6c, 0x64, 0x4b, 0x65, 0x79, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x12, 0x4a, 0x0a, 0x11, 0x6e, 0x65, 0x77, 0x5f, 0x6b, 0x65, 0x79, 0x5f,
+	0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1e, 0x2e, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2e, 0x49, 0x73, 0x73,
+	0x75, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52,
+	0x0f, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x42, 0x26, 0x5a, 0x24, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x74, 0x79, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x3b, 0x70, 0x73,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_psidentity_proto_rawDescOnce sync.Once
+	file_psidentity_proto_rawDescData = file_psidentity_proto_rawDesc
+)
+
+func file_psidentity_proto_rawDescGZIP() []byte {
+	file_psidentity_proto_rawDescOnce.Do(func() {
+		file_psidentity_proto_rawDescData = protoimpl.X.CompressGZIP(file_psidentity_proto_rawDescData)
+	})
+	return file_psidentity_proto_rawDescData
+}
+
+var file_psidentity_proto_msgTypes = make([]protoimpl.MessageInfo, 40)
+var file_psidentity_proto_goTypes = []interface{}{
+	(*IssuerPublicKey)(nil),                 // 0: psidentity.IssuerPublicKey
+	(*IssuerKey)(nil),                       // 1: psidentity.IssuerKey
+	(*Credential)(nil),                      // 2: psidentity.Credential
+	(*CredRequest)(nil),                     // 3: psidentity.CredRequest
+	(*EIDNym)(nil),                          // 4: psidentity.EIDNym
 	(*RHNym)(nil),                           // 5: psidentity.RHNym
 	(*Signature)(nil),                       // 6: psidentity.Signature
 	(*NonRevocationProof)(nil),              // 7: psidentity.NonRevocationProof
@@ -2096,61 +3609,94 @@ var file_psidentity_proto_goTypes = []interface{}{
 	(*RsaKey)(nil),                          // 21: psidentity.RsaKey
 	(*Accumulator)(nil),                     // 22: psidentity.Accumulator
 	(*WitnessList)(nil),                     // 23: psidentity.WitnessList
-	nil,                                     // 24: psidentity.WitnessList.ListEntry
-	(*amcl.ECP)(nil),                        // 25: amcl.ECP
-	(*amcl.ECP2)(nil),                       // 26: amcl.ECP2
+	(*RsaTrapdoor)(nil),                     // 24: psidentity.RsaTrapdoor
+	(*SealedKey)(nil),                       // 25: psidentity.SealedKey
+	(*PairingAccumulatorKey)(nil),           // 26: psidentity.PairingAccumulatorKey
+	(*PairingAccumulator)(nil),              // 27: psidentity.PairingAccumulator
+	(*PairingAccumulatorWitness)(nil),       // 28: psidentity.PairingAccumulatorWitness
+	(*AccumulatorMembershipProof)(nil),      // 29: psidentity.AccumulatorMembershipProof
+	(*EpochNonRevocationCredential)(nil),    // 30: psidentity.EpochNonRevocationCredential
+	(*EpochRevocationData)(nil),             // 31: psidentity.EpochRevocationData
+	(*EpochNonRevocationProof)(nil),         // 32: psidentity.EpochNonRevocationProof
+	(*RangeProof)(nil),                      // 33: psidentity.RangeProof
+	(*ValidityProof)(nil),                   // 34: psidentity.ValidityProof
+	(*RevocationState)(nil),                 // 35: psidentity.RevocationState
+	(*Envelope)(nil),                        // 36: psidentity.Envelope
+	(*IssuerKeySignature)(nil),              // 37: psidentity.IssuerKeySignature
+	(*IssuerKeyRotation)(nil),               // 38: psidentity.IssuerKeyRotation
+	nil,                                     // 39: psidentity.WitnessList.ListEntry
+	(*amcl.ECP)(nil),                        // 40: amcl.ECP
+	(*amcl.ECP2)(nil),                       // 41: amcl.ECP2
 }
 var file_psidentity_proto_depIdxs = []int32{
-	25, // 0: psidentity.IssuerPublicKey.h_sk:type_name -> amcl.ECP
-	25, // 1: psidentity.IssuerPublicKey.h_rand:type_name -> amcl.ECP
-	25, // 2: psidentity.IssuerPublicKey.h_attrs:type_name -> amcl.ECP
-	26, // 3: psidentity.IssuerPublicKey.w:type_name -> amcl.ECP2
-	25, // 4: psidentity.IssuerPublicKey.bar_g1:type_name -> amcl.ECP
-	25, // 5: psidentity.IssuerPublicKey.bar_g2:type_name -> amcl.ECP
+	40, // 0: psidentity.IssuerPublicKey.h_sk:type_name -> amcl.ECP
+	40, // 1: psidentity.IssuerPublicKey.h_rand:type_name -> amcl.ECP
+	40, // 2: psidentity.IssuerPublicKey.h_attrs:type_name -> amcl.ECP
+	41, // 3: psidentity.IssuerPublicKey.w:type_name -> amcl.ECP2
+	40, // 4: psidentity.IssuerPublicKey.bar_g1:type_name -> amcl.ECP
+	40, // 5: psidentity.IssuerPublicKey.bar_g2:type_name -> amcl.ECP
 	0,  // 6: psidentity.IssuerKey.ipk:type_name -> psidentity.IssuerPublicKey
-	25, // 7: psidentity.Credential.a:type_name -> amcl.ECP
-	25, // 8: psidentity.Credential.b:type_name -> amcl.ECP
-	25, // 9: psidentity.CredRequest.nym:type_name -> amcl.ECP
-	25, // 10: psidentity.EIDNym.nym:type_name -> amcl.ECP
-	25, // 11: psidentity.RHNym.nym:type_name -> amcl.ECP
-	25, // 12: psidentity.Signature.a_prime:type_name -> amcl.ECP
-	25, // 13: psidentity.Signature.a_bar:type_name -> amcl.ECP
-	25, // 14: psidentity.Signature.b_prime:type_name -> amcl.ECP
-	25, // 15: psidentity.Signature.nym:type_name -> amcl.ECP
-	26, // 16: psidentity.Signature.revocation_epoch_pk:type_name -> amcl.ECP2
+	40, // 7: psidentity.Credential.a:type_name -> amcl.ECP
+	40, // 8: psidentity.Credential.b:type_name -> amcl.ECP
+	40, // 9: psidentity.CredRequest.nym:type_name -> amcl.ECP
+	40, // 10: psidentity.EIDNym.nym:type_name -> amcl.ECP
+	40, // 11: psidentity.RHNym.nym:type_name -> amcl.ECP
+	40, // 12: psidentity.Signature.a_prime:type_name -> amcl.ECP
+	40, // 13: psidentity.Signature.a_bar:type_name -> amcl.ECP
+	40, // 14: psidentity.Signature.b_prime:type_name -> amcl.ECP
+	40, // 15: psidentity.Signature.nym:type_name -> amcl.ECP
+	41, // 16: psidentity.Signature.revocation_epoch_pk:type_name -> amcl.ECP2
 	7,  // 17: psidentity.Signature.non_revocation_proof:type_name -> psidentity.NonRevocationProof
 	4,  // 18: psidentity.Signature.eid_nym:type_name -> psidentity.EIDNym
 	5,  // 19: psidentity.Signature.rh_nym:type_name -> psidentity.RHNym
-	26, // 20: psidentity.CredentialRevocationInformation.epoch_pk:type_name -> amcl.ECP2
-	25, // 21: psidentity.IssuerPublicKeyPS.X:type_name -> amcl.ECP
-	25, // 22: psidentity.IssuerPublicKeyPS.Y:type_name -> amcl.ECP
-	26, // 23: psidentity.IssuerPublicKeyPS.YBar:type_name -> amcl.ECP2
-	25, // 24: psidentity.IssuerPublicKeyPS.Z_ij:type_name -> amcl.ECP
+	41, // 20: psidentity.CredentialRevocationInformation.epoch_pk:type_name -> amcl.ECP2
+	40, // 21: psidentity.IssuerPublicKeyPS.X:type_name -> amcl.ECP
+	40, // 22: psidentity.IssuerPublicKeyPS.Y:type_name -> amcl.ECP
+	41, // 23: psidentity.IssuerPublicKeyPS.YBar:type_name -> amcl.ECP2
+	40, // 24: psidentity.IssuerPublicKeyPS.Z_ij:type_name -> amcl.ECP
 	11, // 25: psidentity.IssuerKeyPS.isk:type_name -> psidentity.IssuerPrivateKeyPS
 	10, // 26: psidentity.IssuerKeyPS.ipk:type_name -> psidentity.IssuerPublicKeyPS
-	26, // 27: psidentity.BlindCredential.h:type_name -> amcl.ECP2
-	26, // 28: psidentity.BlindCredential.s:type_name -> amcl.ECP2
-	26, // 29: psidentity.PrimaryCredential.h:type_name -> amcl.ECP2
-	26, // 30: psidentity.PrimaryCredential.s:type_name -> amcl.ECP2
-	26, // 31: psidentity.DeriveCredential.hp:type_name -> amcl.ECP2
-	26, // 32: psidentity.DeriveCredential.sp:type_name -> amcl.ECP2
-	25, // 33: psidentity.DeriveCredential.sigma_onep:type_name -> amcl.ECP
-	25, // 34: psidentity.DeriveCredential.sigma_twop:type_name -> amcl.ECP
-	18, // 35: psidentity.UserKey.usk:type_name -> psidentity.UserPrivateKey
-	19, // 36: psidentity.UserKey.upk:type_name -> psidentity.UserPublicKey
-	25, // 37: psidentity.UserPublicKey.b:type_name -> amcl.ECP
-	26, // 38: psidentity.UserPublicKey.b_bar:type_name -> amcl.ECP2
-	25, // 39: psidentity.UserPublicKey.w:type_name -> amcl.ECP
-	26, // 40: psidentity.UserPublicKey.w_bar:type_name -> amcl.ECP2
-	26, // 41: psidentity.AggregateCredential.sigma_onepp:type_name -> amcl.ECP2
-	26, // 42: psidentity.AggregateCredential.sigma_twopp:type_name -> amcl.ECP2
-	16, // 43: psidentity.AggregateCredential.messages:type_name -> psidentity.DeriveCredential
-	24, // 44: psidentity.WitnessList.List:type_name -> psidentity.WitnessList.ListEntry
-	45, // [45:45] is the sub-list for method output_type
-	45, // [45:45] is the sub-list for method input_type
-	45, // [45:45] is the sub-list for extension type_name
-	45, // [45:45] is the sub-list for extension extendee
-	0,  // [0:45] is the sub-list for field type_name
+	41, // 27: psidentity.BlindCredential.h:type_name -> amcl.ECP2
+	41, // 28: psidentity.BlindCredential.s:type_name -> amcl.ECP2
+	41, // 29: psidentity.PrimaryCredential.h:type_name -> amcl.ECP2
+	41, // 30: psidentity.PrimaryCredential.s:type_name -> amcl.ECP2
+	41, // 31: psidentity.DeriveCredential.hp:type_name -> amcl.ECP2
+	41, // 32: psidentity.DeriveCredential.sp:type_name -> amcl.ECP2
+	40, // 33: psidentity.DeriveCredential.sigma_onep:type_name -> amcl.ECP
+	40, // 34: psidentity.DeriveCredential.sigma_twop:type_name -> amcl.ECP
+	41, // 35: psidentity.DeriveCredential.revocation_epoch_pk:type_name -> amcl.ECP2
+	7,  // 36: psidentity.DeriveCredential.non_revocation_proof:type_name -> psidentity.NonRevocationProof
+	34, // 37: psidentity.DeriveCredential.validity_proof:type_name -> psidentity.ValidityProof
+	18, // 38: psidentity.UserKey.usk:type_name -> psidentity.UserPrivateKey
+	19, // 39: psidentity.UserKey.upk:type_name -> psidentity.UserPublicKey
+	40, // 40: psidentity.UserPublicKey.b:type_name -> amcl.ECP
+	41, // 41: psidentity.UserPublicKey.b_bar:type_name -> amcl.ECP2
+	40, // 42: psidentity.UserPublicKey.w:type_name -> amcl.ECP
+	41, // 43: psidentity.UserPublicKey.w_bar:type_name -> amcl.ECP2
+	41, // 44: psidentity.AggregateCredential.sigma_onepp:type_name -> amcl.ECP2
+	41, // 45: psidentity.AggregateCredential.sigma_twopp:type_name -> amcl.ECP2
+	16, // 46: psidentity.AggregateCredential.messages:type_name -> psidentity.DeriveCredential
+	39, // 47: psidentity.WitnessList.List:type_name -> psidentity.WitnessList.ListEntry
+	41, // 48: psidentity.PairingAccumulatorKey.Q:type_name -> amcl.ECP2
+	40, // 49: psidentity.PairingAccumulator.V:type_name -> amcl.ECP
+	40, // 50: psidentity.PairingAccumulatorWitness.W:type_name -> amcl.ECP
+	40, // 51: psidentity.AccumulatorMembershipProof.w_prime:type_name -> amcl.ECP
+	40, // 52: psidentity.AccumulatorMembershipProof.v_bar:type_name -> amcl.ECP
+	40, // 53: psidentity.EpochNonRevocationCredential.sig:type_name -> amcl.ECP
+	30, // 54: psidentity.EpochRevocationData.credentials:type_name -> psidentity.EpochNonRevocationCredential
+	40, // 55: psidentity.EpochNonRevocationProof.w_prime:type_name -> amcl.ECP
+	40, // 56: psidentity.EpochNonRevocationProof.v_bar:type_name -> amcl.ECP
+	40, // 57: psidentity.RangeProof.bit_commitments:type_name -> amcl.ECP
+	33, // 58: psidentity.ValidityProof.not_before:type_name -> psidentity.RangeProof
+	33, // 59: psidentity.ValidityProof.not_after:type_name -> psidentity.RangeProof
+	27, // 60: psidentity.RevocationState.accumulator:type_name -> psidentity.PairingAccumulator
+	37, // 61: psidentity.IssuerKeyRotation.old_key_signature:type_name -> psidentity.IssuerKeySignature
+	37, // 62: psidentity.IssuerKeyRotation.new_key_signature:type_name -> psidentity.IssuerKeySignature
+	63, // [63:63] is the sub-list for method output_type
+	63, // [63:63] is the sub-list for method input_type
+	63, // [63:63] is the sub-list for extension type_name
+	63, // [63:63] is the sub-list for extension extendee
+	0,  // [0:63] is the sub-list for field type_name
 }
 
 func init() { file_psidentity_proto_init() }
@@ -2447,6 +3993,186 @@ func file_psidentity_proto_init() {
 				return nil
 			}
 		}
+		file_psidentity_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RsaTrapdoor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SealedKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairingAccumulatorKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairingAccumulator); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PairingAccumulatorWitness); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccumulatorMembershipProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EpochNonRevocationCredential); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EpochRevocationData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EpochNonRevocationProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RangeProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidityProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevocationState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IssuerKeySignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_psidentity_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IssuerKeyRotation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -2454,7 +4180,7 @@ func file_psidentity_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_psidentity_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   25,
+			NumMessages:   40,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -8,6 +8,8 @@ package psidentity
 
 import (
 	// "crypto/ecdsa"
+	"crypto/rand"
+	"io"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
@@ -15,8 +17,7 @@ import (
 	// math "github.com/IBM/mathlib"
 	user "psidentity/user"
 	// "math/big"
-	"log"
-	// "time"
+	"time"
 )
 
 // GenerateIssuerKey generates an issuer signing key pair.
@@ -38,9 +39,9 @@ func GenerateIssuerKey(psid Psidentity, tr Translator) ([]byte, []byte, error) {
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "cannot generate Issuer key")
 	}
-	log.Printf("Generate Issuer key success!")
+	logger().Info("Generate Issuer key success!")
 
-	log.Printf("User key Ipk is %v", key.Ipk)
+	logger().Debug("Issuer key Ipk", "ipk", key.Ipk)
 	ipkSerialized, err := proto.Marshal(key.Ipk)
 
 	return key.Isk, ipkSerialized, err
@@ -52,14 +53,14 @@ func GenerateIssuerKeyPS(psid Psidentity, tr Translator) ([]byte, []byte, error)
 		return nil, nil, err
 	}
 	// AttributeNames := []string{psidentity.AttributeNameOU, psidentity.AttributeNameRole, psidentity.AttributeNameEnrollmentId, psidentity.AttributeNameRevocationHandle}
-	IssuerAttributeNames := []string{psidentity.IssuerAttributeOne, psidentity.IssuerAttributeTwo, psidentity.IssuerAttributeThree, psidentity.IssuerAttributeFour}
-	log.Printf("IssuerAttributeNames is %v", IssuerAttributeNames)
+	IssuerAttributeNames := psidentity.IssuerAttributeNames
+	logger().Debug("IssuerAttributeNames", "attrs", IssuerAttributeNames)
 
-	key, err := psid.NewIssuerKeyPS(len(IssuerAttributeNames), rng, tr)
+	key, err := psid.NewIssuerKeyPSWithValidity(len(IssuerAttributeNames), rng, tr)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "cannot generate Issuer key")
 	}
-	log.Printf("Generate Issuer key success!")
+	logger().Info("Generate Issuer key success!")
 
 	//log.Printf("Issuer key Ipk is %v\n",key.Ipk)
 	//log.Printf("Issuer key Isk is %v\n",key.Isk)
@@ -69,12 +70,118 @@ func GenerateIssuerKeyPS(psid Psidentity, tr Translator) ([]byte, []byte, error)
 	return iskSerialized, ipkSerialized, err
 }
 
+// GenerateIssuerKeyWithSchemaPS generates an issuer key recording the schema of its credentials, see NewIssuerKeyPSWithSchema
+func GenerateIssuerKeyWithSchemaPS(schema *CredentialSchema, psid Psidentity, tr Translator) ([]byte, []byte, error) {
+	rng, err := psid.Curve.Rand()
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := psid.NewIssuerKeyPSWithSchema(schema, rng, tr)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot generate Issuer key")
+	}
+	logger().Info("Generate Issuer key success!", "schema", schema.Name)
+
+	ipkSerialized, err := proto.Marshal(key.Ipk)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal issuer public key")
+	}
+	iskSerialized, err := proto.Marshal(key.Isk)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal issuer secret key")
+	}
+	return iskSerialized, ipkSerialized, nil
+}
+
+
+
+// GenerateUserPrimaryCred generates a primary cred of the user attributes with the issuer key pair, the window
+// is the validity window the issuer assigns if the credentials of the key have one, nil otherwise
+func GenerateUserPrimaryCred(UserAttributeNames []string, window *ValidityWindow, key *IssuerKeyPS, psid Psidentity, tr Translator) ([]byte, error) {
+	sign := func(msg *CredRequestPS, rng io.Reader) (*BlindCredential, error) {
+		return newBlindCredentialPS(key, msg, window, rng, tr, psid.Curve)
+	}
+	return generateUserPrimaryCred(UserAttributeNames, key.Ipk, sign, psid, tr)
+}
+
+// GenerateUserPrimaryCredWithStore generates a primary cred with the issuer key pair of the name in the key store,
+// the issuer secret key is only used inside the store
+func GenerateUserPrimaryCredWithStore(UserAttributeNames []string, window *ValidityWindow, store KeyStore, name string, psid Psidentity, tr Translator) ([]byte, error) {
+	ipk, err := StoredIssuerPublicKey(store, name)
+	if err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	sign := func(msg *CredRequestPS, rng io.Reader) (*BlindCredential, error) {
+		return store.BlindSign(name, msg, window, rng)
+	}
+	return generateUserPrimaryCred(UserAttributeNames, ipk, sign, psid, tr)
+}
 
+// RotateIssuerKeyWithStore replaces the issuer key pair of the name in the key store by a key pair of the next version.
+// The old public key is kept under ArchivedIssuerKeyName and retires after the overlap, the old secret key is discarded.
+// The rotation statement linking the keys is returned serialized.
+func RotateIssuerKeyWithStore(store KeyStore, name string, overlap time.Duration, psid Psidentity, tr Translator) ([]byte, error) {
+	old, err := storedIssuerKey(store.Get, name)
+	if err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	rng, err := psid.Curve.Rand()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	key, rotation, err := psid.RotateIssuerKey(old, now, now.Add(overlap), rng, tr)
+	if err != nil {
+		return nil, err
+	}
+	logger().Info("Rotate Issuer key success!", "version", key.Ipk.GetVersion())
+
+	oldIpkBytes, err := proto.Marshal(old.Ipk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal issuer public key")
+	}
+	ipkBytes, err := proto.Marshal(key.Ipk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal issuer public key")
+	}
+	iskBytes, err := proto.Marshal(key.Isk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal issuer secret key")
+	}
+
+	// archive the old public key before the key pair is replaced
+	if err := store.Put(KeyID{Name: ArchivedIssuerKeyName(name, old.Ipk.GetVersion()), Type: psidentity.PsIdentityConfigIssuerPublicKey}, oldIpkBytes); err != nil {
+		return nil, err
+	}
+	if err := store.PutIssuerKey(name, ipkBytes, iskBytes); err != nil {
+		return nil, err
+	}
 
-func GenerateUserPrimaryCred(UserAttributeNames []string, key IssuerKeyPS, psid Psidentity, tr Translator) ([]byte, error) {
+	return proto.Marshal(rotation)
+}
+
+// generateUserPrimaryCred generates a primary cred of the issuer public key, sign blind-signs the credential request.
+// The request commits to the user attributes, the validity window is added by the issuer.
+func generateUserPrimaryCred(UserAttributeNames []string, ipk *IssuerPublicKeyPS, sign func(*CredRequestPS, io.Reader) (*BlindCredential, error), psid Psidentity, tr Translator) ([]byte, error) {
 	// UserAttributeNames := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
 
 
+	if err := psid.CheckCurve(ipk.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	// the validity window the issuer assigns is found after the user attributes
+	userAttributes := len(ipk.GetY())
+	if hasValidity(ipk) {
+		userAttributes -= 2
+	}
+	if hasValidity(ipk) && len(UserAttributeNames) != userAttributes {
+		return nil, errors.Errorf("the credentials of the issuer key have %d user attributes followed by a validity window, got %d", userAttributes, len(UserAttributeNames))
+	}
+	// a key with a schema issues credentials of all the attributes of the schema
+	if ipk.GetSchema() != "" && len(UserAttributeNames) != userAttributes {
+		return nil, errors.Errorf("the credentials of the %s schema of the issuer key have %d user attributes, got %d", ipk.GetSchema(), userAttributes, len(UserAttributeNames))
+	}
+
 	rng, err := psid.Curve.Rand()
 	if err != nil {
 		return nil, errors.WithMessage(err, "Error getting PRNG")
@@ -87,52 +194,57 @@ func GenerateUserPrimaryCred(UserAttributeNames []string, key IssuerKeyPS, psid
 	for i := 0; i < len(UserAttributeNames); i++ {
 		temp = temp + len([]byte(UserAttributeNames[i]))
 	}
-	log.Printf("Len of AttributeNames is %v\n", temp)
+	logger().Debug("Len of AttributeNames", "bytes", temp)
 
 
-	msg, d, err := psid.NewCredRequestPS(UserAttributeNames, key.Ipk, rng, tr) //generate commitment (pre-blind-sign), for user
+	msg, d, err := psid.NewCredRequestPS(UserAttributeNames, ipk, rng, tr) //generate commitment (pre-blind-sign), for user
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to generate a credential commitment")
 	}
 
-	err = msg.VerifyZeroKnowledgeOne(key.Ipk, psid.Curve, tr)
+	err = msg.VerifyZeroKnowledgeOne(ipk, psid.Curve, tr)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to Zero knowledge one verification")
 	}
-	log.Printf("Zero knowledge one verification successful.")
+	logger().Debug("Zero knowledge one verification successful.")
 
 	msgBytes, err := proto.Marshal(msg)
-	log.Printf("pre-blind-sign successful. Len of msg is %v", len(msgBytes))
+	logger().Debug("pre-blind-sign successful.", "bytes", len(msgBytes))
 
 	//cred, err := psid.NewCredential(key, msg, attrs, rng, tr)
-	cred, err := psid.NewBlindCredential(&key, msg, rng, tr) //generate signture (blind-sign), for issuer
+	cred, err := sign(msg, rng) //generate signture (blind-sign), for issuer
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to blind-sign")
 	}
 	//log.Printf("User Cred is %v",cred)
-	log.Printf("blind-sign successful.")
+	logger().Debug("blind-sign successful.")
 
-	cred_primary, err := psid.NewPrimaryCredential(UserAttributeNames, d, &key, cred, rng, tr) //unblind signture, for user
+	cred_primary, err := psid.NewPrimaryCredential(UserAttributeNames, d, &IssuerKeyPS{Ipk: ipk}, cred, rng, tr) //unblind signture, for user
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to origin-sign")
 	}
 	//log.Printf("User cred_primary is %v",cred_primary)
-	log.Printf("unblind signture successful.")
+	logger().Debug("unblind signture successful.")
 	// t2 := time.Now().UnixNano() / int64(time.Millisecond)
 	// log.Printf("Sign Latency=%v ms.", t2-t1)
 
 	primaryCredBytes, err := proto.Marshal(cred_primary)
-	log.Printf("User Primary CredBytes is %v", primaryCredBytes)
+	logger().Debug("User Primary CredBytes", "primary_cred", primaryCredBytes)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to marshal credential")
 	}
-	log.Printf("generate PrimaryCred successful.")
+	logger().Info("generate PrimaryCred successful.")
 
-	primaryCRI := CreateCRI(primaryCredBytes)
+	primaryCRI, err := CreateCRIVersion(primaryCredBytes, DefaultHashToPrimeVersion)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create primary CRI")
+	}
 
 	primary := &user.UserPrimaryCred{
 		PrimaryCred:               primaryCredBytes,
 		PrimaryCri:                primaryCRI,
+		CriVersion:                uint32(DefaultHashToPrimeVersion),
+		CurveId:                   CurveName(psid.Curve),
 	}
 
 	return proto.Marshal(primary)
@@ -181,7 +293,7 @@ func GenerateUserPrimaryCred(UserAttributeNames []string, key IssuerKeyPS, psid
 // 	}
 
 // 	msgBytes, err := proto.Marshal(msg)
-// 	log.Printf("pre-blind-sign successful. Len of msg is %v", len(msgBytes))
+// 	logger().Debug("pre-blind-sign successful.", "bytes", len(msgBytes))
 
 // 	//cred, err := psid.NewCredential(key, msg, attrs, rng, tr)
 // 	cred, err := psid.NewBlindCredential(&key, msg, rng, tr) //generate signture (blind-sign), for issuer
@@ -189,14 +301,14 @@ func GenerateUserPrimaryCred(UserAttributeNames []string, key IssuerKeyPS, psid
 // 		return nil, errors.WithMessage(err, "failed to blind-sign")
 // 	}
 // 	//log.Printf("User Cred is %v",cred)
-// 	log.Printf("blind-sign successful.")
+// 	logger().Debug("blind-sign successful.")
 
 // 	cred_primary, err := psid.NewPrimaryCredential(UserAttributeNames, d, &key, cred, rng, tr) //unblind signture, for user
 // 	if err != nil {
 // 		return nil, errors.WithMessage(err, "failed to origin-sign")
 // 	}
 // 	//log.Printf("User cred_primary is %v",cred_primary)
-// 	log.Printf("unblind signture successful.")
+// 	logger().Debug("unblind signture successful.")
 // 	// t2 := time.Now().UnixNano() / int64(time.Millisecond)
 // 	// log.Printf("Sign Latency=%v ms.", t2-t1)
 
@@ -240,10 +352,79 @@ func GenerateRevocationKeyPS(psid Psidentity) ([]byte, error) {
 
 	// log.Printf("the type of rsaKey: %T",rsaKey) //*psidentity.RsaKey
 
-	log.Printf("Generate Revocation key success!")
+	logger().Info("Generate Revocation key success!")
 
 
 	rsaKeySerialized, err := proto.Marshal(rsaKey)
 
 	return rsaKeySerialized, err
 }
+
+// revocationTrapdoorLabel binds a sealed revocation trapdoor to its purpose
+const revocationTrapdoorLabel = "RevocationTrapdoor"
+
+// GenerateRevocationKeyWithTrapdoorPS generates a revocation key and keeps the factorization of its modulus.
+// The revocation key is serialized to bytes, the trapdoor is sealed under the passphrase.
+func GenerateRevocationKeyWithTrapdoorPS(psid Psidentity, passphrase []byte) ([]byte, []byte, error) {
+	rsaKey, trapdoor, err := psid.NewRevocationKeyWithTrapdoor()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot generate revocation key")
+	}
+	logger().Info("Generate Revocation key and trapdoor success!")
+
+	rsaKeySerialized, err := proto.Marshal(rsaKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal revocation key")
+	}
+
+	// the salt and nonce come from the system randomness, as for the other sealed keys
+	sealedTrapdoor, err := SealMessage(trapdoor, passphrase, revocationTrapdoorLabel, rand.Reader)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot seal revocation trapdoor")
+	}
+
+	return rsaKeySerialized, sealedTrapdoor, nil
+}
+
+// RevocationTrapdoorFromBytes opens a sealed revocation trapdoor and checks it against the revocation key
+func RevocationTrapdoorFromBytes(raw []byte, passphrase []byte, key *RsaKey) (*RsaTrapdoor, error) {
+	trapdoor := &RsaTrapdoor{}
+	if err := OpenMessage(raw, passphrase, revocationTrapdoorLabel, trapdoor); err != nil {
+		return nil, errors.WithMessage(err, "cannot open revocation trapdoor")
+	}
+	if err := trapdoor.Check(key); err != nil {
+		return nil, err
+	}
+	return trapdoor, nil
+}
+
+// GenerateRevocationAuthorityPS generates the keys of the revocation authority and the state of its empty accumulator.
+// It returns the long term revocation key signing the CRIs, its public key (PEM) the verifiers check the CRIs with,
+// the pairing accumulator key and the revocation state, serialized to bytes.
+func GenerateRevocationAuthorityPS(psid Psidentity, tr Translator) ([]byte, []byte, []byte, []byte, error) {
+	ltKey, err := psid.GenerateLongTermRevocationKey()
+	if err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "cannot generate long term revocation key")
+	}
+	ltPk, err := LongTermRevocationPublicKeyToBytes(&ltKey.PublicKey)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	rng, err := psid.Curve.Rand()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	accKey, acc := psid.NewPairingAccumulator(rng, tr)
+	accKeyBytes, err := proto.Marshal(accKey)
+	if err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "failed to marshal accumulator key")
+	}
+	stateBytes, err := proto.Marshal(NewRevocationState(acc))
+	if err != nil {
+		return nil, nil, nil, nil, errors.Wrap(err, "failed to marshal revocation state")
+	}
+	logger().Info("Generate revocation authority keys success!")
+
+	return LongTermRevocationKeyToBytes(ltKey), ltPk, accKeyBytes, stateBytes, nil
+}
@@ -1,11 +1,13 @@
 package psidentity
 
 import (
+	"io"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	psidentity "psidentity"
 	user "psidentity/user"
-	"log"
+	"time"
 )
 
 // GenerateUserKey generates a user signing key pair.
@@ -16,13 +18,13 @@ func GenerateUserKeyPS(psid Psidentity, tr Translator) ([]byte, []byte, error) {
 		return nil, nil, err
 	}
 	UserAttributeNames := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
-	log.Printf("UserAttributeNames is %v", UserAttributeNames)
+	logger().Debug("UserAttributeNames", "attrs", UserAttributeNames)
 
 	key, err := psid.NewUserKeyPS(len(UserAttributeNames), rng, tr)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "cannot generate User key")
 	}
-	log.Printf("Generate User key success!")
+	logger().Info("Generate User key success!")
 
 	//log.Printf("User key Ipk is %v\n",key.Upk)
 	//log.Printf("User key Isk is %v\n",key.Usk)
@@ -35,7 +37,40 @@ func GenerateUserKeyPS(psid Psidentity, tr Translator) ([]byte, []byte, error) {
 
 
 
-func GenerateUserDeriveCred(UserAttributeNames []string, cred_primary PrimaryCredential, key IssuerKeyPS, uk UserKey, psid Psidentity, tr Translator) ([]byte, []byte, error) {
+// DefaultDisclosure are the attributes of the DefaultSchema disclosed by GenerateUserDeriveCred
+var DefaultDisclosure = []string{psidentity.IssuerAttributeOne, psidentity.IssuerAttributeThree}
+
+// GenerateUserDeriveCred derives a cred from the primary cred and aggregates it with the user key.
+// With nr the derived cred proves its non-revocation for the CRI of nr, nr is nil for issuers without revocation.
+func GenerateUserDeriveCred(UserAttributeNames []string, cred_primary PrimaryCredential, key IssuerKeyPS, uk UserKey, nr *NonRevocationWitness, psid Psidentity, tr Translator) ([]byte, []byte, error) {
+	aggr := func(messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+		return psid.NewAggregateCredential(&uk, key.Ipk, messages, rng, tr)
+	}
+	return generateUserDeriveCred(UserAttributeNames, &cred_primary, key.Ipk, uk.Upk, nr, aggr, psid, tr)
+}
+
+// GenerateUserDeriveCredWithStore derives a cred from the primary cred and aggregates it with the user key pair
+// of the name in the key store, the user secret key is only used inside the store. nr is as for GenerateUserDeriveCred.
+func GenerateUserDeriveCredWithStore(UserAttributeNames []string, cred_primary *PrimaryCredential, ipk *IssuerPublicKeyPS, store KeyStore, name string, nr *NonRevocationWitness, psid Psidentity, tr Translator) ([]byte, []byte, error) {
+	upk, err := StoredUserPublicKey(store, name)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "user key")
+	}
+	aggr := func(messages []*DeriveCredential, rng io.Reader) (*AggregateCredential, error) {
+		return store.Aggregate(name, ipk, messages, rng)
+	}
+	return generateUserDeriveCred(UserAttributeNames, cred_primary, ipk, upk, nr, aggr, psid, tr)
+}
+
+// generateUserDeriveCred derives a cred from the primary cred, proving its non-revocation with nr if it is not nil,
+// aggr aggregates the derived creds
+func generateUserDeriveCred(UserAttributeNames []string, cred_primary *PrimaryCredential, ipk *IssuerPublicKeyPS, upk *UserPublicKey, nr *NonRevocationWitness, aggr func([]*DeriveCredential, io.Reader) (*AggregateCredential, error), psid Psidentity, tr Translator) ([]byte, []byte, error) {
+	if err := psid.CheckCurve(ipk.GetCurveId()); err != nil {
+		return nil, nil, errors.WithMessage(err, "issuer key")
+	}
+	if err := psid.CheckCurve(upk.GetCurveId()); err != nil {
+		return nil, nil, errors.WithMessage(err, "user key")
+	}
 
 	rng, err := psid.Curve.Rand()
 	if err != nil {
@@ -46,32 +81,49 @@ func GenerateUserDeriveCred(UserAttributeNames []string, cred_primary PrimaryCre
 	for i := 0; i < len(UserAttributeNames); i++ {
 		temp = temp + len([]byte(UserAttributeNames[i]))
 	}
-	log.Printf("Len of UserAttributeNames is %v", temp)
+	logger().Debug("Len of UserAttributeNames", "bytes", temp)
 
-	//var mask []int = []int{0,1,0,0}
-	var mask1 []int = []int{1, 0, 1, 0}
-	var mask2 []int = []int{0, 1, 0, 1}
+	// the attributes of DefaultDisclosure are disclosed, the validity window assigned by the issuer
+	// stays hidden and only its validity is proven. A verifier asks for others with a PresentationRequest.
+	schema, err := defaultSchema(len(UserAttributeNames))
+	if err != nil {
+		return nil, nil, err
+	}
+	mask1, err := schema.Mask(DefaultDisclosure)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	cred_derive, err := psid.NewDeriveCredential(UserAttributeNames, &key, &cred_primary, mask1, rng, tr)
+	cred_derive, t, err := deriveCredentialAt(UserAttributeNames, decodeIssuerKey(ipk, psid.Curve, tr), cred_primary, mask1, time.Now(), rng)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed to derive a credential")
 	}
+	if nr != nil {
+		if err := nr.addProof(cred_derive, t, UserAttributeNames, ipk, mask1, rng, psid.Curve, tr); err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to prove non-revocation")
+		}
+	}
 	// log.Printf("Derive User credential cred_derive is %v",cred_derive)
 	// log.Printf("Derive User credential success!")
 
 	deriveCredBytes, err := proto.Marshal(cred_derive)
 
-	log.Printf("User deriveCredBytes is %v", deriveCredBytes)
+	logger().Debug("User deriveCredBytes", "derive_cred", deriveCredBytes)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed to marshal derive credential")
 	}
-	log.Printf("generate DeriveCred successful.")
+	logger().Info("generate DeriveCred successful.")
 
-	deriveCRI := CreateCRI(deriveCredBytes)
+	deriveCRI, err := CreateCRIVersion(deriveCredBytes, DefaultHashToPrimeVersion)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create derive CRI")
+	}
 
 	derive := &user.UserDeriveCred{
 		DeriveCred:               deriveCredBytes,
 		DeriveCri:                deriveCRI,
+		CriVersion:                uint32(DefaultHashToPrimeVersion),
+		CurveId:                   CurveName(psid.Curve),
 	}
 
 	deriveBytes, err := proto.Marshal(derive)
@@ -86,30 +138,35 @@ func GenerateUserDeriveCred(UserAttributeNames []string, cred_primary PrimaryCre
 	//CredDerive = append(CredDerive, cred_derive)
 
 
-	cred_aggr, err := psid.NewAggregateCredential(&uk, key.Ipk, CredDerive, rng, tr)
+	cred_aggr, err := aggr(CredDerive, rng)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed to aggregate")
 	}
-	log.Printf("Aggregate credential is %v", cred_aggr)
+	logger().Debug("Aggregate credential", "aggregate_cred", cred_aggr)
 
 	aggregateCredBytes, err := proto.Marshal(cred_aggr)
 
-	log.Printf("User aggregateCredBytes is %v", aggregateCredBytes)
+	logger().Debug("User aggregateCredBytes", "aggregate_cred", aggregateCredBytes)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed to marshal aggregate credential")
 	}
-	log.Printf("generate AggregateCred successful.")
+	logger().Info("generate AggregateCred successful.")
 
-	aggregateCRI := CreateCRI(aggregateCredBytes)
+	aggregateCRI, err := CreateCRIVersion(aggregateCredBytes, DefaultHashToPrimeVersion)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create aggregate CRI")
+	}
 
 	aggregate := &user.UserAggregateCred{
 		AggregateCred:               aggregateCredBytes,
 		AggregateCri:                aggregateCRI,
+		CriVersion:                uint32(DefaultHashToPrimeVersion),
+		CurveId:                   CurveName(psid.Curve),
 	}
 
-	err = cred_aggr.VerifyAggregate(uk.Upk, psid.Curve, tr)
+	err = cred_aggr.VerifyAggregate(upk, psid.Curve, tr)
 	if err != nil {
-		log.Printf("need to be improved")
+		return nil, nil, errors.WithMessage(err, "aggregate credential verification failed")
 	}
 
 	aggregateBytes, err := proto.Marshal(aggregate)
@@ -138,6 +195,12 @@ func GenerateUserDeriveCred(UserAttributeNames []string, cred_primary PrimaryCre
 
 
 func GenerateUserAggregateCred(uk UserKey, key IssuerKeyPS, messages []*DeriveCredential, psid Psidentity, tr Translator) ([]byte, error) {
+	if err := psid.CheckCurve(key.Ipk.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	if err := psid.CheckCurve(uk.Upk.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "user key")
+	}
 
 // func GenerateUserAggregateCred( cred_primary PrimaryCredential, key IssuerKeyPS, uk UserKey, psid Psidentity, tr Translator) ([]byte, error) {
 
@@ -154,7 +217,7 @@ func GenerateUserAggregateCred(uk UserKey, key IssuerKeyPS, messages []*DeriveCr
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to aggregate")
 	}
-	log.Printf("Aggregate credential cred_aggr is %v", cred_aggr)
+	logger().Debug("Aggregate credential", "aggregate_cred", cred_aggr)
 	//log.Printf("Aggregate derived credential success!")
 
 	// err = psid.VerifyAggregate(cred_aggr, &uk, tr)
@@ -172,22 +235,27 @@ func GenerateUserAggregateCred(uk UserKey, key IssuerKeyPS, messages []*DeriveCr
 
 	aggregateCredBytes, err := proto.Marshal(cred_aggr)
 
-	log.Printf("User aggregateCredBytes is %v", aggregateCredBytes)
+	logger().Debug("User aggregateCredBytes", "aggregate_cred", aggregateCredBytes)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to marshal aggregate credential")
 	}
-	log.Printf("generate AggregateCred successful.")
+	logger().Info("generate AggregateCred successful.")
 
-	aggregateCRI := CreateCRI(aggregateCredBytes)
+	aggregateCRI, err := CreateCRIVersion(aggregateCredBytes, DefaultHashToPrimeVersion)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create aggregate CRI")
+	}
 
 	aggregate := &user.UserAggregateCred{
 		AggregateCred:               aggregateCredBytes,
 		AggregateCri:                aggregateCRI,
+		CriVersion:                uint32(DefaultHashToPrimeVersion),
+		CurveId:                   CurveName(psid.Curve),
 	}
 
 	err = cred_aggr.VerifyAggregate(uk.Upk, psid.Curve, tr)
 	if err != nil {
-		log.Printf("need to be improved")
+		return nil, errors.WithMessage(err, "aggregate credential verification failed")
 	}
 
 	return proto.Marshal(aggregate)
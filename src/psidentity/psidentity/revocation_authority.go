@@ -11,7 +11,9 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/asn1"
+	"encoding/pem"
 	"io"
 	"math/big"
 
@@ -46,10 +48,44 @@ func longTermRevocationKeyFromBytes(raw []byte) (*ecdsa.PrivateKey, error) {
 	return priv, nil
 }
 
+// LongTermRevocationPublicKeyFromBytes parses the public long term revocation key verifiers check epoch keys with,
+// in PKIX form either PEM or DER encoded
+func LongTermRevocationPublicKeyFromBytes(raw []byte) (*ecdsa.PublicKey, error) {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	pk, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse long term revocation public key")
+	}
+	ecPk, ok := pk.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("long term revocation public key is not an ECDSA key")
+	}
+	return ecPk, nil
+}
+
+// LongTermRevocationKeyToBytes serializes the long term revocation key for LongTermRevocationKeyFromBytes
+func LongTermRevocationKeyToBytes(key *ecdsa.PrivateKey) []byte {
+	return key.D.Bytes()
+}
+
+// LongTermRevocationPublicKeyToBytes encodes the public long term revocation key in PKIX form, PEM encoded,
+// which verifiers read with LongTermRevocationPublicKeyFromBytes
+func LongTermRevocationPublicKeyToBytes(pk *ecdsa.PublicKey) ([]byte, error) {
+	raw, err := x509.MarshalPKIXPublicKey(pk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal long term revocation public key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: raw}), nil
+}
+
 // CreateCRI creates the Credential Revocation Information for a certain time period (epoch).
 // Users can use the CRI to prove that they are not revoked.
 // Note that when not using revocation (i.e., alg = ALG_NO_REVOCATION), the entered unrevokedHandles are not used,
 // and the resulting CRI can be used by any signer.
+// With alg = ALG_EPOCH_SIGNATURE a fresh epoch key is generated and every unrevoked handle gets a signature
+// under it, which the holder of the handle uses to prove non-revocation in this epoch.
 func (i *Psidentity) CreateCRI(key *ecdsa.PrivateKey, unrevokedHandles []*math.Zr, epoch int, alg psidentity.RevocationAlgorithm, rng io.Reader, t Translator) (*CredentialRevocationInformation, error) {
 	return createCRI(key, unrevokedHandles, epoch, alg, rng, i.Curve, t)
 }
@@ -62,33 +98,108 @@ func createCRI(key *ecdsa.PrivateKey, unrevokedHandles []*math.Zr, epoch int, al
 	cri.RevocationAlg = int32(alg)
 	cri.Epoch = int64(epoch)
 
+	var epochSk *math.Zr
 	if alg == psidentity.ALG_NO_REVOCATION {
 		// put a dummy PK in the proto
 		cri.EpochPk = t.G2ToProto(curve.GenG2)
-	} //else {
-	// 	// create epoch key
-	// 	_, epochPk := wbbKeyGen(curve, rng)
-	// 	cri.EpochPk = t.G2ToProto(epochPk)
-	// }
+	} else if alg == psidentity.ALG_EPOCH_SIGNATURE {
+		// create epoch key
+		var epochPk *math.G2
+		epochSk, epochPk = wbbKeyGen(curve, rng)
+		cri.EpochPk = t.G2ToProto(epochPk)
+	} else if alg == psidentity.ALG_PAIRING_ACCUMULATOR {
+		return nil, errors.Errorf("the CRI of the pairing accumulator is created with CreateAccumulatorCRI")
+	} else {
+		return nil, errors.Errorf("the specified revocation algorithm is not supported.")
+	}
 
 	// sign epoch + epoch key with long term key
+	err := signCRI(key, cri)
+	if err != nil {
+		return nil, err
+	}
+
+	if alg == psidentity.ALG_EPOCH_SIGNATURE {
+		// sign the unrevoked handles with the epoch key, the signatures verify under the signed epoch key
+		cri.RevocationData, err = createEpochRevocationData(epochSk, unrevokedHandles, curve, t)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create epoch revocation data")
+		}
+	}
+	return cri, nil
+}
+
+// signCRI signs all fields of the CRI with the long term revocation key and stores the signature in EpochPkSig
+func signCRI(key *ecdsa.PrivateKey, cri *CredentialRevocationInformation) error {
 	bytesToSign, err := proto.Marshal(cri)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal CRI")
+		return errors.Wrap(err, "failed to marshal CRI")
 	}
 
 	digest := sha256.Sum256(bytesToSign)
 
 	cri.EpochPkSig, err = key.Sign(rand.Reader, digest[:], nil)
+	return err
+}
+
+// CreateAccumulatorCRI creates the Credential Revocation Information for the pairing-based accumulator
+// (alg = ALG_PAIRING_ACCUMULATOR). The CRI carries the accumulator public key as EpochPk and the
+// accumulator value of this epoch as revocation data, both signed with the long term revocation key.
+// Users prove with their accumulator witness that their revocation handle is still accumulated.
+func (i *Psidentity) CreateAccumulatorCRI(key *ecdsa.PrivateKey, accKey *PairingAccumulatorKey, acc *PairingAccumulator, epoch int) (*CredentialRevocationInformation, error) {
+	return createAccumulatorCRI(key, accKey, acc, epoch)
+}
+
+func createAccumulatorCRI(key *ecdsa.PrivateKey, accKey *PairingAccumulatorKey, acc *PairingAccumulator, epoch int) (*CredentialRevocationInformation, error) {
+	if key == nil || accKey == nil || acc == nil {
+		return nil, errors.Errorf("CreateAccumulatorCRI received nil input")
+	}
+	accBytes, err := proto.Marshal(acc)
 	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal accumulator")
+	}
+
+	cri := &CredentialRevocationInformation{
+		RevocationAlg:  int32(psidentity.ALG_PAIRING_ACCUMULATOR),
+		Epoch:          int64(epoch),
+		EpochPk:        accKey.GetQ(),
+		RevocationData: accBytes,
+	}
+	if err := signCRI(key, cri); err != nil {
 		return nil, err
 	}
+	return cri, nil
+}
 
-	if alg == psidentity.ALG_NO_REVOCATION {
-		return cri, nil
-	} else {
-		return nil, errors.Errorf("the specified revocation algorithm is not supported.")
+// VerifyAccumulatorCRI verifies that the accumulator value in the CRI was signed with the long term revocation key
+// and returns it
+func (i *Psidentity) VerifyAccumulatorCRI(pk *ecdsa.PublicKey, cri *CredentialRevocationInformation) (*PairingAccumulator, error) {
+	return verifyAccumulatorCRI(pk, cri)
+}
+
+func verifyAccumulatorCRI(pk *ecdsa.PublicKey, cri *CredentialRevocationInformation) (*PairingAccumulator, error) {
+	if pk == nil || cri == nil {
+		return nil, errors.Errorf("CRI invalid: received nil input")
+	}
+	if cri.GetRevocationAlg() != int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		return nil, errors.Errorf("CRI does not use the pairing accumulator")
 	}
+
+	signed := &CredentialRevocationInformation{
+		RevocationAlg:  cri.GetRevocationAlg(),
+		Epoch:          cri.GetEpoch(),
+		EpochPk:        cri.GetEpochPk(),
+		RevocationData: cri.GetRevocationData(),
+	}
+	if err := verifyCRISignature(pk, signed, cri.GetEpochPkSig()); err != nil {
+		return nil, err
+	}
+
+	acc := &PairingAccumulator{}
+	if err := proto.Unmarshal(cri.GetRevocationData(), acc); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal accumulator")
+	}
+	return acc, nil
 }
 
 // VerifyEpochPK verifies that the revocation PK for a certain epoch is valid,
@@ -108,6 +219,20 @@ func verifyEpochPK(pk *ecdsa.PublicKey, epochPK *amcl.ECP2, epochPkSig []byte, e
 	cri.RevocationAlg = int32(alg)
 	cri.EpochPk = epochPK
 	cri.Epoch = int64(epoch)
+	return verifyCRISignature(pk, cri, epochPkSig)
+}
+
+// verifyCRI verifies that the CRI was signed with the long term revocation key: the accumulator value of a CRI
+// of the pairing accumulator, the epoch key of the other CRIs
+func verifyCRI(pk *ecdsa.PublicKey, cri *CredentialRevocationInformation) error {
+	if cri.GetRevocationAlg() == int32(psidentity.ALG_PAIRING_ACCUMULATOR) {
+		_, err := verifyAccumulatorCRI(pk, cri)
+		return err
+	}
+	return verifyEpochPK(pk, cri.GetEpochPk(), cri.GetEpochPkSig(), int(cri.GetEpoch()), psidentity.RevocationAlgorithm(cri.GetRevocationAlg()))
+}
+
+func verifyCRISignature(pk *ecdsa.PublicKey, cri *CredentialRevocationInformation, sigBytes []byte) error {
 	bytesToSign, err := proto.Marshal(cri)
 	if err != nil {
 		return err
@@ -115,7 +240,7 @@ func verifyEpochPK(pk *ecdsa.PublicKey, epochPK *amcl.ECP2, epochPkSig []byte, e
 	digest := sha256.Sum256(bytesToSign)
 
 	var sig struct{ R, S *big.Int }
-	if _, err := asn1.Unmarshal(epochPkSig, &sig); err != nil {
+	if _, err := asn1.Unmarshal(sigBytes, &sig); err != nil {
 		return errors.Wrap(err, "failed unmashalling signature")
 	}
 
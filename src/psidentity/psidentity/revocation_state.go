@@ -0,0 +1,114 @@
+package psidentity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+// Revocation status of a credential in the revocation state
+const (
+	RevocationStatusValid   = "valid"
+	RevocationStatusRevoked = "revoked"
+	RevocationStatusUnknown = "unknown"
+)
+
+// NewRevocationState creates the state of the empty accumulator acc, at epoch 0
+func NewRevocationState(acc *PairingAccumulator) *RevocationState {
+	return &RevocationState{Accumulator: acc}
+}
+
+// PrimaryCredHandle returns the revocation handle of a serialized user primary cred, the handle of its attribute at index
+func PrimaryCredHandle(raw []byte, index int, curve *math.Curve) (*math.Zr, error) {
+	conf, cred := &user.UserPrimaryCred{}, &PrimaryCredential{}
+	if err := proto.Unmarshal(raw, conf); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user primary cred")
+	}
+	if err := proto.Unmarshal(conf.GetPrimaryCred(), cred); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal primary credential")
+	}
+	if index < 0 || index >= len(cred.GetAttrs()) {
+		return nil, errors.Errorf("revocation handle index %d out of range", index)
+	}
+	return revocationHandle(cred.GetAttrs()[index], curve), nil
+}
+
+// handleIndex returns the index of the revocation handle in handles, -1 if it is not there
+func handleIndex(handles [][]byte, rh *math.Zr) int {
+	for j, h := range handles {
+		if bytes.Equal(h, rh.Bytes()) {
+			return j
+		}
+	}
+	return -1
+}
+
+// Status returns the revocation status of the revocation handle rh
+func (s *RevocationState) Status(rh *math.Zr) string {
+	if handleIndex(s.GetRevoked(), rh) >= 0 {
+		return RevocationStatusRevoked
+	}
+	if handleIndex(s.GetMembers(), rh) >= 0 {
+		return RevocationStatusValid
+	}
+	return RevocationStatusUnknown
+}
+
+// Add accumulates the revocation handle rh, V = V^{rh + alpha}, and starts a new epoch
+func (s *RevocationState) Add(key *PairingAccumulatorKey, rh *math.Zr, curve *math.Curve, t Translator) error {
+	if status := s.Status(rh); status != RevocationStatusUnknown {
+		return errors.Errorf("revocation handle is already %s", status)
+	}
+	if err := s.GetAccumulator().Add(key, rh, curve, t); err != nil {
+		return err
+	}
+	s.Members = append(s.Members, rh.Bytes())
+	s.Epoch++
+	return nil
+}
+
+// Revoke deletes the revocation handle rh from the accumulator, V = V^{1/(rh + alpha)}, and starts a new epoch.
+// The witnesses of the other members must be issued again for the new epoch.
+func (s *RevocationState) Revoke(key *PairingAccumulatorKey, rh *math.Zr, curve *math.Curve, t Translator) error {
+	j := handleIndex(s.GetMembers(), rh)
+	if j < 0 {
+		return errors.Errorf("revocation handle is %s", s.Status(rh))
+	}
+	if err := s.GetAccumulator().Delete(key, rh, curve, t); err != nil {
+		return err
+	}
+	s.Members = append(s.Members[:j:j], s.Members[j+1:]...)
+	s.Revoked = append(s.Revoked, rh.Bytes())
+	s.Epoch++
+	return nil
+}
+
+// Witness issues the witness of the revocation handle rh for the accumulator value of the current epoch
+func (s *RevocationState) Witness(key *PairingAccumulatorKey, rh *math.Zr, curve *math.Curve, t Translator) (*PairingAccumulatorWitness, error) {
+	if status := s.Status(rh); status != RevocationStatusValid {
+		return nil, errors.Errorf("revocation handle is %s", status)
+	}
+	return s.GetAccumulator().Witness(key, rh, curve, t)
+}
+
+// CRI returns the CRI of the current epoch, the accumulator value signed with the long term revocation key,
+// which the holders prove the membership of their revocation handle against, see CreateAccumulatorCRI
+func (s *RevocationState) CRI(ltKey *ecdsa.PrivateKey, key *PairingAccumulatorKey) (*CredentialRevocationInformation, error) {
+	return createAccumulatorCRI(ltKey, key, s.GetAccumulator(), int(s.GetEpoch()))
+}
+
+// EpochCRI returns the CRI of the current epoch with the epoch signature of every member, which the holders prove
+// the non-revocation of their revocation handle with without a witness, see CreateCRI
+func (s *RevocationState) EpochCRI(ltKey *ecdsa.PrivateKey, rng io.Reader, curve *math.Curve, t Translator) (*CredentialRevocationInformation, error) {
+	handles := make([]*math.Zr, len(s.GetMembers()))
+	for j, h := range s.GetMembers() {
+		handles[j] = curve.NewZrFromBytes(h)
+	}
+	return createCRI(ltKey, handles, int(s.GetEpoch()), psidentity.ALG_EPOCH_SIGNATURE, rng, curve, t)
+}
@@ -0,0 +1,172 @@
+package psidentity
+
+import (
+	"testing"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+	amcl "psidentity/translator/amcl"
+	user "psidentity/user"
+)
+
+func TestRevocationState(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	ltKeyBytes, ltPkBytes, accKeyBytes, stateBytes, err := GenerateRevocationAuthorityPS(*psid, tr)
+	require.NoError(t, err)
+	ltKey, err := psid.LongTermRevocationKeyFromBytes(ltKeyBytes)
+	require.NoError(t, err)
+	ltPk, err := LongTermRevocationPublicKeyFromBytes(ltPkBytes)
+	require.NoError(t, err)
+	accKey, state := &PairingAccumulatorKey{}, &RevocationState{}
+	require.NoError(t, proto.Unmarshal(accKeyBytes, accKey))
+	require.NoError(t, proto.Unmarshal(stateBytes, state))
+
+	members := []*math.Zr{curve.NewRandomZr(rng), curve.NewRandomZr(rng), curve.NewRandomZr(rng)}
+	for _, rh := range members {
+		require.NoError(t, state.Add(accKey, rh, curve, tr))
+	}
+	require.Error(t, state.Add(accKey, members[0], curve, tr))
+	require.Equal(t, int64(3), state.Epoch)
+	require.Equal(t, RevocationStatusValid, state.Status(members[0]))
+	require.Equal(t, RevocationStatusUnknown, state.Status(curve.NewRandomZr(rng)))
+
+	// the witness verifies against the accumulator of the signed CRI
+	cri, err := state.CRI(ltKey, accKey)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), cri.Epoch)
+	published, err := psid.VerifyAccumulatorCRI(ltPk, cri)
+	require.NoError(t, err)
+	wit, err := state.Witness(accKey, members[0], curve, tr)
+	require.NoError(t, err)
+	require.NoError(t, wit.Verify(published, accKey.Q, curve, tr))
+
+	// a revoked handle can not be added again nor get a witness
+	require.NoError(t, state.Revoke(accKey, members[1], curve, tr))
+	require.Equal(t, RevocationStatusRevoked, state.Status(members[1]))
+	require.Error(t, state.Add(accKey, members[1], curve, tr))
+	require.Error(t, state.Revoke(accKey, members[1], curve, tr))
+	_, err = state.Witness(accKey, members[1], curve, tr)
+	require.Error(t, err)
+
+	// old witnesses are not valid in the new epoch, new ones are
+	cri, err = state.CRI(ltKey, accKey)
+	require.NoError(t, err)
+	published, err = psid.VerifyAccumulatorCRI(ltPk, cri)
+	require.NoError(t, err)
+	require.Error(t, wit.Verify(published, accKey.Q, curve, tr))
+	renewed, err := state.Witness(accKey, members[0], curve, tr)
+	require.NoError(t, err)
+	require.NoError(t, renewed.Verify(published, accKey.Q, curve, tr))
+}
+
+func TestGenerateUserDeriveCredNonRevocation(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{"one", "two", "three", "handle"}
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+
+	// the revocation handle of the serialized primary cred is the one accumulated by the authority
+	primaryBytes, err := proto.Marshal(primary)
+	require.NoError(t, err)
+	raw, err := proto.Marshal(&user.UserPrimaryCred{PrimaryCred: primaryBytes})
+	require.NoError(t, err)
+	rh, err := PrimaryCredHandle(raw, psidentity.AttributeIndexRevocationHandle, curve)
+	require.NoError(t, err)
+	require.True(t, rh.Equals(psid.RevocationHandle(attrs[psidentity.AttributeIndexRevocationHandle])))
+	_, err = PrimaryCredHandle(raw, len(attrs), curve)
+	require.Error(t, err)
+
+	ltKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	accKey, acc := psid.NewPairingAccumulator(rng, tr)
+	state := NewRevocationState(acc)
+	require.NoError(t, state.Add(accKey, rh, curve, tr))
+	require.NoError(t, state.Add(accKey, curve.NewRandomZr(rng), curve, tr))
+	cri, err := state.CRI(ltKey, accKey)
+	require.NoError(t, err)
+	wit, err := state.Witness(accKey, rh, curve, tr)
+	require.NoError(t, err)
+
+	nr := &NonRevocationWitness{CRI: cri, Witness: wit, HandleIndex: psidentity.AttributeIndexRevocationHandle}
+	deriveBytes, _, err := GenerateUserDeriveCred(attrs, *primary, *key, *uk, nr, *psid, tr)
+	require.NoError(t, err)
+	derive := &user.UserDeriveCred{}
+	require.NoError(t, proto.Unmarshal(deriveBytes, derive))
+
+	psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &ltKey.PublicKey, Epoch: int(cri.Epoch), HandleIndex: psidentity.AttributeIndexRevocationHandle, AccumulatorCRI: cri}
+	require.NoError(t, psid.VerifyDeriveEncoded(derive.DeriveCred, key.Ipk))
+
+	// without the proof the verifiers refuse the derived cred
+	policy := psid.NonRevocation
+	psid.NonRevocation = nil
+	deriveBytes, _, err = GenerateUserDeriveCred(attrs, *primary, *key, *uk, nil, *psid, tr)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(deriveBytes, derive))
+	psid.NonRevocation = policy
+	require.Error(t, psid.VerifyDeriveEncoded(derive.DeriveCred, key.Ipk))
+
+	// once the handle is revoked, the holder can not prove membership for the CRI of the next epoch
+	require.NoError(t, state.Revoke(accKey, rh, curve, tr))
+	next, err := state.CRI(ltKey, accKey)
+	require.NoError(t, err)
+	nr.CRI = next
+	_, _, err = GenerateUserDeriveCred(attrs, *primary, *key, *uk, nr, *psid, tr)
+	require.Error(t, err)
+}
+
+func TestGenerateUserDeriveCredEpochNonRevocation(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{"one", "two", "three", "handle"}
+	key, primary := newTestCredential(t, psid, attrs, rng)
+	uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	rh := psid.RevocationHandle(attrs[psidentity.AttributeIndexRevocationHandle])
+
+	ltKey, err := psid.GenerateLongTermRevocationKey()
+	require.NoError(t, err)
+	accKey, acc := psid.NewPairingAccumulator(rng, tr)
+	state := NewRevocationState(acc)
+	require.NoError(t, state.Add(accKey, rh, curve, tr))
+	require.NoError(t, state.Add(accKey, curve.NewRandomZr(rng), curve, tr))
+	cri, err := state.EpochCRI(ltKey, rng, curve, tr)
+	require.NoError(t, err)
+	require.Equal(t, int64(psidentity.ALG_EPOCH_SIGNATURE), int64(cri.RevocationAlg))
+	require.Equal(t, state.Epoch, cri.Epoch)
+
+	// the epoch CRI needs no witness
+	nr := &NonRevocationWitness{CRI: cri, HandleIndex: psidentity.AttributeIndexRevocationHandle}
+	deriveBytes, _, err := GenerateUserDeriveCred(attrs, *primary, *key, *uk, nr, *psid, tr)
+	require.NoError(t, err)
+	derive := &user.UserDeriveCred{}
+	require.NoError(t, proto.Unmarshal(deriveBytes, derive))
+
+	psid.NonRevocation = &NonRevocationPolicy{RevocationPk: &ltKey.PublicKey, Epoch: int(cri.Epoch), HandleIndex: psidentity.AttributeIndexRevocationHandle}
+	require.NoError(t, psid.VerifyDeriveEncoded(derive.DeriveCred, key.Ipk))
+	psid.NonRevocation.Epoch++
+	require.Error(t, psid.VerifyDeriveEncoded(derive.DeriveCred, key.Ipk))
+
+	// once the handle is revoked, it is not signed in the CRI of the next epoch
+	require.NoError(t, state.Revoke(accKey, rh, curve, tr))
+	nr.CRI, err = state.EpochCRI(ltKey, rng, curve, tr)
+	require.NoError(t, err)
+	_, _, err = GenerateUserDeriveCred(attrs, *primary, *key, *uk, nr, *psid, tr)
+	require.Error(t, err)
+}
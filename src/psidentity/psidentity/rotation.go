@@ -0,0 +1,290 @@
+package psidentity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// issuerKeyRotationLabel binds the signatures on a rotation statement to their purpose
+const issuerKeyRotationLabel = "IssuerKeyRotation"
+
+// issuerKeyIDBytes is the length of the prefix of the issuer key hash that forms the key ID
+const issuerKeyIDBytes = 16
+
+// IssuerKeyID returns the key ID of an issuer public key, the hex encoded prefix of its hash.
+// Credentials record the key ID of the issuer key that signed them.
+func IssuerKeyID(ipk *IssuerPublicKeyPS) string {
+	return issuerKeyIDOfHash(ipk.GetHash())
+}
+
+// issuerKeyIDOfHash returns the key ID of the issuer key of the hash
+func issuerKeyIDOfHash(hash []byte) string {
+	if len(hash) > issuerKeyIDBytes {
+		hash = hash[:issuerKeyIDBytes]
+	}
+	return hex.EncodeToString(hash)
+}
+
+// ArchivedIssuerKeyName returns the key store name the public key of the given version of the issuer key
+// of the name is kept under once it is rotated
+func ArchivedIssuerKeyName(name string, version uint32) string {
+	return fmt.Sprintf("%s-v%d", name, version)
+}
+
+// checkIssuerKeyID checks that a credential recording the key ID id was signed by the issuer key.
+// Credentials issued before key IDs were introduced record no key ID and are not checked.
+func checkIssuerKeyID(id string, ipk *IssuerPublicKeyPS) error {
+	if id != "" && id != IssuerKeyID(ipk) {
+		return errors.Errorf("credential was signed by issuer key %s, not %s", id, IssuerKeyID(ipk))
+	}
+	return nil
+}
+
+// RotateIssuerKey generates the issuer key of the next version replacing old, with as many attributes, and the
+// rotation statement signed by both keys. The new key issues credentials from now on and the old key retires at
+// oldKeyRetiresAt, until then verifiers may accept the credentials it signed.
+func (i *Psidentity) RotateIssuerKey(old *IssuerKeyPS, now, oldKeyRetiresAt time.Time, rng io.Reader, t Translator) (*IssuerKeyPS, *IssuerKeyRotation, error) {
+	if err := i.CheckCurve(old.GetIpk().GetCurveId()); err != nil {
+		return nil, nil, errors.WithMessage(err, "issuer key")
+	}
+	if len(old.GetIpk().GetHash()) == 0 {
+		return nil, nil, errors.Errorf("issuer key has no hash")
+	}
+	if oldKeyRetiresAt.Before(now) {
+		return nil, nil, errors.Errorf("old issuer key cannot retire before the rotation")
+	}
+
+	var schema *CredentialSchema
+	if old.GetIpk().GetSchema() != "" {
+		schema = &CredentialSchema{Name: old.GetIpk().GetSchema(), Attributes: old.GetIpk().GetAttributeNames()}
+	}
+	key, err := newVersionedIssuerKeyPS(len(old.GetIpk().GetY()), old.GetIpk().GetValidityWindow(), schema, old.GetIpk().GetVersion()+1, now, old.GetIpk().GetHash(), rng, i.Curve, t)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot generate issuer key")
+	}
+
+	rotation := &IssuerKeyRotation{
+		OldKeyHash:      old.GetIpk().GetHash(),
+		NewKeyHash:      key.Ipk.GetHash(),
+		NewKeyVersion:   key.Ipk.GetVersion(),
+		RotatedAt:       now.Unix(),
+		OldKeyRetiresAt: oldKeyRetiresAt.Unix(),
+		CurveId:         CurveName(i.Curve),
+	}
+	digest, err := rotation.digest()
+	if err != nil {
+		return nil, nil, err
+	}
+	// the old key endorses the new key, the new key proves that the issuer holds it
+	if rotation.OldKeySignature, err = signWithIssuerKey(old, digest, rng, i.Curve, t); err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot sign with the old issuer key")
+	}
+	if rotation.NewKeySignature, err = signWithIssuerKey(key, digest, rng, i.Curve, t); err != nil {
+		return nil, nil, errors.WithMessage(err, "cannot sign with the new issuer key")
+	}
+	return key, rotation, nil
+}
+
+// VerifyIssuerKeyRotation checks that the rotation statement links the new issuer key to the old key it replaces,
+// and that it is signed by both keys
+func (i *Psidentity) VerifyIssuerKeyRotation(rotation *IssuerKeyRotation, oldIpk, newIpk *IssuerPublicKeyPS) error {
+	if err := CheckCurveID(CurveName(i.Curve), rotation.GetCurveId()); err != nil {
+		return err
+	}
+	for _, ipk := range []*IssuerPublicKeyPS{oldIpk, newIpk} {
+		if err := i.CheckCurve(ipk.GetCurveId()); err != nil {
+			return errors.WithMessage(err, "issuer key")
+		}
+		if err := checkIssuerKeyHash(ipk, i); err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(rotation.GetOldKeyHash(), oldIpk.GetHash()) || !bytes.Equal(rotation.GetNewKeyHash(), newIpk.GetHash()) {
+		return errors.Errorf("rotation statement is not about issuer keys %s and %s", IssuerKeyID(oldIpk), IssuerKeyID(newIpk))
+	}
+	if !bytes.Equal(newIpk.GetPredecessorHash(), oldIpk.GetHash()) {
+		return errors.Errorf("issuer key %s does not replace issuer key %s", IssuerKeyID(newIpk), IssuerKeyID(oldIpk))
+	}
+	if newIpk.GetVersion() != oldIpk.GetVersion()+1 || rotation.GetNewKeyVersion() != newIpk.GetVersion() {
+		return errors.Errorf("issuer key version %d does not follow version %d", newIpk.GetVersion(), oldIpk.GetVersion())
+	}
+	if rotation.GetOldKeyRetiresAt() < rotation.GetRotatedAt() {
+		return errors.Errorf("old issuer key retires before the rotation")
+	}
+
+	digest, err := rotation.digest()
+	if err != nil {
+		return err
+	}
+	if err := verifyIssuerKeySignature(rotation.GetOldKeySignature(), oldIpk, digest, i.Curve, i.Translator); err != nil {
+		return errors.WithMessage(err, "old issuer key signature")
+	}
+	return errors.WithMessage(verifyIssuerKeySignature(rotation.GetNewKeySignature(), newIpk, digest, i.Curve, i.Translator), "new issuer key signature")
+}
+
+// digest is the serialized statement the issuer keys sign, without the signatures
+func (rotation *IssuerKeyRotation) digest() ([]byte, error) {
+	unsigned := proto.Clone(rotation).(*IssuerKeyRotation)
+	unsigned.OldKeySignature = nil
+	unsigned.NewKeySignature = nil
+	serialized, err := proto.Marshal(unsigned)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal issuer key rotation")
+	}
+	return append([]byte(issuerKeyRotationLabel), serialized...), nil
+}
+
+// issuerKeyChallenge is the Fiat-Shamir challenge of a signature with the issuer secret x, T is the commitment
+func issuerKeyChallenge(T, X *math.G1, msg []byte, curve *math.Curve) *math.Zr {
+	data := append(append(T.Bytes(), X.Bytes()...), msg...)
+	return curve.HashToZr(data)
+}
+
+// signWithIssuerKey signs msg with a Schnorr signature proving knowledge of the secret x of X = g_1^x
+func signWithIssuerKey(key *IssuerKeyPS, msg []byte, rng io.Reader, curve *math.Curve, t Translator) (*IssuerKeySignature, error) {
+	X, err := t.G1FromProto(key.GetIpk().GetX())
+	if err != nil {
+		return nil, err
+	}
+	x := curve.NewZrFromBytes(key.GetIsk().GetX())
+	if !curve.GenG1.Mul(x).Equals(X) {
+		return nil, errors.Errorf("issuer secret key does not match the issuer public key")
+	}
+
+	r := curve.NewRandomZr(rng)
+	c := issuerKeyChallenge(curve.GenG1.Mul(r), X, msg, curve)
+	s := curve.ModAdd(curve.ModMul(c, x, curve.GroupOrder), r, curve.GroupOrder) // s = r + c \cdot x
+	return &IssuerKeySignature{C: c.Bytes(), S: s.Bytes()}, nil
+}
+
+// verifyIssuerKeySignature verifies a signature of signWithIssuerKey with the issuer public key
+func verifyIssuerKeySignature(sig *IssuerKeySignature, ipk *IssuerPublicKeyPS, msg []byte, curve *math.Curve, t Translator) error {
+	if sig == nil {
+		return errors.Errorf("signature is missing")
+	}
+	X, err := t.G1FromProto(ipk.GetX())
+	if err != nil {
+		return err
+	}
+	c := curve.NewZrFromBytes(sig.GetC())
+	s := curve.NewZrFromBytes(sig.GetS())
+
+	T := curve.GenG1.Mul(s)
+	T.Add(X.Mul(curve.ModNeg(c, curve.GroupOrder))) // T = g_1^s \cdot X^{-c}
+	if !c.Equals(issuerKeyChallenge(T, X, msg, curve)) {
+		return errors.Errorf("signature is invalid")
+	}
+	return nil
+}
+
+// retiringIssuerKey is an issuer key replaced by a newer key, accepted until it retires
+type retiringIssuerKey struct {
+	ipk       *IssuerPublicKeyPS
+	retiresAt time.Time
+}
+
+// IssuerKeySet is the set of issuer keys a verifier accepts credentials of: the active key of the issuer and
+// the retiring keys added with their rotation statements, each accepted until it retires
+type IssuerKeySet struct {
+	psid     *Psidentity
+	active   *IssuerPublicKeyPS
+	retiring map[string]retiringIssuerKey
+}
+
+// NewIssuerKeySet returns the set of the active issuer key
+func (i *Psidentity) NewIssuerKeySet(active *IssuerPublicKeyPS) (*IssuerKeySet, error) {
+	if err := i.CheckCurve(active.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	if err := checkIssuerKeyHash(active, i); err != nil {
+		return nil, err
+	}
+	return &IssuerKeySet{psid: i, active: active, retiring: map[string]retiringIssuerKey{}}, nil
+}
+
+// lookup returns the key of the ID in the set, regardless of whether it is retired
+func (s *IssuerKeySet) lookup(id string) (*IssuerPublicKeyPS, bool) {
+	if id == IssuerKeyID(s.active) {
+		return s.active, true
+	}
+	k, ok := s.retiring[id]
+	return k.ipk, ok
+}
+
+// AddRetiring adds an issuer key replaced by a key of the set, the rotation statement links the two keys and
+// tells when the key retires. The keys of a chain of rotations are added from the newest to the oldest.
+func (s *IssuerKeySet) AddRetiring(ipk *IssuerPublicKeyPS, rotation *IssuerKeyRotation) error {
+	successor, ok := s.lookup(issuerKeyIDOfHash(rotation.GetNewKeyHash()))
+	if !ok {
+		return errors.Errorf("issuer key %s is not replaced by a key of the set", IssuerKeyID(ipk))
+	}
+	if err := s.psid.VerifyIssuerKeyRotation(rotation, ipk, successor); err != nil {
+		return errors.WithMessagef(err, "invalid rotation of issuer key %s", IssuerKeyID(ipk))
+	}
+	s.retiring[IssuerKeyID(ipk)] = retiringIssuerKey{ipk: ipk, retiresAt: time.Unix(rotation.GetOldKeyRetiresAt(), 0)}
+	return nil
+}
+
+// Active returns the active issuer key
+func (s *IssuerKeySet) Active() *IssuerPublicKeyPS {
+	return s.active
+}
+
+// Key returns the issuer key of the ID if it is accepted at time now. Credentials without a key ID were
+// issued before key rotation and belong to the active key.
+func (s *IssuerKeySet) Key(id string, now time.Time) (*IssuerPublicKeyPS, error) {
+	if id == "" || id == IssuerKeyID(s.active) {
+		return s.active, nil
+	}
+	k, ok := s.retiring[id]
+	if !ok {
+		return nil, errors.Errorf("unknown issuer key %s", id)
+	}
+	if !now.Before(k.retiresAt) {
+		return nil, errors.Errorf("issuer key %s retired at %s", id, k.retiresAt.UTC().Format(time.RFC3339))
+	}
+	return k.ipk, nil
+}
+
+// Keys returns the issuer keys accepted at time now, the active key first and the retiring keys from the newest
+func (s *IssuerKeySet) Keys(now time.Time) []*IssuerPublicKeyPS {
+	var retiring []*IssuerPublicKeyPS
+	for _, k := range s.retiring {
+		if now.Before(k.retiresAt) {
+			retiring = append(retiring, k.ipk)
+		}
+	}
+	sort.Slice(retiring, func(a, b int) bool {
+		return retiring[a].GetVersion() > retiring[b].GetVersion()
+	})
+	return append([]*IssuerPublicKeyPS{s.active}, retiring...)
+}
+
+// VerifyPrimary verifies a primary credential with the issuer key that signed it, if that is accepted at time now
+func (s *IssuerKeySet) VerifyPrimary(cred *PrimaryCredential, now time.Time) error {
+	ipk, err := s.Key(cred.GetIssuerKeyId(), now)
+	if err != nil {
+		return err
+	}
+	return cred.VerifyPrimary(ipk, s.psid.Curve, s.psid.Translator)
+}
+
+// VerifyDerive verifies a derived credential with the issuer key that signed it, if that is accepted at time now
+func (s *IssuerKeySet) VerifyDerive(cred *DeriveCredential, now time.Time) error {
+	ipk, err := s.Key(cred.GetIssuerKeyId(), now)
+	if err != nil {
+		return err
+	}
+	if err := cred.VerifyDerive(ipk, s.psid.Curve, s.psid.Translator); err != nil {
+		return err
+	}
+	return s.psid.verifyNonRevocation(cred, ipk)
+}
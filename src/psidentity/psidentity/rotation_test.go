@@ -0,0 +1,128 @@
+package psidentity
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestIssuerKeyRotation(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	attrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+	old, oldPrimary := newTestCredential(t, psid, attrs, rng)
+	require.Equal(t, uint32(1), old.Ipk.Version)
+	require.Equal(t, IssuerKeyID(old.Ipk), oldPrimary.IssuerKeyId)
+
+	now := time.Now()
+	retiresAt := now.Add(time.Hour)
+	key, rotation, err := psid.RotateIssuerKey(old, now, retiresAt, rng, tr)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), key.Ipk.Version)
+	require.Equal(t, old.Ipk.Hash, key.Ipk.PredecessorHash)
+	require.Len(t, key.Ipk.Y, len(old.Ipk.Y))
+	require.NoError(t, psid.VerifyIssuerKeyRotation(rotation, old.Ipk, key.Ipk))
+	primary := issueTestCredential(t, psid, key, attrs, rng)
+
+	// a credential only verifies with the key it records
+	require.Error(t, oldPrimary.VerifyPrimary(key.Ipk, psid.Curve, tr))
+	require.Error(t, primary.VerifyPrimary(old.Ipk, psid.Curve, tr))
+
+	// the statement must link the keys in order and carry both signatures
+	require.Error(t, psid.VerifyIssuerKeyRotation(rotation, key.Ipk, old.Ipk))
+	tampered := proto.Clone(rotation).(*IssuerKeyRotation)
+	tampered.OldKeyRetiresAt = retiresAt.Add(time.Hour).Unix()
+	require.Error(t, psid.VerifyIssuerKeyRotation(tampered, old.Ipk, key.Ipk))
+	tampered = proto.Clone(rotation).(*IssuerKeyRotation)
+	tampered.OldKeySignature = tampered.NewKeySignature
+	require.Error(t, psid.VerifyIssuerKeyRotation(tampered, old.Ipk, key.Ipk))
+	other, err := psid.NewIssuerKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	_, forged, err := psid.RotateIssuerKey(other, now, retiresAt, rng, tr)
+	require.NoError(t, err)
+	require.Error(t, psid.VerifyIssuerKeyRotation(forged, old.Ipk, key.Ipk))
+
+	// the verifier accepts the retiring key until it retires
+	keys, err := psid.NewIssuerKeySet(key.Ipk)
+	require.NoError(t, err)
+	require.Error(t, keys.VerifyPrimary(oldPrimary, now))
+	require.Error(t, keys.AddRetiring(other.Ipk, forged))
+	require.NoError(t, keys.AddRetiring(old.Ipk, rotation))
+	require.NoError(t, keys.VerifyPrimary(primary, now))
+	require.NoError(t, keys.VerifyPrimary(oldPrimary, now))
+	require.Len(t, keys.Keys(now), 2)
+	require.Error(t, keys.VerifyPrimary(oldPrimary, retiresAt))
+	require.NoError(t, keys.VerifyPrimary(primary, retiresAt))
+	require.Len(t, keys.Keys(retiresAt), 1)
+
+	// a credential recording another key ID, or signed by a key outside the set, is refused
+	moved := proto.Clone(primary).(*PrimaryCredential)
+	moved.IssuerKeyId = oldPrimary.IssuerKeyId
+	require.Error(t, keys.VerifyPrimary(moved, now))
+	_, otherPrimary := newTestCredential(t, psid, attrs, rng)
+	require.Error(t, keys.VerifyPrimary(otherPrimary, now))
+
+	// credentials without a key ID belong to the active key
+	primary.IssuerKeyId = ""
+	require.NoError(t, keys.VerifyPrimary(primary, now))
+	oldPrimary.IssuerKeyId = ""
+	require.Error(t, keys.VerifyPrimary(oldPrimary, now))
+
+	// the new key keeps the schema of the old one
+	schema := &CredentialSchema{Name: "license", Attributes: []string{"Number", "Class", psidentity.IssuerAttributeNotBefore, psidentity.IssuerAttributeNotAfter}}
+	licenseKey, err := psid.NewIssuerKeyPSWithSchema(schema, rng, tr)
+	require.NoError(t, err)
+	require.True(t, licenseKey.Ipk.ValidityWindow)
+	rotated, _, err := psid.RotateIssuerKey(licenseKey, now, retiresAt, rng, tr)
+	require.NoError(t, err)
+	rotatedSchema, err := IssuerSchema(rotated.Ipk, len(schema.Attributes))
+	require.NoError(t, err)
+	require.Equal(t, schema, rotatedSchema)
+	require.True(t, rotated.Ipk.ValidityWindow)
+}
+
+func TestRotateIssuerKeyWithStore(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	store := NewFileKeyStore(dir, psid)
+	iskBytes, ipkBytes, err := GenerateIssuerKeyPS(*psid, psid.Translator)
+	require.NoError(t, err)
+	name := psidentity.PsIdentityDirIssuerKey
+	require.NoError(t, store.PutIssuerKey(name, ipkBytes, iskBytes))
+	old, err := StoredIssuerPublicKey(store, name)
+	require.NoError(t, err)
+
+	_, err = RotateIssuerKeyWithStore(store, name, time.Hour, *psid, psid.Translator)
+	require.NoError(t, err)
+	ipk, err := StoredIssuerPublicKey(store, name)
+	require.NoError(t, err)
+	require.Equal(t, old.Version+1, ipk.Version)
+	archived, err := StoredIssuerPublicKey(store, ArchivedIssuerKeyName(name, old.Version))
+	require.NoError(t, err)
+	require.True(t, proto.Equal(old, archived))
+
+	// the new secret key is bound to the new public key
+	raw, err := ioutil.ReadFile(filepath.Join(dir, name, psidentity.PsIdentityConfigIssuerSecretKey))
+	require.NoError(t, err)
+	env, err := psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerSecretKey, ipk.Hash)
+	require.NoError(t, err)
+	require.Equal(t, ipk.Hash, env.IssuerKeyHash)
+	_, err = psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerSecretKey, old.Hash)
+	require.Error(t, err)
+
+
+	// no temporary file is left next to the keys
+	entries, err := ioutil.ReadDir(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
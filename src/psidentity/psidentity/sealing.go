@@ -0,0 +1,175 @@
+package psidentity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Key derivation functions of the sealing key
+const (
+	SealKDFScrypt   = 0
+	SealKDFArgon2id = 1
+)
+
+// Default scrypt parameters used to derive the sealing key from a passphrase
+const (
+	sealScryptLogN = 15
+	sealScryptR    = 8
+	sealScryptP    = 1
+	sealSaltSize   = 16
+	sealKeySize    = 32
+)
+
+// Default argon2id parameters, the second recommended option of RFC 9106
+const (
+	sealArgon2Time    = 3
+	sealArgon2Memory  = 64 * 1024
+	sealArgon2Threads = 4
+	// sealArgon2MaxMemory bounds the memory, in KiB, a sealed key may require to be opened
+	sealArgon2MaxMemory = 2 * sealArgon2Memory
+)
+
+// SealKey encrypts raw secret key material under a passphrase, with a sealing key derived by scrypt.
+// The label binds the ciphertext to the kind of key it contains,
+// so that a sealed key cannot be opened as a different kind of key.
+func SealKey(raw []byte, passphrase []byte, label string, rng io.Reader) (*SealedKey, error) {
+	return SealKeyWithKDF(raw, passphrase, label, SealKDFScrypt, rng)
+}
+
+// SealKeyWithKDF encrypts raw secret key material under a passphrase, with a sealing key derived by kdf
+func SealKeyWithKDF(raw []byte, passphrase []byte, label string, kdf uint32, rng io.Reader) (*SealedKey, error) {
+	return sealKey(raw, passphrase, []byte(label), kdf, rng)
+}
+
+// sealKey encrypts raw secret key material under a passphrase, with a sealing key derived by kdf.
+// The ciphertext is authenticated together with the additional data aad.
+func sealKey(raw []byte, passphrase []byte, aad []byte, kdf uint32, rng io.Reader) (*SealedKey, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.Errorf("empty passphrase")
+	}
+
+	sealed := &SealedKey{
+		Salt: make([]byte, sealSaltSize),
+		Kdf:  kdf,
+	}
+	switch kdf {
+	case SealKDFScrypt:
+		sealed.LogN, sealed.R, sealed.P = sealScryptLogN, sealScryptR, sealScryptP
+	case SealKDFArgon2id:
+		sealed.Argon2Time, sealed.Argon2Memory, sealed.Argon2Threads = sealArgon2Time, sealArgon2Memory, sealArgon2Threads
+	default:
+		return nil, errors.Errorf("unknown key derivation function %d", kdf)
+	}
+	if _, err := io.ReadFull(rng, sealed.Salt); err != nil {
+		return nil, errors.Wrap(err, "failed to sample salt")
+	}
+
+	aead, err := sealed.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rng, sealed.Nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to sample nonce")
+	}
+	sealed.Ciphertext = aead.Seal(nil, sealed.Nonce, raw, aad)
+
+	return sealed, nil
+}
+
+// Open decrypts the sealed key material with the passphrase it was sealed with
+func (sealed *SealedKey) Open(passphrase []byte, label string) ([]byte, error) {
+	return sealed.open(passphrase, []byte(label), label)
+}
+
+// open decrypts the sealed key material with the passphrase and the additional data it was sealed with,
+// label names the key material in errors
+func (sealed *SealedKey) open(passphrase []byte, aad []byte, label string) ([]byte, error) {
+	aead, err := sealed.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.GetNonce()) != aead.NonceSize() {
+		return nil, errors.Errorf("invalid nonce length")
+	}
+
+	raw, err := aead.Open(nil, sealed.GetNonce(), sealed.GetCiphertext(), aad)
+	if err != nil {
+		return nil, errors.Errorf("wrong passphrase or corrupted %s", label)
+	}
+	return raw, nil
+}
+
+func (sealed *SealedKey) aead(passphrase []byte) (cipher.AEAD, error) {
+	key, err := sealed.sealingKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealingKey derives the sealing key from the passphrase with the parameters of the sealed key
+func (sealed *SealedKey) sealingKey(passphrase []byte) ([]byte, error) {
+	switch sealed.GetKdf() {
+	case SealKDFScrypt:
+		if sealed.GetLogN() == 0 || sealed.GetLogN() > 30 {
+			return nil, errors.Errorf("invalid scrypt cost parameter %d", sealed.GetLogN())
+		}
+		key, err := scrypt.Key(passphrase, sealed.GetSalt(), 1<<sealed.GetLogN(), int(sealed.GetR()), int(sealed.GetP()), sealKeySize)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to derive sealing key")
+		}
+		return key, nil
+
+	case SealKDFArgon2id:
+		// the bounds keep a corrupted file from exhausting the memory or running forever
+		if sealed.GetArgon2Time() == 0 || sealed.GetArgon2Time() > 64 {
+			return nil, errors.Errorf("invalid argon2id time parameter %d", sealed.GetArgon2Time())
+		}
+		if sealed.GetArgon2Memory() < 8*sealed.GetArgon2Threads() || sealed.GetArgon2Memory() > sealArgon2MaxMemory {
+			return nil, errors.Errorf("invalid argon2id memory parameter %d", sealed.GetArgon2Memory())
+		}
+		if sealed.GetArgon2Threads() == 0 || sealed.GetArgon2Threads() > 255 {
+			return nil, errors.Errorf("invalid argon2id threads parameter %d", sealed.GetArgon2Threads())
+		}
+		return argon2.IDKey(passphrase, sealed.GetSalt(), sealed.GetArgon2Time(), sealed.GetArgon2Memory(), uint8(sealed.GetArgon2Threads()), sealKeySize), nil
+	}
+	return nil, errors.Errorf("unknown key derivation function %d", sealed.GetKdf())
+}
+
+// SealMessage serializes a proto message and seals it under a passphrase
+func SealMessage(msg proto.Message, passphrase []byte, label string, rng io.Reader) ([]byte, error) {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %s", label)
+	}
+	sealed, err := SealKey(raw, passphrase, label, rng)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(sealed)
+}
+
+// OpenMessage opens a sealed serialized proto message into msg
+func OpenMessage(sealedBytes []byte, passphrase []byte, label string, msg proto.Message) error {
+	sealed := &SealedKey{}
+	if err := proto.Unmarshal(sealedBytes, sealed); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal sealed %s", label)
+	}
+	raw, err := sealed.Open(passphrase, label)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(raw, msg)
+}
@@ -27,13 +27,15 @@ const (
 
 // ECP is an elliptic curve point specified by its coordinates
 // ECP corresponds to an element of the first group (G1)
+// compressed holds the compressed encoding of the point instead of x and y
 type ECP struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	X []byte `protobuf:"bytes,1,opt,name=x,proto3" json:"x,omitempty"`
-	Y []byte `protobuf:"bytes,2,opt,name=y,proto3" json:"y,omitempty"`
+	X          []byte `protobuf:"bytes,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y          []byte `protobuf:"bytes,2,opt,name=y,proto3" json:"y,omitempty"`
+	Compressed []byte `protobuf:"bytes,3,opt,name=compressed,proto3" json:"compressed,omitempty"`
 }
 
 func (x *ECP) Reset() {
@@ -82,17 +84,26 @@ func (x *ECP) GetY() []byte {
 	return nil
 }
 
+func (x *ECP) GetCompressed() []byte {
+	if x != nil {
+		return x.Compressed
+	}
+	return nil
+}
+
 // ECP2 is an elliptic curve point specified by its coordinates
 // ECP2 corresponds to an element of the second group (G2)
+// compressed holds the compressed encoding of the point instead of xa, xb, ya and yb
 type ECP2 struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Xa []byte `protobuf:"bytes,1,opt,name=xa,proto3" json:"xa,omitempty"`
-	Xb []byte `protobuf:"bytes,2,opt,name=xb,proto3" json:"xb,omitempty"`
-	Ya []byte `protobuf:"bytes,3,opt,name=ya,proto3" json:"ya,omitempty"`
-	Yb []byte `protobuf:"bytes,4,opt,name=yb,proto3" json:"yb,omitempty"`
+	Xa         []byte `protobuf:"bytes,1,opt,name=xa,proto3" json:"xa,omitempty"`
+	Xb         []byte `protobuf:"bytes,2,opt,name=xb,proto3" json:"xb,omitempty"`
+	Ya         []byte `protobuf:"bytes,3,opt,name=ya,proto3" json:"ya,omitempty"`
+	Yb         []byte `protobuf:"bytes,4,opt,name=yb,proto3" json:"yb,omitempty"`
+	Compressed []byte `protobuf:"bytes,5,opt,name=compressed,proto3" json:"compressed,omitempty"`
 }
 
 func (x *ECP2) Reset() {
@@ -155,18 +166,31 @@ func (x *ECP2) GetYb() []byte {
 	return nil
 }
 
+func (x *ECP2) GetCompressed() []byte {
+	if x != nil {
+		return x.Compressed
+	}
+	return nil
+}
+
 var File_amcl_proto protoreflect.FileDescriptor
 
 var file_amcl_proto_rawDesc = []byte{
 	0x0a, 0x0a, 0x61, 0x6d, 0x63, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x61, 0x6d,
-	0x63, 0x6c, 0x22, 0x21, 0x0a, 0x03, 0x45, 0x43, 0x50, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01,
+	0x63, 0x6c, 0x22, 0x41, 0x0a, 0x03, 0x45, 0x43, 0x50, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x01, 0x79, 0x22, 0x46, 0x0a, 0x04, 0x45, 0x43, 0x50, 0x32, 0x12, 0x0e, 0x0a,
+	0x28, 0x0c, 0x52, 0x01, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72,
+	0x65, 0x73, 0x73, 0x65, 0x64, 0x22, 0x66, 0x0a, 0x04, 0x45, 0x43, 0x50, 0x32, 0x12, 0x0e, 0x0a,
 	0x02, 0x78, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x78, 0x61, 0x12, 0x0e, 0x0a,
 	0x02, 0x78, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x78, 0x62, 0x12, 0x0e, 0x0a,
 	0x02, 0x79, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x79, 0x61, 0x12, 0x0e, 0x0a,
-	0x02, 0x79, 0x62, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x79, 0x62, 0x42, 0x04, 0x5a,
-	0x02, 0x2e, 0x2f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x02, 0x79, 0x62, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x79, 0x62, 0x12, 0x1e, 0x0a,
+	0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x42, 0x25, 0x5a,
+	0x23, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2f,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x61, 0x6d, 0x63, 0x6c, 0x3b,
+	0x61, 0x6d, 0x63, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
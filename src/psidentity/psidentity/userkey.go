@@ -17,8 +17,8 @@ func NewUserKeyPS(n int, rng io.Reader, curve *math.Curve, t Translator) (*UserK
 	key := new(UserKey)
 
 	// generate user key
-	key.Usk = new(UserPrivateKey)
-	key.Upk = new(UserPublicKey)
+	key.Usk = &UserPrivateKey{CurveId: CurveName(curve)}
+	key.Upk = &UserPublicKey{CurveId: CurveName(curve)}
 
 	key.Upk.W = make([]*amcl.ECP, n)
 	key.Upk.WBar = make([]*amcl.ECP2, n)
@@ -0,0 +1,515 @@
+package psidentity
+
+import (
+	"encoding/binary"
+	"io"
+	"math/big"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/pkg/errors"
+
+	amcl "psidentity/translator/amcl"
+)
+
+// validityLabel is the label used in the zero-knowledge proof that a derived credential is valid at a certain time
+const validityLabel = "validity"
+
+const (
+	// validityAttributeLayout is the layout of a validity attribute, a UTC day.
+	// The attribute is signed as the number of the day since 1970-01-01, see attributeZr.
+	validityAttributeLayout = "2006-01-02"
+	// validityRangeBits bounds the difference of two validity days, 2^16 days are almost 180 years
+	validityRangeBits = 16
+	// attributeRangeBits bounds the difference of the values of two other attributes, up to 12 bytes long
+	attributeRangeBits = 96
+	// secondsPerDay is the length of a validity day
+	secondsPerDay = 24 * 60 * 60
+)
+
+// ValidityClockSkew is how far the time of a validity proof may be from the clock of the verifier
+const ValidityClockSkew = 5 * time.Minute
+
+// EncodeTimeAttribute encodes the UTC day of a time as the value of a validity attribute,
+// times before 1970 are encoded as 1970-01-01
+func EncodeTimeAttribute(t time.Time) string {
+	return time.Unix(validityDay(t)*secondsPerDay, 0).UTC().Format(validityAttributeLayout)
+}
+
+// DecodeTimeAttribute decodes the value of a validity attribute to the start of its UTC day
+func DecodeTimeAttribute(attr string) (time.Time, error) {
+	t, err := time.Parse(validityAttributeLayout, attr)
+	if err != nil || t.Unix() < 0 {
+		return time.Time{}, errors.Errorf("validity attribute %q is not a day since 1970-01-01", attr)
+	}
+	return t, nil
+}
+
+// validityDay is the number of the UTC day of a time since 1970-01-01, times before 1970 are on day 0
+func validityDay(t time.Time) int64 {
+	secs := t.Unix()
+	if secs < 0 {
+		return 0
+	}
+	return secs / secondsPerDay
+}
+
+// endOfDay is the last second of the UTC day of a decoded validity attribute
+func endOfDay(day time.Time) time.Time {
+	return day.Add(secondsPerDay*time.Second - time.Second)
+}
+
+// ValidityWindow is the validity window an issuer assigns to a credential, at day granularity:
+// the credential is valid from the start of the UTC day of NotBefore through the end of the UTC day of NotAfter
+type ValidityWindow struct {
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// attributes returns the not-before and not-after attributes of the window
+func (w *ValidityWindow) attributes() ([]string, error) {
+	if validityDay(w.NotAfter) < validityDay(w.NotBefore) {
+		return nil, errors.Errorf("validity window ends before it starts")
+	}
+	return []string{EncodeTimeAttribute(w.NotBefore), EncodeTimeAttribute(w.NotAfter)}, nil
+}
+
+// AppendValidity appends a validity window to the user attributes, the not-before and not-after attributes are
+// the last two attributes of the credentials of a key made with NewIssuerKeyPSWithValidity.
+// The issuer signs them in clear, see NewBlindCredentialWithValidity.
+func AppendValidity(attrs []string, notBefore, notAfter time.Time) ([]string, error) {
+	if len(attrs) == 0 {
+		return nil, errors.Errorf("a credential with a validity window needs at least one user attribute")
+	}
+	window, err := (&ValidityWindow{NotBefore: notBefore, NotAfter: notAfter}).attributes()
+	if err != nil {
+		return nil, err
+	}
+	withValidity := make([]string, 0, len(attrs)+2)
+	withValidity = append(withValidity, attrs...)
+	return append(withValidity, window...), nil
+}
+
+// hasValidity tells whether the credentials of the issuer key have a validity window
+func hasValidity(ipk *IssuerPublicKeyPS) bool {
+	return ipk.GetValidityWindow() && len(ipk.GetY()) > 2
+}
+
+// validityIndices returns the indices of the not-before and not-after attributes of the credentials of the issuer key
+func validityIndices(ipk *IssuerPublicKeyPS) (int, int, error) {
+	if !hasValidity(ipk) {
+		return 0, 0, errors.Errorf("the credentials of the issuer key have no validity window")
+	}
+	n := len(ipk.GetY())
+	return n - 2, n - 1, nil
+}
+
+// isValidityIndex tells whether the attribute at the index of the credentials of the issuer key is a validity attribute
+func isValidityIndex(ipk *IssuerPublicKeyPS, index int) bool {
+	return hasValidity(ipk) && index >= len(ipk.GetY())-2 && index < len(ipk.GetY())
+}
+
+// attributeValue is the integer the attribute at the index of the credentials of the issuer key is mapped to:
+// the day number of a validity attribute, so that the range proofs on the validity window are short,
+// and the big-endian integer of the bytes of any other attribute
+func attributeValue(ipk *IssuerPublicKeyPS, index int, attr string) *big.Int {
+	if isValidityIndex(ipk, index) {
+		if t, err := DecodeTimeAttribute(attr); err == nil {
+			return big.NewInt(validityDay(t))
+		}
+	}
+	return new(big.Int).SetBytes([]byte(attr))
+}
+
+// attributeZr is the element of Z_r the attribute at the index of the credentials of the issuer key is signed as
+func attributeZr(ipk *IssuerPublicKeyPS, index int, attr string, curve *math.Curve) *math.Zr {
+	if isValidityIndex(ipk, index) {
+		if t, err := DecodeTimeAttribute(attr); err == nil {
+			return curve.NewZrFromInt(validityDay(t))
+		}
+	}
+	return curve.NewZrFromBytes([]byte(attr))
+}
+
+// rangeBits is the number of bits of the range proofs on the attribute at the index
+func rangeBits(ipk *IssuerPublicKeyPS, index int) int {
+	if isValidityIndex(ipk, index) {
+		return validityRangeBits
+	}
+	return attributeRangeBits
+}
+
+// Validity returns the validity window at the end of the credential attributes, see AppendValidity:
+// the start of the not-before day and the end of the not-after day.
+// Whether the credentials have one is recorded in their issuer key, see NewIssuerKeyPSWithValidity.
+func Validity(attrs []string) (time.Time, time.Time, error) {
+	if len(attrs) < 3 {
+		return time.Time{}, time.Time{}, errors.Errorf("credential has no validity attributes")
+	}
+	notBefore, err := DecodeTimeAttribute(attrs[len(attrs)-2])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	notAfter, err := DecodeTimeAttribute(attrs[len(attrs)-1])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return notBefore, endOfDay(notAfter), nil
+}
+
+// Validity returns the validity window of the primary credential
+func (cred *PrimaryCredential) Validity() (time.Time, time.Time, error) {
+	return Validity(cred.GetAttrs())
+}
+
+// checkValidity checks that now is within the validity window
+func checkValidity(notBefore, notAfter time.Time, now time.Time) error {
+	if now.Unix() < notBefore.Unix() {
+		return errors.Errorf("credential is not valid before %s", notBefore.UTC().Format(time.RFC3339))
+	}
+	if now.Unix() > notAfter.Unix() {
+		return errors.Errorf("credential expired at %s", notAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// validityGenerator is the second base of the Pedersen commitments of the range proofs,
+// derived by hashing so that nobody knows its discrete logarithm to the base g_1
+func validityGenerator(curve *math.Curve) *math.G1 {
+	return curve.HashToG1WithDomain([]byte(validityLabel), []byte("psidentity"))
+}
+
+// rangeTranscript are the values of a range proof that go into the challenge
+type rangeTranscript struct {
+	C     []*math.G1 // bit commitments C_i = g_1^{b_i} \cdot h^{r_i}
+	A0    []*math.G1 // first moves of the 0-branches
+	A1    []*math.G1 // first moves of the 1-branches
+	TLink *math.G1   // first move of the proof that the bits add up to the attribute
+}
+
+// rangeCommitment is the first move of a proof that a hidden attribute m satisfies
+// m - v \in [0, 2^k) (upper = false) or v - m \in [0, 2^k) (upper = true) for a public value v
+type rangeCommitment struct {
+	rangeTranscript
+	bits []uint
+	r    []*math.Zr
+	w    []*math.Zr // randomness of the real branches
+	simC []*math.Zr // challenges of the simulated branches
+	simZ []*math.Zr // s-values of the simulated branches
+	rho  *math.Zr   // exponent of h in the commitment D = g_1^m \cdot h^{rho} the proof links to
+	rRho *math.Zr
+}
+
+func newRangeCommitment(delta *big.Int, k int, upper bool, rM *math.Zr, h *math.G1, rng io.Reader, curve *math.Curve) (*rangeCommitment, error) {
+	if delta.Sign() < 0 || delta.BitLen() > k {
+		return nil, errors.Errorf("value out of range")
+	}
+	rc := &rangeCommitment{
+		rangeTranscript: rangeTranscript{
+			C:  make([]*math.G1, k),
+			A0: make([]*math.G1, k),
+			A1: make([]*math.G1, k),
+		},
+		bits: make([]uint, k),
+		r:    make([]*math.Zr, k),
+		w:    make([]*math.Zr, k),
+		simC: make([]*math.Zr, k),
+		simZ: make([]*math.Zr, k),
+	}
+
+	rho := curve.NewZrFromInt(0)
+	two := curve.NewZrFromInt(2)
+	for i := k - 1; i >= 0; i-- {
+		rc.bits[i] = delta.Bit(i)
+		rc.r[i] = curve.NewRandomZr(rng)
+		rc.C[i] = h.Mul(rc.r[i])
+		if rc.bits[i] == 1 {
+			rc.C[i].Add(curve.GenG1)
+		}
+
+		// the branch of the actual bit is proven, the other one is simulated
+		rc.w[i] = curve.NewRandomZr(rng)
+		rc.simC[i] = curve.NewRandomZr(rng)
+		rc.simZ[i] = curve.NewRandomZr(rng)
+		if rc.bits[i] == 0 {
+			rc.A0[i] = h.Mul(rc.w[i])
+			rc.A1[i] = h.Mul2(rc.simZ[i], bitBase(rc.C[i], 1, curve), curve.ModNeg(rc.simC[i], curve.GroupOrder))
+		} else {
+			rc.A0[i] = h.Mul2(rc.simZ[i], bitBase(rc.C[i], 0, curve), curve.ModNeg(rc.simC[i], curve.GroupOrder))
+			rc.A1[i] = h.Mul(rc.w[i])
+		}
+
+		// rho = \sum_i 2^i r_i
+		rho = curve.ModAdd(curve.ModMul(rho, two, curve.GroupOrder), rc.r[i], curve.GroupOrder)
+	}
+
+	// \prod_i C_i^{2^i} = g_1^{delta} \cdot h^{rho}, so D = g_1^m \cdot h^{rho} for a lower bound
+	// and D = g_1^m \cdot h^{-rho} for an upper bound
+	rc.rho = rho
+	if upper {
+		rc.rho = curve.ModNeg(rho, curve.GroupOrder)
+	}
+	rc.rRho = curve.NewRandomZr(rng)
+	rc.TLink = curve.GenG1.Mul2(rM, h, rc.rRho) // TLink = g_1^{r_m} \cdot h^{r_rho}, covers D
+
+	return rc, nil
+}
+
+// bitBase returns C_i for the 0-branch and C_i / g_1 for the 1-branch, both are h^{r_i} for the actual bit
+func bitBase(C *math.G1, bit uint, curve *math.Curve) *math.G1 {
+	base := C.Copy()
+	if bit == 1 {
+		base.Sub(curve.GenG1)
+	}
+	return base
+}
+
+// respond completes the OR-proofs of all bits and the link proof for the challenge
+func (rc *rangeCommitment) respond(proofC *math.Zr, curve *math.Curve, tr Translator) *RangeProof {
+	k := len(rc.C)
+	proof := &RangeProof{
+		BitCommitments: make([]*amcl.ECP, k),
+		ProofC0:        make([][]byte, k),
+		ProofZ0:        make([][]byte, k),
+		ProofZ1:        make([][]byte, k),
+	}
+	for i := 0; i < k; i++ {
+		proof.BitCommitments[i] = tr.G1ToProto(rc.C[i])
+
+		// the challenges of both branches add up to the challenge of the proof
+		realC := curve.ModSub(proofC, rc.simC[i], curve.GroupOrder)
+		realZ := curve.ModAdd(rc.w[i], curve.ModMul(realC, rc.r[i], curve.GroupOrder), curve.GroupOrder)
+		if rc.bits[i] == 0 {
+			proof.ProofC0[i] = realC.Bytes()
+			proof.ProofZ0[i] = realZ.Bytes()
+			proof.ProofZ1[i] = rc.simZ[i].Bytes()
+		} else {
+			proof.ProofC0[i] = rc.simC[i].Bytes()
+			proof.ProofZ0[i] = rc.simZ[i].Bytes()
+			proof.ProofZ1[i] = realZ.Bytes()
+		}
+	}
+	proof.ProofSRho = curve.ModAdd(rc.rRho, curve.ModMul(proofC, rc.rho, curve.GroupOrder), curve.GroupOrder).Bytes()
+	return proof
+}
+
+// recomputeRangeTranscript recomputes the first moves of a k-bit range proof from the s-values
+// for the public value v and the s-value sM of the hidden attribute
+func recomputeRangeTranscript(proof *RangeProof, k int, upper bool, v *math.Zr, sM *math.Zr, proofC *math.Zr, h *math.G1, curve *math.Curve, tr Translator) (*rangeTranscript, error) {
+	if proof == nil || len(proof.GetBitCommitments()) != k || len(proof.GetProofC0()) != k ||
+		len(proof.GetProofZ0()) != k || len(proof.GetProofZ1()) != k || proof.GetProofSRho() == nil {
+		return nil, errors.Errorf("malformed range proof")
+	}
+
+	rt := &rangeTranscript{
+		C:  make([]*math.G1, k),
+		A0: make([]*math.G1, k),
+		A1: make([]*math.G1, k),
+	}
+	two := curve.NewZrFromInt(2)
+	var sum *math.G1
+	for i := k - 1; i >= 0; i-- {
+		C, err := tr.G1FromProto(proof.BitCommitments[i])
+		if err != nil {
+			return nil, err
+		}
+		rt.C[i] = C
+
+		c0 := curve.NewZrFromBytes(proof.ProofC0[i])
+		c1 := curve.ModSub(proofC, c0, curve.GroupOrder)
+		rt.A0[i] = h.Mul2(curve.NewZrFromBytes(proof.ProofZ0[i]), bitBase(C, 0, curve), curve.ModNeg(c0, curve.GroupOrder))
+		rt.A1[i] = h.Mul2(curve.NewZrFromBytes(proof.ProofZ1[i]), bitBase(C, 1, curve), curve.ModNeg(c1, curve.GroupOrder))
+
+		// sum = \prod_i C_i^{2^i}, by Horner's rule
+		if sum == nil {
+			sum = C.Copy()
+		} else {
+			sum = sum.Mul(two)
+			sum.Add(C)
+		}
+	}
+
+	// D = sum \cdot g_1^v for a lower bound v, D = g_1^v / sum for an upper bound v
+	D := curve.GenG1.Mul(v)
+	if upper {
+		D.Sub(sum)
+	} else {
+		D.Add(sum)
+	}
+
+	// TLink = g_1^{s_m} \cdot h^{s_rho} \cdot D^{-C}
+	rt.TLink = curve.GenG1.Mul2(sM, h, curve.NewZrFromBytes(proof.GetProofSRho()))
+	rt.TLink.Sub(D.Mul(proofC))
+	return rt, nil
+}
+
+// validityChallenge computes the Fiat-Shamir challenge of the validity proof
+func validityChallenge(sigmaOnep, T1 *math.G1, now int64, notBefore, notAfter *rangeTranscript, curve *math.Curve) *math.Zr {
+	k := validityRangeBits
+	proofData := make([]byte, len([]byte(validityLabel))+2*curve.G1ByteSize+8+2*(3*k+1)*curve.G1ByteSize)
+	index := appendBytesString(proofData, 0, validityLabel)
+	index = appendBytesG1(proofData, index, sigmaOnep)
+	index = appendBytesG1(proofData, index, T1)
+	binary.BigEndian.PutUint64(proofData[index:], uint64(now))
+	index += 8
+	for _, rt := range []*rangeTranscript{notBefore, notAfter} {
+		for i := 0; i < k; i++ {
+			index = appendBytesG1(proofData, index, rt.C[i])
+			index = appendBytesG1(proofData, index, rt.A0[i])
+			index = appendBytesG1(proofData, index, rt.A1[i])
+		}
+		index = appendBytesG1(proofData, index, rt.TLink)
+	}
+	return curve.HashToZr(proofData)
+}
+
+// NewDeriveCredentialWithValidity derives a credential like NewDeriveCredential, with the validity attributes hidden,
+// and adds a proof that the credential is valid at time now, without revealing the validity window
+func (i *Psidentity) NewDeriveCredentialWithValidity(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, now time.Time, rng io.Reader, tr Translator) (*DeriveCredential, error) {
+	return newDeriveCredentialWithValidity(Attrs, key, m, Mask, now, rng, tr, i.Curve)
+}
+
+func newDeriveCredentialWithValidity(Attrs []string, key *IssuerKeyPS, m *PrimaryCredential, Mask []int, now time.Time, rng io.Reader, tr Translator, curve *math.Curve) (*DeriveCredential, error) {
+	cred, _, err := deriveCredentialWithValidity(Attrs, decodeIssuerKey(key.Ipk, curve, tr), m, Mask, now, rng)
+	return cred, err
+}
+
+// deriveCredentialAt derives the credential like deriveCredential. If the credentials of the issuer key have a
+// validity window and the mask hides it, the derived credential proves that it is valid at time now.
+func deriveCredentialAt(Attrs []string, pk *PreparedIssuerKey, m *PrimaryCredential, Mask []int, now time.Time, rng io.Reader) (*DeriveCredential, *math.Zr, error) {
+	nbIndex, naIndex, err := validityIndices(pk.Ipk)
+	if err == nil && len(Mask) > naIndex && Mask[nbIndex] == 0 && Mask[naIndex] == 0 {
+		return deriveCredentialWithValidity(Attrs, pk, m, Mask, now, rng)
+	}
+	return deriveCredential(Attrs, pk, m, Mask, rng)
+}
+
+// deriveCredentialWithValidity derives the credential with the validity proof and also returns the randomness t
+// of sigma_onep, see deriveCredential
+func deriveCredentialWithValidity(Attrs []string, pk *PreparedIssuerKey, m *PrimaryCredential, Mask []int, now time.Time, rng io.Reader) (*DeriveCredential, *math.Zr, error) {
+	curve, tr := pk.curve, pk.tr
+	nbIndex, naIndex, err := validityIndices(pk.Ipk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(Attrs) != naIndex+1 {
+		return nil, nil, errors.Errorf("the issuer key has %d attributes, got %d", naIndex+1, len(Attrs))
+	}
+	if len(Mask) <= naIndex || Mask[nbIndex] != 0 || Mask[naIndex] != 0 {
+		return nil, nil, errors.Errorf("the validity attributes must not be disclosed")
+	}
+	notBefore, notAfter, err := Validity(Attrs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkValidity(notBefore, notAfter, now); err != nil {
+		return nil, nil, err
+	}
+
+	cred, t, err := deriveCredential(Attrs, pk, m, Mask, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	HideIndices := hideIndices(Mask)
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return nil, nil, err
+	}
+	sc, err := newSigmaOnepCommitment(pk.Ipk, HideIndices, rng, curve, tr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := validityGenerator(curve)
+	today := big.NewInt(validityDay(now))
+
+	// not-before <= today and today <= not-after, the randomness of the validity attributes is shared with sc
+	nb, err := newRangeCommitment(new(big.Int).Sub(today, attributeValue(pk.Ipk, nbIndex, Attrs[nbIndex])), validityRangeBits, true,
+		sc.rAttrs[hiddenPosition(HideIndices, nbIndex)], h, rng, curve)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "not-before")
+	}
+	na, err := newRangeCommitment(new(big.Int).Sub(attributeValue(pk.Ipk, naIndex, Attrs[naIndex]), today), validityRangeBits, false,
+		sc.rAttrs[hiddenPosition(HideIndices, naIndex)], h, rng, curve)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "not-after")
+	}
+
+	proofC := validityChallenge(sigmaOnep, sc.T1, now.Unix(), &nb.rangeTranscript, &na.rangeTranscript, curve)
+
+	proof := &ValidityProof{
+		Time:      now.Unix(),
+		ProofC:    proofC.Bytes(),
+		NotBefore: nb.respond(proofC, curve, tr),
+		NotAfter:  na.respond(proofC, curve, tr),
+	}
+	proof.ProofST, proof.ProofSAttrs = sc.respond(proofC, t, pk.Ipk, Attrs, HideIndices, curve)
+
+	cred.ValidityProof = proof
+	return cred, t, nil
+}
+
+// VerifyValidity verifies that the derived credential is valid at time now, the current time of the verifier.
+// If the validity attributes are disclosed they are checked directly, otherwise the validity proof is verified.
+// The proof may have been made up to ValidityClockSkew before or after now.
+func (cred *DeriveCredential) VerifyValidity(ipk *IssuerPublicKeyPS, now time.Time, curve *math.Curve, tr Translator) error {
+	nbIndex, naIndex, err := validityIndices(ipk)
+	if err != nil {
+		return err
+	}
+	if len(cred.GetDiscloseMsg()) != naIndex+1 {
+		return errors.Errorf("the issuer key has %d attributes, the credential %d", naIndex+1, len(cred.GetDiscloseMsg()))
+	}
+
+	HideIndices := cred.hiddenIndices()
+	nbPosition, naPosition := hiddenPosition(HideIndices, nbIndex), hiddenPosition(HideIndices, naIndex)
+	if nbPosition < 0 && naPosition < 0 {
+		notBefore, notAfter, err := Validity(cred.GetDiscloseMsg())
+		if err != nil {
+			return err
+		}
+		return checkValidity(notBefore, notAfter, now)
+	}
+	if nbPosition < 0 || naPosition < 0 {
+		return errors.Errorf("the validity attributes must both be disclosed or both be hidden")
+	}
+
+	proof := cred.GetValidityProof()
+	if proof == nil {
+		return errors.Errorf("credential has no validity proof")
+	}
+	if proof.GetProofC() == nil {
+		return errors.Errorf("one of the proof values is undefined")
+	}
+	proofTime := time.Unix(proof.GetTime(), 0)
+	if proofTime.Before(now.Add(-ValidityClockSkew)) || proofTime.After(now.Add(ValidityClockSkew)) {
+		return errors.Errorf("validity proof is for %s, too far from %s", proofTime.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))
+	}
+
+	sigmaOnep, err := tr.G1FromProto(cred.GetSigmaOnep())
+	if err != nil {
+		return err
+	}
+	proofC := curve.NewZrFromBytes(proof.GetProofC())
+	T1, err := recomputeSigmaOnepT(ipk, HideIndices, sigmaOnep, proofC, proof.GetProofST(), proof.GetProofSAttrs(), curve, tr)
+	if err != nil {
+		return errors.Wrap(err, "validity proof")
+	}
+
+	h := validityGenerator(curve)
+	v := curve.NewZrFromInt(validityDay(proofTime))
+	nb, err := recomputeRangeTranscript(proof.GetNotBefore(), validityRangeBits, true, v, curve.NewZrFromBytes(proof.ProofSAttrs[nbPosition]), proofC, h, curve, tr)
+	if err != nil {
+		return errors.Wrap(err, "not-before")
+	}
+	na, err := recomputeRangeTranscript(proof.GetNotAfter(), validityRangeBits, false, v, curve.NewZrFromBytes(proof.ProofSAttrs[naPosition]), proofC, h, curve, tr)
+	if err != nil {
+		return errors.Wrap(err, "not-after")
+	}
+
+	if !proofC.Equals(validityChallenge(sigmaOnep, T1, proof.GetTime(), nb, na, curve)) {
+		return errors.Errorf("validity proof is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package psidentity
+
+import (
+	"testing"
+	"time"
+
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+	amcl "psidentity/translator/amcl"
+)
+
+func TestTimeAttribute(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	attr := EncodeTimeAttribute(now)
+	require.Equal(t, "2023-11-14", attr)
+	decoded, err := DecodeTimeAttribute(attr)
+	require.NoError(t, err)
+	require.True(t, time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC).Equal(decoded))
+	require.Equal(t, "1970-01-01", EncodeTimeAttribute(time.Unix(-1, 0)))
+
+	// validity attributes are signed as their day number, other attributes as the integer of their bytes
+	ipk := &IssuerPublicKeyPS{Y: make([]*amcl.ECP, 3), ValidityWindow: true}
+	require.Equal(t, int64(19675), attributeValue(ipk, 2, attr).Int64())
+	require.Equal(t, int64(19676), attributeValue(ipk, 2, EncodeTimeAttribute(now.Add(24*time.Hour))).Int64())
+	require.Equal(t, int64(0x32), attributeValue(ipk, 0, "2").Int64())
+	require.Equal(t, validityRangeBits, rangeBits(ipk, 1))
+	require.Equal(t, attributeRangeBits, rangeBits(ipk, 0))
+
+	_, err = DecodeTimeAttribute("001700000000")
+	require.Error(t, err)
+	_, err = DecodeTimeAttribute("2023-11-1x")
+	require.Error(t, err)
+	_, err = DecodeTimeAttribute("1969-12-31")
+	require.Error(t, err)
+}
+
+func TestValidityProof(t *testing.T) {
+	curve := math.Curves[math.FP256BN_AMCL]
+	tr := &amcl.Fp256bn{C: curve}
+	psid := &Psidentity{Curve: curve, Translator: tr}
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+
+	now := time.Now()
+	attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+		now.Add(-time.Hour), now.Add(24*time.Hour), rng)
+	mask := []int{1, 0, 1, 0, 0, 0}
+
+	// the window covers whole UTC days
+	notBefore, notAfter, err := primary.Validity()
+	require.NoError(t, err)
+	require.Equal(t, EncodeTimeAttribute(now.Add(24*time.Hour)), EncodeTimeAttribute(notAfter))
+	require.Equal(t, EncodeTimeAttribute(now.Add(-time.Hour)), EncodeTimeAttribute(notBefore))
+	require.Equal(t, notBefore.Truncate(24*time.Hour), notBefore)
+	require.Equal(t, notAfter.Add(time.Second).Truncate(24*time.Hour), notAfter.Add(time.Second))
+
+	cred, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now, rng, tr)
+	require.NoError(t, err)
+	require.Empty(t, cred.DiscloseMsg[len(attrs)-1])
+	require.Len(t, cred.ValidityProof.NotBefore.BitCommitments, validityRangeBits)
+	require.Len(t, cred.ValidityProof.NotAfter.BitCommitments, validityRangeBits)
+	require.NoError(t, cred.VerifyValidity(key.Ipk, now, curve, tr))
+	require.NoError(t, cred.VerifyValidity(key.Ipk, now.Add(ValidityClockSkew/2), curve, tr))
+
+	// the proof is bound to its time
+	require.Error(t, cred.VerifyValidity(key.Ipk, now.Add(time.Hour), curve, tr))
+	cred.ValidityProof.Time += 60
+	require.Error(t, cred.VerifyValidity(key.Ipk, now, curve, tr))
+
+	// an expired or not yet valid credential cannot prove validity
+	_, err = psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now.Add(72*time.Hour), rng, tr)
+	require.Error(t, err)
+	_, err = psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now.Add(-48*time.Hour), rng, tr)
+	require.Error(t, err)
+
+	// disclosed validity attributes are checked directly
+	disclosed, err := psid.NewDeriveCredential(attrs, key, primary, []int{1, 0, 1, 0, 1, 1}, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, disclosed.VerifyValidity(key.Ipk, now, curve, tr))
+	require.Error(t, disclosed.VerifyValidity(key.Ipk, now.Add(72*time.Hour), curve, tr))
+}
+
+func TestValidityWindowLayout(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	curve, tr := psid.Curve, psid.Translator
+	rng, err := curve.Rand()
+	require.NoError(t, err)
+	now := time.Now()
+
+	_, err = AppendValidity(nil, now, now.Add(time.Hour))
+	require.Error(t, err)
+	_, err = AppendValidity([]string{psidentity.UserAttributeNumber}, now, now.Add(-48*time.Hour))
+	require.Error(t, err)
+	_, err = psid.NewIssuerKeyPSWithValidity(2, rng, tr)
+	require.Error(t, err)
+
+	// the window is at the end of the attributes, whatever their number
+	attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer},
+		now.Add(-time.Hour), now.Add(time.Hour), rng)
+	require.Len(t, attrs, 4)
+	require.True(t, key.Ipk.ValidityWindow)
+	notBefore, notAfter, err := primary.Validity()
+	require.NoError(t, err)
+	require.Equal(t, EncodeTimeAttribute(now.Add(-time.Hour)), EncodeTimeAttribute(notBefore))
+	require.Equal(t, EncodeTimeAttribute(now.Add(time.Hour)), EncodeTimeAttribute(notAfter))
+	mask := []int{1, 0, 0, 0}
+	cred, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, mask, now, rng, tr)
+	require.NoError(t, err)
+	require.NoError(t, cred.VerifyValidity(key.Ipk, now, curve, tr))
+	_, err = psid.NewDeriveCredentialWithValidity(attrs, key, primary, []int{1, 0, 1, 0}, now, rng, tr)
+	require.Error(t, err)
+
+	// the issuer assigns the window: it refuses requests committing to the validity attributes, and the holder
+	// can not change the window it signed
+	window := &ValidityWindow{NotBefore: now, NotAfter: now.Add(time.Hour)}
+	req, _, err := psid.NewCredRequestPS(attrs, key.Ipk, rng, tr)
+	require.NoError(t, err)
+	_, err = psid.NewBlindCredentialWithValidity(key, req, window, rng, tr)
+	require.Error(t, err)
+	req, d, err := psid.NewCredRequestPS(attrs[:2], key.Ipk, rng, tr)
+	require.NoError(t, err)
+	_, err = psid.NewBlindCredential(key, req, rng, tr)
+	require.Error(t, err)
+	_, err = psid.NewBlindCredentialWithValidity(key, req, &ValidityWindow{NotBefore: now, NotAfter: now.Add(-48 * time.Hour)}, rng, tr)
+	require.Error(t, err)
+	blind, err := psid.NewBlindCredentialWithValidity(key, req, window, rng, tr)
+	require.NoError(t, err)
+	require.Equal(t, EncodeTimeAttribute(now), blind.NotBefore)
+	blind.NotAfter = EncodeTimeAttribute(now.Add(1000 * time.Hour))
+	extended, err := psid.NewPrimaryCredential(attrs[:2], d, key, blind, rng, tr)
+	require.NoError(t, err)
+	require.Error(t, extended.VerifyPrimary(key.Ipk, curve, tr))
+	_, err = psid.NewPrimaryCredential(attrs, d, key, blind, rng, tr)
+	require.Error(t, err)
+
+	// the disclosed attributes must match the attributes of the issuer key
+	truncated := proto.Clone(cred).(*DeriveCredential)
+	truncated.DiscloseMsg = truncated.DiscloseMsg[:len(truncated.DiscloseMsg)-1]
+	require.Error(t, truncated.VerifyValidity(key.Ipk, now, curve, tr))
+
+	// the flag is covered by the hash of the issuer key
+	require.NoError(t, checkIssuerKeyHash(key.Ipk, psid))
+	unflagged := proto.Clone(key.Ipk).(*IssuerPublicKeyPS)
+	unflagged.ValidityWindow = false
+	require.Error(t, checkIssuerKeyHash(unflagged, psid))
+
+	// the credentials of a key without the flag have no validity window, even with enough attributes
+	plain, plainPrimary := newTestCredential(t, psid, attrs, rng)
+	require.False(t, plain.Ipk.ValidityWindow)
+	req, _, err = psid.NewCredRequestPS(attrs, plain.Ipk, rng, tr)
+	require.NoError(t, err)
+	_, err = psid.NewBlindCredentialWithValidity(plain, req, window, rng, tr)
+	require.Error(t, err)
+	_, err = psid.NewDeriveCredentialWithValidity(attrs, plain, plainPrimary, mask, now, rng, tr)
+	require.Error(t, err)
+	require.Error(t, cred.VerifyValidity(plain.Ipk, now, curve, tr))
+	vc, err := psid.PrimaryCredentialToVC(plainPrimary, plain.Ipk)
+	require.NoError(t, err)
+	require.Empty(t, vc.ExpirationDate)
+	require.NoError(t, psid.VerifyVC(vc, plain.Ipk, now.Add(48*time.Hour)))
+}
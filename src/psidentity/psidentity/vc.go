@@ -0,0 +1,415 @@
+package psidentity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+)
+
+const (
+	// VCContext is the JSON-LD context of the W3C Verifiable Credentials Data Model
+	VCContext = "https://www.w3.org/2018/credentials/v1"
+	// PSIdentityVCContext is the JSON-LD context of the PS proof suites and credential types
+	PSIdentityVCContext = "urn:psidentity:vc:v1"
+
+	// PSSignatureType is the proof type of a primary credential, the PS signature on all attributes
+	PSSignatureType = "PSSignature2022"
+	// PSSignatureProofType is the proof type of a derived credential, a randomized PS signature on the disclosed attributes
+	PSSignatureProofType = "PSSignatureProof2022"
+	// PSAggregateProofType is the proof type of a presentation, the user's aggregate signature on the derived credentials
+	PSAggregateProofType = "PSAggregateProof2022"
+
+	// PSIdentityCredentialType is the type of the credentials exported from PS credentials
+	PSIdentityCredentialType = "PSIdentityCredential"
+
+	// vcIssuerPrefix prefixes the hex issuer key hash in the issuer of a credential
+	vcIssuerPrefix = "urn:psidentity:issuer:"
+)
+
+// VerifiableCredential is a W3C verifiable credential, credentialSubject holds the disclosed attributes by name
+type VerifiableCredential struct {
+	Context           []string          `json:"@context"`
+	Type              []string          `json:"type"`
+	Issuer            string            `json:"issuer"`
+	IssuanceDate      string            `json:"issuanceDate"`
+	ExpirationDate    string            `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]string `json:"credentialSubject"`
+	Proof             *VCProof          `json:"proof"`
+}
+
+// VerifiablePresentation is a W3C verifiable presentation of derived credentials, aggregated by the user
+type VerifiablePresentation struct {
+	Context              []string                `json:"@context"`
+	Type                 []string                `json:"type"`
+	VerifiableCredential []*VerifiableCredential `json:"verifiableCredential"`
+	Proof                *VCProof                `json:"proof"`
+}
+
+// VCProof is a proof of one of the PS proof suites. ProofValue is the base64 serialized credential
+// without its attribute values, which are in the credential subject.
+// AttributeCount is the number of attributes signed by the issuer, of which the derived credentials disclose some.
+type VCProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	ProofPurpose       string `json:"proofPurpose"`
+	VerificationMethod string `json:"verificationMethod"`
+	Curve              string `json:"curve"`
+	AttributeCount     int    `json:"attributeCount,omitempty"`
+	ProofValue         string `json:"proofValue"`
+}
+
+// VCIssuer returns the issuer of the credentials of an issuer public key
+func VCIssuer(ipk *IssuerPublicKeyPS) string {
+	return vcIssuerPrefix + hex.EncodeToString(ipk.GetHash())
+}
+
+// attributeName returns the name of the attribute at index j
+func attributeName(j int) string {
+	if j < len(psidentity.IssuerAttributeNames) {
+		return psidentity.IssuerAttributeNames[j]
+	}
+	return fmt.Sprintf("Attribute%d", j)
+}
+
+// attributeIndex returns the index of the attribute name, or -1
+func attributeIndex(name string, count int) int {
+	for j := 0; j < count; j++ {
+		if attributeName(j) == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// PrimaryCredentialToVC exports a primary credential of the issuer public key as a verifiable credential
+func (i *Psidentity) PrimaryCredentialToVC(cred *PrimaryCredential, ipk *IssuerPublicKeyPS) (*VerifiableCredential, error) {
+	return primaryCredentialToVC(cred, ipk, CurveName(i.Curve), time.Now())
+}
+
+func primaryCredentialToVC(cred *PrimaryCredential, ipk *IssuerPublicKeyPS, curveID string, now time.Time) (*VerifiableCredential, error) {
+	subject := map[string]string{}
+	for j, attr := range cred.GetAttrs() {
+		subject[attributeName(j)] = attr
+	}
+
+	signature := proto.Clone(cred).(*PrimaryCredential)
+	signature.Attrs = nil
+	vc, err := newVC(ipk, subject, PSSignatureType, curveID, len(cred.GetAttrs()), signature, now)
+	if err != nil {
+		return nil, err
+	}
+	if !hasValidity(ipk) {
+		return vc, nil
+	}
+	if notBefore, notAfter, err := cred.Validity(); err == nil {
+		vc.IssuanceDate = notBefore.UTC().Format(time.RFC3339)
+		vc.ExpirationDate = notAfter.UTC().Format(time.RFC3339)
+	}
+	return vc, nil
+}
+
+// DeriveCredentialToVC exports a derived credential of the issuer public key as a verifiable credential
+// with the disclosed attributes
+func (i *Psidentity) DeriveCredentialToVC(cred *DeriveCredential, ipk *IssuerPublicKeyPS) (*VerifiableCredential, error) {
+	return deriveCredentialToVC(cred, ipk, CurveName(i.Curve), time.Now())
+}
+
+func deriveCredentialToVC(cred *DeriveCredential, ipk *IssuerPublicKeyPS, curveID string, now time.Time) (*VerifiableCredential, error) {
+	subject := map[string]string{}
+	for _, j := range cred.GetDiscloseIndices() {
+		if int(j) >= len(cred.GetDiscloseMsg()) {
+			return nil, errors.Errorf("disclosed attribute %d out of range", j)
+		}
+		subject[attributeName(int(j))] = cred.GetDiscloseMsg()[j]
+	}
+
+	signature := proto.Clone(cred).(*DeriveCredential)
+	signature.DiscloseMsg = nil
+	vc, err := newVC(ipk, subject, PSSignatureProofType, curveID, len(cred.GetDiscloseMsg()), signature, now)
+	if err != nil {
+		return nil, err
+	}
+	if cred.GetValidityProof() != nil {
+		vc.IssuanceDate = time.Unix(cred.GetValidityProof().GetTime(), 0).UTC().Format(time.RFC3339)
+	}
+	if !hasValidity(ipk) {
+		return vc, nil
+	}
+	if notBefore, notAfter, err := Validity(cred.GetDiscloseMsg()); err == nil {
+		vc.IssuanceDate = notBefore.UTC().Format(time.RFC3339)
+		vc.ExpirationDate = notAfter.UTC().Format(time.RFC3339)
+	}
+	return vc, nil
+}
+
+func newVC(ipk *IssuerPublicKeyPS, subject map[string]string, proofType, curveID string, attributeCount int, signature proto.Message, now time.Time) (*VerifiableCredential, error) {
+	proofValue, err := proto.Marshal(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal credential signature")
+	}
+	created := now.UTC().Format(time.RFC3339)
+	return &VerifiableCredential{
+		Context:           []string{VCContext, PSIdentityVCContext},
+		Type:              []string{"VerifiableCredential", PSIdentityCredentialType},
+		Issuer:            VCIssuer(ipk),
+		IssuanceDate:      created,
+		CredentialSubject: subject,
+		Proof: &VCProof{
+			Type:               proofType,
+			Created:            created,
+			ProofPurpose:       "assertionMethod",
+			VerificationMethod: VCIssuer(ipk) + "#key",
+			Curve:              curveID,
+			AttributeCount:     attributeCount,
+			ProofValue:         base64.StdEncoding.EncodeToString(proofValue),
+		},
+	}, nil
+}
+
+// AggregateCredentialToVP exports an aggregate credential of derived credentials of the issuer public key
+// as a verifiable presentation
+func (i *Psidentity) AggregateCredentialToVP(cred *AggregateCredential, ipk *IssuerPublicKeyPS) (*VerifiablePresentation, error) {
+	return aggregateCredentialToVP(cred, ipk, CurveName(i.Curve), time.Now())
+}
+
+func aggregateCredentialToVP(cred *AggregateCredential, ipk *IssuerPublicKeyPS, curveID string, now time.Time) (*VerifiablePresentation, error) {
+	vp := &VerifiablePresentation{
+		Context: []string{VCContext, PSIdentityVCContext},
+		Type:    []string{"VerifiablePresentation"},
+	}
+	for _, m := range cred.GetMessages() {
+		vc, err := deriveCredentialToVC(m, ipk, curveID, now)
+		if err != nil {
+			return nil, err
+		}
+		vp.VerifiableCredential = append(vp.VerifiableCredential, vc)
+	}
+
+	signature := proto.Clone(cred).(*AggregateCredential)
+	signature.Messages = nil
+	proofValue, err := proto.Marshal(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal aggregate signature")
+	}
+	vp.Proof = &VCProof{
+		Type:         PSAggregateProofType,
+		Created:      now.UTC().Format(time.RFC3339),
+		ProofPurpose: "authentication",
+		Curve:        curveID,
+		ProofValue:   base64.StdEncoding.EncodeToString(proofValue),
+	}
+	return vp, nil
+}
+
+// ParseVC parses a verifiable credential document
+func ParseVC(raw []byte) (*VerifiableCredential, error) {
+	vc := &VerifiableCredential{}
+	if err := json.Unmarshal(raw, vc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse verifiable credential")
+	}
+	if !containsString(vc.Type, "VerifiableCredential") {
+		return nil, errors.Errorf("document is not a verifiable credential")
+	}
+	if vc.Proof == nil {
+		return nil, errors.Errorf("verifiable credential has no proof")
+	}
+	return vc, nil
+}
+
+// ParseVP parses a verifiable presentation document
+func ParseVP(raw []byte) (*VerifiablePresentation, error) {
+	vp := &VerifiablePresentation{}
+	if err := json.Unmarshal(raw, vp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse verifiable presentation")
+	}
+	if !containsString(vp.Type, "VerifiablePresentation") {
+		return nil, errors.Errorf("document is not a verifiable presentation")
+	}
+	if vp.Proof == nil {
+		return nil, errors.Errorf("verifiable presentation has no proof")
+	}
+	return vp, nil
+}
+
+// IsVP reports whether a document is a verifiable presentation rather than a verifiable credential
+func IsVP(raw []byte) bool {
+	doc := struct {
+		Type []string `json:"type"`
+	}{}
+	return json.Unmarshal(raw, &doc) == nil && containsString(doc.Type, "VerifiablePresentation")
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// proofValue decodes the proof value into msg after checking the proof type
+func (p *VCProof) proofValue(proofType string, msg proto.Message) error {
+	if p.Type != proofType {
+		return errors.Errorf("proof is a %s, not a %s", p.Type, proofType)
+	}
+	raw, err := base64.StdEncoding.DecodeString(p.ProofValue)
+	if err != nil {
+		return errors.Wrap(err, "invalid proof value")
+	}
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal %s", proofType)
+	}
+	return nil
+}
+
+// PrimaryCredential imports the primary credential of a verifiable credential
+func (vc *VerifiableCredential) PrimaryCredential() (*PrimaryCredential, error) {
+	cred := &PrimaryCredential{}
+	if err := vc.Proof.proofValue(PSSignatureType, cred); err != nil {
+		return nil, err
+	}
+	if len(vc.CredentialSubject) != vc.Proof.AttributeCount {
+		return nil, errors.Errorf("credential subject has %d attributes, the signature is on %d", len(vc.CredentialSubject), vc.Proof.AttributeCount)
+	}
+	cred.Attrs = make([]string, vc.Proof.AttributeCount)
+	for j := range cred.Attrs {
+		attr, ok := vc.CredentialSubject[attributeName(j)]
+		if !ok {
+			return nil, errors.Errorf("credential subject has no attribute %s", attributeName(j))
+		}
+		cred.Attrs[j] = attr
+	}
+	return cred, nil
+}
+
+// DeriveCredential imports the derived credential of a verifiable credential
+func (vc *VerifiableCredential) DeriveCredential() (*DeriveCredential, error) {
+	cred := &DeriveCredential{}
+	if err := vc.Proof.proofValue(PSSignatureProofType, cred); err != nil {
+		return nil, err
+	}
+	if len(vc.CredentialSubject) != len(cred.GetDiscloseIndices()) {
+		return nil, errors.Errorf("credential subject has %d attributes, %d are disclosed", len(vc.CredentialSubject), len(cred.GetDiscloseIndices()))
+	}
+	cred.DiscloseMsg = make([]string, vc.Proof.AttributeCount)
+	for name, attr := range vc.CredentialSubject {
+		j := attributeIndex(name, vc.Proof.AttributeCount)
+		if j < 0 || !containsIndex(cred.GetDiscloseIndices(), j) {
+			return nil, errors.Errorf("attribute %s is not disclosed", name)
+		}
+		cred.DiscloseMsg[j] = attr
+	}
+	return cred, nil
+}
+
+func containsIndex(indices []int64, j int) bool {
+	for _, i := range indices {
+		if int(i) == j {
+			return true
+		}
+	}
+	return false
+}
+
+// AggregateCredential imports the aggregate credential of a verifiable presentation
+func (vp *VerifiablePresentation) AggregateCredential() (*AggregateCredential, error) {
+	cred := &AggregateCredential{}
+	if err := vp.Proof.proofValue(PSAggregateProofType, cred); err != nil {
+		return nil, err
+	}
+	for _, vc := range vp.VerifiableCredential {
+		m, err := vc.DeriveCredential()
+		if err != nil {
+			return nil, err
+		}
+		cred.Messages = append(cred.Messages, m)
+	}
+	return cred, nil
+}
+
+// VerifyVC verifies a verifiable credential against the issuer public key at time now. Derived credentials
+// must also prove their non-revocation if i has a NonRevocationPolicy.
+func (i *Psidentity) VerifyVC(vc *VerifiableCredential, ipk *IssuerPublicKeyPS, now time.Time) error {
+	if err := i.checkVCIssuer(vc, ipk); err != nil {
+		return err
+	}
+
+	switch vc.Proof.Type {
+	case PSSignatureType:
+		cred, err := vc.PrimaryCredential()
+		if err != nil {
+			return err
+		}
+		if err := cred.VerifyPrimary(ipk, i.Curve, i.Translator); err != nil {
+			return err
+		}
+		if !hasValidity(ipk) {
+			return nil
+		}
+		notBefore, notAfter, err := cred.Validity()
+		if err != nil {
+			return err
+		}
+		return checkValidity(notBefore, notAfter, now)
+
+	case PSSignatureProofType:
+		cred, err := vc.DeriveCredential()
+		if err != nil {
+			return err
+		}
+		if err := cred.VerifyDerive(ipk, i.Curve, i.Translator); err != nil {
+			return err
+		}
+		if err := i.verifyNonRevocation(cred, ipk); err != nil {
+			return err
+		}
+		if cred.GetValidityProof() != nil {
+			return cred.VerifyValidity(ipk, now, i.Curve, i.Translator)
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported proof type %s", vc.Proof.Type)
+}
+
+// VerifyVP verifies the credentials of a verifiable presentation against the issuer public key at time now,
+// and their aggregation against the user public key
+func (i *Psidentity) VerifyVP(vp *VerifiablePresentation, ipk *IssuerPublicKeyPS, upk *UserPublicKey, now time.Time) error {
+	if err := CheckCurveID(CurveName(i.Curve), vp.Proof.Curve); err != nil {
+		return err
+	}
+	for _, vc := range vp.VerifiableCredential {
+		if vc.Proof == nil || vc.Proof.Type != PSSignatureProofType {
+			return errors.Errorf("presented credentials must be derived credentials")
+		}
+		if err := i.VerifyVC(vc, ipk, now); err != nil {
+			return err
+		}
+	}
+	cred, err := vp.AggregateCredential()
+	if err != nil {
+		return err
+	}
+	return i.NewAggregateVerifier(i.Parallelism).VerifyAggregate(context.Background(), cred, upk)
+}
+
+// checkVCIssuer checks that the credential was issued with the issuer public key on the curve
+func (i *Psidentity) checkVCIssuer(vc *VerifiableCredential, ipk *IssuerPublicKeyPS) error {
+	if vc.Proof == nil {
+		return errors.Errorf("verifiable credential has no proof")
+	}
+	if vc.Issuer != VCIssuer(ipk) {
+		return errors.Errorf("credential issuer %s is not the issuer key %s", vc.Issuer, VCIssuer(ipk))
+	}
+	if err := CheckCurveID(CurveName(i.Curve), vc.Proof.Curve); err != nil {
+		return err
+	}
+	return i.CheckCurve(ipk.GetCurveId())
+}
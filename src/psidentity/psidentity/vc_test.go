@@ -0,0 +1,121 @@
+package psidentity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+func TestVerifiableCredentials(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	tr := psid.Translator
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+
+	now := time.Now()
+	attrs, key, primary := newValidityTestCredential(t, psid, []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel},
+		now.Add(-time.Hour), now.Add(time.Hour), rng)
+	key.Ipk.CurveId = CurveFP256BN_AMCL
+	uk, err := psid.NewUserKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	derived, err := psid.NewDeriveCredentialWithValidity(attrs, key, primary, []int{1, 0, 1, 0, 0, 0}, now, rng, tr)
+	require.NoError(t, err)
+	aggregate, err := psid.NewAggregateCredential(uk, key.Ipk, []*DeriveCredential{derived}, rng, tr)
+	require.NoError(t, err)
+
+	// the primary credential round-trips through its JSON document
+	vc, err := psid.PrimaryCredentialToVC(primary, key.Ipk)
+	require.NoError(t, err)
+	require.Equal(t, VCIssuer(key.Ipk), vc.Issuer)
+	require.Equal(t, psidentity.UserAttributeManufacturer, vc.CredentialSubject[psidentity.IssuerAttributeTwo])
+	require.NotEmpty(t, vc.ExpirationDate)
+	raw, err := json.Marshal(vc)
+	require.NoError(t, err)
+	require.False(t, IsVP(raw))
+	parsed, err := ParseVC(raw)
+	require.NoError(t, err)
+	imported, err := parsed.PrimaryCredential()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(primary, imported))
+	require.NoError(t, psid.VerifyVC(parsed, key.Ipk, now))
+
+	// the derived credential only carries the disclosed attributes
+	vc, err = psid.DeriveCredentialToVC(derived, key.Ipk)
+	require.NoError(t, err)
+	require.Len(t, vc.CredentialSubject, 2)
+	importedDerived, err := vc.DeriveCredential()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(derived, importedDerived))
+	require.NoError(t, psid.VerifyVC(vc, key.Ipk, now))
+	require.Error(t, psid.VerifyVC(vc, key.Ipk, now.Add(48*time.Hour)))
+
+	vc.CredentialSubject[psidentity.IssuerAttributeFour] = psidentity.UserAttributeLevel
+	_, err = vc.DeriveCredential()
+	require.Error(t, err)
+
+	// the presentation verifies against the issuer and user public keys
+	vp, err := psid.AggregateCredentialToVP(aggregate, key.Ipk)
+	require.NoError(t, err)
+	raw, err = json.Marshal(vp)
+	require.NoError(t, err)
+	require.True(t, IsVP(raw))
+	parsedVP, err := ParseVP(raw)
+	require.NoError(t, err)
+	importedAggregate, err := parsedVP.AggregateCredential()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(aggregate, importedAggregate))
+	require.NoError(t, psid.VerifyVP(parsedVP, key.Ipk, uk.Upk, now))
+
+	// tampered credential subjects do not verify
+	for name, tamper := range map[string]func(map[string]string){
+		"changed": func(s map[string]string) { s[psidentity.IssuerAttributeOne] = "number2" },
+		"swapped": func(s map[string]string) {
+			s[psidentity.IssuerAttributeOne], s[psidentity.IssuerAttributeThree] = s[psidentity.IssuerAttributeThree], s[psidentity.IssuerAttributeOne]
+		},
+		"moved": func(s map[string]string) {
+			s[psidentity.IssuerAttributeFour] = s[psidentity.IssuerAttributeThree]
+			delete(s, psidentity.IssuerAttributeThree)
+		},
+	} {
+		vc, err := psid.PrimaryCredentialToVC(primary, key.Ipk)
+		require.NoError(t, err)
+		tamper(vc.CredentialSubject)
+		require.Error(t, psid.VerifyVC(vc, key.Ipk, now), name)
+
+		if name == "moved" {
+			// only disclosed attributes can be moved in a derived credential
+			continue
+		}
+		vc, err = psid.DeriveCredentialToVC(derived, key.Ipk)
+		require.NoError(t, err)
+		tamper(vc.CredentialSubject)
+		require.Error(t, psid.VerifyVC(vc, key.Ipk, now), name)
+
+		vp, err := psid.AggregateCredentialToVP(aggregate, key.Ipk)
+		require.NoError(t, err)
+		tamper(vp.VerifiableCredential[0].CredentialSubject)
+		require.Error(t, psid.VerifyVP(vp, key.Ipk, uk.Upk, now), name)
+	}
+
+	// a primary credential whose validity window does not decode is refused
+	invalidPrimary := proto.Clone(primary).(*PrimaryCredential)
+	invalidPrimary.Attrs[len(attrs)-1] = "forever"
+	vc, err = psid.PrimaryCredentialToVC(invalidPrimary, key.Ipk)
+	require.NoError(t, err)
+	require.Error(t, psid.VerifyVC(vc, key.Ipk, now))
+
+	// credentials of another issuer key or curve are refused
+	other, err := psid.NewIssuerKeyPS(len(attrs), rng, tr)
+	require.NoError(t, err)
+	require.Error(t, psid.VerifyVC(parsed, other.Ipk, now))
+	bls, err := NewPsidentityForCurve(CurveBLS12_381)
+	require.NoError(t, err)
+	require.Error(t, bls.VerifyVC(parsed, key.Ipk, now))
+	_, err = ParseVC([]byte(`{"type":["VerifiablePresentation"]}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,491 @@
+package psidentity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	psidentity "psidentity"
+	user "psidentity/user"
+)
+
+// walletIDBytes is the length of the prefix of the hash of a primary cred that forms its wallet ID
+const walletIDBytes = 16
+
+// DefaultSchema is the schema of the creds imported without one, the attribute names of the issuer key
+const DefaultSchema = "device"
+
+// ErrWalletCredNotFound is returned by a Wallet for a cred it does not hold
+var ErrWalletCredNotFound = errors.New("credential not in the wallet")
+
+// CredentialSchema names a kind of credential and its attributes, by attribute index
+type CredentialSchema struct {
+	Name       string   `json:"name" yaml:"name"`
+	Attributes []string `json:"attributes" yaml:"attributes"`
+}
+
+// index returns the index of the attribute name in the schema, -1 if it has none
+func (s *CredentialSchema) index(name string) int {
+	for j, a := range s.Attributes {
+		if a == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// Mask returns the disclosure mask of a cred of the schema disclosing the named attributes
+func (s *CredentialSchema) Mask(attributes []string) ([]int, error) {
+	mask := make([]int, len(s.Attributes))
+	for _, name := range attributes {
+		j := s.index(name)
+		if j < 0 {
+			return nil, errors.Errorf("schema %s has no attribute %s", s.Name, name)
+		}
+		mask[j] = 1
+	}
+	return mask, nil
+}
+
+// defaultSchema returns the DefaultSchema of a cred with n attributes
+func defaultSchema(n int) (*CredentialSchema, error) {
+	if n > len(psidentity.IssuerAttributeNames) {
+		return nil, errors.Errorf("the %s schema has %d attributes, the credential has %d", DefaultSchema, len(psidentity.IssuerAttributeNames), n)
+	}
+	return &CredentialSchema{Name: DefaultSchema, Attributes: psidentity.IssuerAttributeNames[:n]}, nil
+}
+
+// validate checks that the schema has a name and distinct attribute names
+func (s *CredentialSchema) validate() error {
+	if s.Name == "" || len(s.Attributes) == 0 {
+		return errors.Errorf("schema %q has no name or no attributes", s.Name)
+	}
+	seen := map[string]bool{}
+	for _, a := range s.Attributes {
+		if a == "" || seen[a] {
+			return errors.Errorf("schema %s has an empty or repeated attribute name %q", s.Name, a)
+		}
+		seen[a] = true
+	}
+	return nil
+}
+
+// hasValidity reports whether the schema ends with the attributes of a validity window
+func (s *CredentialSchema) hasValidity() bool {
+	n := len(s.Attributes)
+	return n >= 2 && s.Attributes[n-2] == psidentity.IssuerAttributeNotBefore && s.Attributes[n-1] == psidentity.IssuerAttributeNotAfter
+}
+
+// equal reports whether the schemas have the same name and attributes
+func (s *CredentialSchema) equal(o *CredentialSchema) bool {
+	if s.Name != o.Name || len(s.Attributes) != len(o.Attributes) {
+		return false
+	}
+	for j := range s.Attributes {
+		if s.Attributes[j] != o.Attributes[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// IssuerSchema returns the schema of the creds of n attributes of the issuer key: the schema recorded in the key,
+// which its hash covers, or the DefaultSchema for a key without one
+func IssuerSchema(ipk *IssuerPublicKeyPS, n int) (*CredentialSchema, error) {
+	if ipk.GetSchema() == "" {
+		return defaultSchema(n)
+	}
+	if n != len(ipk.GetAttributeNames()) {
+		return nil, errors.Errorf("the %s schema of the issuer key has %d attributes, the credential has %d", ipk.GetSchema(), len(ipk.GetAttributeNames()), n)
+	}
+	return &CredentialSchema{Name: ipk.GetSchema(), Attributes: append([]string(nil), ipk.GetAttributeNames()...)}, nil
+}
+
+// WalletEntry describes a primary cred in a Wallet. NotBefore and NotAfter are zero for a cred without a validity window.
+type WalletEntry struct {
+	ID            string    `json:"id"`
+	IssuerKeyID   string    `json:"issuer_key_id"`
+	IssuerKeyHash string    `json:"issuer_key_hash"`
+	Schema        string    `json:"schema"`
+	Attributes    []string  `json:"attributes"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	Imported      time.Time `json:"imported"`
+}
+
+// ValidAt reports whether the cred is inside its validity window at time t
+func (e *WalletEntry) ValidAt(t time.Time) bool {
+	if e.NotAfter.IsZero() {
+		return true
+	}
+	return !t.Before(e.NotBefore) && t.Before(e.NotAfter)
+}
+
+// Mask returns the disclosure mask of the cred disclosing the named attributes
+func (e *WalletEntry) Mask(attributes []string) ([]int, error) {
+	return (&CredentialSchema{Name: e.Schema, Attributes: e.Attributes}).Mask(attributes)
+}
+
+// WalletQuery selects the creds listed by a Wallet, the empty fields match every cred
+type WalletQuery struct {
+	IssuerKeyID string
+	Schema      string
+	// ValidAt only matches the creds inside their validity window at the time
+	ValidAt time.Time
+}
+
+func (q *WalletQuery) matches(e *WalletEntry) bool {
+	return (q.IssuerKeyID == "" || q.IssuerKeyID == e.IssuerKeyID) &&
+		(q.Schema == "" || q.Schema == e.Schema) &&
+		(q.ValidAt.IsZero() || e.ValidAt(q.ValidAt))
+}
+
+// WalletBundle is a cred exported from a Wallet with the issuer public key that signed it, both artifacts
+// in the envelope format
+type WalletBundle struct {
+	Entry           *WalletEntry `json:"entry"`
+	PrimaryCred     []byte       `json:"primary_cred"`
+	IssuerPublicKey []byte       `json:"issuer_public_key"`
+}
+
+// WalletSelection is a cred picked by Select and the requested attributes it discloses
+type WalletSelection struct {
+	Entry      *WalletEntry
+	Attributes []string
+}
+
+// Mask returns the disclosure mask of the selected cred
+func (s *WalletSelection) Mask() ([]int, error) {
+	return s.Entry.Mask(s.Attributes)
+}
+
+// Wallet stores the primary creds of a device, from any number of issuers, in the directory
+// <Dir>/<id> of each cred: the PrimaryCred and IssuerPublicKey artifacts and the WalletEntry as JSON.
+type Wallet struct {
+	Dir string
+	// CRIs are the revocation information of the current epoch by issuer key ID. The creds of an issuer key
+	// with a CRI are presented with the proof that their revocation handle at RevocationHandleIndex is not revoked.
+	CRIs                  map[string]*CredentialRevocationInformation
+	RevocationHandleIndex int
+
+	psid *Psidentity
+}
+
+// NewWallet returns the Wallet in dir for creds on the curve of psid
+func NewWallet(dir string, psid *Psidentity) *Wallet {
+	return &Wallet{Dir: dir, RevocationHandleIndex: psidentity.AttributeIndexRevocationHandle, psid: psid}
+}
+
+func (w *Wallet) path(id, file string) (string, error) {
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 2*walletIDBytes {
+		return "", errors.Errorf("invalid wallet ID %s", id)
+	}
+	return filepath.Join(w.Dir, id, file), nil
+}
+
+// Import verifies a PrimaryCred artifact with the issuer public key that signed it and stores both.
+// The attributes are named by the IssuerSchema of the key, schema is nil or must be that schema.
+func (w *Wallet) Import(primaryCred []byte, ipk *IssuerPublicKeyPS, schema *CredentialSchema) (*WalletEntry, error) {
+	if err := w.psid.CheckCurve(ipk.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "issuer key")
+	}
+	if err := checkIssuerKeyHash(ipk, w.psid); err != nil {
+		return nil, err
+	}
+	env, err := w.psid.UnwrapArtifact(primaryCred, psidentity.PsIdentityConfigPrimaryCred, ipk.GetHash())
+	if err != nil {
+		return nil, err
+	}
+	conf := &user.UserPrimaryCred{}
+	if err := proto.Unmarshal(env.GetPayload(), conf); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal user primary cred")
+	}
+	if err := w.psid.CheckCurve(conf.GetCurveId()); err != nil {
+		return nil, errors.WithMessage(err, "user primary cred")
+	}
+	cred := &PrimaryCredential{}
+	if err := proto.Unmarshal(conf.GetPrimaryCred(), cred); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal primary cred")
+	}
+	if err := checkIssuerKeyID(cred.GetIssuerKeyId(), ipk); err != nil {
+		return nil, err
+	}
+	if err := cred.VerifyPrimary(ipk, w.psid.Curve, w.psid.Translator); err != nil {
+		return nil, err
+	}
+
+	issuerSchema, err := IssuerSchema(ipk, len(cred.GetAttrs()))
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		schema = issuerSchema
+	}
+	if !schema.equal(issuerSchema) {
+		return nil, errors.Errorf("the credential is of the %s schema %v of its issuer key, not of %s %v", issuerSchema.Name, issuerSchema.Attributes, schema.Name, schema.Attributes)
+	}
+	hash := sha256.Sum256(conf.GetPrimaryCred())
+	entry := &WalletEntry{
+		ID:            hex.EncodeToString(hash[:walletIDBytes]),
+		IssuerKeyID:   IssuerKeyID(ipk),
+		IssuerKeyHash: hex.EncodeToString(ipk.GetHash()),
+		Schema:        schema.Name,
+		Attributes:    append([]string(nil), schema.Attributes...),
+		Imported:      time.Now().UTC().Truncate(time.Second),
+	}
+	if notBefore, notAfter, err := Validity(cred.GetAttrs()); hasValidity(ipk) && err == nil {
+		entry.NotBefore, entry.NotAfter = notBefore.UTC(), notAfter.UTC()
+	}
+	if _, err := w.Entry(entry.ID); err == nil {
+		return nil, errors.Errorf("credential %s is already in the wallet", entry.ID)
+	}
+
+	credArtifact, err := w.psid.WrapArtifact(psidentity.PsIdentityConfigPrimaryCred, ipk.GetHash(), env.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	ipkBytes, err := proto.Marshal(ipk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal issuer public key")
+	}
+	ipkArtifact, err := w.psid.WrapArtifact(psidentity.PsIdentityConfigIssuerPublicKey, ipk.GetHash(), ipkBytes)
+	if err != nil {
+		return nil, err
+	}
+	entryJSON, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal wallet entry")
+	}
+
+	dir := filepath.Join(w.Dir, entry.ID)
+	if err := os.MkdirAll(dir, 0770); err != nil {
+		return nil, errors.Wrapf(err, "failed to create wallet directory %s", dir)
+	}
+	// the entry is written last, a directory without one is not listed
+	for file, raw := range map[string][]byte{
+		psidentity.PsIdentityConfigPrimaryCred:     credArtifact,
+		psidentity.PsIdentityConfigIssuerPublicKey: ipkArtifact,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), raw, 0640); err != nil {
+			return nil, errors.Wrapf(err, "failed to write %s", filepath.Join(dir, file))
+		}
+	}
+	path := filepath.Join(dir, psidentity.PsIdentityConfigWalletEntry)
+	if err := ioutil.WriteFile(path, entryJSON, 0640); err != nil {
+		return nil, errors.Wrapf(err, "failed to write %s", path)
+	}
+	return entry, nil
+}
+
+// ImportBundle imports a cred exported by Export, the schema of the bundle is kept
+func (w *Wallet) ImportBundle(raw []byte) (*WalletEntry, error) {
+	bundle := &WalletBundle{}
+	if err := json.Unmarshal(raw, bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to parse wallet bundle")
+	}
+	env, err := w.psid.UnwrapArtifact(bundle.IssuerPublicKey, psidentity.PsIdentityConfigIssuerPublicKey, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "issuer public key")
+	}
+	ipk := &IssuerPublicKeyPS{}
+	if err := proto.Unmarshal(env.GetPayload(), ipk); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	var schema *CredentialSchema
+	if bundle.Entry != nil {
+		schema = &CredentialSchema{Name: bundle.Entry.Schema, Attributes: bundle.Entry.Attributes}
+	}
+	return w.Import(bundle.PrimaryCred, ipk, schema)
+}
+
+// Export returns the cred of the ID as a WalletBundle in JSON
+func (w *Wallet) Export(id string) ([]byte, error) {
+	entry, err := w.Entry(id)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &WalletBundle{Entry: entry}
+	for file, raw := range map[string]*[]byte{
+		psidentity.PsIdentityConfigPrimaryCred:     &bundle.PrimaryCred,
+		psidentity.PsIdentityConfigIssuerPublicKey: &bundle.IssuerPublicKey,
+	} {
+		path, _ := w.path(id, file)
+		if *raw, err = ioutil.ReadFile(path); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+	}
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// Entry returns the entry of the cred of the ID
+func (w *Wallet) Entry(id string) (*WalletEntry, error) {
+	path, err := w.path(id, psidentity.PsIdentityConfigWalletEntry)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrWalletCredNotFound, id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read wallet entry %s", path)
+	}
+	entry := &WalletEntry{}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse wallet entry %s", path)
+	}
+	return entry, nil
+}
+
+// Credential returns the primary cred of the ID and the issuer public key that signed it
+func (w *Wallet) Credential(id string) (*PrimaryCredential, *IssuerPublicKeyPS, error) {
+	entry, err := w.Entry(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ipkHash, err := hex.DecodeString(entry.IssuerKeyHash)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid issuer key hash of credential %s", id)
+	}
+
+	path, _ := w.path(id, psidentity.PsIdentityConfigIssuerPublicKey)
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	env, err := w.psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigIssuerPublicKey, ipkHash)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "invalid artifact %s", path)
+	}
+	ipk := &IssuerPublicKeyPS{}
+	if err := proto.Unmarshal(env.GetPayload(), ipk); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal issuer public key")
+	}
+	if !bytes.Equal(ipk.GetHash(), ipkHash) {
+		return nil, nil, errors.Errorf("issuer public key of credential %s does not match its entry", id)
+	}
+
+	path, _ = w.path(id, psidentity.PsIdentityConfigPrimaryCred)
+	if raw, err = ioutil.ReadFile(path); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if env, err = w.psid.UnwrapArtifact(raw, psidentity.PsIdentityConfigPrimaryCred, ipkHash); err != nil {
+		return nil, nil, errors.WithMessagef(err, "invalid artifact %s", path)
+	}
+	conf := &user.UserPrimaryCred{}
+	if err := proto.Unmarshal(env.GetPayload(), conf); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal user primary cred")
+	}
+	cred := &PrimaryCredential{}
+	if err := proto.Unmarshal(conf.GetPrimaryCred(), cred); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal primary cred")
+	}
+	return cred, ipk, nil
+}
+
+// Delete removes the cred of the ID from the wallet
+func (w *Wallet) Delete(id string) error {
+	if _, err := w.Entry(id); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.RemoveAll(filepath.Join(w.Dir, id)), "failed to delete credential %s", id)
+}
+
+// List returns the entries of the creds matching the query, the creds expiring first first
+func (w *Wallet) List(q WalletQuery) ([]*WalletEntry, error) {
+	dirs, err := ioutil.ReadDir(w.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read wallet %s", w.Dir)
+	}
+	var entries []*WalletEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := w.Entry(dir.Name())
+		if errors.Cause(err) == ErrWalletCredNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if q.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		if !entries[a].NotAfter.Equal(entries[b].NotAfter) {
+			return expiresBefore(entries[a], entries[b])
+		}
+		return entries[a].ID < entries[b].ID
+	})
+	return entries, nil
+}
+
+// expiresBefore reports whether the cred a expires before b, creds without a validity window never expire
+func expiresBefore(a, b *WalletEntry) bool {
+	if a.NotAfter.IsZero() || b.NotAfter.IsZero() {
+		return b.NotAfter.IsZero() && !a.NotAfter.IsZero()
+	}
+	return a.NotAfter.Before(b.NotAfter)
+}
+
+// Select picks the creds of the query disclosing the requested attributes, by attribute name. Each pick
+// is the cred holding the most attributes not disclosed yet, the cred expiring last on a tie.
+func (w *Wallet) Select(q WalletQuery, attributes []string) ([]*WalletSelection, error) {
+	entries, err := w.List(q)
+	if err != nil {
+		return nil, err
+	}
+	return selectEntries(entries, attributes)
+}
+
+// selectEntries picks the creds for the attributes among the entries listed by expiry, see Select
+func selectEntries(entries []*WalletEntry, attributes []string) ([]*WalletSelection, error) {
+	remaining := map[string]bool{}
+	for _, name := range attributes {
+		remaining[name] = true
+	}
+
+	var selections []*WalletSelection
+	for len(remaining) != 0 {
+		var best *WalletSelection
+		for _, entry := range entries {
+			s := &WalletSelection{Entry: entry}
+			for _, name := range entry.Attributes {
+				if remaining[name] {
+					s.Attributes = append(s.Attributes, name)
+				}
+			}
+			// the entries are listed by expiry, a later one wins a tie
+			if len(s.Attributes) != 0 && (best == nil || len(s.Attributes) >= len(best.Attributes)) {
+				best = s
+			}
+		}
+		if best == nil {
+			missing := make([]string, 0, len(remaining))
+			for name := range remaining {
+				missing = append(missing, name)
+			}
+			sort.Strings(missing)
+			return nil, errors.Errorf("no credential in the wallet holds the attributes %s", strings.Join(missing, ", "))
+		}
+		for _, name := range best.Attributes {
+			delete(remaining, name)
+		}
+		selections = append(selections, best)
+	}
+	return selections, nil
+}
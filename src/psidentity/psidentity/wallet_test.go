@@ -0,0 +1,150 @@
+package psidentity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	psidentity "psidentity"
+)
+
+// newWalletIssuer returns a new issuer key recording the schema and a function issuing primary cred artifacts with it.
+// Without a schema the key is of the default schema and its creds end with their validity window.
+func newWalletIssuer(t *testing.T, psid *Psidentity, schema *CredentialSchema) (*IssuerPublicKeyPS, func(attrs []string) []byte) {
+	rng, err := psid.Curve.Rand()
+	require.NoError(t, err)
+	var key *IssuerKeyPS
+	if schema == nil {
+		key, err = psid.NewIssuerKeyPSWithValidity(len(psidentity.IssuerAttributeNames), rng, psid.Translator)
+	} else {
+		key, err = psid.NewIssuerKeyPSWithSchema(schema, rng, psid.Translator)
+	}
+	require.NoError(t, err)
+	return key.Ipk, func(attrs []string) []byte {
+		// the issuer assigns the validity window at the end of the attributes
+		var window *ValidityWindow
+		if hasValidity(key.Ipk) {
+			notBefore, notAfter, err := Validity(attrs)
+			require.NoError(t, err)
+			attrs, window = attrs[:len(attrs)-2], &ValidityWindow{NotBefore: notBefore, NotAfter: notAfter}
+		}
+		primaryBytes, err := GenerateUserPrimaryCred(attrs, window, key, *psid, psid.Translator)
+		require.NoError(t, err)
+		raw, err := psid.WrapArtifact(psidentity.PsIdentityConfigPrimaryCred, key.Ipk.Hash, primaryBytes)
+		require.NoError(t, err)
+		return raw
+	}
+}
+
+func TestWallet(t *testing.T) {
+	psid, err := NewPsidentityForCurve(CurveFP256BN_AMCL)
+	require.NoError(t, err)
+	w := NewWallet(t.TempDir(), psid)
+	entries, err := w.List(WalletQuery{})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	licenseSchema := &CredentialSchema{Name: "license", Attributes: []string{"Number", "Class", "Country", "Holder"}}
+	deviceIpk, issueDevice := newWalletIssuer(t, psid, nil)
+	licenseIpk, issueLicense := newWalletIssuer(t, psid, licenseSchema)
+	now := time.Now()
+	userAttrs := []string{psidentity.UserAttributeNumber, psidentity.UserAttributeManufacturer, psidentity.UserAttributeDate, psidentity.UserAttributeLevel}
+
+	// creds of the default schema, one expiring soon
+	soonAttrs, err := AppendValidity(userAttrs, now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	soon, err := w.Import(issueDevice(soonAttrs), deviceIpk, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultSchema, soon.Schema)
+	require.Equal(t, psidentity.IssuerAttributeNames, soon.Attributes)
+	require.Equal(t, IssuerKeyID(deviceIpk), soon.IssuerKeyID)
+	require.True(t, soon.ValidAt(now))
+	require.False(t, soon.ValidAt(now.Add(48*time.Hour)))
+
+	lateAttrs, err := AppendValidity(userAttrs, now.Add(-time.Hour), now.Add(96*time.Hour))
+	require.NoError(t, err)
+	late, err := w.Import(issueDevice(lateAttrs), deviceIpk, nil)
+	require.NoError(t, err)
+
+	// a cred of another issuer and schema, without a validity window
+	licenseRaw := issueLicense([]string{"42", "B", "DE", "holder"})
+	_, err = w.Import(licenseRaw, deviceIpk, licenseSchema)
+	require.Error(t, err)
+	// the schema is the one the issuer key records, its hash covers it
+	_, err = w.Import(licenseRaw, licenseIpk, &CredentialSchema{Name: "license", Attributes: []string{"Number"}})
+	require.Error(t, err)
+	_, err = w.Import(licenseRaw, licenseIpk, &CredentialSchema{Name: "permit", Attributes: licenseSchema.Attributes})
+	require.Error(t, err)
+	renamed := proto.Clone(licenseIpk).(*IssuerPublicKeyPS)
+	renamed.Schema = "permit"
+	_, err = w.Import(licenseRaw, renamed, nil)
+	require.Error(t, err)
+	_, err = w.Import(issueDevice(soonAttrs), deviceIpk, &CredentialSchema{Name: "license", Attributes: psidentity.IssuerAttributeNames})
+	require.Error(t, err)
+	license, err := w.Import(licenseRaw, licenseIpk, nil)
+	require.NoError(t, err)
+	require.Equal(t, licenseSchema.Name, license.Schema)
+	require.Equal(t, licenseSchema.Attributes, license.Attributes)
+	require.True(t, license.NotAfter.IsZero())
+	require.True(t, license.ValidAt(now.Add(1000*time.Hour)))
+	_, err = w.Import(licenseRaw, licenseIpk, licenseSchema)
+	require.Error(t, err)
+
+	// the creds are listed by expiry, the creds without a validity window last
+	entries, err = w.List(WalletQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []string{soon.ID, late.ID, license.ID}, []string{entries[0].ID, entries[1].ID, entries[2].ID})
+	entries, err = w.List(WalletQuery{IssuerKeyID: IssuerKeyID(licenseIpk)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, license, entries[0])
+	entries, err = w.List(WalletQuery{Schema: DefaultSchema, ValidAt: now.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, late.ID, entries[0].ID)
+
+	cred, ipk, err := w.Credential(soon.ID)
+	require.NoError(t, err)
+	require.Equal(t, soonAttrs, cred.GetAttrs())
+	require.True(t, proto.Equal(deviceIpk, ipk))
+
+	// the selection covers the attributes with the fewest creds, the one expiring last first
+	selections, err := w.Select(WalletQuery{ValidAt: now}, []string{psidentity.IssuerAttributeOne, "Class", psidentity.IssuerAttributeThree})
+	require.NoError(t, err)
+	require.Len(t, selections, 2)
+	require.Equal(t, late.ID, selections[0].Entry.ID)
+	require.Equal(t, []string{psidentity.IssuerAttributeOne, psidentity.IssuerAttributeThree}, selections[0].Attributes)
+	mask, err := selections[0].Mask()
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 0, 1, 0, 0, 0}, mask)
+	require.Equal(t, license.ID, selections[1].Entry.ID)
+	mask, err = selections[1].Mask()
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 0, 0}, mask)
+	_, err = w.Select(WalletQuery{ValidAt: now}, []string{"Class", "Unknown"})
+	require.EqualError(t, err, "no credential in the wallet holds the attributes Unknown")
+	_, err = w.Select(WalletQuery{Schema: DefaultSchema}, []string{"Class"})
+	require.Error(t, err)
+
+	// an exported cred is imported with its schema by another wallet
+	bundle, err := w.Export(license.ID)
+	require.NoError(t, err)
+	other := NewWallet(t.TempDir(), psid)
+	imported, err := other.ImportBundle(bundle)
+	require.NoError(t, err)
+	require.Equal(t, license.ID, imported.ID)
+	require.Equal(t, licenseSchema.Attributes, imported.Attributes)
+	require.Equal(t, IssuerKeyID(licenseIpk), imported.IssuerKeyID)
+
+	require.NoError(t, w.Delete(license.ID))
+	_, err = w.Entry(license.ID)
+	require.Equal(t, ErrWalletCredNotFound, errors.Cause(err))
+	require.Equal(t, ErrWalletCredNotFound, errors.Cause(w.Delete(license.ID)))
+	_, err = w.Entry("../" + license.ID)
+	require.Error(t, err)
+	entries, err = w.List(WalletQuery{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
@@ -0,0 +1,69 @@
+package amcl
+
+import (
+	fmt "fmt"
+
+	math "github.com/IBM/mathlib"
+)
+
+func (a *Fp256bn) G1ToCompressedProto(g1 *math.G1) *ECP {
+	return &ECP{Compressed: g1.Compressed()}
+}
+
+func (a *Fp256bn) G2ToCompressedProto(g2 *math.G2) *ECP2 {
+	return &ECP2{Compressed: g2.Compressed()}
+}
+
+func (a *Fp256bnMiracl) G1ToCompressedProto(g1 *math.G1) *ECP {
+	return &ECP{Compressed: g1.Compressed()}
+}
+
+func (a *Fp256bnMiracl) G2ToCompressedProto(g2 *math.G2) *ECP2 {
+	return &ECP2{Compressed: g2.Compressed()}
+}
+
+func (a *Gurvy) G1ToCompressedProto(g1 *math.G1) *ECP {
+	return &ECP{Compressed: g1.Compressed()}
+}
+
+func (a *Gurvy) G2ToCompressedProto(g2 *math.G2) *ECP2 {
+	return &ECP2{Compressed: g2.Compressed()}
+}
+
+// g1FromCompressed decompresses a G1 point and checks that it is in the subgroup of order c.GroupOrder.
+// The point at infinity is refused, it is what some curves decompress invalid encodings to.
+func g1FromCompressed(c *math.Curve, b []byte) (*math.G1, error) {
+	if len(b) != c.CompressedG1ByteSize {
+		return nil, fmt.Errorf("invalid compressed length")
+	}
+	p, err := c.NewG1FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	if p.IsInfinity() {
+		return nil, fmt.Errorf("invalid compressed point")
+	}
+	if !p.Mul(c.GroupOrder).IsInfinity() {
+		return nil, fmt.Errorf("point is not in the G1 subgroup")
+	}
+	return p, nil
+}
+
+// g2FromCompressed decompresses a G2 point and checks that it is in the subgroup of order c.GroupOrder.
+// The point at infinity is refused, it is what some curves decompress invalid encodings to.
+func g2FromCompressed(c *math.Curve, b []byte) (*math.G2, error) {
+	if len(b) != c.CompressedG2ByteSize {
+		return nil, fmt.Errorf("invalid compressed length")
+	}
+	p, err := c.NewG2FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	if p.Equals(c.NewG2()) {
+		return nil, fmt.Errorf("invalid compressed point")
+	}
+	if !p.Mul(c.GroupOrder).Equals(c.NewG2()) {
+		return nil, fmt.Errorf("point is not in the G2 subgroup")
+	}
+	return p, nil
+}
@@ -0,0 +1,74 @@
+package amcl
+
+import (
+	"testing"
+
+	math "github.com/IBM/mathlib"
+	"github.com/stretchr/testify/assert"
+)
+
+type compressingTranslator interface {
+	G1ToProto(*math.G1) *ECP
+	G1FromProto(*ECP) (*math.G1, error)
+	G2ToProto(*math.G2) *ECP2
+	G2FromProto(*ECP2) (*math.G2, error)
+	G1ToCompressedProto(*math.G1) *ECP
+	G2ToCompressedProto(*math.G2) *ECP2
+}
+
+func TestCompressedTranslators(t *testing.T) {
+	translators := map[string]compressingTranslator{
+		"FP256BN_AMCL":        &Fp256bn{C: math.Curves[math.FP256BN_AMCL]},
+		"FP256BN_AMCL_MIRACL": &Fp256bnMiracl{C: math.Curves[math.FP256BN_AMCL_MIRACL]},
+		"BN254":               &Gurvy{C: math.Curves[math.BN254]},
+		"BLS12_377_GURVY":     &Gurvy{C: math.Curves[math.BLS12_377_GURVY]},
+		"BLS12_381_GURVY":     &Gurvy{C: math.Curves[math.BLS12_381_GURVY]},
+		"BLS12_381":           &Gurvy{C: math.Curves[math.BLS12_381]},
+	}
+	curves := map[string]*math.Curve{
+		"FP256BN_AMCL":        math.Curves[math.FP256BN_AMCL],
+		"FP256BN_AMCL_MIRACL": math.Curves[math.FP256BN_AMCL_MIRACL],
+		"BN254":               math.Curves[math.BN254],
+		"BLS12_377_GURVY":     math.Curves[math.BLS12_377_GURVY],
+		"BLS12_381_GURVY":     math.Curves[math.BLS12_381_GURVY],
+		"BLS12_381":           math.Curves[math.BLS12_381],
+	}
+
+	for name, tr := range translators {
+		t.Run(name, func(t *testing.T) {
+			curve := curves[name]
+			rng, err := curve.Rand()
+			assert.NoError(t, err)
+			r := curve.NewRandomZr(rng)
+
+			g1 := curve.GenG1.Mul(r)
+			ecp := tr.G1ToCompressedProto(g1)
+			assert.Len(t, ecp.Compressed, curve.CompressedG1ByteSize)
+			p, err := tr.G1FromProto(ecp)
+			assert.NoError(t, err)
+			assert.True(t, p.Equals(g1))
+
+			g2 := curve.GenG2.Mul(r)
+			ecp2 := tr.G2ToCompressedProto(g2)
+			assert.Len(t, ecp2.Compressed, curve.CompressedG2ByteSize)
+			p2, err := tr.G2FromProto(ecp2)
+			assert.NoError(t, err)
+			assert.True(t, p2.Equals(g2))
+
+			// the uncompressed form is still decoded
+			p2, err = tr.G2FromProto(tr.G2ToProto(g2))
+			assert.NoError(t, err)
+			assert.True(t, p2.Equals(g2))
+
+			// truncated and corrupted encodings are refused
+			_, err = tr.G1FromProto(&ECP{Compressed: ecp.Compressed[1:]})
+			assert.Error(t, err)
+			corrupted := append([]byte{}, ecp2.Compressed...)
+			for i := range corrupted[1:] {
+				corrupted[i+1] = 0xff
+			}
+			_, err = tr.G2FromProto(&ECP2{Compressed: corrupted})
+			assert.Error(t, err)
+		})
+	}
+}
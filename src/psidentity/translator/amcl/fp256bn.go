@@ -39,6 +39,10 @@ func (a *Fp256bn) G1FromRawBytes(raw []byte) (*math.G1, error) {
 }
 
 func (a *Fp256bn) G1FromProto(e *ECP) (*math.G1, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g1FromCompressed(a.C, e.GetCompressed())
+	}
+
 	if e == nil {
 		return nil, fmt.Errorf("nil argument")
 	}
@@ -72,6 +76,10 @@ func (a *Fp256bn) G2ToProto(g2 *math.G2) *ECP2 {
 }
 
 func (a *Fp256bn) G2FromProto(e *ECP2) (*math.G2, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g2FromCompressed(a.C, e.GetCompressed())
+	}
+
 	if e == nil {
 		return nil, fmt.Errorf("nil argument")
 	}
@@ -116,6 +124,10 @@ func (a *Fp256bnMiracl) G1FromRawBytes(raw []byte) (*math.G1, error) {
 }
 
 func (a *Fp256bnMiracl) G1FromProto(e *ECP) (*math.G1, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g1FromCompressed(a.C, e.GetCompressed())
+	}
+
 	if e == nil {
 		return nil, fmt.Errorf("nil argument")
 	}
@@ -149,6 +161,10 @@ func (a *Fp256bnMiracl) G2ToProto(g2 *math.G2) *ECP2 {
 }
 
 func (a *Fp256bnMiracl) G2FromProto(e *ECP2) (*math.G2, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g2FromCompressed(a.C, e.GetCompressed())
+	}
+
 	if e == nil {
 		return nil, fmt.Errorf("nil argument")
 	}
@@ -34,6 +34,10 @@ func (a *Gurvy) G1FromRawBytes(raw []byte) (*math.G1, error) {
 }
 
 func (a *Gurvy) G1FromProto(e *ECP) (*math.G1, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g1FromCompressed(a.C, e.GetCompressed())
+	}
+
 	bytes := make([]byte, len(e.X)*2)
 	l := len(e.X)
 	copy(bytes, e.X)
@@ -54,6 +58,10 @@ func (a *Gurvy) G2ToProto(g2 *math.G2) *ECP2 {
 }
 
 func (a *Gurvy) G2FromProto(e *ECP2) (*math.G2, error) {
+	if len(e.GetCompressed()) != 0 {
+		return g2FromCompressed(a.C, e.GetCompressed())
+	}
+
 	bytes := make([]byte, len(e.Xa)*4)
 	l := len(e.Xa)
 	copy(bytes[0:l], e.Xa)
@@ -223,6 +223,10 @@ type UserPrimaryCred struct {
 
 	PrimaryCred []byte `protobuf:"bytes,1,opt,name=primary_cred,json=primaryCred,proto3" json:"primary_cred,omitempty"`
 	PrimaryCri  []byte `protobuf:"bytes,2,opt,name=primary_cri,json=primaryCri,proto3" json:"primary_cri,omitempty"`
+	// cri_version is the hash-to-prime version primary_cri was created with
+	CriVersion uint32 `protobuf:"varint,3,opt,name=cri_version,json=criVersion,proto3" json:"cri_version,omitempty"`
+	// curve_id is the curve the credential was issued on
+	CurveId string `protobuf:"bytes,4,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *UserPrimaryCred) Reset() {
@@ -271,6 +275,20 @@ func (x *UserPrimaryCred) GetPrimaryCri() []byte {
 	return nil
 }
 
+func (x *UserPrimaryCred) GetCriVersion() uint32 {
+	if x != nil {
+		return x.CriVersion
+	}
+	return 0
+}
+
+func (x *UserPrimaryCred) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 type UserDeriveCred struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -278,6 +296,10 @@ type UserDeriveCred struct {
 
 	DeriveCred []byte `protobuf:"bytes,1,opt,name=derive_cred,json=deriveCred,proto3" json:"derive_cred,omitempty"`
 	DeriveCri  []byte `protobuf:"bytes,2,opt,name=derive_cri,json=deriveCri,proto3" json:"derive_cri,omitempty"`
+	// cri_version is the hash-to-prime version derive_cri was created with
+	CriVersion uint32 `protobuf:"varint,3,opt,name=cri_version,json=criVersion,proto3" json:"cri_version,omitempty"`
+	// curve_id is the curve the credential was issued on
+	CurveId string `protobuf:"bytes,4,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *UserDeriveCred) Reset() {
@@ -326,6 +348,20 @@ func (x *UserDeriveCred) GetDeriveCri() []byte {
 	return nil
 }
 
+func (x *UserDeriveCred) GetCriVersion() uint32 {
+	if x != nil {
+		return x.CriVersion
+	}
+	return 0
+}
+
+func (x *UserDeriveCred) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 type UserAggregateCred struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -333,6 +369,10 @@ type UserAggregateCred struct {
 
 	AggregateCred []byte `protobuf:"bytes,1,opt,name=aggregate_cred,json=aggregateCred,proto3" json:"aggregate_cred,omitempty"`
 	AggregateCri  []byte `protobuf:"bytes,2,opt,name=aggregate_cri,json=aggregateCri,proto3" json:"aggregate_cri,omitempty"`
+	// cri_version is the hash-to-prime version aggregate_cri was created with
+	CriVersion uint32 `protobuf:"varint,3,opt,name=cri_version,json=criVersion,proto3" json:"cri_version,omitempty"`
+	// curve_id is the curve the credential was issued on
+	CurveId string `protobuf:"bytes,4,opt,name=curve_id,json=curveId,proto3" json:"curve_id,omitempty"`
 }
 
 func (x *UserAggregateCred) Reset() {
@@ -381,6 +421,20 @@ func (x *UserAggregateCred) GetAggregateCri() []byte {
 	return nil
 }
 
+func (x *UserAggregateCred) GetCriVersion() uint32 {
+	if x != nil {
+		return x.CriVersion
+	}
+	return 0
+}
+
+func (x *UserAggregateCred) GetCurveId() string {
+	if x != nil {
+		return x.CurveId
+	}
+	return ""
+}
+
 var File_usermessage_proto protoreflect.FileDescriptor
 
 var file_usermessage_proto_rawDesc = []byte{
@@ -416,26 +470,37 @@ var file_usermessage_proto_rawDesc = []byte{
 	0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2b, 0x0a, 0x11, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x10, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x61, 0x6e,
-	0x64, 0x6c, 0x65, 0x22, 0x55, 0x0a, 0x0f, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x69, 0x6d, 0x61,
-	0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x69, 0x6d, 0x61, 0x72,
-	0x79, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70, 0x72,
-	0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x72, 0x69,
-	0x6d, 0x61, 0x72, 0x79, 0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a,
-	0x70, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x69, 0x22, 0x50, 0x0a, 0x0e, 0x55, 0x73,
-	0x65, 0x72, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b,
-	0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x0a, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1d, 0x0a,
-	0x0a, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x09, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x69, 0x22, 0x5f, 0x0a, 0x11,
-	0x55, 0x73, 0x65, 0x72, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x65,
-	0x64, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x5f, 0x63,
-	0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x61, 0x67, 0x67, 0x72, 0x65,
-	0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x61, 0x67, 0x67, 0x72,
-	0x65, 0x67, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x0c, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x69, 0x42, 0x1a, 0x5a,
-	0x18, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x73, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2f,
-	0x75, 0x73, 0x65, 0x72, 0x3b, 0x75, 0x73, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x64, 0x6c, 0x65, 0x22, 0x91, 0x01, 0x0a, 0x0f, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x69, 0x6d,
+	0x61, 0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x69, 0x6d, 0x61,
+	0x72, 0x79, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70,
+	0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x72,
+	0x69, 0x6d, 0x61, 0x72, 0x79, 0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0a, 0x70, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x43, 0x72, 0x69, 0x12, 0x1f, 0x0a, 0x0b, 0x63,
+	0x72, 0x69, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0a, 0x63, 0x72, 0x69, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08,
+	0x63, 0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x63, 0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x22, 0x8c, 0x01, 0x0a, 0x0e, 0x55, 0x73, 0x65, 0x72,
+	0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65,
+	0x72, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0a, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x64,
+	0x65, 0x72, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x09, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x43, 0x72, 0x69, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x72,
+	0x69, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0a, 0x63, 0x72, 0x69, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x75, 0x72, 0x76, 0x65, 0x49, 0x64, 0x22, 0x9b, 0x01, 0x0a, 0x11, 0x55, 0x73, 0x65, 0x72, 0x41,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x0e,
+	0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x43,
+	0x72, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x5f, 0x63, 0x72, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x61, 0x67, 0x67, 0x72,
+	0x65, 0x67, 0x61, 0x74, 0x65, 0x43, 0x72, 0x69, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x72, 0x69, 0x5f,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x63,
+	0x72, 0x69, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x75, 0x72,
+	0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x75, 0x72,
+	0x76, 0x65, 0x49, 0x64, 0x42, 0x1a, 0x5a, 0x18, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x73, 0x69, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x3b, 0x75, 0x73, 0x65, 0x72,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (